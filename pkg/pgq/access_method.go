@@ -0,0 +1,40 @@
+package pgq
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func accessMethodClause(method string) string {
+	if method == "" {
+		return ""
+	}
+	return " USING " + pgx.Identifier{method}.Sanitize()
+}
+
+// GetAccessMethod returns the table access method of the queue table, e.g.
+// heap, or an empty string for a partitioned table without one, which
+// only PostgreSQL 17 or later can set
+func (m *Manager) GetAccessMethod(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var method string
+	err := m.retry(ctx, func() error {
+		err := m.pool.QueryRow(ctx, `
+			SELECT coalesce(am.amname, '')
+			FROM pg_class c
+			LEFT JOIN pg_am am ON am.oid = c.relam
+			WHERE c.oid = format('%I.%I', $1, $2)::regclass
+		`, schema, name).Scan(&method)
+		return wrapErr("get_access_method", fqn, err)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return method, nil
+}
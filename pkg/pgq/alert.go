@@ -0,0 +1,215 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const alertPrefix = "pgq_alert_"
+
+// Alert raises a NOTIFY and/or writes a row to an alerts table when a queue
+// grows beyond its thresholds. The check runs as a pg_cron job.
+type Alert struct {
+	Name string
+	// MaxRows is the unprocessed message threshold; zero disables it
+	MaxRows int64
+	// MaxBytes is the total size threshold (all partitions and indexes); zero disables it
+	MaxBytes int64
+	// NotifyChannel receives a JSON payload per breached threshold
+	NotifyChannel string
+	// AlertsTable receives a row per breached threshold; created if missing
+	AlertsTable FQN
+	// Schedule is the pg_cron schedule of the check
+	Schedule string
+}
+
+// FunctionFQN returns the check function name for an alert on a queue in schema
+func (a *Alert) FunctionFQN(schema SchemaName) FQN {
+	return FQN(fmt.Sprintf("%s.%s%s", schema, alertPrefix, a.Name))
+}
+
+// CreateAlert installs the check function and schedules it. Alert names
+// are unique per schema, as the function is named after the alert only, so
+// it fails if the schema already has an alert of the same name, on any
+// queue.
+func (m *Manager) CreateAlert(ctx context.Context, schema SchemaName, name QueueName, a *Alert) error {
+	return m.installAlert(ctx, schema, name, a, false)
+}
+
+// UpdateAlert replaces the check function and schedule of an existing
+// alert with a
+func (m *Manager) UpdateAlert(ctx context.Context, schema SchemaName, name QueueName, a *Alert) error {
+	return m.installAlert(ctx, schema, name, a, true)
+}
+
+func (m *Manager) installAlert(ctx context.Context, schema SchemaName, name QueueName, a *Alert, replace bool) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	if !QueueName(a.Name).Valid() {
+		return wrapErr("create_alert", fqn, fmt.Errorf("invalid alert name %q", a.Name))
+	}
+	if a.MaxRows <= 0 && a.MaxBytes <= 0 {
+		return wrapErr("create_alert", fqn, errors.New("at least one of max rows or max bytes is required"))
+	}
+	if a.NotifyChannel == "" && a.AlertsTable == "" {
+		return wrapErr("create_alert", fqn, errors.New("at least one of notify channel or alerts table is required"))
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	fn := a.FunctionFQN(schema)
+	if !replace {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT to_regprocedure($1) IS NOT NULL`, fn.Sanitize()+"()").Scan(&exists); err != nil {
+			return wrapErr("check_alert", fqn, err)
+		}
+		if exists {
+			return wrapErr("create_alert", fqn, fmt.Errorf("alert %q already exists in schema %s (function %s)", a.Name, schema, fn))
+		}
+	}
+
+	if a.AlertsTable != "" {
+		if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+a.AlertsTable.Sanitize()+` (
+			queue     TEXT        NOT NULL,
+			alert     TEXT        NOT NULL,
+			metric    TEXT        NOT NULL,
+			value     BIGINT      NOT NULL,
+			threshold BIGINT      NOT NULL,
+			raised_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+			return wrapErr("create_alerts_table", fqn, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, alertFunctionSQL(schema, name, a)); err != nil {
+		return wrapErr("create_alert_function", fqn, err)
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT cron.schedule($1, $2, $3)`,
+		fn.String(), a.Schedule, "SELECT "+fn.Sanitize()+"()"); err != nil {
+		return wrapErr("schedule_alert", fqn, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+func alertFunctionSQL(schema SchemaName, name QueueName, a *Alert) string {
+	fqn := MakeFQN(schema, name)
+
+	var sql strings.Builder
+	sql.WriteString("CREATE OR REPLACE FUNCTION ")
+	sql.WriteString(a.FunctionFQN(schema).Sanitize())
+	sql.WriteString("() RETURNS void LANGUAGE plpgsql AS $pgq$\nDECLARE\n\tv_rows bigint;\n\tv_bytes bigint;\nBEGIN\n")
+
+	if a.MaxRows > 0 {
+		fmt.Fprintf(&sql, "\tSELECT count(*) INTO v_rows FROM %s WHERE processed_at IS NULL;\n", fqn.Sanitize())
+		writeAlertCheck(&sql, fqn, a, "rows", "v_rows", a.MaxRows)
+	}
+	if a.MaxBytes > 0 {
+		fmt.Fprintf(&sql, "\tSELECT coalesce(sum(pg_total_relation_size(relid)), 0) INTO v_bytes FROM pg_partition_tree(%s::regclass);\n",
+			quoteLiteral(fqn.Sanitize()))
+		writeAlertCheck(&sql, fqn, a, "bytes", "v_bytes", a.MaxBytes)
+	}
+
+	sql.WriteString("END\n$pgq$")
+	return sql.String()
+}
+
+func writeAlertCheck(sql *strings.Builder, fqn FQN, a *Alert, metric, variable string, threshold int64) {
+	fmt.Fprintf(sql, "\tIF %s > %d THEN\n", variable, threshold)
+	if a.NotifyChannel != "" {
+		fmt.Fprintf(sql, "\t\tPERFORM pg_notify(%s, json_build_object('queue', %s, 'alert', %s, 'metric', %s, 'value', %s, 'threshold', %d)::text);\n",
+			quoteLiteral(a.NotifyChannel), quoteLiteral(fqn.String()), quoteLiteral(a.Name), quoteLiteral(metric), variable, threshold)
+	}
+	if a.AlertsTable != "" {
+		fmt.Fprintf(sql, "\t\tINSERT INTO %s (queue, alert, metric, value, threshold) VALUES (%s, %s, %s, %s, %d);\n",
+			a.AlertsTable.Sanitize(), quoteLiteral(fqn.String()), quoteLiteral(a.Name), quoteLiteral(metric), variable, threshold)
+	}
+	sql.WriteString("\tEND IF;\n")
+}
+
+// AlertExists checks that both the check function and its cron job exist,
+// returning the job's schedule
+func (m *Manager) AlertExists(ctx context.Context, schema SchemaName, name QueueName, alertName string) (bool, string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	fn := (&Alert{Name: alertName}).FunctionFQN(schema)
+
+	var schedule string
+	err := m.pool.QueryRow(ctx, `
+		SELECT j.schedule
+		FROM cron.job j
+		WHERE j.jobname = $1
+		  AND EXISTS (
+		      SELECT 1 FROM pg_proc p
+		      JOIN pg_namespace n ON n.oid = p.pronamespace
+		      WHERE n.nspname = $2 AND p.proname = $3
+		  )
+	`, fn.String(), schema, alertPrefix+alertName).Scan(&schedule)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", wrapErr("get_alert", fqn, err)
+	}
+
+	return true, schedule, nil
+}
+
+// DropAlert unschedules the check and drops its function. The alerts table
+// is shared and kept.
+func (m *Manager) DropAlert(ctx context.Context, schema SchemaName, name QueueName, alertName string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	fn := (&Alert{Name: alertName}).FunctionFQN(schema)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT cron.unschedule(jobid) FROM cron.job WHERE jobname = $1`, fn.String()); err != nil {
+		return wrapErr("unschedule_alert", fqn, err)
+	}
+
+	if _, err := tx.Exec(ctx, "DROP FUNCTION IF EXISTS "+fn.Sanitize()+"()"); err != nil {
+		return wrapErr("drop_alert_function", fqn, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
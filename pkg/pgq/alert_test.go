@@ -0,0 +1,33 @@
+package pgq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlertFunctionSQL(t *testing.T) {
+	a := &Alert{
+		Name:          "backlog",
+		MaxRows:       1000,
+		NotifyChannel: "queue_alerts",
+		AlertsTable:   "ops.alerts",
+	}
+
+	sql := alertFunctionSQL("public", "orders", a)
+
+	for _, want := range []string{
+		`CREATE OR REPLACE FUNCTION "public"."pgq_alert_backlog"()`,
+		`FROM "public"."orders" WHERE processed_at IS NULL`,
+		`IF v_rows > 1000 THEN`,
+		`pg_notify('queue_alerts'`,
+		`INSERT INTO "ops"."alerts"`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("alertFunctionSQL() missing %q in:\n%s", want, sql)
+		}
+	}
+
+	if strings.Contains(sql, "v_bytes >") {
+		t.Error("alertFunctionSQL() should not check bytes without MaxBytes")
+	}
+}
@@ -0,0 +1,15 @@
+package pgq
+
+import "context"
+
+// The create methods are part of the package's public API. Settings are
+// added to QueueOptions rather than as parameters, and these fail to
+// compile if a signature changes anyway.
+var (
+	_ func(*Manager, context.Context, SchemaName, QueueName, *PartitionConfig, *QueueOptions) error   = (*Manager).Create
+	_ func(*Manager, context.Context, SchemaName, QueueName, *QueueOptions) error                     = (*Manager).CreateSimple
+	_ func(*Manager, context.Context, SchemaName, QueueName, *PartitionConfig, *QueueOptions) error   = (*Manager).CreatePartitioned
+	_ func(*Manager, context.Context, SchemaName, []QueueName, *PartitionConfig, *QueueOptions) error = (*Manager).CreateBatch
+	_ func(*Manager, context.Context, SchemaName, QueueName) (*Queue, error)                          = (*Manager).Get
+	_ func(*Manager, context.Context, SchemaName, QueueName) error                                    = (*Manager).Drop
+)
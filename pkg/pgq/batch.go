@@ -0,0 +1,168 @@
+package pgq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateBatch creates several queues with identical settings. The DDL for
+// all of them runs in one transaction and, for partitioned queues (non-nil
+// cfg), the partman setup in a second one, which is much cheaper than
+// creating them one by one.
+func (m *Manager) CreateBatch(ctx context.Context, schema SchemaName, names []QueueName, cfg *PartitionConfig, opts *QueueOptions) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if len(names) == 0 {
+		return nil
+	}
+	if opts == nil {
+		opts = &QueueOptions{}
+	}
+
+	existing, err := m.ExistingQueues(ctx, schema, names)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return &QueueExistsError{Queue: MakeFQN(schema, existing[0])}
+	}
+
+	batchFQN := MakeFQN(schema, names[0])
+	if cfg != nil {
+		if err := checkStrategy(batchFQN, cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := m.installExtensions(ctx, batchFQN, cfg != nil && !cfg.Native); err != nil {
+		return err
+	}
+
+	err = m.retryTx(ctx, batchFQN, "commit_ddl", func(tx pgx.Tx) error {
+		for _, name := range names {
+			fqn := MakeFQN(schema, name)
+
+			if err := execHooks(ctx, tx, fqn, "before_create", opts.BeforeCreateSQL); err != nil {
+				return err
+			}
+			if err := m.createTable(ctx, tx, schema, name, cfg, opts); err != nil {
+				return err
+			}
+			if err := m.createIndexes(ctx, tx, schema, name, opts); err != nil {
+				return err
+			}
+
+			if cfg != nil && cfg.Native {
+				if err := m.maintainNative(ctx, tx, schema, name, cfg, true); err != nil {
+					return err
+				}
+			}
+			if cfg == nil || cfg.Native {
+				if err := execHooks(ctx, tx, fqn, "after_create", opts.AfterCreateSQL); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := m.createTemplate(ctx, tx, schema, name, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg == nil || cfg.Native {
+		return nil
+	}
+
+	return m.partmanTx(ctx, batchFQN, func(ptx pgx.Tx) error {
+		for _, name := range names {
+			if err := m.createParent(ctx, ptx, schema, name, cfg); err != nil {
+				return err
+			}
+			if err := execHooks(ctx, ptx, MakeFQN(schema, name), "after_create", opts.AfterCreateSQL); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExistingQueues returns the subset of names that exist as tables in schema
+func (m *Manager) ExistingQueues(ctx context.Context, schema SchemaName, names []QueueName) ([]QueueName, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	tables := make([]string, len(names))
+	for i, name := range names {
+		tables[i] = name.String()
+	}
+
+	var existing []QueueName
+	err := m.retry(ctx, func() error {
+		rows, err := m.pool.Query(ctx, `
+			SELECT tablename FROM pg_tables
+			WHERE schemaname = $1 AND tablename = ANY($2)
+			ORDER BY tablename
+		`, schema, tables)
+		if err != nil {
+			return wrapErr("check_exists", MakeFQN(schema, names[0]), err)
+		}
+
+		existing, err = pgx.CollectRows(rows, pgx.RowTo[QueueName])
+		if err != nil {
+			return wrapErr("check_exists_rows", MakeFQN(schema, names[0]), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// DropBatch removes several queue tables with a single statement.
+// Partman config must be removed beforehand, see RemovePartmanConfig.
+func (m *Manager) DropBatch(ctx context.Context, schema SchemaName, names []QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	var sql strings.Builder
+	sql.WriteString("DROP TABLE IF EXISTS ")
+	for i, name := range names {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(schema.Sanitize())
+		sql.WriteString(".")
+		sql.WriteString(name.Sanitize())
+	}
+	sql.WriteString(" CASCADE")
+
+	if _, err := m.pool.Exec(ctx, sql.String()); err != nil {
+		return wrapErr("drop", MakeFQN(schema, names[0]), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cloneIndexName names the copy of the source queue's index on the queue:
+// the queue's name replaces the source's as prefix, or is added in front
+// within the same schema, where index names must be unique
+func cloneIndexName(index string, from, to QueueName, sameSchema bool) (string, error) {
+	var cloned string
+	switch {
+	case strings.HasPrefix(index, from.String()):
+		cloned = to.String() + strings.TrimPrefix(index, from.String())
+	case sameSchema:
+		cloned = to.String() + "_" + index
+	default:
+		cloned = index
+	}
+	if len(cloned) > maxIdentifierLength {
+		return "", fmt.Errorf("index name %s of the copy of %s exceeds %d characters", cloned, index, maxIdentifierLength)
+	}
+	return cloned, nil
+}
+
+// CloneObjects copies to the queue what its options don't describe from
+// the source queue: the storage parameters of the table and of its
+// template table, the privileges granted on the table, and the custom
+// indexes, except those whose copy would be named in skip. See
+// cloneIndexName for the names of the copies, which are returned.
+func (m *Manager) CloneObjects(ctx context.Context, source FQN, schema SchemaName, name QueueName, skip []string) ([]string, error) {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	srcSchema, srcName, err := source.Split()
+	if err != nil {
+		return nil, wrapErr("clone", fqn, err)
+	}
+
+	indexes, err := m.queryCustomIndexes(ctx, srcSchema, srcName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return nil, wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	src := &Queue{Schema: srcSchema, Name: srcName}
+	dst := &Queue{Schema: schema, Name: name}
+	for _, pair := range [][2]FQN{{source, fqn}, {src.TemplateFQN(), dst.TemplateFQN()}} {
+		var options string
+		err := tx.QueryRow(ctx, `
+			SELECT coalesce(array_to_string(reloptions, ', '), '')
+			FROM pg_class
+			WHERE oid = to_regclass($1)
+		`, pair[0].Sanitize()).Scan(&options)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && options == "") {
+			continue
+		}
+		if err != nil {
+			return nil, wrapErr("clone_storage_parameters", fqn, err)
+		}
+		if _, err := tx.Exec(ctx, "ALTER TABLE IF EXISTS "+pair[1].Sanitize()+" SET ("+options+")"); err != nil {
+			return nil, wrapErr("clone_storage_parameters", fqn, err)
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT acl.privilege_type,
+		       CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE quote_ident(pg_get_userbyid(acl.grantee)) END,
+		       acl.is_grantable
+		FROM pg_class c
+		CROSS JOIN LATERAL aclexplode(c.relacl) acl
+		WHERE c.oid = $1::regclass AND acl.grantee <> c.relowner
+		ORDER BY 2, 1
+	`, source.Sanitize())
+	if err != nil {
+		return nil, wrapErr("clone_grants", fqn, err)
+	}
+	var grants []string
+	var privilege, grantee string
+	var grantable bool
+	_, err = pgx.ForEachRow(rows, []any{&privilege, &grantee, &grantable}, func() error {
+		grant := "GRANT " + privilege + " ON " + fqn.Sanitize() + " TO " + grantee
+		if grantable {
+			grant += " WITH GRANT OPTION"
+		}
+		grants = append(grants, grant)
+		return nil
+	})
+	if err != nil {
+		return nil, wrapErr("clone_grants", fqn, err)
+	}
+	for _, grant := range grants {
+		if _, err := tx.Exec(ctx, grant); err != nil {
+			return nil, wrapErr("clone_grants", fqn, err)
+		}
+	}
+
+	var cloned []CustomIndex
+	for _, idx := range indexes {
+		idx.Name, err = cloneIndexName(idx.Name, srcName, name, srcSchema == schema)
+		if err != nil {
+			return nil, wrapErr("clone_indexes", fqn, err)
+		}
+		if !slices.Contains(skip, idx.Name) {
+			cloned = append(cloned, idx)
+		}
+	}
+	if err := m.CreateCustomIndexes(ctx, tx, schema, name, cloned); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrapErr("commit", fqn, err)
+	}
+
+	names := make([]string, len(cloned))
+	for i, idx := range cloned {
+		names[i] = idx.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,33 @@
+package pgq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloneIndexName(t *testing.T) {
+	tests := []struct {
+		index      string
+		sameSchema bool
+		want       string
+	}{
+		{"orders_queue_customer_idx", true, "orders_staging_customer_idx"},
+		{"orders_queue_customer_idx", false, "orders_staging_customer_idx"},
+		{"by_customer", true, "orders_staging_by_customer"},
+		{"by_customer", false, "by_customer"},
+	}
+	for _, tt := range tests {
+		got, err := cloneIndexName(tt.index, "orders_queue", "orders_staging", tt.sameSchema)
+		if err != nil {
+			t.Errorf("cloneIndexName(%q) error = %v", tt.index, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("cloneIndexName(%q, sameSchema %v) = %q, want %q", tt.index, tt.sameSchema, got, tt.want)
+		}
+	}
+
+	if _, err := cloneIndexName("by_customer", "orders_queue", QueueName(strings.Repeat("q", 60)), true); err == nil {
+		t.Error("cloneIndexName() should reject names over the identifier length limit")
+	}
+}
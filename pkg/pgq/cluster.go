@@ -0,0 +1,290 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const clusterPrefix = "pgq_cluster_"
+
+// clusterTargetsSQL lists every leaf table of a queue (the table itself for
+// simple queues) plus its template, each with its index equivalent to the
+// parent index $3. Partition indexes get generated names, so indexes are
+// matched on the table independent part of their definition.
+const clusterTargetsSQL = `
+	WITH parent_idx AS (
+		SELECT substring(pg_get_indexdef(ci.oid) from ' USING .*$') AS def
+		FROM pg_class ci
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		WHERE n.nspname = $1 AND ci.relname = $3
+	),
+	tables AS (
+		SELECT relid FROM pg_partition_tree(format('%I.%I', $1, $2)::regclass) WHERE isleaf
+		UNION ALL
+		SELECT to_regclass(format('%I.%I', $1, $2 || '_template'))
+	)
+	SELECT format('%I.%I', n.nspname, c.relname), ci.relname
+	FROM tables t
+	JOIN pg_class c ON c.oid = t.relid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	JOIN pg_index x ON x.indrelid = t.relid
+	JOIN pg_class ci ON ci.oid = x.indexrelid
+	WHERE substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') = (SELECT def FROM parent_idx)
+`
+
+// SetClusterIndex marks index (an index on the queue) as the CLUSTER index
+// of the queue, its existing partitions and its template table. An empty
+// index removes the marking.
+func (m *Manager) SetClusterIndex(ctx context.Context, schema SchemaName, name QueueName, index string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var stmts []string
+	if index == "" {
+		rows, err := tx.Query(ctx, `
+			SELECT format('%I.%I', n.nspname, c.relname)
+			FROM pg_index x
+			JOIN pg_class c ON c.oid = x.indrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE x.indisclustered
+			  AND (x.indrelid IN (SELECT relid FROM pg_partition_tree(format('%I.%I', $1, $2)::regclass))
+			       OR x.indrelid = to_regclass(format('%I.%I', $1, $2 || '_template')))
+		`, schema, name)
+		if err != nil {
+			return wrapErr("get_cluster_targets", fqn, err)
+		}
+		tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return wrapErr("get_cluster_targets", fqn, err)
+		}
+		for _, table := range tables {
+			stmts = append(stmts, "ALTER TABLE "+table+" SET WITHOUT CLUSTER")
+		}
+	} else {
+		rows, err := tx.Query(ctx, clusterTargetsSQL, schema, name, index)
+		if err != nil {
+			return wrapErr("get_cluster_targets", fqn, err)
+		}
+		var table, tableIndex string
+		_, err = pgx.ForEachRow(rows, []any{&table, &tableIndex}, func() error {
+			stmts = append(stmts, "ALTER TABLE "+table+" CLUSTER ON "+pgx.Identifier{tableIndex}.Sanitize())
+			return nil
+		})
+		if err != nil {
+			return wrapErr("get_cluster_targets", fqn, err)
+		}
+		if len(stmts) == 0 {
+			return wrapErr("set_cluster_index", fqn, fmt.Errorf("index %s not found", index))
+		}
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_cluster_index", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// GetClusterIndex returns the queue index whose equivalent is marked as
+// CLUSTER index on the queue (or its template, for partitioned queues),
+// or an empty string if none is
+func (m *Manager) GetClusterIndex(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var index string
+	err := m.pool.QueryRow(ctx, `
+		WITH clustered AS (
+			SELECT substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') AS def
+			FROM pg_index x
+			WHERE x.indisclustered
+			  AND x.indrelid IN (
+			      to_regclass(format('%I.%I', $1, $2)),
+			      to_regclass(format('%I.%I', $1, $2 || '_template'))
+			  )
+			LIMIT 1
+		)
+		SELECT ci.relname
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		WHERE x.indrelid = format('%I.%I', $1, $2)::regclass
+		  AND substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') = (SELECT def FROM clustered)
+		LIMIT 1
+	`, schema, name).Scan(&index)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_cluster_index", fqn, err)
+	}
+
+	return index, nil
+}
+
+func clusterFunctionFQN(schema SchemaName, name QueueName) FQN {
+	return FQN(fmt.Sprintf("%s.%s%s", schema, clusterPrefix, name))
+}
+
+// ScheduleCluster installs a pg_cron job that CLUSTERs the most recently
+// closed partition of a partitioned queue on its equivalent of index.
+// The job should run at least once per partition interval.
+func (m *Manager) ScheduleCluster(ctx context.Context, schema SchemaName, name QueueName, index, schedule string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	fn := clusterFunctionFQN(schema, name)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var def string
+	err = tx.QueryRow(ctx, `
+		SELECT substring(pg_get_indexdef(ci.oid) from ' USING .*$')
+		FROM pg_class ci
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		WHERE n.nspname = $1 AND ci.relname = $2
+	`, schema, index).Scan(&def)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return wrapErr("schedule_cluster", fqn, fmt.Errorf("index %s not found", index))
+	}
+	if err != nil {
+		return wrapErr("schedule_cluster", fqn, err)
+	}
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return wrapErr("schedule_cluster", fqn, err)
+	}
+
+	body := fmt.Sprintf(partmanSQL(pm, `CREATE OR REPLACE FUNCTION %s() RETURNS void LANGUAGE plpgsql AS $pgq$
+DECLARE
+	v_child record;
+	v_index text;
+BEGIN
+	FOR v_child IN
+		SELECT p.partition_schemaname AS s, p.partition_tablename AS t
+		FROM {{partman}}.show_partitions(%[2]s) p
+		CROSS JOIN LATERAL {{partman}}.show_partition_info(p.partition_schemaname || '.' || p.partition_tablename, p_parent_table := %[2]s) i
+		WHERE i.child_end_time <= now()
+		  AND i.child_end_time > now() - (SELECT partition_interval::interval FROM {{partman}}.part_config WHERE parent_table = %[2]s)
+	LOOP
+		SELECT ci.relname INTO v_index
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		WHERE x.indrelid = format('%%I.%%I', v_child.s, v_child.t)::regclass
+		  AND substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') = %[3]s;
+
+		IF v_index IS NOT NULL THEN
+			EXECUTE format('ALTER TABLE %%I.%%I CLUSTER ON %%I', v_child.s, v_child.t, v_index);
+			EXECUTE format('CLUSTER %%I.%%I', v_child.s, v_child.t);
+		END IF;
+	END LOOP;
+END
+$pgq$`), fn.Sanitize(), quoteLiteral(fqn.String()), quoteLiteral(def))
+
+	if _, err := tx.Exec(ctx, body); err != nil {
+		return wrapErr("create_cluster_function", fqn, err)
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT cron.schedule($1, $2, $3)`,
+		fn.String(), schedule, "SELECT "+fn.Sanitize()+"()"); err != nil {
+		return wrapErr("schedule_cluster", fqn, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// GetClusterSchedule returns the pg_cron schedule of the queue's CLUSTER
+// job, or an empty string if there is none
+func (m *Manager) GetClusterSchedule(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var schedule string
+	err := m.pool.QueryRow(ctx, `
+		SELECT schedule FROM cron.job WHERE jobname = $1
+	`, clusterFunctionFQN(schema, name).String()).Scan(&schedule)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_cluster_schedule", fqn, err)
+	}
+
+	return schedule, nil
+}
+
+// UnscheduleCluster removes the queue's CLUSTER job and function
+func (m *Manager) UnscheduleCluster(ctx context.Context, schema SchemaName, name QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	fn := clusterFunctionFQN(schema, name)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT cron.unschedule(jobid) FROM cron.job WHERE jobname = $1`, fn.String()); err != nil {
+		return wrapErr("unschedule_cluster", fqn, err)
+	}
+
+	if _, err := tx.Exec(ctx, "DROP FUNCTION IF EXISTS "+fn.Sanitize()+"()"); err != nil {
+		return wrapErr("drop_cluster_function", fqn, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
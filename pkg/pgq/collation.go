@@ -0,0 +1,133 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultCollation is what pg_collation calls the database default
+const defaultCollation = "default"
+
+// textColumns are the built-in text columns affected by TextCollation.
+// Extra columns of a text type, other than generated ones, follow it too.
+var textColumns = []string{"error_detail"}
+
+func collateClause(collation string) string {
+	if collation == "" {
+		return ""
+	}
+	return " COLLATE " + pgx.Identifier{collation}.Sanitize()
+}
+
+// GetTextCollation returns the collation of the queue's text columns,
+// or an empty string when they use the database default
+func (m *Manager) GetTextCollation(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var collation string
+	err := m.pool.QueryRow(ctx, `
+		SELECT coalesce(co.collname, $4)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attname = $3
+	`, schema, name, textColumns[0], defaultCollation).Scan(&collation)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_text_collation", fqn, err)
+	}
+
+	if collation == defaultCollation {
+		return "", nil
+	}
+	return collation, nil
+}
+
+// SetTextCollation changes the collation of the queue's text columns,
+// built-in and extra (and its template table's, if any). Empty resets to the database default.
+// Text to text collation changes don't rewrite the table but do rebuild
+// indexes on the affected columns.
+func (m *Manager) SetTextCollation(ctx context.Context, schema SchemaName, name QueueName, collation string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if collation == "" {
+		collation = defaultCollation
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	columns := make([]Column, len(textColumns))
+	for i, col := range textColumns {
+		columns[i] = Column{Name: col, Type: "TEXT"}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::regclass
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		  AND a.attcollation <> 0 AND a.attgenerated = ''
+		  AND a.attname <> ALL($2)
+		ORDER BY a.attnum
+	`, fqn.Sanitize(), ReservedColumns)
+	if err != nil {
+		return wrapErr("get_text_columns", fqn, err)
+	}
+	extra, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Column, error) {
+		var c Column
+		err := row.Scan(&c.Name, &c.Type)
+		return c, err
+	})
+	if err != nil {
+		return wrapErr("get_text_columns", fqn, err)
+	}
+	columns = append(columns, extra...)
+
+	q := &Queue{Schema: schema, Name: name}
+	for _, table := range []FQN{fqn, q.TemplateFQN()} {
+		var sql strings.Builder
+		sql.WriteString("ALTER TABLE IF EXISTS ")
+		sql.WriteString(table.Sanitize())
+		for i, col := range columns {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(" ALTER COLUMN ")
+			sql.WriteString(pgx.Identifier{col.Name}.Sanitize())
+			sql.WriteString(" TYPE ")
+			sql.WriteString(col.Type)
+			sql.WriteString(collateClause(collation))
+		}
+
+		if _, err := tx.Exec(ctx, sql.String()); err != nil {
+			return wrapErr("set_text_collation", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
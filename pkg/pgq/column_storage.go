@@ -0,0 +1,163 @@
+package pgq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ColumnStorage is the TOAST setting of a queue column
+type ColumnStorage struct {
+	Column string
+	// Compression is lz4 or pglz, empty for the server's
+	// default_toast_compression
+	Compression string
+	// Storage is extended, external or main, empty for the type's default
+	Storage string
+}
+
+// storageModes maps pg_attribute.attstorage to SET STORAGE modes
+var storageModes = map[string]string{
+	"p": "plain",
+	"x": "extended",
+	"e": "external",
+	"m": "main",
+}
+
+// compressionMethods maps pg_attribute.attcompression to SET COMPRESSION
+// methods
+var compressionMethods = map[string]string{
+	"p": "pglz",
+	"l": "lz4",
+}
+
+// GetColumnStorage returns the TOAST settings of columns of the queue
+// table, in table order. Columns that don't exist are left out.
+func (m *Manager) GetColumnStorage(ctx context.Context, schema SchemaName, name QueueName, columns []string) ([]ColumnStorage, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT a.attname, a.attcompression::text, a.attstorage::text
+		FROM pg_attribute a
+		WHERE a.attrelid = format('%I.%I', $1, $2)::regclass
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		  AND a.attname = ANY($3)
+		ORDER BY a.attnum
+	`, schema, name, columns)
+	if err != nil {
+		return nil, wrapErr("get_column_storage", fqn, err)
+	}
+
+	settings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ColumnStorage, error) {
+		var s ColumnStorage
+		var compression, storage string
+		err := row.Scan(&s.Column, &compression, &storage)
+		s.Compression = compressionMethods[compression]
+		s.Storage = storageModes[storage]
+		return s, err
+	})
+	if err != nil {
+		return nil, wrapErr("get_column_storage", fqn, err)
+	}
+
+	return settings, nil
+}
+
+// SetColumnStorage changes the TOAST settings of the queue table, and its
+// template table, from the from set to the to set, matching them by
+// column. Settings only in from are reset to the defaults. Partitions
+// follow the queue. Only values written afterwards are compressed or
+// stored the new way.
+func (m *Manager) SetColumnStorage(ctx context.Context, schema SchemaName, name QueueName, from, to []ColumnStorage) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+
+	old := make(map[string]ColumnStorage, len(from))
+	for _, s := range from {
+		old[s.Column] = s
+	}
+	kept := make(map[string]bool, len(to))
+	for _, s := range to {
+		kept[s.Column] = true
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var actions []string
+	change := func(prev, s ColumnStorage) error {
+		col := pgx.Identifier{s.Column}.Sanitize()
+		if prev.Compression != s.Compression {
+			method := s.Compression
+			if method == "" {
+				method = "DEFAULT"
+			}
+			actions = append(actions, "ALTER COLUMN "+col+" SET COMPRESSION "+method)
+		}
+		if prev.Storage != s.Storage {
+			mode := s.Storage
+			if mode == "" {
+				// SET STORAGE DEFAULT needs PostgreSQL 16
+				var typstorage string
+				err := tx.QueryRow(ctx, `
+					SELECT t.typstorage::text
+					FROM pg_attribute a
+					JOIN pg_type t ON t.oid = a.atttypid
+					WHERE a.attrelid = $1::regclass AND a.attname = $2
+				`, fqn.Sanitize(), s.Column).Scan(&typstorage)
+				if err != nil {
+					return err
+				}
+				mode = storageModes[typstorage]
+			}
+			actions = append(actions, "ALTER COLUMN "+col+" SET STORAGE "+strings.ToUpper(mode))
+		}
+		return nil
+	}
+	for _, s := range to {
+		if err := change(old[s.Column], s); err != nil {
+			return wrapErr("set_column_storage", fqn, err)
+		}
+	}
+	for _, s := range from {
+		if !kept[s.Column] {
+			if err := change(s, ColumnStorage{Column: s.Column}); err != nil {
+				return wrapErr("set_column_storage", fqn, err)
+			}
+		}
+	}
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	for _, stmt := range []string{
+		"ALTER TABLE " + fqn.Sanitize() + " " + strings.Join(actions, ", "),
+		"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " " + strings.Join(actions, ", "),
+	} {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_column_storage", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReservedColumns are the columns every queue table has, which extra
+// columns can't replace
+var ReservedColumns = []string{
+	"id", "created_at", "started_at", "locked_until", "scheduled_for",
+	"processed_at", "consumed_count", "error_detail", "payload", "metadata",
+	orderingColumn,
+}
+
+// Column is a column added to a queue table on top of the built-in ones
+type Column struct {
+	Name string
+	// Type is a PostgreSQL type name, e.g. 'uuid' or 'varchar(64)'
+	Type    string
+	NotNull bool
+	// Default is the default expression, empty for none
+	Default string
+	// Generated is the expression of a STORED generated column, empty for
+	// a regular one. Generated columns have no Default.
+	Generated string
+}
+
+// definition returns the column as written in CREATE TABLE, with
+// collation applied if it is a text column
+func (c Column) definition(collation string) string {
+	var sql strings.Builder
+	sql.WriteString(pgx.Identifier{c.Name}.Sanitize())
+	sql.WriteString(" ")
+	sql.WriteString(c.Type)
+	sql.WriteString(c.collateClause(collation))
+	if c.NotNull {
+		sql.WriteString(" NOT NULL")
+	}
+	if c.Default != "" {
+		sql.WriteString(" DEFAULT ")
+		sql.WriteString(c.Default)
+	}
+	if c.Generated != "" {
+		sql.WriteString(" GENERATED ALWAYS AS (")
+		sql.WriteString(c.Generated)
+		sql.WriteString(") STORED")
+	}
+	return sql.String()
+}
+
+// textTypes are the string types TextCollation applies to
+var textTypes = []string{"text", "varchar", "character varying", "char", "character", "bpchar", "citext"}
+
+// collateClause returns the COLLATE clause of collation for a text column
+// that isn't generated, which follows the expression it is computed from
+func (c Column) collateClause(collation string) string {
+	if c.Generated != "" {
+		return ""
+	}
+	typ := strings.ToLower(strings.TrimSpace(c.Type))
+	typ = strings.TrimSpace(strings.TrimSuffix(typ, "[]"))
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		typ = strings.TrimSpace(typ[:i])
+	}
+	if !slices.Contains(textTypes, typ) {
+		return ""
+	}
+	return collateClause(collation)
+}
+
+// GetExtraColumns returns the columns of the queue table beyond the
+// built-in ones, in table order. Types are formatted by the server, e.g.
+// 'character varying(64)' for 'varchar(64)', see FormatType.
+func (m *Manager) GetExtraColumns(ctx context.Context, schema SchemaName, name QueueName) ([]Column, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.queryColumns(ctx, schema, name, ReservedColumns)
+}
+
+// queryColumns returns the columns of the queue table other than exclude,
+// in table order
+func (m *Manager) queryColumns(ctx context.Context, schema SchemaName, name QueueName, exclude []string) ([]Column, error) {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+		       coalesce(pg_get_expr(d.adbin, d.adrelid), ''), a.attgenerated <> ''
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relname = $2
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		  AND a.attname <> ALL($3)
+		ORDER BY a.attnum
+	`, schema, name, exclude)
+	if err != nil {
+		return nil, wrapErr("get_columns", fqn, err)
+	}
+
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Column, error) {
+		var c Column
+		var generated bool
+		err := row.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default, &generated)
+		// pg_attrdef holds the expression of generated columns as well
+		if generated {
+			c.Generated, c.Default = c.Default, ""
+		}
+		return c, err
+	})
+	if err != nil {
+		return nil, wrapErr("get_columns", fqn, err)
+	}
+
+	return columns, nil
+}
+
+// FormatType returns typ the way the server formats column types, to
+// compare a configured type with one read by GetExtraColumns
+func (m *Manager) FormatType(ctx context.Context, typ string) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Release()
+
+	// describing the statement resolves the type, with its modifier,
+	// without running anything
+	sd, err := conn.Conn().PgConn().Prepare(ctx, "", "SELECT NULL::"+typ, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid type %s: %w", typ, err)
+	}
+
+	var formatted string
+	err = conn.QueryRow(ctx, `SELECT format_type($1, $2)`, sd.Fields[0].DataTypeOID, sd.Fields[0].TypeModifier).Scan(&formatted)
+	return formatted, err
+}
+
+// SetExtraColumns changes the extra columns of the queue table, and its
+// template table, from the from set to the to set, matching them by name.
+// Columns only in from are dropped with their data. Columns only in to
+// are added unless they exist already, so columns added by hand can be
+// adopted. Changing a type rewrites the table, and so does changing a
+// generated column, which is dropped and added again.
+func (m *Manager) SetExtraColumns(ctx context.Context, schema SchemaName, name QueueName, from, to []Column) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+
+	old := make(map[string]Column, len(from))
+	for _, c := range from {
+		old[c.Name] = c
+	}
+	kept := make(map[string]bool, len(to))
+
+	// new and retyped text columns follow the queue's text collation
+	collation, err := m.GetTextCollation(ctx, schema, name)
+	if err != nil {
+		return err
+	}
+
+	var actions []string
+	for _, c := range to {
+		kept[c.Name] = true
+		col := pgx.Identifier{c.Name}.Sanitize()
+
+		prev, ok := old[c.Name]
+		if !ok {
+			actions = append(actions, "ADD COLUMN IF NOT EXISTS "+c.definition(collation))
+			continue
+		}
+		if prev.Generated != "" || c.Generated != "" {
+			if prev != c {
+				actions = append(actions, "DROP COLUMN IF EXISTS "+col, "ADD COLUMN "+c.definition(collation))
+			}
+			continue
+		}
+		if prev.Type != c.Type {
+			actions = append(actions, "ALTER COLUMN "+col+" TYPE "+c.Type+c.collateClause(collation))
+		}
+		if prev.Default != c.Default {
+			if c.Default == "" {
+				actions = append(actions, "ALTER COLUMN "+col+" DROP DEFAULT")
+			} else {
+				actions = append(actions, "ALTER COLUMN "+col+" SET DEFAULT "+c.Default)
+			}
+		}
+		if prev.NotNull != c.NotNull {
+			if c.NotNull {
+				actions = append(actions, "ALTER COLUMN "+col+" SET NOT NULL")
+			} else {
+				actions = append(actions, "ALTER COLUMN "+col+" DROP NOT NULL")
+			}
+		}
+	}
+	for _, c := range from {
+		if !kept[c.Name] {
+			actions = append(actions, "DROP COLUMN IF EXISTS "+pgx.Identifier{c.Name}.Sanitize())
+		}
+	}
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, stmt := range []string{
+		"ALTER TABLE " + fqn.Sanitize() + " " + strings.Join(actions, ", "),
+		"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " " + strings.Join(actions, ", "),
+	} {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_extra_columns", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package pgq
+
+import "testing"
+
+func TestColumnDefinition(t *testing.T) {
+	tests := []struct {
+		col       Column
+		collation string
+		want      string
+	}{
+		{Column{Name: "tenant_id", Type: "uuid"}, "", `"tenant_id" uuid`},
+		{Column{Name: "tenant_id", Type: "uuid", NotNull: true}, "", `"tenant_id" uuid NOT NULL`},
+		{Column{Name: "Priority", Type: "smallint", NotNull: true, Default: "0"}, "", `"Priority" smallint NOT NULL DEFAULT 0`},
+		{Column{Name: "type", Type: "text", Generated: "payload->>'type'"}, "C", `"type" text GENERATED ALWAYS AS (payload->>'type') STORED`},
+		{Column{Name: "region", Type: "varchar(16)", NotNull: true}, "C", `"region" varchar(16) COLLATE "C" NOT NULL`},
+		{Column{Name: "tags", Type: "TEXT[]"}, "C", `"tags" TEXT[] COLLATE "C"`},
+		{Column{Name: "tenant_id", Type: "uuid"}, "C", `"tenant_id" uuid`},
+	}
+	for _, tt := range tests {
+		if got := tt.col.definition(tt.collation); got != tt.want {
+			t.Errorf("definition() = %s, want %s", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func commentLiteral(comment string) string {
+	if comment == "" {
+		return "NULL"
+	}
+	return quoteLiteral(comment)
+}
+
+// GetComment returns the comment on the queue table, empty if it has none
+func (m *Manager) GetComment(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var comment string
+	err := m.pool.QueryRow(ctx, `
+		SELECT coalesce(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, name).Scan(&comment)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_comment", fqn, err)
+	}
+
+	return comment, nil
+}
+
+// SetComment sets the comment on the queue table; empty removes it
+func (m *Manager) SetComment(ctx context.Context, schema SchemaName, name QueueName, comment string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	if _, err := m.pool.Exec(ctx, "COMMENT ON TABLE "+fqn.Sanitize()+" IS "+commentLiteral(comment)); err != nil {
+		return wrapErr("set_comment", fqn, err)
+	}
+
+	return nil
+}
+
+// SetIndexComment sets the comment on an index of the queue; empty
+// removes it
+func (m *Manager) SetIndexComment(ctx context.Context, schema SchemaName, name QueueName, index, comment string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	if _, err := m.pool.Exec(ctx, "COMMENT ON INDEX "+MakeFQN(schema, QueueName(index)).Sanitize()+" IS "+commentLiteral(comment)); err != nil {
+		return wrapErr("set_index_comment_"+index, fqn, err)
+	}
+
+	return nil
+}
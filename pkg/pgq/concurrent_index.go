@@ -0,0 +1,183 @@
+package pgq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateIndexConcurrently creates idx on the queue with CREATE INDEX
+// CONCURRENTLY, which doesn't block writes and so can't run in a
+// transaction. Partitioned tables can't be indexed concurrently, so the
+// index is created on the parent only and each partition builds its own
+// concurrently before it is attached; the template table of a partman
+// queue gets a copy too. An invalid index left by an earlier failed build
+// is dropped and built again.
+func (m *Manager) CreateIndexConcurrently(ctx context.Context, schema SchemaName, name QueueName, idx CustomIndex) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	indexName := idx.Name
+	if indexName == "" {
+		indexName = IndexName(name, idx.Columns, idx.Type)
+	}
+
+	if err := m.buildIndexConcurrently(ctx, fqn, schema, name, indexName, idx); err != nil {
+		return err
+	}
+
+	template := QueueName(name.String() + "_template")
+	var hasTemplate bool
+	err = m.pool.QueryRow(ctx, `
+		SELECT to_regclass(format('%I.%I', $1::text, $2::text)) IS NOT NULL
+	`, schema, template).Scan(&hasTemplate)
+	if err != nil {
+		return wrapErr("check_template", fqn, err)
+	}
+	if hasTemplate {
+		if err := m.buildIndexConcurrently(ctx, fqn, schema, template, childIndexName(template, indexName), idx); err != nil {
+			return err
+		}
+	}
+
+	valid, exists, err := m.indexValid(ctx, schema, indexName)
+	if err != nil {
+		return wrapErr("check_index_"+indexName, fqn, err)
+	}
+	if !exists || !valid {
+		return wrapErr("create_index_concurrently_"+indexName, fqn, fmt.Errorf("index %s is invalid after the build", indexName))
+	}
+
+	if idx.Comment != "" {
+		comment := "COMMENT ON INDEX " + MakeFQN(schema, QueueName(indexName)).Sanitize() + " IS " + commentLiteral(idx.Comment)
+		if _, err := m.pool.Exec(ctx, comment); err != nil {
+			return wrapErr("comment_custom_index_"+indexName, fqn, err)
+		}
+	}
+
+	return nil
+}
+
+// buildIndexConcurrently builds indexName on table, recursing into the
+// partitions of a partitioned table. A valid index is left alone.
+func (m *Manager) buildIndexConcurrently(ctx context.Context, fqn FQN, schema SchemaName, table QueueName, indexName string, idx CustomIndex) error {
+	valid, exists, err := m.indexValid(ctx, schema, indexName)
+	if err != nil {
+		return wrapErr("check_index_"+indexName, fqn, err)
+	}
+	if valid {
+		return nil
+	}
+
+	var partitioned bool
+	err = m.pool.QueryRow(ctx, `
+		SELECT relkind = 'p' FROM pg_class WHERE oid = format('%I.%I', $1, $2)::regclass
+	`, schema, table).Scan(&partitioned)
+	if err != nil {
+		return wrapErr("check_partitioned", fqn, err)
+	}
+
+	if !partitioned {
+		if exists {
+			drop := "DROP INDEX CONCURRENTLY IF EXISTS " + MakeFQN(schema, QueueName(indexName)).Sanitize()
+			if _, err := m.pool.Exec(ctx, drop); err != nil {
+				return wrapErr("drop_invalid_index_"+indexName, fqn, err)
+			}
+		}
+		if _, err := m.pool.Exec(ctx, createIndexSQL(schema, table, indexName, idx, true, false)); err != nil {
+			return wrapErr("create_index_concurrently_"+indexName, fqn, err)
+		}
+		return nil
+	}
+
+	// an invalid index on a partitioned table is one with partitions
+	// still missing their index, so building it resumes from there
+	if _, err := m.pool.Exec(ctx, createIndexSQL(schema, table, indexName, idx, false, true)); err != nil {
+		return wrapErr("create_index_concurrently_"+indexName, fqn, err)
+	}
+
+	// partitions created since the parent index was get a copy of it
+	rows, err := m.pool.Query(ctx, `
+		SELECT n.nspname, c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE i.inhparent = format('%I.%I', $1, $2)::regclass
+		  AND NOT EXISTS (
+		      SELECT 1
+		      FROM pg_inherits ii
+		      JOIN pg_index x ON x.indexrelid = ii.inhrelid
+		      WHERE ii.inhparent = format('%I.%I', $1, $3)::regclass
+		        AND x.indrelid = c.oid
+		        AND x.indisvalid
+		  )
+		ORDER BY c.relname
+	`, schema, table, indexName)
+	if err != nil {
+		return wrapErr("get_partitions", fqn, err)
+	}
+	type partition struct {
+		Schema SchemaName
+		Name   QueueName
+	}
+	partitions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[partition])
+	if err != nil {
+		return wrapErr("get_partitions", fqn, err)
+	}
+
+	for _, p := range partitions {
+		partIndex := childIndexName(p.Name, indexName)
+		if err := m.buildIndexConcurrently(ctx, fqn, p.Schema, p.Name, partIndex, idx); err != nil {
+			return err
+		}
+
+		attach := "ALTER INDEX " + MakeFQN(schema, QueueName(indexName)).Sanitize() +
+			" ATTACH PARTITION " + MakeFQN(p.Schema, QueueName(partIndex)).Sanitize()
+		if _, err := m.pool.Exec(ctx, attach); err != nil {
+			return wrapErr("attach_index_"+partIndex, fqn, err)
+		}
+	}
+
+	return nil
+}
+
+// indexValid reports whether the index exists in schema and is valid
+func (m *Manager) indexValid(ctx context.Context, schema SchemaName, index string) (valid, exists bool, err error) {
+	err = m.pool.QueryRow(ctx, `
+		SELECT x.indisvalid
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		WHERE n.nspname = $1 AND ci.relname = $2
+	`, schema, index).Scan(&valid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return valid, true, nil
+}
+
+// childIndexName names the copy of a queue index on table, one of its
+// partitions or its template: the table name and a hash of the queue
+// index name, cut to the identifier length limit
+func childIndexName(table QueueName, index string) string {
+	hash := sha256.Sum256([]byte(index))
+	suffix := "_" + hex.EncodeToString(hash[:])[:hashLength] + "_idx"
+
+	base := table.String()
+	if len(base)+len(suffix) > maxIdentifierLength {
+		base = base[:maxIdentifierLength-len(suffix)]
+	}
+	return base + suffix
+}
@@ -0,0 +1,21 @@
+package pgq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChildIndexName(t *testing.T) {
+	got := childIndexName("orders_p20260101", "orders_created_at_idx")
+	if !strings.HasPrefix(got, "orders_p20260101_") || !strings.HasSuffix(got, "_idx") {
+		t.Errorf("childIndexName() = %q, want orders_p20260101_<hash>_idx", got)
+	}
+	if got == childIndexName("orders_p20260101", "orders_payload_idx") {
+		t.Error("childIndexName() should depend on the parent index")
+	}
+
+	long := childIndexName(QueueName(strings.Repeat("q", 60)), "orders_created_at_idx")
+	if len(long) != maxIdentifierLength || !strings.HasSuffix(long, "_idx") {
+		t.Errorf("childIndexName() = %q, want it cut to %d characters keeping the suffix", long, maxIdentifierLength)
+	}
+}
@@ -0,0 +1,130 @@
+package pgq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CheckConstraint is a CHECK constraint on a queue table
+type CheckConstraint struct {
+	Name string
+	// Expression is the boolean expression, without CHECK and parentheses
+	Expression string
+}
+
+// definition returns the constraint as written in CREATE TABLE
+func (c CheckConstraint) definition() string {
+	return "CONSTRAINT " + pgx.Identifier{c.Name}.Sanitize() + " CHECK (" + c.Expression + ")"
+}
+
+// GetCheckConstraints returns the CHECK constraints of the queue table,
+// by name. Expressions are as the server deparses them, e.g.
+// (jsonb_typeof(payload) = 'object'::text) for jsonb_typeof(payload) = 'object'.
+func (m *Manager) GetCheckConstraints(ctx context.Context, schema SchemaName, name QueueName) ([]CheckConstraint, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT co.conname, pg_get_constraintdef(co.oid)
+		FROM pg_constraint co
+		JOIN pg_class c ON c.oid = co.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND co.contype = 'c'
+		ORDER BY co.conname
+	`, schema, name)
+	if err != nil {
+		return nil, wrapErr("get_check_constraints", fqn, err)
+	}
+
+	constraints, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (CheckConstraint, error) {
+		var c CheckConstraint
+		var def string
+		err := row.Scan(&c.Name, &def)
+		c.Expression = checkExpression(def)
+		return c, err
+	})
+	if err != nil {
+		return nil, wrapErr("get_check_constraints", fqn, err)
+	}
+
+	return constraints, nil
+}
+
+// checkExpression strips CHECK (...) and a NOT VALID suffix from a
+// constraint definition
+func checkExpression(def string) string {
+	def = strings.TrimSuffix(def, " NOT VALID")
+	def = strings.TrimPrefix(def, "CHECK ")
+	if strings.HasPrefix(def, "(") && strings.HasSuffix(def, ")") {
+		def = def[1 : len(def)-1]
+	}
+	return def
+}
+
+// SetCheckConstraints changes the CHECK constraints of the queue table, and
+// its template table, from the from set to the to set, matching them by
+// name. Changed constraints are dropped and added again. Adding a
+// constraint validates the existing rows.
+func (m *Manager) SetCheckConstraints(ctx context.Context, schema SchemaName, name QueueName, from, to []CheckConstraint) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+
+	old := make(map[string]CheckConstraint, len(from))
+	for _, c := range from {
+		old[c.Name] = c
+	}
+	kept := make(map[string]bool, len(to))
+
+	var drops, adds []string
+	for _, c := range to {
+		prev, ok := old[c.Name]
+		if ok && prev.Expression == c.Expression {
+			kept[c.Name] = true
+			continue
+		}
+		adds = append(adds, "ADD "+c.definition())
+	}
+	for _, c := range from {
+		if !kept[c.Name] {
+			drops = append(drops, "DROP CONSTRAINT IF EXISTS "+pgx.Identifier{c.Name}.Sanitize())
+		}
+	}
+
+	actions := append(drops, adds...)
+	if len(actions) == 0 {
+		return nil
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, stmt := range []string{
+		"ALTER TABLE " + fqn.Sanitize() + " " + strings.Join(actions, ", "),
+		"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " " + strings.Join(actions, ", "),
+	} {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_check_constraints", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
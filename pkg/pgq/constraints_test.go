@@ -0,0 +1,19 @@
+package pgq
+
+import "testing"
+
+func TestCheckExpression(t *testing.T) {
+	tests := []struct {
+		def  string
+		want string
+	}{
+		{"CHECK ((consumed_count < 100))", "(consumed_count < 100)"},
+		{"CHECK ((jsonb_typeof(payload) = 'object'::text)) NOT VALID", "(jsonb_typeof(payload) = 'object'::text)"},
+		{"CHECK (flag)", "flag"},
+	}
+	for _, tt := range tests {
+		if got := checkExpression(tt.def); got != tt.want {
+			t.Errorf("checkExpression(%q) = %q, want %q", tt.def, got, tt.want)
+		}
+	}
+}
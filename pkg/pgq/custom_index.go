@@ -0,0 +1,559 @@
+package pgq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxColumnNameLength = 20
+	hashLength          = 8
+
+	// Default pgq index suffixes
+	indexCreatedAt       = "_created_at_idx"
+	indexProcessedAtNull = "_processed_at_null_idx"
+	indexScheduledFor    = "_scheduled_for_idx"
+	indexMetadata        = "_metadata_idx"
+	indexOrdering        = "_seq_idx"
+)
+
+type CustomIndex struct {
+	Name string
+	// Columns are the index columns as written in CREATE INDEX, each an
+	// expression optionally followed by an operator class and sort order,
+	// see IndexColumn
+	Columns []string
+	Type    string
+	Where   string
+	// Comment is set with COMMENT ON INDEX, empty for none
+	Comment string
+	// With are the storage parameters, e.g. fillfactor
+	With map[string]string
+	// Tablespace is empty for the default tablespace
+	Tablespace string
+}
+
+// IndexColumn is one column of a custom index
+type IndexColumn struct {
+	Expression string
+	// Opclass is the operator class, e.g. jsonb_path_ops, empty for the
+	// type's default
+	Opclass string
+	// Order is ASC or DESC, empty for ascending
+	Order string
+	// Nulls is FIRST or LAST, empty for the default of the order
+	Nulls string
+}
+
+// String returns the column as written in CREATE INDEX
+func (c IndexColumn) String() string {
+	var sb strings.Builder
+	sb.WriteString(c.Expression)
+	if c.Opclass != "" {
+		sb.WriteString(" ")
+		sb.WriteString(c.Opclass)
+	}
+	if c.Order != "" {
+		sb.WriteString(" ")
+		sb.WriteString(c.Order)
+	}
+	if c.Nulls != "" {
+		sb.WriteString(" NULLS ")
+		sb.WriteString(c.Nulls)
+	}
+	return sb.String()
+}
+
+// Equivalent reports whether c and o define the same index column, taking
+// the defaults of the sort order and the server's spelling of expressions
+// into account
+func (c IndexColumn) Equivalent(o IndexColumn) bool {
+	return EquivalentExpressions(c.Expression, o.Expression) && c.Opclass == o.Opclass &&
+		c.order() == o.order() && c.nulls() == o.nulls()
+}
+
+func (c IndexColumn) order() string {
+	if c.Order == "" {
+		return "ASC"
+	}
+	return strings.ToUpper(c.Order)
+}
+
+// nulls returns where NULLs sort: last in ascending, first in descending
+// order unless set
+func (c IndexColumn) nulls() string {
+	if c.Nulls != "" {
+		return strings.ToUpper(c.Nulls)
+	}
+	if c.order() == "DESC" {
+		return "FIRST"
+	}
+	return "LAST"
+}
+
+// ParseIndexColumn splits an index column as written in CREATE INDEX, or
+// as returned by pg_get_indexdef, into its parts
+func ParseIndexColumn(s string) IndexColumn {
+	fields := splitTopLevel(s, unicode.IsSpace)
+	n := len(fields)
+
+	var c IndexColumn
+	if n > 2 && strings.EqualFold(fields[n-2], "NULLS") &&
+		(strings.EqualFold(fields[n-1], "FIRST") || strings.EqualFold(fields[n-1], "LAST")) {
+		c.Nulls = strings.ToUpper(fields[n-1])
+		n -= 2
+	}
+	if n > 1 && (strings.EqualFold(fields[n-1], "ASC") || strings.EqualFold(fields[n-1], "DESC")) {
+		c.Order = strings.ToUpper(fields[n-1])
+		n--
+	}
+	// anything left after the expression and its collation is the opclass
+	if n > 1 && !(n > 2 && strings.EqualFold(fields[n-2], "COLLATE")) {
+		c.Opclass = fields[n-1]
+		n--
+	}
+	c.Expression = strings.Join(fields[:n], " ")
+
+	return c
+}
+
+// IndexColumnsEquivalent reports whether two lists of index columns, as
+// written in CREATE INDEX, define the same index
+func IndexColumnsEquivalent(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !ParseIndexColumn(a[i]).Equivalent(ParseIndexColumn(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddCustomIndexes creates indexes on a queue in a transaction of its
+// own. The DDL slot is taken before a connection is acquired, so waiting
+// for a slot doesn't hold a pool connection.
+func (m *Manager) AddCustomIndexes(ctx context.Context, schema SchemaName, name QueueName, indexes []CustomIndex) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	return m.retryTx(ctx, fqn, "commit_custom_indexes", func(tx pgx.Tx) error {
+		return m.CreateCustomIndexes(ctx, tx, schema, name, indexes)
+	})
+}
+
+// CreateCustomIndexes creates indexes on a queue in tx. Callers should hold
+// a DDL slot before opening tx, as AddCustomIndexes does.
+func (m *Manager) CreateCustomIndexes(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, indexes []CustomIndex) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	for _, idx := range indexes {
+		indexName := idx.Name
+		if indexName == "" {
+			indexName = IndexName(name, idx.Columns, idx.Type)
+		}
+
+		if _, err := tx.Exec(ctx, createIndexSQL(schema, name, indexName, idx, false, false)); err != nil {
+			return wrapErr("create_custom_index_"+indexName, fqn, err)
+		}
+		if err := createTemplateIndex(ctx, tx, schema, name, indexName, idx); err != nil {
+			return err
+		}
+
+		if idx.Comment != "" {
+			comment := "COMMENT ON INDEX " + MakeFQN(schema, QueueName(indexName)).Sanitize() + " IS " + commentLiteral(idx.Comment)
+			if _, err := tx.Exec(ctx, comment); err != nil {
+				return wrapErr("comment_custom_index_"+indexName, fqn, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createTemplateIndex copies the queue index indexName to the template
+// table of a partman queue, which pg_partman creates new partitions from.
+// Existing partitions have the index already, through the partitioned
+// parent index. Queues without a template are left alone.
+func createTemplateIndex(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, indexName string, idx CustomIndex) error {
+	fqn := MakeFQN(schema, name)
+	template := QueueName(name.String() + "_template")
+
+	var exists bool
+	err := tx.QueryRow(ctx, `
+		SELECT to_regclass(format('%I.%I', $1::text, $2::text)) IS NOT NULL
+	`, schema, template).Scan(&exists)
+	if err != nil {
+		return wrapErr("check_template", fqn, err)
+	}
+	if !exists {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, createIndexSQL(schema, template, childIndexName(template, indexName), idx, false, false)); err != nil {
+		return wrapErr("create_template_index_"+indexName, fqn, err)
+	}
+	return nil
+}
+
+// SyncTemplateIndexes copies the custom indexes of a partman queue that
+// its template table is missing, e.g. for queues whose indexes were
+// created before they were copied to the template
+func (m *Manager) SyncTemplateIndexes(ctx context.Context, schema SchemaName, name QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	indexes, err := m.queryCustomIndexes(ctx, schema, name)
+	if err != nil {
+		return err
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	return m.retryTx(ctx, fqn, "commit_template_indexes", func(tx pgx.Tx) error {
+		for _, idx := range indexes {
+			if err := createTemplateIndex(ctx, tx, schema, name, idx.Name, idx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createIndexSQL returns the CREATE INDEX statement for idx on table,
+// optionally built CONCURRENTLY or ON ONLY a partitioned table
+func createIndexSQL(schema SchemaName, table QueueName, indexName string, idx CustomIndex, concurrently, only bool) string {
+	var sql strings.Builder
+	sql.WriteString("CREATE INDEX ")
+	if concurrently {
+		sql.WriteString("CONCURRENTLY ")
+	}
+	sql.WriteString("IF NOT EXISTS ")
+	sql.WriteString(pgx.Identifier{indexName}.Sanitize())
+	sql.WriteString(" ON ")
+	if only {
+		sql.WriteString("ONLY ")
+	}
+	sql.WriteString(schema.Sanitize())
+	sql.WriteString(".")
+	sql.WriteString(table.Sanitize())
+
+	if idx.Type != "" && idx.Type != "btree" {
+		sql.WriteString(" USING ")
+		sql.WriteString(idx.Type)
+	}
+
+	sql.WriteString(" (")
+	for i, col := range idx.Columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(col)
+	}
+	sql.WriteString(")")
+
+	if len(idx.With) > 0 {
+		sql.WriteString(" WITH (")
+		for i, key := range slices.Sorted(maps.Keys(idx.With)) {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(key)
+			sql.WriteString(" = ")
+			sql.WriteString(quoteLiteral(idx.With[key]))
+		}
+		sql.WriteString(")")
+	}
+
+	sql.WriteString(tablespaceClause(idx.Tablespace))
+
+	if idx.Where != "" {
+		sql.WriteString(" WHERE ")
+		sql.WriteString(idx.Where)
+	}
+
+	return sql.String()
+}
+
+func (m *Manager) GetCustomIndexes(ctx context.Context, schema SchemaName, name QueueName) ([]CustomIndex, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var indexes []CustomIndex
+	err := m.retry(ctx, func() (err error) {
+		indexes, err = m.queryCustomIndexes(ctx, schema, name)
+		return err
+	})
+	return indexes, err
+}
+
+func (m *Manager) queryCustomIndexes(ctx context.Context, schema SchemaName, name QueueName) ([]CustomIndex, error) {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT
+			i.relname AS index_name,
+			pg_get_indexdef(i.oid) AS index_def,
+			coalesce(obj_description(i.oid, 'pg_class'), '') AS index_comment,
+			coalesce(ts.spcname, '') AS index_tablespace
+		FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		LEFT JOIN pg_tablespace ts ON ts.oid = i.reltablespace
+		WHERE n.nspname = $1
+		  AND t.relname = $2
+		  AND i.relname NOT LIKE '%_pkey'
+		  AND i.relname NOT IN (
+		      $3, $4, $5, $6, $7
+		  )
+		ORDER BY i.relname
+	`, schema, name,
+		name.String()+indexCreatedAt,
+		name.String()+indexProcessedAtNull,
+		name.String()+indexScheduledFor,
+		name.String()+indexMetadata,
+		name.String()+indexOrdering)
+
+	if err != nil {
+		return nil, wrapErr("get_custom_indexes", fqn, err)
+	}
+	defer rows.Close()
+
+	var indexes []CustomIndex
+	for rows.Next() {
+		var indexName, indexDef, comment, tablespace string
+		if err := rows.Scan(&indexName, &indexDef, &comment, &tablespace); err != nil {
+			return nil, wrapErr("scan_custom_index", fqn, err)
+		}
+
+		idx := parseIndexDef(indexName, indexDef)
+		idx.Comment = comment
+		idx.Tablespace = tablespace
+		indexes = append(indexes, idx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr("get_custom_indexes_rows", fqn, err)
+	}
+
+	return indexes, nil
+}
+
+func (m *Manager) DropCustomIndexes(ctx context.Context, schema SchemaName, name QueueName, indexNames []string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	template := QueueName(name.String() + "_template")
+	for _, indexName := range indexNames {
+		sql := fmt.Sprintf("DROP INDEX IF EXISTS %s.%s, %s.%s",
+			schema.Sanitize(),
+			pgx.Identifier{indexName}.Sanitize(),
+			schema.Sanitize(),
+			pgx.Identifier{childIndexName(template, indexName)}.Sanitize())
+
+		if _, err := m.pool.Exec(ctx, sql); err != nil {
+			return wrapErr("drop_custom_index_"+indexName, fqn, err)
+		}
+	}
+
+	return nil
+}
+
+// IndexName returns the name CreateCustomIndexes gives an index on queue
+// without an explicit name: the queue name, shortened columns and type,
+// and a hash of the columns, cut to the identifier length limit
+func IndexName(queue QueueName, columns []string, indexType string) string {
+	// Use strings.Replacer for efficient multiple replacements
+	replacer := strings.NewReplacer(
+		"(", "",
+		")", "",
+		"'", "",
+		"\"", "",
+		"->>", "_",
+		"->", "_",
+		" ", "_",
+	)
+
+	parts := []string{queue.String()}
+	for _, col := range columns {
+		clean := replacer.Replace(col)
+		if len(clean) > maxColumnNameLength {
+			clean = clean[:maxColumnNameLength]
+		}
+		parts = append(parts, clean)
+	}
+
+	baseName := strings.Join(parts, "_")
+
+	if indexType != "" && indexType != "btree" {
+		baseName += "_" + indexType
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(columns, ",")))
+	baseName += "_" + hex.EncodeToString(hash[:])[:hashLength]
+	baseName += "_idx"
+
+	if len(baseName) > maxIdentifierLength {
+		baseName = baseName[:maxIdentifierLength]
+	}
+
+	return baseName
+}
+
+func parseIndexDef(name, def string) CustomIndex {
+	idx := CustomIndex{Name: name}
+
+	if strings.Contains(def, " USING gin ") {
+		idx.Type = "gin"
+	} else if strings.Contains(def, " USING gist ") {
+		idx.Type = "gist"
+	} else if strings.Contains(def, " USING hash ") {
+		idx.Type = "hash"
+	} else if strings.Contains(def, " USING brin ") {
+		idx.Type = "brin"
+	} else {
+		idx.Type = "btree"
+	}
+
+	// the column list is the parenthesized group after USING method
+	using := max(strings.Index(def, " USING "), 0)
+	columnsStart := strings.Index(def[using:], "(")
+	if columnsStart == -1 {
+		return idx
+	}
+	columnsStart += using
+	columnsEnd := closingParen(def, columnsStart)
+	if columnsEnd == -1 {
+		return idx
+	}
+	idx.Columns = splitTopLevel(def[columnsStart+1:columnsEnd], func(r rune) bool { return r == ',' })
+
+	rest := def[columnsEnd:]
+	if whereIdx := strings.Index(rest, " WHERE "); whereIdx != -1 {
+		idx.Where = strings.TrimSpace(rest[whereIdx+7:])
+		rest = rest[:whereIdx]
+	}
+
+	if withIdx := strings.Index(rest, " WITH ("); withIdx != -1 {
+		withStart := withIdx + len(" WITH ")
+		if withEnd := closingParen(rest, withStart); withEnd != -1 {
+			idx.With = parseReloptions(rest[withStart+1 : withEnd])
+		}
+	}
+
+	return idx
+}
+
+// parseReloptions parses storage parameters as pg_get_indexdef prints them,
+// e.g. fillfactor='70', fastupdate=off
+func parseReloptions(s string) map[string]string {
+	opts := make(map[string]string)
+	for _, opt := range splitTopLevel(s, func(r rune) bool { return r == ',' }) {
+		key, value, _ := strings.Cut(opt, "=")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		opts[strings.TrimSpace(key)] = value
+	}
+	return opts
+}
+
+// splitTopLevel splits s at the runes sep accepts outside parentheses,
+// brackets and quotes, trimming the parts and dropping empty ones
+func splitTopLevel(s string, sep func(rune) bool) []string {
+	var (
+		parts []string
+		depth int
+		quote rune
+		start int
+	)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			continue
+		case r == '\'' || r == '"':
+			quote = r
+			continue
+		case r == '(' || r == '[':
+			depth++
+			continue
+		case r == ')' || r == ']':
+			depth--
+			continue
+		}
+		if depth == 0 && sep(r) {
+			if part := strings.TrimSpace(s[start:i]); part != "" {
+				parts = append(parts, part)
+			}
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	if part := strings.TrimSpace(s[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// closingParen returns the index of the parenthesis closing the one at
+// open, skipping quoted text, or -1 if there is none
+func closingParen(s string, open int) int {
+	var (
+		depth int
+		quote rune
+	)
+	for i, r := range s[open:] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return open + i
+			}
+		}
+	}
+	return -1
+}
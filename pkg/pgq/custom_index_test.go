@@ -0,0 +1,154 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexName(t *testing.T) {
+	got := IndexName("orders", []string{"(payload->>'user_id')"}, "btree")
+	if !strings.HasPrefix(got, "orders_payload_user_id_") || !strings.HasSuffix(got, "_idx") ||
+		len(got) != len("orders_payload_user_id_")+hashLength+len("_idx") {
+		t.Errorf("IndexName() = %q, want orders_payload_user_id_<hash>_idx", got)
+	}
+
+	if gin := IndexName("orders", []string{"payload"}, "gin"); !strings.HasPrefix(gin, "orders_payload_gin_") {
+		t.Errorf("IndexName() = %q, want the type after the columns", gin)
+	}
+
+	long := IndexName(QueueName(strings.Repeat("q", 60)), []string{"created_at"}, "btree")
+	if len(long) != maxIdentifierLength {
+		t.Errorf("IndexName() is %d characters, want it cut to %d", len(long), maxIdentifierLength)
+	}
+
+	if IndexName("orders", []string{"a", "b"}, "btree") == IndexName("orders", []string{"b", "a"}, "btree") {
+		t.Error("IndexName() should depend on the column order")
+	}
+}
+
+func TestParseIndexColumn(t *testing.T) {
+	tests := []struct {
+		in   string
+		want IndexColumn
+	}{
+		{"created_at", IndexColumn{Expression: "created_at"}},
+		{"created_at DESC NULLS LAST", IndexColumn{Expression: "created_at", Order: "DESC", Nulls: "LAST"}},
+		{"payload jsonb_path_ops", IndexColumn{Expression: "payload", Opclass: "jsonb_path_ops"}},
+		{"((payload ->> 'user id'::text)) text_pattern_ops DESC", IndexColumn{Expression: "((payload ->> 'user id'::text))", Opclass: "text_pattern_ops", Order: "DESC"}},
+		{`name COLLATE "C"`, IndexColumn{Expression: `name COLLATE "C"`}},
+		{`name COLLATE "C" text_pattern_ops`, IndexColumn{Expression: `name COLLATE "C"`, Opclass: "text_pattern_ops"}},
+		{`"desc" nulls first`, IndexColumn{Expression: `"desc"`, Nulls: "FIRST"}},
+		{"lower(coalesce(a, b))", IndexColumn{Expression: "lower(coalesce(a, b))"}},
+	}
+	for _, tt := range tests {
+		got := ParseIndexColumn(tt.in)
+		if got != tt.want {
+			t.Errorf("ParseIndexColumn(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if ParseIndexColumn(got.String()) != got {
+			t.Errorf("ParseIndexColumn(%q) doesn't round-trip through String() = %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestIndexColumnsEquivalent(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{[]string{"created_at"}, []string{"created_at ASC NULLS LAST"}, true},
+		{[]string{"created_at DESC"}, []string{"created_at DESC NULLS FIRST"}, true},
+		{[]string{"created_at DESC"}, []string{"created_at DESC NULLS LAST"}, false},
+		{[]string{"created_at NULLS FIRST"}, []string{"created_at"}, false},
+		{[]string{"payload jsonb_path_ops"}, []string{"payload"}, false},
+		{[]string{"a", "b"}, []string{"b", "a"}, false},
+		{[]string{"a"}, []string{"a", "b"}, false},
+		{[]string{"(payload->>'user_id') text_pattern_ops"}, []string{"((payload ->> 'user_id'::text)) text_pattern_ops"}, true},
+	}
+	for _, tt := range tests {
+		if got := IndexColumnsEquivalent(tt.a, tt.b); got != tt.want {
+			t.Errorf("IndexColumnsEquivalent(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseIndexDef(t *testing.T) {
+	got := parseIndexDef("orders_idx", `CREATE INDEX orders_idx ON public.orders USING btree (created_at DESC NULLS LAST, COALESCE(a, b), ((payload ->> 'x, y'::text)) text_pattern_ops) INCLUDE (id) WHERE (processed_at IS NULL)`)
+	want := []string{"created_at DESC NULLS LAST", "COALESCE(a, b)", "((payload ->> 'x, y'::text)) text_pattern_ops"}
+	if strings.Join(got.Columns, "|") != strings.Join(want, "|") {
+		t.Errorf("parseIndexDef() columns = %q, want %q", got.Columns, want)
+	}
+	if got.Type != "btree" || got.Where != "(processed_at IS NULL)" {
+		t.Errorf("parseIndexDef() = %+v, want a btree WHERE (processed_at IS NULL)", got)
+	}
+
+	gin := parseIndexDef("orders_gin", `CREATE INDEX orders_gin ON public.orders USING gin (payload jsonb_path_ops)`)
+	if gin.Type != "gin" || len(gin.Columns) != 1 || gin.Columns[0] != "payload jsonb_path_ops" || gin.Where != "" {
+		t.Errorf("parseIndexDef() = %+v, want a gin index on payload jsonb_path_ops", gin)
+	}
+}
+
+func TestCreateIndexSQL(t *testing.T) {
+	idx := CustomIndex{Columns: []string{"payload jsonb_path_ops"}, Type: "gin", Where: "processed_at IS NULL"}
+
+	got := createIndexSQL("public", "orders", "orders_idx", idx, false, false)
+	want := `CREATE INDEX IF NOT EXISTS "orders_idx" ON "public"."orders" USING gin (payload jsonb_path_ops) WHERE processed_at IS NULL`
+	if got != want {
+		t.Errorf("createIndexSQL() = %q, want %q", got, want)
+	}
+
+	if got := createIndexSQL("public", "orders", "orders_idx", idx, true, false); !strings.HasPrefix(got, `CREATE INDEX CONCURRENTLY IF NOT EXISTS "orders_idx" ON "public"`) {
+		t.Errorf("createIndexSQL() = %q, want a concurrent build", got)
+	}
+	if got := createIndexSQL("public", "orders", "orders_idx", idx, false, true); !strings.Contains(got, ` ON ONLY "public"."orders" `) {
+		t.Errorf("createIndexSQL() = %q, want the parent only", got)
+	}
+}
+
+func TestIndexStorage(t *testing.T) {
+	idx := CustomIndex{
+		Columns:    []string{"payload"},
+		Type:       "gin",
+		With:       map[string]string{"fastupdate": "off", "gin_pending_list_limit": "4096"},
+		Tablespace: "fast_ssd",
+		Where:      "processed_at IS NULL",
+	}
+	got := createIndexSQL("public", "orders", "orders_idx", idx, false, false)
+	want := `CREATE INDEX IF NOT EXISTS "orders_idx" ON "public"."orders" USING gin (payload) WITH (fastupdate = 'off', gin_pending_list_limit = '4096') TABLESPACE "fast_ssd" WHERE processed_at IS NULL`
+	if got != want {
+		t.Errorf("createIndexSQL() = %q, want %q", got, want)
+	}
+
+	parsed := parseIndexDef("orders_idx", `CREATE INDEX orders_idx ON public.orders USING gin (payload) WITH (fastupdate=off, gin_pending_list_limit='4096') WHERE (processed_at IS NULL)`)
+	if len(parsed.With) != 2 || parsed.With["fastupdate"] != "off" || parsed.With["gin_pending_list_limit"] != "4096" {
+		t.Errorf("parseIndexDef() with = %v, want the storage parameters", parsed.With)
+	}
+	if len(parsed.Columns) != 1 || parsed.Where != "(processed_at IS NULL)" {
+		t.Errorf("parseIndexDef() = %+v", parsed)
+	}
+
+	if plain := parseIndexDef("orders_idx", `CREATE INDEX orders_idx ON public.orders USING btree (created_at)`); plain.With != nil {
+		t.Errorf("parseIndexDef() with = %v, want none", plain.With)
+	}
+}
+
+func TestAddCustomIndexesWaitsForSlot(t *testing.T) {
+	// a nil pool panics if a connection is acquired before the slot
+	m := NewManagerWithOptions(nil, &ManagerOptions{MaxConcurrentDDL: 1})
+
+	_, release, err := m.beginDDL(context.Background())
+	if err != nil {
+		t.Fatalf("beginDDL() error = %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = m.AddCustomIndexes(ctx, "public", "jobs", []CustomIndex{{Columns: []string{"payload"}}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AddCustomIndexes() with a held slot error = %v, want deadline exceeded", err)
+	}
+}
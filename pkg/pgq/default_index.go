@@ -0,0 +1,303 @@
+package pgq
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultIndexes selects the built-in indexes of a queue
+type DefaultIndexes struct {
+	CreatedAt       bool
+	ProcessedAtNull bool
+	ScheduledFor    bool
+	Metadata        bool
+	// MetadataOpclass is the operator class of the metadata GIN index,
+	// e.g. jsonb_path_ops; empty uses jsonb_ops
+	MetadataOpclass string
+}
+
+// AllDefaultIndexes returns the built-in indexes a queue gets unless
+// configured otherwise
+func AllDefaultIndexes() *DefaultIndexes {
+	return &DefaultIndexes{CreatedAt: true, ProcessedAtNull: true, ScheduledFor: true, Metadata: true}
+}
+
+// defaultIndexDefs returns the built-in indexes of queue selected by d,
+// all of them when d is nil
+func defaultIndexDefs(name QueueName, d *DefaultIndexes, tablespace string) []CustomIndex {
+	if d == nil {
+		d = AllDefaultIndexes()
+	}
+
+	var indexes []CustomIndex
+	add := func(enabled bool, suffix, indexType, column, where string) {
+		if enabled {
+			indexes = append(indexes, CustomIndex{
+				Name:       name.String() + suffix,
+				Columns:    []string{column},
+				Type:       indexType,
+				Where:      where,
+				Tablespace: tablespace,
+			})
+		}
+	}
+	add(d.CreatedAt, indexCreatedAt, "", "created_at", "")
+	add(d.ProcessedAtNull, indexProcessedAtNull, "", "processed_at", "(processed_at IS NULL)")
+	add(d.ScheduledFor, indexScheduledFor, "", "scheduled_for ASC NULLS LAST", "(processed_at IS NULL)")
+	add(d.Metadata, indexMetadata, "gin", IndexColumn{Expression: "metadata", Opclass: d.MetadataOpclass}.String(), "processed_at IS NULL")
+
+	return indexes
+}
+
+// GetDefaultIndexes returns the built-in indexes the queue has
+func (m *Manager) GetDefaultIndexes(ctx context.Context, schema SchemaName, name QueueName) (*DefaultIndexes, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var d *DefaultIndexes
+	err := m.retry(ctx, func() (err error) {
+		d, err = m.queryDefaultIndexes(ctx, schema, name)
+		return err
+	})
+	return d, err
+}
+
+func (m *Manager) queryDefaultIndexes(ctx context.Context, schema SchemaName, name QueueName) (*DefaultIndexes, error) {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT ci.relname, pg_get_indexdef(ci.oid)
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		WHERE x.indrelid = format('%I.%I', $1::text, $2::text)::regclass
+		  AND x.indisvalid
+		  AND ci.relname IN ($3, $4, $5, $6)
+	`, schema, name,
+		name.String()+indexCreatedAt,
+		name.String()+indexProcessedAtNull,
+		name.String()+indexScheduledFor,
+		name.String()+indexMetadata)
+	if err != nil {
+		return nil, wrapErr("get_default_indexes", fqn, err)
+	}
+
+	d := &DefaultIndexes{}
+	var index, def string
+	_, err = pgx.ForEachRow(rows, []any{&index, &def}, func() error {
+		switch index {
+		case name.String() + indexCreatedAt:
+			d.CreatedAt = true
+		case name.String() + indexProcessedAtNull:
+			d.ProcessedAtNull = true
+		case name.String() + indexScheduledFor:
+			d.ScheduledFor = true
+		case name.String() + indexMetadata:
+			d.Metadata = true
+			if cols := parseIndexDef(index, def).Columns; len(cols) == 1 {
+				d.MetadataOpclass = ParseIndexColumn(cols[0]).Opclass
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapErr("get_default_indexes", fqn, err)
+	}
+
+	return d, nil
+}
+
+// SetDefaultIndexes creates the built-in indexes of the queue selected by
+// want and drops the others, on the queue and its template table. An index
+// whose operator class changes is rebuilt. tablespace is where new indexes
+// go, empty for the database default.
+func (m *Manager) SetDefaultIndexes(ctx context.Context, schema SchemaName, name QueueName, want *DefaultIndexes, tablespace string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	have, err := m.queryDefaultIndexes(ctx, schema, name)
+	if err != nil {
+		return err
+	}
+
+	haveDefs := make(map[string]CustomIndex)
+	for _, idx := range defaultIndexDefs(name, have, "") {
+		haveDefs[idx.Name] = idx
+	}
+	wantDefs := make(map[string]CustomIndex)
+	for _, idx := range defaultIndexDefs(name, want, tablespace) {
+		wantDefs[idx.Name] = idx
+	}
+
+	return m.retryTx(ctx, fqn, "commit_default_indexes", func(tx pgx.Tx) error {
+		for _, idx := range defaultIndexDefs(name, AllDefaultIndexes(), "") {
+			h, hasIt := haveDefs[idx.Name]
+			w, wantIt := wantDefs[idx.Name]
+			same := hasIt && wantIt && IndexColumnsEquivalent(h.Columns, w.Columns)
+
+			if hasIt && !same {
+				if err := dropTemplateCopies(ctx, tx, schema, name, idx.Name); err != nil {
+					return err
+				}
+				if _, err := tx.Exec(ctx, "DROP INDEX "+MakeFQN(schema, QueueName(idx.Name)).Sanitize()); err != nil {
+					return wrapErr("drop_index_"+idx.Name, fqn, err)
+				}
+			}
+			if wantIt && !same {
+				// an invalid index left by a failed build counts as missing
+				if !hasIt {
+					if _, err := tx.Exec(ctx, "DROP INDEX IF EXISTS "+MakeFQN(schema, QueueName(idx.Name)).Sanitize()); err != nil {
+						return wrapErr("drop_index_"+idx.Name, fqn, err)
+					}
+				}
+				if _, err := tx.Exec(ctx, createIndexSQL(schema, name, w.Name, w, false, false)); err != nil {
+					return wrapErr("create_index_"+idx.Name, fqn, err)
+				}
+				if err := createTemplateIndex(ctx, tx, schema, name, w.Name, w); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// orderingIndexDef is the index on the ordering column
+func orderingIndexDef(name QueueName, tablespace string) CustomIndex {
+	return CustomIndex{
+		Name:       name.String() + indexOrdering,
+		Columns:    []string{orderingColumn},
+		Tablespace: tablespace,
+	}
+}
+
+// MissingDefaultIndexes returns the built-in indexes selected by want, and
+// the ordering index if the queue has the ordering column, that the queue
+// lacks, e.g. after a manual DROP INDEX, or has invalid, e.g. after a
+// failed build
+func (m *Manager) MissingDefaultIndexes(ctx context.Context, schema SchemaName, name QueueName, want *DefaultIndexes) ([]string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var missing []CustomIndex
+	err := m.retry(ctx, func() (err error) {
+		missing, err = m.queryMissingIndexes(ctx, schema, name, want, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(missing))
+	for i, idx := range missing {
+		names[i] = idx.Name
+	}
+	return names, nil
+}
+
+func (m *Manager) queryMissingIndexes(ctx context.Context, schema SchemaName, name QueueName, want *DefaultIndexes, tablespace string) ([]CustomIndex, error) {
+	fqn := MakeFQN(schema, name)
+
+	var ordering bool
+	var valid []string
+	err := m.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+		           SELECT 1 FROM pg_attribute
+		           WHERE attrelid = format('%I.%I', $1::text, $2::text)::regclass
+		             AND attname = $3 AND NOT attisdropped
+		       ),
+		       coalesce(array_agg(ci.relname::text), '{}')
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		WHERE x.indrelid = format('%I.%I', $1::text, $2::text)::regclass
+		  AND x.indisvalid
+	`, schema, name, orderingColumn).Scan(&ordering, &valid)
+	if err != nil {
+		return nil, wrapErr("get_missing_indexes", fqn, err)
+	}
+
+	expected := defaultIndexDefs(name, want, tablespace)
+	if ordering {
+		expected = append(expected, orderingIndexDef(name, tablespace))
+	}
+
+	var missing []CustomIndex
+	for _, idx := range expected {
+		if !slices.Contains(valid, idx.Name) {
+			missing = append(missing, idx)
+		}
+	}
+	return missing, nil
+}
+
+// RepairDefaultIndexes recreates the indexes MissingDefaultIndexes reports
+// in tablespace (empty for the database default), replacing invalid ones.
+// The template table keeps its copies, which a manual drop on the queue
+// doesn't touch.
+func (m *Manager) RepairDefaultIndexes(ctx context.Context, schema SchemaName, name QueueName, want *DefaultIndexes, tablespace string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	missing, err := m.queryMissingIndexes(ctx, schema, name, want, tablespace)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return m.retryTx(ctx, fqn, "commit_repair_indexes", func(tx pgx.Tx) error {
+		for _, idx := range missing {
+			if _, err := tx.Exec(ctx, "DROP INDEX IF EXISTS "+MakeFQN(schema, QueueName(idx.Name)).Sanitize()); err != nil {
+				return wrapErr("drop_index_"+idx.Name, fqn, err)
+			}
+			if _, err := tx.Exec(ctx, createIndexSQL(schema, name, idx.Name, idx, false, false)); err != nil {
+				return wrapErr("create_index_"+idx.Name, fqn, err)
+			}
+		}
+		return nil
+	})
+}
+
+// dropTemplateCopies drops the indexes of the queue's template table that
+// are defined like the queue index, whether copied by CREATE TABLE LIKE or
+// by createTemplateIndex
+func dropTemplateCopies(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, index string) error {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := tx.Query(ctx, `
+		SELECT format('%I.%I', n.nspname, ci.relname)
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		WHERE x.indrelid = to_regclass(format('%I.%I', $1::text, $2::text || '_template'))
+		  AND substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') = (
+		      SELECT substring(pg_get_indexdef(to_regclass(format('%I.%I', $1::text, $3::text))) from ' USING .*$')
+		  )
+	`, schema, name, index)
+	if err != nil {
+		return wrapErr("get_template_indexes", fqn, err)
+	}
+	copies, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return wrapErr("get_template_indexes", fqn, err)
+	}
+
+	for _, c := range copies {
+		if _, err := tx.Exec(ctx, "DROP INDEX "+c); err != nil {
+			return wrapErr("drop_template_index", fqn, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package pgq
+
+import "testing"
+
+func TestDefaultIndexDefs(t *testing.T) {
+	all := defaultIndexDefs("orders", nil, "")
+	if len(all) != 4 {
+		t.Fatalf("defaultIndexDefs(nil) = %d indexes, want 4", len(all))
+	}
+	want := `CREATE INDEX IF NOT EXISTS "orders_metadata_idx" ON "public"."orders" USING gin (metadata) WHERE processed_at IS NULL`
+	if got := createIndexSQL("public", "orders", all[3].Name, all[3], false, false); got != want {
+		t.Errorf("metadata index = %q, want %q", got, want)
+	}
+
+	some := defaultIndexDefs("orders", &DefaultIndexes{CreatedAt: true, Metadata: true, MetadataOpclass: "jsonb_path_ops"}, "fast")
+	if len(some) != 2 || some[0].Name != "orders_created_at_idx" || some[1].Name != "orders_metadata_idx" {
+		t.Fatalf("defaultIndexDefs() = %+v, want created_at and metadata", some)
+	}
+	want = `CREATE INDEX IF NOT EXISTS "orders_metadata_idx" ON "public"."orders" USING gin (metadata jsonb_path_ops) TABLESPACE "fast" WHERE processed_at IS NULL`
+	if got := createIndexSQL("public", "orders", some[1].Name, some[1], false, false); got != want {
+		t.Errorf("metadata index = %q, want %q", got, want)
+	}
+}
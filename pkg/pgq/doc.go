@@ -0,0 +1,26 @@
+// Package pgq manages pgq queue tables in PostgreSQL.
+//
+// It is the same code the Terraform provider uses, exposed so operational
+// tooling and migration scripts can create, inspect and drop queues without
+// reimplementing the DDL:
+//
+//	pool, _ := pgxpool.New(ctx, connStr)
+//	mgr := pgq.NewManager(pool)
+//
+//	err := mgr.Create(ctx, "public", "orders", &pgq.PartitionConfig{
+//		Interval:           "1 day",
+//		Premake:            7,
+//		Retention:          "14 days",
+//		DatetimeString:     "YYYYMMDD",
+//		OptimizeConstraint: 30,
+//		DefaultPartition:   true,
+//	}, nil)
+//
+// Create, CreateSimple, CreatePartitioned and CreateBatch take a
+// *QueueOptions, which may be nil for the defaults. Settings applied when a
+// queue is created are added to QueueOptions as fields whose zero value
+// keeps the previous behavior, so these signatures don't change again.
+//
+// Errors are typed (QueueExistsError, QueueNotFoundError, QueueError,
+// PartmanError) so callers can branch on them with errors.As.
+package pgq
@@ -25,6 +25,14 @@ type (
 		Queue FQN
 		Err   error
 	}
+
+	// UnsupportedError means the server lacks a capability a queue needs
+	UnsupportedError struct {
+		Reason string
+		// Missing is the extension that isn't installed, if that is the
+		// reason, e.g. pg_partman
+		Missing string
+	}
 )
 
 func (e *QueueError) Error() string {
@@ -47,6 +55,10 @@ func (e *PartmanError) Error() string {
 
 func (e *PartmanError) Unwrap() error { return e.Err }
 
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported server: %s", e.Reason)
+}
+
 // Helper to wrap errors with context
 func wrapErr(op string, fqn FQN, err error) error {
 	if err == nil {
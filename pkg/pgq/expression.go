@@ -0,0 +1,171 @@
+package pgq
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// plainIdentifier matches identifiers the server prints without quotes
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// groupingKeywords are the words a parenthesis can follow without being
+// the argument list of a function call
+var groupingKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "in": true, "like": true, "ilike": true,
+	"similar": true, "between": true, "case": true, "when": true, "then": true, "else": true,
+	"escape": true, "distinct": true, "any": true, "all": true, "some": true, "array": true,
+}
+
+// typeWords continue a type name after its first word, e.g. character
+// varying or timestamp with time zone
+var typeWords = map[string]bool{
+	"varying": true, "precision": true, "with": true, "without": true, "time": true, "zone": true,
+}
+
+type exprToken struct {
+	text string
+	// word is set for identifiers, keywords, numbers and literals, which
+	// need a space between them
+	word    bool
+	literal bool
+}
+
+// EquivalentExpressions reports whether two SQL expressions, such as an
+// index expression or WHERE clause as configured and as pg_get_indexdef
+// returns it, are written the same apart from what the server adds or
+// drops when it prints them: whitespace, letter case outside quotes,
+// quotes around plain identifiers, casts of string literals (the server
+// prints 'x' as 'x'::text) and parentheses that don't enclose function
+// arguments. Expressions differing only in the latter, e.g. (a + b) * c
+// and a + b * c, count as equivalent.
+func EquivalentExpressions(a, b string) bool {
+	return a == b || normalizeExpression(a) == normalizeExpression(b)
+}
+
+// normalizeExpression rewrites an expression in the form compared by
+// EquivalentExpressions
+func normalizeExpression(s string) string {
+	tokens := tokenizeExpression(s)
+
+	var out []exprToken
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.text == "::" && len(out) > 0 && out[len(out)-1].literal:
+			i = skipTypeName(tokens, i+1) - 1
+			continue
+		case t.text == "(" || t.text == ")":
+			if t.text == "(" && len(out) > 0 && out[len(out)-1].word && !out[len(out)-1].literal &&
+				!groupingKeywords[out[len(out)-1].text] {
+				out = append(out, t)
+				continue
+			}
+			if t.text == ")" && closesCall(tokens, i) {
+				out = append(out, t)
+			}
+			continue
+		}
+		out = append(out, t)
+	}
+
+	var sb strings.Builder
+	for i, t := range out {
+		if i > 0 && t.word && out[i-1].word {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(t.text)
+	}
+	return sb.String()
+}
+
+// closesCall reports whether the parenthesis at tokens[i] closes the
+// argument list of a function call, which normalizeExpression keeps
+func closesCall(tokens []exprToken, i int) bool {
+	depth := 0
+	for j := i; j >= 0; j-- {
+		switch tokens[j].text {
+		case ")":
+			depth++
+		case "(":
+			depth--
+			if depth == 0 {
+				prev := j - 1
+				return prev >= 0 && tokens[prev].word && !tokens[prev].literal && !groupingKeywords[tokens[prev].text]
+			}
+		}
+	}
+	return false
+}
+
+// skipTypeName returns the index of the first token after the type name
+// starting at tokens[i]
+func skipTypeName(tokens []exprToken, i int) int {
+	if i < len(tokens) && tokens[i].word {
+		i++
+	}
+	for i < len(tokens) && tokens[i].word && typeWords[tokens[i].text] {
+		i++
+	}
+	for i+1 < len(tokens) && tokens[i].text == "[" && tokens[i+1].text == "]" {
+		i += 2
+	}
+	return i
+}
+
+// tokenizeExpression splits an expression into literals, identifiers,
+// operators and punctuation, lower-casing what isn't quoted
+func tokenizeExpression(s string) []exprToken {
+	runes := []rune(s)
+	var tokens []exprToken
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			j := i + 1
+			for j < len(runes) {
+				if runes[j] == r {
+					// a doubled quote is an escaped one
+					if j+1 < len(runes) && runes[j+1] == r {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+			text := string(runes[i:j])
+			if r == '"' {
+				if inner := strings.Trim(text, `"`); plainIdentifier.MatchString(inner) {
+					text = inner
+				}
+				tokens = append(tokens, exprToken{text: text, word: true})
+			} else {
+				tokens = append(tokens, exprToken{text: text, word: true, literal: true})
+			}
+			i = j
+		case r == '_' || r == '$' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '$' || runes[j] == '.' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, exprToken{text: strings.ToLower(string(runes[i:j])), word: true})
+			i = j
+		case strings.ContainsRune("+-*/<>=~!@#%^&|`?:", r):
+			j := i
+			for j < len(runes) && strings.ContainsRune("+-*/<>=~!@#%^&|`?:", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, exprToken{text: string(runes[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, exprToken{text: string(r)})
+			i++
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,31 @@
+package pgq
+
+import "testing"
+
+func TestEquivalentExpressions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"(payload->>'user_id')", "((payload ->> 'user_id'::text))", true},
+		{"processed_at IS NULL", "(processed_at IS NULL)", true},
+		{"status = 'failed' AND attempts > 3", "((status = 'failed'::text) AND (attempts > 3))", true},
+		{"lower(\"Email\")", "lower(\"Email\")", true},
+		{"lower(\"email\")", "lower(email)", true},
+		{"created_at > '2024-01-01'", "(created_at > '2024-01-01 00:00:00+00'::timestamp with time zone)", false},
+		{"(metadata->'tags')", "((metadata -> 'tags'::text))", true},
+		{"coalesce((a), b)", "COALESCE(a, b)", true},
+		{"lower(email)", "upper(email)", false},
+		{"(payload->>'user_id')", "((payload ->> 'User_id'::text))", false},
+		{"lower(\"Email\")", "lower(email)", false},
+		{"status IN ('a', 'b')", "(status = ANY (ARRAY['a'::text, 'b'::text]))", false},
+		{"f(a, b)", "f(a), b", false},
+		{"'it''s'", "'it''s'::text", true},
+	}
+	for _, tt := range tests {
+		if got := EquivalentExpressions(tt.a, tt.b); got != tt.want {
+			t.Errorf("EquivalentExpressions(%q, %q) = %v, want %v (%q, %q)", tt.a, tt.b, got, tt.want,
+				normalizeExpression(tt.a), normalizeExpression(tt.b))
+		}
+	}
+}
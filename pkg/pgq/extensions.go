@@ -0,0 +1,73 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+)
+
+// installExtensions creates the extensions a queue needs before it is
+// created, if InstallPartman is set: pgcrypto, which provides
+// gen_random_uuid before PostgreSQL 13, and pg_partman for partitioned
+// queues. Each is checked once per Manager.
+func (m *Manager) installExtensions(ctx context.Context, fqn FQN, partitioned bool) error {
+	if !m.opts.InstallPartman {
+		return nil
+	}
+
+	m.extMu.Lock()
+	defer m.extMu.Unlock()
+
+	if !m.pgcryptoChecked {
+		var serverVersion int
+		if err := m.pool.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&serverVersion); err != nil {
+			return wrapErr("check_server_version", fqn, err)
+		}
+		if serverVersion < 130000 {
+			if _, err := m.pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pgcrypto"); err != nil {
+				return wrapErr("install_pgcrypto", fqn, err)
+			}
+		}
+		m.pgcryptoChecked = true
+	}
+
+	if !partitioned || m.partmanInstalled {
+		return nil
+	}
+
+	var installed bool
+	err := m.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_partman')`).Scan(&installed)
+	if err != nil {
+		return wrapErr("check_partman", fqn, err)
+	}
+
+	if !installed {
+		schema := m.opts.PartmanSchema
+		if schema == "" {
+			schema = defaultPartmanSchema
+		}
+
+		tx, err := m.pool.Begin(ctx)
+		if err != nil {
+			return wrapErr("begin_tx", fqn, err)
+		}
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+
+		for _, stmt := range []string{
+			"CREATE SCHEMA IF NOT EXISTS " + schema.Sanitize(),
+			"CREATE EXTENSION IF NOT EXISTS pg_partman SCHEMA " + schema.Sanitize(),
+		} {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return wrapErr("install_partman", fqn, fmt.Errorf("%s: %w", stmt, err))
+			}
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return wrapErr("install_partman", fqn, err)
+		}
+	}
+
+	m.partmanInstalled = true
+	return nil
+}
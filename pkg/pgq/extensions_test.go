@@ -0,0 +1,21 @@
+package pgq
+
+import (
+	"context"
+	"testing"
+)
+
+func TestManagerInstallExtensionsDisabled(t *testing.T) {
+	// without InstallPartman nothing is queried, so a nil pool must do
+	m := NewManager(nil)
+	fqn := MakeFQN("public", "orders")
+
+	for _, partitioned := range []bool{false, true} {
+		if err := m.installExtensions(context.Background(), fqn, partitioned); err != nil {
+			t.Errorf("installExtensions(partitioned=%v) error = %v", partitioned, err)
+		}
+	}
+	if m.pgcryptoChecked || m.partmanInstalled {
+		t.Error("installExtensions() without InstallPartman should not record extensions as checked")
+	}
+}
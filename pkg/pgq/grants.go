@@ -0,0 +1,68 @@
+package pgq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PartitionGrantDrift is a partition missing privileges granted on its
+// parent, typically one created before the parent's grants were fixed
+type PartitionGrantDrift struct {
+	Partition FQN
+	// Missing lists privileges as "PRIVILEGE to role"
+	Missing []string
+}
+
+func (d PartitionGrantDrift) String() string {
+	return d.Partition.String() + ": missing " + strings.Join(d.Missing, ", ")
+}
+
+// GetPartitionGrantDrift compares the privileges of every partition of a
+// queue with the parent's and returns the partitions lacking any of them
+func (m *Manager) GetPartitionGrantDrift(ctx context.Context, schema SchemaName, name QueueName) ([]PartitionGrantDrift, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		WITH parent AS (
+			SELECT acl.grantee, acl.privilege_type
+			FROM pg_class c
+			CROSS JOIN LATERAL aclexplode(coalesce(c.relacl, acldefault('r', c.relowner))) acl
+			WHERE c.oid = $1::regclass
+		)
+		SELECT format('%s.%s', n.nspname, c.relname),
+		       array_agg(format('%s to %s', p.privilege_type,
+		           CASE WHEN p.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(p.grantee) END)
+		           ORDER BY p.privilege_type, p.grantee)
+		FROM pg_partition_tree($1::regclass) t
+		JOIN pg_class c ON c.oid = t.relid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN parent p
+		WHERE t.relid <> $1::regclass
+		  AND NOT EXISTS (
+		      SELECT 1
+		      FROM aclexplode(coalesce(c.relacl, acldefault('r', c.relowner))) a
+		      WHERE a.grantee = p.grantee AND a.privilege_type = p.privilege_type
+		  )
+		GROUP BY n.nspname, c.relname
+		ORDER BY n.nspname, c.relname
+	`, fqn.Sanitize())
+	if err != nil {
+		return nil, wrapErr("get_partition_grants", fqn, err)
+	}
+
+	drift, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (PartitionGrantDrift, error) {
+		var d PartitionGrantDrift
+		err := row.Scan(&d.Partition, &d.Missing)
+		return d, err
+	})
+	if err != nil {
+		return nil, wrapErr("get_partition_grants", fqn, err)
+	}
+
+	return drift, nil
+}
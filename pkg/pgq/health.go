@@ -0,0 +1,235 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pgqColumns identify a pgq-shaped table
+var pgqColumns = []string{
+	"id", "created_at", "started_at", "locked_until", "scheduled_for",
+	"processed_at", "consumed_count", "error_detail", "payload", "metadata",
+}
+
+// FleetHealth summarizes every pgq queue in a database
+type FleetHealth struct {
+	Queues                   int
+	TotalBacklog             int64
+	QueuesWithDefaultRows    []FQN
+	QueuesMissingMaintenance []FQN
+	InvalidIndexes           []FQN
+}
+
+// ListQueues returns every pgq-shaped table in schema, or in all schemas
+// when schema is empty. Partition children and template tables of
+// partitioned queues are skipped.
+func (m *Manager) ListQueues(ctx context.Context, schema SchemaName) ([]*Queue, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT n.nspname, c.relname, c.relkind = 'p'
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p')
+		  AND NOT c.relispartition
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND ($1 = '' OR n.nspname = $1)
+		  AND (
+		      SELECT count(*) FROM pg_attribute a
+		      WHERE a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		        AND a.attname = ANY($2)
+		  ) = cardinality($2)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM pg_class p
+		      WHERE p.relnamespace = c.relnamespace
+		        AND p.relkind = 'p'
+		        AND c.relname = p.relname || '_template'
+		  )
+		ORDER BY n.nspname, c.relname
+	`, schema, pgqColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	var queues []*Queue
+	var q Queue
+	_, err = pgx.ForEachRow(rows, []any{&q.Schema, &q.Name, &q.Partitioned}, func() error {
+		queue := q
+		queues = append(queues, &queue)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	return queues, nil
+}
+
+// Backlog counts the unprocessed messages of a queue
+func (m *Manager) Backlog(ctx context.Context, schema SchemaName, name QueueName) (int64, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var backlog int64
+	err := m.pool.QueryRow(ctx, "SELECT count(*) FROM "+fqn.Sanitize()+" WHERE processed_at IS NULL").Scan(&backlog)
+	if err != nil {
+		return 0, wrapErr("backlog", fqn, err)
+	}
+
+	return backlog, nil
+}
+
+// HasMessages reports whether a queue holds any messages, processed or not
+func (m *Manager) HasMessages(ctx context.Context, schema SchemaName, name QueueName) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var has bool
+	err := m.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+fqn.Sanitize()+")").Scan(&has)
+	if err != nil {
+		return false, wrapErr("has_messages", fqn, err)
+	}
+
+	return has, nil
+}
+
+// FleetHealth inspects every queue in schema (all schemas if empty)
+func (m *Manager) FleetHealth(ctx context.Context, schema SchemaName) (*FleetHealth, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	queues, err := m.ListQueues(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &FleetHealth{Queues: len(queues)}
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var partmanInstalled bool
+	if err := m.pool.QueryRow(ctx, partmanSQL(pm, `SELECT to_regclass('{{partman}}.part_config') IS NOT NULL`)).Scan(&partmanInstalled); err != nil {
+		return nil, fmt.Errorf("failed to check pg_partman: %w", err)
+	}
+
+	for _, q := range queues {
+		backlog, err := m.Backlog(ctx, q.Schema, q.Name)
+		if err != nil {
+			return nil, err
+		}
+		health.TotalBacklog += backlog
+
+		if !q.Partitioned {
+			continue
+		}
+
+		hasDefaultRows, err := m.hasDefaultRows(ctx, q.Schema, q.Name)
+		if err != nil {
+			return nil, err
+		}
+		if hasDefaultRows {
+			health.QueuesWithDefaultRows = append(health.QueuesWithDefaultRows, q.FQN())
+		}
+
+		// queues pg_partman doesn't know are natively partitioned, their
+		// partitions are maintained by the provider
+		if !partmanInstalled {
+			continue
+		}
+		var maintenance string
+		err = m.pool.QueryRow(ctx, partmanSQL(pm, `
+			SELECT automatic_maintenance FROM {{partman}}.part_config WHERE parent_table = $1
+		`), q.FQN().String()).Scan(&maintenance)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, wrapPartmanErr("get_config", q.FQN(), err)
+		}
+		if maintenance != "on" {
+			health.QueuesMissingMaintenance = append(health.QueuesMissingMaintenance, q.FQN())
+		}
+	}
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT format('%s.%s', n.nspname, ci.relname)
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		WHERE NOT x.indisvalid
+		  AND x.indrelid IN (
+		      SELECT t.relid
+		      FROM unnest($1::text[]) q(fqn)
+		      CROSS JOIN LATERAL pg_partition_tree(q.fqn::regclass) t
+		  )
+		ORDER BY 1
+	`, sanitizedFQNs(queues))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check invalid indexes: %w", err)
+	}
+
+	health.InvalidIndexes, err = pgx.CollectRows(rows, pgx.RowTo[FQN])
+	if err != nil {
+		return nil, fmt.Errorf("failed to check invalid indexes: %w", err)
+	}
+
+	return health, nil
+}
+
+// hasDefaultRows reports whether the default partition of a queue holds rows
+func (m *Manager) hasDefaultRows(ctx context.Context, schema SchemaName, name QueueName) (bool, error) {
+	fqn := MakeFQN(schema, name)
+
+	child, err := m.defaultPartition(ctx, fqn)
+	if err != nil || child == "" {
+		return false, err
+	}
+
+	var hasRows bool
+	if err := m.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+child+")").Scan(&hasRows); err != nil {
+		return false, wrapErr("check_default_rows", fqn, err)
+	}
+
+	return hasRows, nil
+}
+
+// defaultPartition returns the quoted name of the default partition of a
+// queue, or an empty string when it has none
+func (m *Manager) defaultPartition(ctx context.Context, fqn FQN) (string, error) {
+	var child string
+	err := m.pool.QueryRow(ctx, `
+		SELECT format('%I.%I', n.nspname, c.relname)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE i.inhparent = $1::regclass
+		  AND pg_get_expr(c.relpartbound, c.oid) = 'DEFAULT'
+	`, fqn.Sanitize()).Scan(&child)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("find_default_partition", fqn, err)
+	}
+
+	return child, nil
+}
+
+func sanitizedFQNs(queues []*Queue) []string {
+	out := make([]string, len(queues))
+	for i, q := range queues {
+		out[i] = q.FQN().Sanitize()
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ID types, see QueueOptions.IDType
+const (
+	IDUUIDv4         = "uuid_v4"
+	IDUUIDv7         = "uuid_v7"
+	IDBigintIdentity = "bigint_identity"
+)
+
+// idColumn returns the definition of the id column for idType. UUIDv7
+// comes from uuidv7() on PostgreSQL 18 and uuid_generate_v7() of the
+// pg_uuidv7 extension before. Partitioned tables can't have identity
+// columns before PostgreSQL 17, so they get a sequence default instead.
+func idColumn(ctx context.Context, tx pgx.Tx, idType string, partitioned bool) (string, error) {
+	switch idType {
+	case "", IDUUIDv4:
+		return "id             UUID        NOT NULL DEFAULT gen_random_uuid()", nil
+	case IDUUIDv7, IDBigintIdentity:
+	default:
+		return "", fmt.Errorf("unknown id type %q", idType)
+	}
+
+	var serverVersion int
+	var extension bool
+	err := tx.QueryRow(ctx, `
+		SELECT current_setting('server_version_num')::int, to_regproc('uuid_generate_v7') IS NOT NULL
+	`).Scan(&serverVersion, &extension)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case idType == IDBigintIdentity && partitioned && serverVersion < 170000:
+		return "id             BIGSERIAL   NOT NULL", nil
+	case idType == IDBigintIdentity:
+		return "id             BIGINT      NOT NULL GENERATED BY DEFAULT AS IDENTITY", nil
+	case serverVersion >= 180000:
+		return "id             UUID        NOT NULL DEFAULT uuidv7()", nil
+	case extension:
+		return "id             UUID        NOT NULL DEFAULT uuid_generate_v7()", nil
+	}
+	return "", errors.New("uuid_v7 ids need PostgreSQL 18 or the pg_uuidv7 extension")
+}
+
+// GetIDType returns the id type of a queue, from the type and default of
+// its id column
+func (m *Manager) GetIDType(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var typ, def string
+	var identity bool
+	err := m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, `
+			SELECT format_type(a.atttypid, a.atttypmod), a.attidentity <> '',
+			       coalesce(pg_get_expr(d.adbin, d.adrelid), '')
+			FROM pg_attribute a
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE a.attrelid = $1::regclass AND a.attname = 'id' AND NOT a.attisdropped
+		`, fqn.Sanitize()).Scan(&typ, &identity, &def)
+	})
+	if err != nil {
+		return "", wrapErr("get_id_type", fqn, err)
+	}
+
+	switch {
+	case typ == "bigint" && (identity || strings.HasPrefix(def, "nextval(")):
+		return IDBigintIdentity, nil
+	case typ == "uuid" && strings.Contains(def, "v7"):
+		return IDUUIDv7, nil
+	case typ == "uuid":
+		return IDUUIDv4, nil
+	}
+	return "", wrapErr("get_id_type", fqn, fmt.Errorf("unexpected id column %s %s", typ, def))
+}
@@ -0,0 +1,2534 @@
+//go:build integration
+
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	connStr := fmt.Sprintf(
+		"host=%s port=%s database=%s user=%s password=%s sslmode=disable",
+		getEnv("PGHOST", "localhost"),
+		getEnv("PGPORT", "5432"),
+		getEnv("PGDATABASE", "postgres"),
+		getEnv("PGUSER", "postgres"),
+		getEnv("PGPASSWORD", ""),
+	)
+
+	pool, err := pgxpool.New(context.Background(), connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping database: %v", err)
+	}
+
+	return pool
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func TestManagerSimpleQueue(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_simple_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	exists, err := mgr.Exists(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Exists() error = %v", err)
+	}
+	if !exists {
+		t.Error("queue should exist after creation")
+	}
+
+	q, err := mgr.Get(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if q.Partitioned {
+		t.Error("simple queue should not be partitioned")
+	}
+	if drift := q.Drift(); len(drift) > 0 {
+		t.Errorf("Drift() of a new queue = %v, want none", drift)
+	}
+
+	if _, err := pool.Exec(ctx, "ALTER TABLE "+MakeFQN(schema, name).Sanitize()+" ALTER COLUMN payload DROP NOT NULL"); err != nil {
+		t.Fatal(err)
+	}
+	altered, err := mgr.Get(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(altered.Drift()) != 1 || altered.StructureHash() == q.StructureHash() {
+		t.Errorf("dropped NOT NULL not detected, Drift() = %v", altered.Drift())
+	}
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err == nil {
+		t.Error("creating duplicate queue should fail")
+	}
+}
+
+func TestManagerPartitionedQueue(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_part_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            3,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+		ConstraintCols:     []string{"scheduled_for"},
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	q, err := mgr.Get(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !q.Partitioned {
+		t.Error("partitioned queue should be marked as partitioned")
+	}
+
+	gotCfg, err := mgr.GetPartitionConfig(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionConfig() error = %v", err)
+	}
+
+	if gotCfg.Interval != cfg.Interval {
+		t.Errorf("interval = %q, want %q", gotCfg.Interval, cfg.Interval)
+	}
+	if gotCfg.Premake != cfg.Premake {
+		t.Errorf("premake = %d, want %d", gotCfg.Premake, cfg.Premake)
+	}
+
+	if !slices.Equal(gotCfg.ConstraintCols, cfg.ConstraintCols) {
+		t.Errorf("constraint cols = %v, want %v", gotCfg.ConstraintCols, cfg.ConstraintCols)
+	}
+	if gotCfg.Jobmon == nil {
+		t.Error("jobmon should be read")
+	}
+	if gotCfg.AutomaticMaintenance != "on" {
+		t.Errorf("automatic_maintenance = %q, want on", gotCfg.AutomaticMaintenance)
+	}
+
+	// automatic_maintenance isn't managed, but a change made outside the
+	// provider is read back
+	if _, err := pool.Exec(ctx, `UPDATE partman.part_config SET automatic_maintenance = 'off' WHERE parent_table = $1`, MakeFQN(schema, name).String()); err != nil {
+		t.Fatalf("updating automatic_maintenance: %v", err)
+	}
+	if gotCfg, err = mgr.GetPartitionConfig(ctx, schema, name); err != nil {
+		t.Fatalf("GetPartitionConfig() error = %v", err)
+	}
+	if gotCfg.AutomaticMaintenance != "off" {
+		t.Errorf("automatic_maintenance after change = %q, want off", gotCfg.AutomaticMaintenance)
+	}
+
+	jobmon := false
+	newCfg := &PartitionConfig{
+		Interval:               "1 day",
+		Premake:                5,
+		Retention:              "14 days",
+		DatetimeString:         "YYYYMMDD",
+		OptimizeConstraint:     20,
+		DefaultPartition:       true,
+		RetentionSchema:        fmt.Sprintf("test_archive_%d", os.Getpid()),
+		RetentionKeepTable:     true,
+		RetentionDropIndexes:   true,
+		InfiniteTimePartitions: true,
+		Jobmon:                 &jobmon,
+		InheritPrivileges:      true,
+	}
+	defer pool.Exec(ctx, "DROP SCHEMA IF EXISTS "+SchemaName(newCfg.RetentionSchema).Sanitize())
+
+	if err := mgr.UpdatePartitionConfig(ctx, schema, name, newCfg); err != nil {
+		t.Fatalf("UpdatePartitionConfig() error = %v", err)
+	}
+
+	updatedCfg, err := mgr.GetPartitionConfig(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionConfig() after update error = %v", err)
+	}
+
+	if updatedCfg.Premake != newCfg.Premake {
+		t.Errorf("after update: premake = %d, want %d", updatedCfg.Premake, newCfg.Premake)
+	}
+	if updatedCfg.Retention != newCfg.Retention {
+		t.Errorf("after update: retention = %q, want %q", updatedCfg.Retention, newCfg.Retention)
+	}
+	if updatedCfg.RetentionSchema != newCfg.RetentionSchema {
+		t.Errorf("after update: retention schema = %q, want %q", updatedCfg.RetentionSchema, newCfg.RetentionSchema)
+	}
+	if !updatedCfg.RetentionKeepTable || !updatedCfg.RetentionDropIndexes {
+		t.Errorf("after update: keep table = %v, drop indexes = %v, want both", updatedCfg.RetentionKeepTable, updatedCfg.RetentionDropIndexes)
+	}
+	if !updatedCfg.InfiniteTimePartitions {
+		t.Error("after update: infinite_time_partitions should be set")
+	}
+	if updatedCfg.Jobmon == nil || *updatedCfg.Jobmon {
+		t.Error("after update: jobmon should be off")
+	}
+	if !updatedCfg.InheritPrivileges {
+		t.Error("after update: inherit_privileges should be set")
+	}
+
+	if err := mgr.ReapplyPrivileges(ctx, schema, name); err != nil {
+		t.Errorf("ReapplyPrivileges() error = %v", err)
+	}
+}
+
+func TestManagerPartitionStart(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_start_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	start := time.Now().AddDate(0, 0, -20)
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "30 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+		StartPartition:     start.Format("2006-01-02"),
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	first := MakeFQN(schema, QueueName(name.String()+"_p"+start.Format("20060102")))
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, first.Sanitize()).Scan(&exists); err != nil {
+		t.Fatalf("checking partition %s: %v", first, err)
+	}
+	if !exists {
+		t.Errorf("partition %s for the start date should exist", first)
+	}
+}
+
+func TestManagerNativePartitions(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_native_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	start := time.Now().UTC().AddDate(0, 0, -20)
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		Retention:        "10 days",
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+		Timezone:         "UTC",
+		StartPartition:   start.Format("2006-01-02"),
+		Native:           true,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	partition := func(day time.Time) FQN {
+		return MakeFQN(schema, QueueName(name.String()+"_p"+day.Format("20060102")))
+	}
+	exists := func(table FQN) bool {
+		t.Helper()
+		var ok bool
+		if err := pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table.Sanitize()).Scan(&ok); err != nil {
+			t.Fatalf("checking partition %s: %v", table, err)
+		}
+		return ok
+	}
+
+	if exists(partition(start)) {
+		t.Errorf("partition %s past retention should be dropped", partition(start))
+	}
+	ahead := time.Now().UTC().AddDate(0, 0, 2)
+	if !exists(partition(ahead)) {
+		t.Errorf("premade partition %s should exist", partition(ahead))
+	}
+
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	pending, err := mgr.PendingNativeMaintenance(ctx, schema, name, cfg)
+	if err != nil {
+		t.Fatalf("PendingNativeMaintenance() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("PendingNativeMaintenance() = %v, want none right after creation", pending)
+	}
+
+	cfg.Premake = 4
+	ahead = time.Now().UTC().AddDate(0, 0, 4)
+	pending, err = mgr.PendingNativeMaintenance(ctx, schema, name, cfg)
+	if err != nil {
+		t.Fatalf("PendingNativeMaintenance() error = %v", err)
+	}
+	if !slices.Contains(pending, "create "+partition(ahead).String()) {
+		t.Errorf("PendingNativeMaintenance() = %v, want it to list %s", pending, partition(ahead))
+	}
+	if exists(partition(ahead)) {
+		t.Errorf("PendingNativeMaintenance() created partition %s", partition(ahead))
+	}
+
+	if err := mgr.MaintainNativePartitions(ctx, schema, name, cfg); err != nil {
+		t.Fatalf("MaintainNativePartitions() error = %v", err)
+	}
+	if !exists(partition(ahead)) {
+		t.Errorf("premade partition %s should exist after maintenance", partition(ahead))
+	}
+	if pending, err := mgr.PendingNativeMaintenance(ctx, schema, name, cfg); err != nil || len(pending) != 0 {
+		t.Errorf("PendingNativeMaintenance() = %v, %v after maintenance, want none", pending, err)
+	}
+
+	health, err := mgr.FleetHealth(ctx, schema)
+	if err != nil {
+		t.Fatalf("FleetHealth() error = %v", err)
+	}
+	for _, q := range health.QueuesMissingMaintenance {
+		if q == MakeFQN(schema, name) {
+			t.Errorf("FleetHealth() reports native queue %s as missing partman maintenance", q)
+		}
+	}
+}
+
+func TestManagerPartitionStrategies(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	opts := &QueueOptions{ExtraColumns: []Column{{Name: "tenant_id", Type: "integer", NotNull: true}}}
+
+	tests := []struct {
+		strategy string
+		cfg      PartitionConfig
+		want     int
+	}{
+		{PartitionHash, PartitionConfig{Native: true, HashPartitions: 4}, 4},
+		// values 0 through premake, plus the default partition
+		{PartitionList, PartitionConfig{Native: true, Premake: 2, DefaultPartition: true}, 4},
+		{PartitionList, PartitionConfig{Premake: 2, DefaultPartition: true, DatetimeString: "YYYYMMDD"}, 4},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			name := QueueName(fmt.Sprintf("test_strategy_%d_%d", i, os.Getpid()))
+			defer mgr.Drop(ctx, schema, name)
+			if !tt.cfg.Native {
+				defer mgr.RemovePartmanConfig(ctx, schema, name)
+			}
+
+			cfg := tt.cfg
+			cfg.Strategy = tt.strategy
+			cfg.Key = "tenant_id"
+			if err := mgr.CreatePartitioned(ctx, schema, name, &cfg, opts); err != nil {
+				t.Fatalf("CreatePartitioned() error = %v", err)
+			}
+
+			q, err := mgr.Get(ctx, schema, name)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if q.Strategy != tt.strategy || q.Key != "tenant_id" {
+				t.Errorf("Get() strategy = %q, key = %q, want %q, tenant_id", q.Strategy, q.Key, tt.strategy)
+			}
+			if q.Partitions < tt.want {
+				t.Errorf("Get() partitions = %d, want at least %d", q.Partitions, tt.want)
+			}
+			if q.DefaultPartition != tt.cfg.DefaultPartition {
+				t.Errorf("Get() default partition = %v, want %v", q.DefaultPartition, tt.cfg.DefaultPartition)
+			}
+
+			if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+
+				" (payload, metadata, tenant_id) VALUES ('{}', '{}', 1)"); err != nil {
+				t.Errorf("insert error = %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerIDType(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+
+	for _, idType := range []string{IDUUIDv4, IDBigintIdentity} {
+		t.Run(idType, func(t *testing.T) {
+			name := QueueName(fmt.Sprintf("test_%s_%d", idType, os.Getpid()))
+			defer mgr.Drop(ctx, schema, name)
+
+			if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{IDType: idType}); err != nil {
+				t.Fatalf("CreateSimple() error = %v", err)
+			}
+
+			got, err := mgr.GetIDType(ctx, schema, name)
+			if err != nil {
+				t.Fatalf("GetIDType() error = %v", err)
+			}
+			if got != idType {
+				t.Errorf("GetIDType() = %q, want %q", got, idType)
+			}
+
+			if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+				t.Errorf("insert error = %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerPrimaryKey(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_pkey_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+	}
+	opts := &QueueOptions{PrimaryKey: []string{"created_at", "id"}}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, opts); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	key, err := mgr.GetPrimaryKey(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPrimaryKey() error = %v", err)
+	}
+	if !slices.Equal(key, opts.PrimaryKey) {
+		t.Errorf("GetPrimaryKey() = %v, want %v", key, opts.PrimaryKey)
+	}
+
+	if err := mgr.SetPrimaryKey(ctx, schema, name, []string{"id", "created_at"}); err != nil {
+		t.Fatalf("SetPrimaryKey() error = %v", err)
+	}
+	key, err = mgr.GetPrimaryKey(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPrimaryKey() error = %v", err)
+	}
+	if !slices.Equal(key, []string{"id", "created_at"}) {
+		t.Errorf("GetPrimaryKey() after SetPrimaryKey() = %v", key)
+	}
+
+	if err := mgr.SetPrimaryKey(ctx, schema, name, []string{"id"}); err == nil {
+		t.Error("SetPrimaryKey() without the partition key should fail")
+	}
+}
+
+func TestManagerSubPartition(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_sub_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+		SubPartition:       &SubPartitionConfig{Column: "shard", Interval: "4", Premake: 2},
+	}
+	opts := &QueueOptions{ExtraColumns: []Column{{Name: "shard", Type: "integer", NotNull: true}}}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, opts); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	gotCfg, err := mgr.GetPartitionConfig(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionConfig() error = %v", err)
+	}
+	if gotCfg.SubPartition == nil {
+		t.Fatal("sub-partitioning should be read")
+	}
+	if gotCfg.SubPartition.Column != "shard" || gotCfg.SubPartition.Type != "range" {
+		t.Errorf("sub-partition = %+v, want range on shard", gotCfg.SubPartition)
+	}
+
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+
+		" (payload, metadata, shard) VALUES ('{}', '{}', 1)"); err != nil {
+		t.Errorf("insert into a sub-partition: %v", err)
+	}
+}
+
+func TestManagerPremakePartitions(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_premake_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	created, err := mgr.PremakePartitions(ctx, schema, name, time.Now().AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatalf("PremakePartitions() error = %v", err)
+	}
+	if !created {
+		t.Error("PremakePartitions() should create partitions beyond premake")
+	}
+
+	gotCfg, err := mgr.GetPartitionConfig(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionConfig() error = %v", err)
+	}
+	if gotCfg.Premake != cfg.Premake {
+		t.Errorf("premake = %d, want unchanged %d", gotCfg.Premake, cfg.Premake)
+	}
+}
+
+func TestManagerPartitionDefaultData(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_backfill_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		Retention:        "7 days",
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	// beyond premake, so the rows land in the default partition
+	const rows = 5
+	if _, err := pool.Exec(ctx, "INSERT INTO "+fqn.Sanitize()+
+		" (payload, metadata, created_at) SELECT '{}', '{}', now() + interval '30 days' FROM generate_series(1, $1)", rows); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	hasRows, err := mgr.hasDefaultRows(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("hasDefaultRows() error = %v", err)
+	}
+	if !hasRows {
+		t.Fatal("rows beyond premake should be in the default partition")
+	}
+
+	moved, err := mgr.PartitionDefaultData(ctx, schema, name, &BackfillOptions{BatchInterval: "1 hour"})
+	if err != nil {
+		t.Fatalf("PartitionDefaultData() error = %v", err)
+	}
+	if moved != rows {
+		t.Errorf("PartitionDefaultData() moved %d rows, want %d", moved, rows)
+	}
+
+	hasRows, err = mgr.hasDefaultRows(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("hasDefaultRows() error = %v", err)
+	}
+	if hasRows {
+		t.Error("default partition should be empty after PartitionDefaultData()")
+	}
+
+	var total int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM "+fqn.Sanitize()).Scan(&total); err != nil {
+		t.Fatalf("count error = %v", err)
+	}
+	if total != rows {
+		t.Errorf("queue holds %d rows, want %d", total, rows)
+	}
+}
+
+func TestManagerLegalHold(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_hold_%d", os.Getpid()))
+	hold := SchemaName(fmt.Sprintf("test_hold_schema_%d", os.Getpid()))
+
+	defer pool.Exec(ctx, "DROP SCHEMA IF EXISTS "+hold.Sanitize()+" CASCADE")
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	var partition string
+	err := pool.QueryRow(ctx, `
+		SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass ORDER BY c.relname LIMIT 1
+	`, MakeFQN(schema, name).Sanitize()).Scan(&partition)
+	if err != nil {
+		t.Fatalf("failed to find a partition: %v", err)
+	}
+
+	if err := mgr.HoldPartition(ctx, schema, name, partition, hold); err != nil {
+		t.Fatalf("HoldPartition() error = %v", err)
+	}
+
+	held, err := mgr.HeldPartitions(ctx, schema, name, hold)
+	if err != nil {
+		t.Fatalf("HeldPartitions() error = %v", err)
+	}
+	if len(held) != 1 || held[0] != partition {
+		t.Errorf("HeldPartitions() = %v, want [%s]", held, partition)
+	}
+
+	if err := mgr.ReleasePartition(ctx, schema, name, partition, hold); err != nil {
+		t.Fatalf("ReleasePartition() error = %v", err)
+	}
+
+	held, err = mgr.HeldPartitions(ctx, schema, name, hold)
+	if err != nil {
+		t.Fatalf("HeldPartitions() error = %v", err)
+	}
+	if len(held) != 0 {
+		t.Errorf("HeldPartitions() after release = %v, want none", held)
+	}
+
+	if err := mgr.HoldPartition(ctx, schema, name, "no_such_partition", hold); err == nil {
+		t.Error("HoldPartition() of a missing partition should fail")
+	}
+}
+
+func TestManagerPartitionGrantDrift(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_grants_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	drift, err := mgr.GetPartitionGrantDrift(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionGrantDrift() error = %v", err)
+	}
+	if len(drift) != 0 {
+		t.Errorf("GetPartitionGrantDrift() = %v, want no drift on a new queue", drift)
+	}
+
+	// grants on a partitioned table don't reach existing partitions
+	if _, err := pool.Exec(ctx, "GRANT SELECT ON "+MakeFQN(schema, name).Sanitize()+" TO PUBLIC"); err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err = mgr.GetPartitionGrantDrift(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPartitionGrantDrift() error = %v", err)
+	}
+	if len(drift) == 0 || drift[0].Missing[0] != "SELECT to PUBLIC" {
+		t.Errorf("GetPartitionGrantDrift() = %v, want partitions missing SELECT to PUBLIC", drift)
+	}
+}
+
+func TestManagerOwner(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_owner_%d", os.Getpid()))
+	role := fmt.Sprintf("test_owner_role_%d", os.Getpid())
+
+	if _, err := pool.Exec(ctx, "CREATE ROLE "+QueueName(role).Sanitize()); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Exec(ctx, "DROP ROLE "+QueueName(role).Sanitize())
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:           "1 day",
+		Premake:            2,
+		Retention:          "7 days",
+		DatetimeString:     "YYYYMMDD",
+		OptimizeConstraint: 10,
+		DefaultPartition:   true,
+	}
+
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	if err := mgr.SetOwner(ctx, schema, name, role); err != nil {
+		t.Fatalf("SetOwner() error = %v", err)
+	}
+
+	owner, err := mgr.GetOwner(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetOwner() error = %v", err)
+	}
+	if owner != role {
+		t.Errorf("GetOwner() = %s, want %s", owner, role)
+	}
+
+	var others int
+	err = pool.QueryRow(ctx, `
+		SELECT count(*) FROM pg_partition_tree($1::regclass) t
+		JOIN pg_class c ON c.oid = t.relid
+		WHERE pg_get_userbyid(c.relowner) <> $2
+	`, MakeFQN(schema, name).Sanitize(), role).Scan(&others)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if others != 0 {
+		t.Errorf("%d partitions not owned by %s", others, role)
+	}
+}
+
+func TestManagerComments(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_comments_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	if err := mgr.SetComment(ctx, schema, name, "it's a queue"); err != nil {
+		t.Fatalf("SetComment() error = %v", err)
+	}
+	if comment, err := mgr.GetComment(ctx, schema, name); err != nil || comment != "it's a queue" {
+		t.Errorf("GetComment() = %q, %v, want the comment set", comment, err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	index := CustomIndex{Name: string(name) + "_payload_idx", Columns: []string{"payload"}, Type: "gin", Comment: "payload lookups"}
+	if err := mgr.CreateCustomIndexes(ctx, tx, schema, name, []CustomIndex{index}); err != nil {
+		t.Fatalf("CreateCustomIndexes() error = %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.SetIndexComment(ctx, schema, name, index.Name, "changed"); err != nil {
+		t.Fatalf("SetIndexComment() error = %v", err)
+	}
+
+	indexes, err := mgr.GetCustomIndexes(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetCustomIndexes() error = %v", err)
+	}
+	if len(indexes) != 1 || indexes[0].Comment != "changed" {
+		t.Errorf("GetCustomIndexes() = %+v, want one index commented 'changed'", indexes)
+	}
+}
+
+func TestManagerIndexColumns(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_index_columns_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	columns := []string{
+		IndexColumn{Expression: "created_at", Order: "DESC", Nulls: "LAST"}.String(),
+		IndexColumn{Expression: "(payload->>'user_id')", Opclass: "text_pattern_ops", Order: "ASC"}.String(),
+	}
+	gin := []string{IndexColumn{Expression: "payload", Opclass: "jsonb_path_ops"}.String()}
+	indexes := []CustomIndex{
+		{Name: string(name) + "_sorted_idx", Columns: columns, Type: "btree", Where: "processed_at IS NULL AND error_detail <> ''"},
+		{Name: string(name) + "_path_idx", Columns: gin, Type: "gin", With: map[string]string{"fastupdate": "off"}},
+	}
+	if err := mgr.AddCustomIndexes(ctx, schema, name, indexes); err != nil {
+		t.Fatalf("AddCustomIndexes() error = %v", err)
+	}
+
+	got, err := mgr.GetCustomIndexes(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetCustomIndexes() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetCustomIndexes() = %+v, want 2 indexes", got)
+	}
+	// ordered by name
+	if !IndexColumnsEquivalent(got[0].Columns, gin) {
+		t.Errorf("GetCustomIndexes() columns = %q, want %q", got[0].Columns, gin)
+	}
+	if len(got[0].With) != 1 || got[0].With["fastupdate"] != "off" {
+		t.Errorf("GetCustomIndexes() with = %v, want fastupdate off", got[0].With)
+	}
+	if c := ParseIndexColumn(got[1].Columns[0]); !c.Equivalent(ParseIndexColumn(columns[0])) {
+		t.Errorf("GetCustomIndexes() column = %q, want %q", got[1].Columns[0], columns[0])
+	}
+	if c := ParseIndexColumn(got[1].Columns[1]); c.Opclass != "text_pattern_ops" || c.Order != "" {
+		t.Errorf("GetCustomIndexes() column = %q, want text_pattern_ops ascending", got[1].Columns[1])
+	}
+	if !IndexColumnsEquivalent(got[1].Columns, columns) {
+		t.Errorf("GetCustomIndexes() columns = %q, want the equivalent of %q", got[1].Columns, columns)
+	}
+	if !EquivalentExpressions(got[1].Where, indexes[0].Where) {
+		t.Errorf("GetCustomIndexes() where = %q, want the equivalent of %q", got[1].Where, indexes[0].Where)
+	}
+}
+
+func TestManagerIndexConcurrently(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	simple := QueueName(fmt.Sprintf("test_concurrent_%d", os.Getpid()))
+	partitioned := QueueName(fmt.Sprintf("test_concurrent_part_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, simple)
+	defer mgr.Drop(ctx, schema, partitioned)
+
+	if err := mgr.CreateSimple(ctx, schema, simple, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+		Timezone:         "UTC",
+		Native:           true,
+	}
+	if err := mgr.CreatePartitioned(ctx, schema, partitioned, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	invalid := func(name QueueName) int {
+		t.Helper()
+		var n int
+		err := pool.QueryRow(ctx, `
+			SELECT count(*) FROM pg_index x
+			WHERE NOT x.indisvalid
+			  AND x.indrelid IN (SELECT relid FROM pg_partition_tree(format('%I.%I', $1::text, $2::text)::regclass))
+		`, schema, name).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	for _, name := range []QueueName{simple, partitioned} {
+		idx := CustomIndex{Name: string(name) + "_created_idx", Columns: []string{"created_at DESC"}, Comment: "recent first"}
+		if err := mgr.CreateIndexConcurrently(ctx, schema, name, idx); err != nil {
+			t.Fatalf("CreateIndexConcurrently(%s) error = %v", name, err)
+		}
+		// building it again is a no-op
+		if err := mgr.CreateIndexConcurrently(ctx, schema, name, idx); err != nil {
+			t.Fatalf("CreateIndexConcurrently(%s) again error = %v", name, err)
+		}
+		if n := invalid(name); n != 0 {
+			t.Errorf("%s has %d invalid indexes, want none", name, n)
+		}
+
+		indexes, err := mgr.GetCustomIndexes(ctx, schema, name)
+		if err != nil {
+			t.Fatalf("GetCustomIndexes() error = %v", err)
+		}
+		if len(indexes) != 1 || indexes[0].Name != idx.Name || indexes[0].Comment != idx.Comment {
+			t.Errorf("GetCustomIndexes() = %+v, want %s", indexes, idx.Name)
+		}
+	}
+
+	var attached int
+	err := pool.QueryRow(ctx, `
+		SELECT count(*) FROM pg_inherits WHERE inhparent = format('%I.%I', $1::text, $2::text)::regclass
+	`, schema, string(partitioned)+"_created_idx").Scan(&attached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attached == 0 {
+		t.Error("no partition indexes are attached to the parent index")
+	}
+}
+
+func TestManagerTemplateIndexes(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_template_idx_%d", os.Getpid()))
+	template := QueueName(name.String() + "_template")
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+	}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	templateIndex := func(index string) bool {
+		t.Helper()
+		var ok bool
+		err := pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`,
+			MakeFQN(schema, QueueName(childIndexName(template, index))).Sanitize()).Scan(&ok)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	index := CustomIndex{Name: string(name) + "_user_idx", Columns: []string{"(payload->>'user_id')"}}
+	if err := mgr.CreateCustomIndexes(ctx, tx, schema, name, []CustomIndex{index}); err != nil {
+		t.Fatalf("CreateCustomIndexes() error = %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !templateIndex(index.Name) {
+		t.Fatal("the template table is missing the custom index")
+	}
+
+	if _, err := pool.Exec(ctx, "DROP INDEX "+MakeFQN(schema, QueueName(childIndexName(template, index.Name))).Sanitize()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.SyncTemplateIndexes(ctx, schema, name); err != nil {
+		t.Fatalf("SyncTemplateIndexes() error = %v", err)
+	}
+	if !templateIndex(index.Name) {
+		t.Error("SyncTemplateIndexes() didn't copy the custom index to the template table")
+	}
+
+	if err := mgr.DropCustomIndexes(ctx, schema, name, []string{index.Name}); err != nil {
+		t.Fatalf("DropCustomIndexes() error = %v", err)
+	}
+	if templateIndex(index.Name) {
+		t.Error("DropCustomIndexes() left the template copy")
+	}
+}
+
+func TestManagerDefaultIndexes(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_default_idx_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+	}
+	opts := &QueueOptions{DefaultIndexes: &DefaultIndexes{CreatedAt: true, ProcessedAtNull: true, ScheduledFor: true}}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, opts); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	got, err := mgr.GetDefaultIndexes(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetDefaultIndexes() error = %v", err)
+	}
+	if *got != *opts.DefaultIndexes {
+		t.Errorf("GetDefaultIndexes() = %+v, want %+v", got, opts.DefaultIndexes)
+	}
+
+	want := &DefaultIndexes{ScheduledFor: true, Metadata: true, MetadataOpclass: "jsonb_path_ops"}
+	if err := mgr.SetDefaultIndexes(ctx, schema, name, want, ""); err != nil {
+		t.Fatalf("SetDefaultIndexes() error = %v", err)
+	}
+	if got, err := mgr.GetDefaultIndexes(ctx, schema, name); err != nil || *got != *want {
+		t.Errorf("GetDefaultIndexes() = %+v, %v, want %+v", got, err, want)
+	}
+
+	// the template's copies of the dropped indexes go too
+	rows, err := pool.Query(ctx, `
+		SELECT pg_get_indexdef(indexrelid) FROM pg_index
+		WHERE indrelid = format('%I.%I', $1::text, $2::text || '_template')::regclass
+	`, schema, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templateIndexes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range templateIndexes {
+		if strings.Contains(def, "(created_at)") || strings.Contains(def, "(processed_at)") {
+			t.Errorf("template index %s should have been dropped", def)
+		}
+	}
+}
+
+func TestManagerExtraColumns(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_columns_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	tenant := Column{Name: "tenant_id", Type: "uuid", NotNull: true}
+	opts := &QueueOptions{ExtraColumns: []Column{tenant}}
+	if err := mgr.CreateSimple(ctx, schema, name, opts); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	priority := Column{Name: "priority", Type: "int2", Default: "0"}
+	if err := mgr.SetExtraColumns(ctx, schema, name, opts.ExtraColumns, []Column{tenant, priority}); err != nil {
+		t.Fatalf("SetExtraColumns() error = %v", err)
+	}
+
+	columns, err := mgr.GetExtraColumns(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetExtraColumns() error = %v", err)
+	}
+	want := []Column{
+		{Name: "tenant_id", Type: "uuid", NotNull: true},
+		{Name: "priority", Type: "smallint", Default: "0"},
+	}
+	if fmt.Sprint(columns) != fmt.Sprint(want) {
+		t.Errorf("GetExtraColumns() = %v, want %v", columns, want)
+	}
+
+	if typ, err := mgr.FormatType(ctx, "varchar(64)"); err != nil || typ != "character varying(64)" {
+		t.Errorf("FormatType() = %s, %v, want character varying(64)", typ, err)
+	}
+}
+
+func TestManagerCheckConstraints(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_checks_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	object := CheckConstraint{Name: string(name) + "_object", Expression: "jsonb_typeof(payload) = 'object'"}
+	opts := &QueueOptions{CheckConstraints: []CheckConstraint{object}}
+	if err := mgr.CreateSimple(ctx, schema, name, opts); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	_, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+" (payload, metadata) VALUES ('[]', '{}')")
+	if err == nil {
+		t.Error("insert violating the check constraint succeeded")
+	}
+
+	bounded := CheckConstraint{Name: string(name) + "_bounded", Expression: "consumed_count < 10"}
+	if err := mgr.SetCheckConstraints(ctx, schema, name, opts.CheckConstraints, []CheckConstraint{bounded}); err != nil {
+		t.Fatalf("SetCheckConstraints() error = %v", err)
+	}
+
+	constraints, err := mgr.GetCheckConstraints(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetCheckConstraints() error = %v", err)
+	}
+	if len(constraints) != 1 || constraints[0].Name != bounded.Name {
+		t.Errorf("GetCheckConstraints() = %v, want only %s", constraints, bounded.Name)
+	}
+}
+
+func TestManagerListQueues(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_list_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	queues, err := mgr.ListQueues(ctx, schema)
+	if err != nil {
+		t.Fatalf("ListQueues() error = %v", err)
+	}
+
+	found := false
+	for _, q := range queues {
+		if q.Name == name {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("ListQueues() did not return %s", name)
+	}
+
+	health, err := mgr.FleetHealth(ctx, schema)
+	if err != nil {
+		t.Fatalf("FleetHealth() error = %v", err)
+	}
+	if health.Queues != len(queues) {
+		t.Errorf("FleetHealth().Queues = %d, want %d", health.Queues, len(queues))
+	}
+}
+
+func TestManagerMaxMessageAge(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_max_age_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer func() {
+		_ = mgr.SetMaxMessageAge(ctx, schema, name, "")
+		_ = mgr.Drop(ctx, schema, name)
+	}()
+
+	if err := mgr.SetMaxMessageAge(ctx, schema, name, "1 day"); err != nil {
+		t.Fatalf("SetMaxMessageAge() error = %v", err)
+	}
+
+	maxAge, err := mgr.GetMaxMessageAge(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetMaxMessageAge() error = %v", err)
+	}
+	if maxAge != "1 day" {
+		t.Errorf("GetMaxMessageAge() = %q, want %q", maxAge, "1 day")
+	}
+
+	insert := "INSERT INTO " + fqn.Sanitize() + " (created_at, payload, metadata) VALUES ($1, '{}', '{}')"
+	if _, err := pool.Exec(ctx, insert, time.Now()); err != nil {
+		t.Errorf("insert of fresh message error = %v", err)
+	}
+	if _, err := pool.Exec(ctx, insert, time.Now().Add(-48*time.Hour)); err == nil {
+		t.Error("insert of old message should be rejected")
+	}
+
+	if err := mgr.SetMaxMessageAge(ctx, schema, name, ""); err != nil {
+		t.Fatalf("SetMaxMessageAge(\"\") error = %v", err)
+	}
+	if _, err := pool.Exec(ctx, insert, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Errorf("insert after removing the limit error = %v", err)
+	}
+}
+
+func TestManagerNotifyChannel(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_notify_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+	channel := name.String() + "_new"
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer func() {
+		_ = mgr.SetNotifyChannel(ctx, schema, name, "")
+		_ = mgr.Drop(ctx, schema, name)
+	}()
+
+	if err := mgr.SetNotifyChannel(ctx, schema, name, channel); err != nil {
+		t.Fatalf("SetNotifyChannel() error = %v", err)
+	}
+
+	got, err := mgr.GetNotifyChannel(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetNotifyChannel() error = %v", err)
+	}
+	if got != channel {
+		t.Errorf("GetNotifyChannel() = %q, want %q", got, channel)
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		t.Fatal(err)
+	}
+
+	var id string
+	err = pool.QueryRow(ctx, "INSERT INTO "+fqn.Sanitize()+" (payload, metadata) VALUES ('{}', '{}') RETURNING id::text").Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	n, err := conn.Conn().WaitForNotification(waitCtx)
+	if err != nil {
+		t.Fatalf("WaitForNotification() error = %v", err)
+	}
+	if n.Payload != id {
+		t.Errorf("notification payload = %q, want the message id %q", n.Payload, id)
+	}
+
+	if err := mgr.SetNotifyChannel(ctx, schema, name, ""); err != nil {
+		t.Fatalf("SetNotifyChannel(\"\") error = %v", err)
+	}
+	if got, err := mgr.GetNotifyChannel(ctx, schema, name); err != nil || got != "" {
+		t.Errorf("GetNotifyChannel() after removal = %q, %v, want none", got, err)
+	}
+}
+
+func TestManagerRegistry(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_registry_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer func() {
+		_ = mgr.SyncRegistry(ctx, schema, "pgq_queue", fqn.String(), "", nil)
+		_ = mgr.Drop(ctx, schema, name)
+	}()
+
+	objects, err := mgr.QueueObjects(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("QueueObjects() error = %v", err)
+	}
+
+	var tables, indexes int
+	for _, o := range objects {
+		switch o.ObjectType {
+		case ObjectTable:
+			tables++
+			if o.ObjectName != fqn.String() {
+				t.Errorf("table object = %q, want %q", o.ObjectName, fqn)
+			}
+		case ObjectIndex:
+			indexes++
+		}
+	}
+	if tables != 1 || indexes == 0 {
+		t.Errorf("QueueObjects() = %d tables, %d indexes, want 1 table and its indexes", tables, indexes)
+	}
+
+	if err := mgr.SyncRegistry(ctx, schema, "pgq_queue", fqn.String(), "test", objects); err != nil {
+		t.Fatalf("SyncRegistry() error = %v", err)
+	}
+
+	entries, err := mgr.ListRegistry(ctx, schema)
+	if err != nil {
+		t.Fatalf("ListRegistry() error = %v", err)
+	}
+
+	var recorded int
+	for _, e := range entries {
+		if e.ResourceID == fqn.String() {
+			recorded++
+			if e.ResourceType != "pgq_queue" || e.Owner != "test" {
+				t.Errorf("entry %+v has wrong owner", e)
+			}
+		}
+	}
+	if recorded != len(objects) {
+		t.Errorf("ListRegistry() has %d entries of the queue, want %d", recorded, len(objects))
+	}
+
+	if err := mgr.SyncRegistry(ctx, schema, "pgq_queue", fqn.String(), "test", nil); err != nil {
+		t.Fatalf("SyncRegistry(nil) error = %v", err)
+	}
+	entries, err = mgr.ListRegistry(ctx, schema)
+	if err != nil {
+		t.Fatalf("ListRegistry() error = %v", err)
+	}
+	for _, e := range entries {
+		if e.ResourceID == fqn.String() {
+			t.Errorf("entry %+v left after removing the resource", e)
+		}
+	}
+}
+
+func TestManagerDrop(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_drop_%d", os.Getpid()))
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	if err := mgr.Drop(ctx, schema, name); err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+
+	exists, err := mgr.Exists(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Exists() after drop error = %v", err)
+	}
+	if exists {
+		t.Error("queue should not exist after drop")
+	}
+
+	if err := mgr.Drop(ctx, schema, name); err != nil {
+		t.Error("dropping non-existent queue should not error")
+	}
+}
+
+func TestManagerGetNotFound(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName("nonexistent_queue_test")
+
+	_, err := mgr.Get(ctx, schema, name)
+	if err == nil {
+		t.Fatal("Get() on non-existent queue should return error")
+	}
+
+	if _, ok := err.(*QueueNotFoundError); !ok {
+		t.Errorf("Get() error type = %T, want *QueueNotFoundError", err)
+	}
+}
+
+func TestManagerGetExpressionPartitionKey(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_expression_key_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE "+fqn.Sanitize()+" (id bigint, created_at timestamptz NOT NULL) PARTITION BY RANGE ((created_at::date))"); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Exec(ctx, "DROP TABLE "+fqn.Sanitize())
+
+	_, err := mgr.Get(ctx, schema, name)
+	if err == nil || !strings.Contains(err.Error(), "not a single column") {
+		t.Errorf("Get() error = %v, want the expression key rejected", err)
+	}
+}
+
+func TestManagerRename(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	from := QueueName(fmt.Sprintf("test_rename_%d", os.Getpid()))
+	to := QueueName(fmt.Sprintf("test_renamed_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, from)
+	defer mgr.RemovePartmanConfig(ctx, schema, from)
+	defer mgr.Drop(ctx, schema, to)
+	defer mgr.RemovePartmanConfig(ctx, schema, to)
+
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+	}
+	if err := mgr.CreatePartitioned(ctx, schema, from, cfg, &QueueOptions{OrderingColumn: true}); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	index := CustomIndex{Name: string(from) + "_user_idx", Columns: []string{"(payload->>'user_id')"}}
+	if err := mgr.CreateCustomIndexes(ctx, tx, schema, from, []CustomIndex{index}); err != nil {
+		t.Fatalf("CreateCustomIndexes() error = %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, from).Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.Rename(ctx, schema, from, to, true); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	if _, err := mgr.Get(ctx, schema, from); err == nil {
+		t.Error("Get() found the queue under its old name")
+	}
+
+	var messages int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM "+MakeFQN(schema, to).Sanitize()).Scan(&messages); err != nil {
+		t.Fatal(err)
+	}
+	if messages != 1 {
+		t.Errorf("renamed queue has %d messages, want 1", messages)
+	}
+
+	if _, err := mgr.GetPartitionConfig(ctx, schema, to); err != nil {
+		t.Errorf("GetPartitionConfig() after rename error = %v", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND starts_with(c.relname, $2) AND c.relname <> $3
+	`, schema, from.String(), index.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leftovers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("Rename() left objects named after the old queue: %v", leftovers)
+	}
+
+	var templateIndex bool
+	err = pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`,
+		MakeFQN(schema, QueueName(childIndexName(QueueName(to.String()+"_template"), index.Name))).Sanitize()).Scan(&templateIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !templateIndex {
+		t.Error("Rename() didn't rename the template copy of the custom index")
+	}
+
+	if err := mgr.Create(ctx, schema, from, nil, nil); err != nil {
+		t.Fatalf("Create() under the old name error = %v", err)
+	}
+	if err := mgr.Rename(ctx, schema, from, to, false); err == nil {
+		t.Error("Rename() onto an existing queue should fail")
+	}
+}
+
+func TestManagerMoveSchema(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	from := SchemaName("public")
+	to := SchemaName(fmt.Sprintf("test_move_%d", os.Getpid()))
+	name := QueueName(fmt.Sprintf("test_move_schema_%d", os.Getpid()))
+
+	if _, err := pool.Exec(ctx, "CREATE SCHEMA "+to.Sanitize()); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Exec(ctx, "DROP SCHEMA "+to.Sanitize()+" CASCADE")
+
+	defer mgr.Drop(ctx, from, name)
+	defer mgr.RemovePartmanConfig(ctx, from, name)
+	defer mgr.Drop(ctx, to, name)
+	defer mgr.RemovePartmanConfig(ctx, to, name)
+
+	cfg := &PartitionConfig{
+		Interval:         "1 day",
+		Premake:          2,
+		DatetimeString:   "YYYYMMDD",
+		DefaultPartition: true,
+	}
+	if err := mgr.CreatePartitioned(ctx, from, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+	if err := mgr.SetMaxMessageAge(ctx, from, name, "1 day"); err != nil {
+		t.Fatalf("SetMaxMessageAge() error = %v", err)
+	}
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(from, name).Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.MoveSchema(ctx, from, name, to, true); err != nil {
+		t.Fatalf("MoveSchema() error = %v", err)
+	}
+
+	if _, err := mgr.Get(ctx, from, name); err == nil {
+		t.Error("Get() found the queue in its old schema")
+	}
+
+	var messages int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM "+MakeFQN(to, name).Sanitize()).Scan(&messages); err != nil {
+		t.Fatal(err)
+	}
+	if messages != 1 {
+		t.Errorf("moved queue has %d messages, want 1", messages)
+	}
+
+	if _, err := mgr.GetPartitionConfig(ctx, to, name); err != nil {
+		t.Errorf("GetPartitionConfig() after move error = %v", err)
+	}
+
+	var left int
+	err := pool.QueryRow(ctx, `
+		SELECT count(*) FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND starts_with(c.relname, $2)
+	`, from, name.String()).Scan(&left)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left > 0 {
+		t.Errorf("MoveSchema() left %d relations in the old schema", left)
+	}
+
+	var hasMaxAge bool
+	if err := pool.QueryRow(ctx, `SELECT to_regprocedure($1) IS NOT NULL`, maxAgeFunctionFQN(to, name).Sanitize()+"()").Scan(&hasMaxAge); err != nil {
+		t.Fatal(err)
+	}
+	if !hasMaxAge {
+		t.Error("MoveSchema() didn't move the max age function")
+	}
+}
+
+func TestManagerRepairDefaultIndexes(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_repair_idx_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{OrderingColumn: true}); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	missing, err := mgr.MissingDefaultIndexes(ctx, schema, name, nil)
+	if err != nil {
+		t.Fatalf("MissingDefaultIndexes() error = %v", err)
+	}
+	if len(missing) > 0 {
+		t.Fatalf("MissingDefaultIndexes() of a new queue = %v, want none", missing)
+	}
+
+	for _, index := range []string{name.String() + indexScheduledFor, name.String() + indexOrdering} {
+		if _, err := pool.Exec(ctx, "DROP INDEX "+MakeFQN(schema, QueueName(index)).Sanitize()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	missing, err = mgr.MissingDefaultIndexes(ctx, schema, name, nil)
+	if err != nil {
+		t.Fatalf("MissingDefaultIndexes() error = %v", err)
+	}
+	want := []string{name.String() + indexScheduledFor, name.String() + indexOrdering}
+	if !slices.Equal(missing, want) {
+		t.Errorf("MissingDefaultIndexes() = %v, want %v", missing, want)
+	}
+
+	if err := mgr.RepairDefaultIndexes(ctx, schema, name, nil, ""); err != nil {
+		t.Fatalf("RepairDefaultIndexes() error = %v", err)
+	}
+	missing, err = mgr.MissingDefaultIndexes(ctx, schema, name, nil)
+	if err != nil {
+		t.Fatalf("MissingDefaultIndexes() error = %v", err)
+	}
+	if len(missing) > 0 {
+		t.Errorf("MissingDefaultIndexes() after repair = %v, want none", missing)
+	}
+}
+
+func TestManagerRowLevelSecurity(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_rls_%d", os.Getpid()))
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer func() {
+		_ = mgr.Drop(ctx, schema, name)
+	}()
+
+	consumer := Policy{Name: "consumer", Command: "SELECT", Using: "metadata ? 'tenant'"}
+	producer := Policy{Name: "producer", Command: "INSERT", WithCheck: "metadata ? 'tenant'"}
+	if err := mgr.SetRowLevelSecurity(ctx, schema, name, true, nil, []Policy{consumer, producer}); err != nil {
+		t.Fatalf("SetRowLevelSecurity() error = %v", err)
+	}
+
+	enabled, policies, err := mgr.GetRowLevelSecurity(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetRowLevelSecurity() error = %v", err)
+	}
+	if !enabled {
+		t.Error("row-level security not enabled")
+	}
+	if len(policies) != 2 || policies[0].Name != "consumer" || policies[0].Command != "SELECT" ||
+		policies[0].Using == "" || policies[0].WithCheck != "" || policies[1].Command != "INSERT" || policies[1].WithCheck == "" {
+		t.Errorf("GetRowLevelSecurity() policies = %+v", policies)
+	}
+
+	// changing one policy leaves the other alone
+	changed := consumer
+	changed.Command = "ALL"
+	if err := mgr.SetRowLevelSecurity(ctx, schema, name, true, []Policy{consumer, producer}, []Policy{changed, producer}); err != nil {
+		t.Fatalf("SetRowLevelSecurity() change error = %v", err)
+	}
+	_, policies, err = mgr.GetRowLevelSecurity(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetRowLevelSecurity() error = %v", err)
+	}
+	if len(policies) != 2 || policies[0].Command != "ALL" {
+		t.Errorf("GetRowLevelSecurity() after change = %+v", policies)
+	}
+
+	if err := mgr.SetRowLevelSecurity(ctx, schema, name, false, []Policy{changed, producer}, nil); err != nil {
+		t.Fatalf("SetRowLevelSecurity() disable error = %v", err)
+	}
+	enabled, policies, err = mgr.GetRowLevelSecurity(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetRowLevelSecurity() error = %v", err)
+	}
+	if enabled || len(policies) != 0 {
+		t.Errorf("GetRowLevelSecurity() after disable = %v, %+v", enabled, policies)
+	}
+}
+
+func TestManagerPublications(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_pub_%d", os.Getpid()))
+	pub := name.String() + "_analytics"
+
+	if _, err := pool.Exec(ctx, "CREATE PUBLICATION "+pgx.Identifier{pub}.Sanitize()); err != nil {
+		t.Skipf("CREATE PUBLICATION: %v", err)
+	}
+	defer pool.Exec(ctx, "DROP PUBLICATION IF EXISTS "+pgx.Identifier{pub}.Sanitize())
+
+	cfg := &PartitionConfig{
+		Interval:       "1 day",
+		Premake:        2,
+		Retention:      "7 days",
+		DatetimeString: "YYYYMMDD",
+		Native:         true,
+	}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.SetPublications(ctx, schema, name, nil, []string{pub}, true); err != nil {
+		t.Fatalf("SetPublications() error = %v", err)
+	}
+
+	got, err := mgr.GetPublications(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPublications() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != pub {
+		t.Errorf("GetPublications() = %v, want [%s]", got, pub)
+	}
+
+	var viaRoot bool
+	if err := pool.QueryRow(ctx, "SELECT pubviaroot FROM pg_publication WHERE pubname = $1", pub).Scan(&viaRoot); err != nil {
+		t.Fatal(err)
+	}
+	if !viaRoot {
+		t.Error("publication should publish via the partition root")
+	}
+
+	// adding again is a no-op
+	if err := mgr.SetPublications(ctx, schema, name, nil, []string{pub}, true); err != nil {
+		t.Fatalf("SetPublications() again error = %v", err)
+	}
+
+	if err := mgr.SetPublications(ctx, schema, name, []string{pub}, nil, true); err != nil {
+		t.Fatalf("SetPublications() remove error = %v", err)
+	}
+	got, err = mgr.GetPublications(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetPublications() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetPublications() after remove = %v, want none", got)
+	}
+}
+
+func TestManagerReplicaIdentity(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_replident_%d", os.Getpid()))
+
+	cfg := &PartitionConfig{
+		Interval:       "1 day",
+		Premake:        2,
+		Retention:      "7 days",
+		DatetimeString: "YYYYMMDD",
+		Native:         true,
+	}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	partitionIdentities := func() []string {
+		t.Helper()
+		rows, err := pool.Query(ctx, `
+			SELECT c.relreplident::text
+			FROM pg_partition_tree(format('%I.%I', $1::text, $2::text)::regclass) t
+			JOIN pg_class c ON c.oid = t.relid
+			WHERE t.isleaf
+		`, schema, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	for _, identity := range []string{"full", ReplicaIdentityIndexPrefix + name.String() + "_pkey", "default"} {
+		if err := mgr.SetReplicaIdentity(ctx, schema, name, identity); err != nil {
+			t.Fatalf("SetReplicaIdentity(%q) error = %v", identity, err)
+		}
+		got, err := mgr.GetReplicaIdentity(ctx, schema, name)
+		if err != nil {
+			t.Fatalf("GetReplicaIdentity() error = %v", err)
+		}
+		if got != identity {
+			t.Errorf("GetReplicaIdentity() = %q, want %q", got, identity)
+		}
+		want := map[string]string{"full": "f", "default": "d"}[identity]
+		if want == "" {
+			want = "i"
+		}
+		for _, id := range partitionIdentities() {
+			if id != want {
+				t.Errorf("partition replica identity = %q after %q, want %q", id, identity, want)
+			}
+		}
+	}
+
+	// partitions created later get the queue's identity
+	if err := mgr.SetReplicaIdentity(ctx, schema, name, "full"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Premake = 4
+	if err := mgr.MaintainNativePartitions(ctx, schema, name, cfg); err != nil {
+		t.Fatalf("MaintainNativePartitions() error = %v", err)
+	}
+	for _, id := range partitionIdentities() {
+		if id != "f" {
+			t.Errorf("new partition replica identity = %q, want f", id)
+		}
+	}
+}
+
+func TestManagerGeneratedColumns(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_generated_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	msgType := Column{Name: "msg_type", Type: "text", Generated: "payload->>'type'"}
+	opts := &QueueOptions{ExtraColumns: []Column{msgType}}
+	if err := mgr.CreateSimple(ctx, schema, name, opts); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	columns, err := mgr.GetExtraColumns(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetExtraColumns() error = %v", err)
+	}
+	if len(columns) != 1 || columns[0].Generated == "" || columns[0].Default != "" {
+		t.Errorf("GetExtraColumns() = %+v, want a generated msg_type", columns)
+	}
+
+	var got string
+	err = pool.QueryRow(ctx, "INSERT INTO "+fqn.Sanitize()+` (payload, metadata) VALUES ('{"type": "order"}', '{}') RETURNING msg_type`).Scan(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "order" {
+		t.Errorf("msg_type = %q, want order", got)
+	}
+
+	// changing the expression recomputes existing rows
+	upper := msgType
+	upper.Generated = "upper(payload->>'type')"
+	if err := mgr.SetExtraColumns(ctx, schema, name, []Column{msgType}, []Column{upper}); err != nil {
+		t.Fatalf("SetExtraColumns() error = %v", err)
+	}
+	if err := pool.QueryRow(ctx, "SELECT msg_type FROM "+fqn.Sanitize()).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "ORDER" {
+		t.Errorf("msg_type after change = %q, want ORDER", got)
+	}
+}
+
+func TestManagerColumnStorage(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	var version int
+	if err := pool.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version < 140000 {
+		t.Skip("column compression requires PostgreSQL 14")
+	}
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_storage_%d", os.Getpid()))
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	columns := []string{"payload", "error_detail"}
+	settings := []ColumnStorage{
+		{Column: "payload", Compression: "pglz", Storage: "main"},
+		{Column: "error_detail", Storage: "external"},
+	}
+	if err := mgr.SetColumnStorage(ctx, schema, name, nil, settings); err != nil {
+		t.Fatalf("SetColumnStorage() error = %v", err)
+	}
+
+	got, err := mgr.GetColumnStorage(ctx, schema, name, columns)
+	if err != nil {
+		t.Fatalf("GetColumnStorage() error = %v", err)
+	}
+	want := []ColumnStorage{
+		{Column: "error_detail", Storage: "external"},
+		{Column: "payload", Compression: "pglz", Storage: "main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetColumnStorage() = %+v, want %+v", got, want)
+	}
+
+	// removing the settings restores the defaults
+	if err := mgr.SetColumnStorage(ctx, schema, name, settings, nil); err != nil {
+		t.Fatalf("SetColumnStorage() reset error = %v", err)
+	}
+	got, err = mgr.GetColumnStorage(ctx, schema, name, columns)
+	if err != nil {
+		t.Fatalf("GetColumnStorage() error = %v", err)
+	}
+	want = []ColumnStorage{
+		{Column: "error_detail", Storage: "extended"},
+		{Column: "payload", Storage: "extended"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetColumnStorage() after reset = %+v, want %+v", got, want)
+	}
+}
+
+func TestManagerAccessMethod(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_am_%d", os.Getpid()))
+
+	if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{AccessMethod: "heap"}); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	got, err := mgr.GetAccessMethod(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetAccessMethod() error = %v", err)
+	}
+	if got != "heap" {
+		t.Errorf("GetAccessMethod() = %q, want heap", got)
+	}
+
+	other := QueueName(name.String() + "_x")
+	err = mgr.CreateSimple(ctx, schema, other, &QueueOptions{AccessMethod: "no_such_am"})
+	if err == nil {
+		_ = mgr.Drop(ctx, schema, other)
+		t.Error("CreateSimple() with an unknown access method should fail")
+	}
+}
+
+func TestManagerCloneObjects(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	source := QueueName(fmt.Sprintf("test_clone_src_%d", os.Getpid()))
+	name := QueueName(fmt.Sprintf("test_clone_%d", os.Getpid()))
+
+	if err := mgr.CreateSimple(ctx, schema, source, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, source)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	index := CustomIndex{Name: string(source) + "_payload_idx", Columns: []string{"payload"}, Type: "gin"}
+	if err := mgr.CreateCustomIndexes(ctx, tx, schema, source, []CustomIndex{index}); err != nil {
+		t.Fatalf("CreateCustomIndexes() error = %v", err)
+	}
+	srcFQN := MakeFQN(schema, source)
+	for _, stmt := range []string{
+		"ALTER TABLE " + srcFQN.Sanitize() + " SET (autovacuum_enabled = false)",
+		"GRANT SELECT ON " + srcFQN.Sanitize() + " TO PUBLIC",
+	} {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	defer mgr.Drop(ctx, schema, name)
+
+	cloned, err := mgr.CloneObjects(ctx, srcFQN, schema, name, nil)
+	if err != nil {
+		t.Fatalf("CloneObjects() error = %v", err)
+	}
+	want := []string{string(name) + "_payload_idx"}
+	if !reflect.DeepEqual(cloned, want) {
+		t.Errorf("CloneObjects() = %v, want %v", cloned, want)
+	}
+
+	indexes, err := mgr.GetCustomIndexes(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetCustomIndexes() error = %v", err)
+	}
+	if len(indexes) != 1 || indexes[0].Name != want[0] || indexes[0].Type != "gin" {
+		t.Errorf("GetCustomIndexes() = %+v, want the copied gin index", indexes)
+	}
+
+	var options []string
+	var public bool
+	err = pool.QueryRow(ctx, `
+		SELECT coalesce(reloptions, '{}'), has_table_privilege('public', oid, 'SELECT')
+		FROM pg_class WHERE oid = $1::regclass
+	`, MakeFQN(schema, name).Sanitize()).Scan(&options, &public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(options, []string{"autovacuum_enabled=false"}) {
+		t.Errorf("reloptions = %v, want autovacuum_enabled=false", options)
+	}
+	if !public {
+		t.Error("SELECT granted to PUBLIC on the source wasn't copied")
+	}
+}
+
+func TestManagerHasMessages(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	ctx := context.Background()
+	mgr := NewManager(pool)
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_has_messages_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	has, err := mgr.HasMessages(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("HasMessages() error = %v", err)
+	}
+	if has {
+		t.Error("HasMessages() = true for a new queue")
+	}
+
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+" (payload, metadata, processed_at) VALUES ('{}', '{}', now())"); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	has, err = mgr.HasMessages(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("HasMessages() error = %v", err)
+	}
+	if !has {
+		t.Error("HasMessages() = false for a queue holding a processed message")
+	}
+}
+
+func TestManagerBacklog(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	ctx := context.Background()
+	mgr := NewManager(pool)
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_backlog_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	// processed messages don't count
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, name).Sanitize()+
+		" (payload, metadata, processed_at) VALUES ('{}', '{}', now()), ('{}', '{}', NULL), ('{}', '{}', NULL)"); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	backlog, err := mgr.Backlog(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("Backlog() error = %v", err)
+	}
+	if backlog != 2 {
+		t.Errorf("Backlog() = %d, want 2", backlog)
+	}
+}
+
+func TestManagerMetricViews(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	kept := QueueName(fmt.Sprintf("test_metrics_kept_%d", os.Getpid()))
+	dropped := QueueName(fmt.Sprintf("test_metrics_dropped_%d", os.Getpid()))
+
+	defer mgr.DropMetricViews(ctx, schema)
+	defer mgr.Drop(ctx, schema, kept)
+	defer mgr.Drop(ctx, schema, dropped)
+
+	for _, name := range []QueueName{kept, dropped} {
+		if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+			t.Fatalf("CreateSimple(%s) error = %v", name, err)
+		}
+	}
+	if _, err := pool.Exec(ctx, "INSERT INTO "+MakeFQN(schema, kept).Sanitize()+" (payload, metadata) VALUES ('{}', '{}'), ('{}', '{}')"); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	if err := mgr.CreateMetricViews(ctx, schema, []FQN{MakeFQN(schema, kept), MakeFQN(schema, dropped)}); err != nil {
+		t.Fatalf("CreateMetricViews() error = %v", err)
+	}
+
+	backlog := func() map[QueueName]int64 {
+		t.Helper()
+		rows, err := pool.Query(ctx, "SELECT queue, messages FROM "+MakeFQN(schema, "pgq_backlog").Sanitize()+" WHERE schema = $1", schema)
+		if err != nil {
+			t.Fatalf("querying pgq_backlog: %v", err)
+		}
+		got := map[QueueName]int64{}
+		for rows.Next() {
+			var queue QueueName
+			var messages int64
+			if err := rows.Scan(&queue, &messages); err != nil {
+				t.Fatalf("scanning pgq_backlog: %v", err)
+			}
+			got[queue] = messages
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("querying pgq_backlog: %v", err)
+		}
+		return got
+	}
+
+	if got := backlog(); len(got) != 2 || got[kept] != 2 || got[dropped] != 0 {
+		t.Errorf("pgq_backlog = %v, want %s: 2 and %s: 0", got, kept, dropped)
+	}
+
+	// the views don't depend on the queue tables
+	if err := mgr.Drop(ctx, schema, dropped); err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+
+	exists, err := mgr.MetricViewsExist(ctx, schema)
+	if err != nil {
+		t.Fatalf("MetricViewsExist() error = %v", err)
+	}
+	if !exists {
+		t.Fatal("dropping a queue should keep the metric views")
+	}
+	if got := backlog(); len(got) != 1 || got[kept] != 2 {
+		t.Errorf("pgq_backlog after drop = %v, want only %s: 2", got, kept)
+	}
+}
+
+func TestManagerTextCollation(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_collation_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	defer mgr.Drop(ctx, schema, name)
+
+	columns := []Column{
+		{Name: "region", Type: "varchar(16)"},
+		{Name: "tenant_id", Type: "uuid"},
+	}
+	if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{TextCollation: "C", ExtraColumns: columns}); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	collation := func(column string) string {
+		t.Helper()
+		var name string
+		err := pool.QueryRow(ctx, `
+			SELECT coalesce(co.collname, '')
+			FROM pg_attribute a
+			LEFT JOIN pg_collation co ON co.oid = a.attcollation
+			WHERE a.attrelid = $1::regclass AND a.attname = $2
+		`, fqn.Sanitize(), column).Scan(&name)
+		if err != nil {
+			t.Fatalf("reading collation of %s: %v", column, err)
+		}
+		return name
+	}
+
+	for column, want := range map[string]string{"error_detail": "C", "region": "C", "tenant_id": ""} {
+		if got := collation(column); got != want {
+			t.Errorf("collation of %s = %q, want %q", column, got, want)
+		}
+	}
+
+	added := append(columns, Column{Name: "category", Type: "text"})
+	if err := mgr.SetExtraColumns(ctx, schema, name, columns, added); err != nil {
+		t.Fatalf("SetExtraColumns() error = %v", err)
+	}
+	if got := collation("category"); got != "C" {
+		t.Errorf("collation of added column = %q, want C", got)
+	}
+
+	if err := mgr.SetTextCollation(ctx, schema, name, ""); err != nil {
+		t.Fatalf("SetTextCollation() error = %v", err)
+	}
+	for _, column := range []string{"error_detail", "region", "category"} {
+		if got := collation(column); got != defaultCollation {
+			t.Errorf("collation of %s after reset = %q, want %q", column, got, defaultCollation)
+		}
+	}
+}
+
+func TestManagerInstallPartman(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManagerWithOptions(pool, &ManagerOptions{InstallPartman: true})
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_install_partman_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+	defer mgr.RemovePartmanConfig(ctx, schema, name)
+
+	// a missing pg_partman doesn't make partitioned queues unsupported, as
+	// it is installed before the first one
+	if err := mgr.CheckSupport(ctx, true); err != nil {
+		t.Fatalf("CheckSupport() error = %v", err)
+	}
+
+	cfg := &PartitionConfig{Interval: "1 day", Premake: 1, DatetimeString: "YYYYMMDD"}
+	if err := mgr.CreatePartitioned(ctx, schema, name, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	var installed bool
+	if err := pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_partman')`).Scan(&installed); err != nil {
+		t.Fatalf("checking pg_partman: %v", err)
+	}
+	if !installed {
+		t.Error("CreatePartitioned() with InstallPartman should leave pg_partman installed")
+	}
+	if !mgr.pgcryptoChecked || !mgr.partmanInstalled {
+		t.Error("installExtensions() should only check the extensions once per Manager")
+	}
+
+	// later queues skip the checks
+	if err := mgr.installExtensions(ctx, MakeFQN(schema, name), true); err != nil {
+		t.Errorf("installExtensions() again error = %v", err)
+	}
+}
+
+func TestManagerTablespace(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_tablespace_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	defer mgr.Drop(ctx, schema, name)
+
+	// pg_default exists everywhere; tables in the database default have
+	// no tablespace of their own
+	if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{Tablespace: defaultTablespace}); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	if got, err := mgr.GetTablespace(ctx, schema, name); err != nil || got != "" {
+		t.Errorf("GetTablespace() = %q, %v, want the database default", got, err)
+	}
+
+	// another tablespace needs a directory on the server, so it is only
+	// tested when one is provided
+	tablespace := os.Getenv("PGQ_TEST_TABLESPACE")
+	if tablespace == "" {
+		t.Skip("PGQ_TEST_TABLESPACE not set")
+	}
+
+	if err := mgr.SetTablespace(ctx, schema, name, tablespace); err != nil {
+		t.Fatalf("SetTablespace() error = %v", err)
+	}
+	if got, err := mgr.GetTablespace(ctx, schema, name); err != nil || got != tablespace {
+		t.Errorf("GetTablespace() = %q, %v, want %q", got, err, tablespace)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT ci.relname
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		LEFT JOIN pg_tablespace ts ON ts.oid = ci.reltablespace
+		WHERE x.indrelid = $1::regclass AND ts.spcname IS DISTINCT FROM $2
+	`, fqn.Sanitize(), tablespace)
+	if err != nil {
+		t.Fatalf("reading index tablespaces: %v", err)
+	}
+	misplaced, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatalf("reading index tablespaces: %v", err)
+	}
+	if len(misplaced) > 0 {
+		t.Errorf("SetTablespace() left indexes %v outside %s", misplaced, tablespace)
+	}
+
+	if err := mgr.SetTablespace(ctx, schema, name, ""); err != nil {
+		t.Fatalf("SetTablespace(\"\") error = %v", err)
+	}
+	if got, err := mgr.GetTablespace(ctx, schema, name); err != nil || got != "" {
+		t.Errorf("GetTablespace() after reset = %q, %v, want the database default", got, err)
+	}
+}
+
+func TestManagerOrderingColumn(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := NewManager(pool)
+	ctx := context.Background()
+
+	schema := SchemaName("public")
+	name := QueueName(fmt.Sprintf("test_ordering_%d", os.Getpid()))
+	fqn := MakeFQN(schema, name)
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, &QueueOptions{OrderingColumn: true}); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	if ok, err := mgr.HasOrderingColumn(ctx, schema, name); err != nil || !ok {
+		t.Fatalf("HasOrderingColumn() = %v, %v, want true", ok, err)
+	}
+
+	insert := func() {
+		t.Helper()
+		if _, err := pool.Exec(ctx, "INSERT INTO "+fqn.Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+			t.Fatalf("insert error = %v", err)
+		}
+	}
+	seqs := func() []int64 {
+		t.Helper()
+		rows, err := pool.Query(ctx, "SELECT seq FROM "+fqn.Sanitize()+" ORDER BY created_at, seq")
+		if err != nil {
+			t.Fatalf("reading seq: %v", err)
+		}
+		got, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			t.Fatalf("reading seq: %v", err)
+		}
+		return got
+	}
+
+	for range 3 {
+		insert()
+	}
+	got := seqs()
+	if len(got) != 3 || !slices.IsSorted(got) || got[0] == got[1] || got[1] == got[2] {
+		t.Errorf("seq = %v, want strictly increasing", got)
+	}
+
+	indexes, err := mgr.GetCustomIndexes(ctx, schema, name)
+	if err != nil {
+		t.Fatalf("GetCustomIndexes() error = %v", err)
+	}
+	for _, idx := range indexes {
+		if idx.Name == name.String()+indexOrdering {
+			t.Errorf("GetCustomIndexes() lists the ordering index %s", idx.Name)
+		}
+	}
+
+	if err := mgr.SetOrderingColumn(ctx, schema, name, false); err != nil {
+		t.Fatalf("SetOrderingColumn(false) error = %v", err)
+	}
+	if ok, err := mgr.HasOrderingColumn(ctx, schema, name); err != nil || ok {
+		t.Errorf("HasOrderingColumn() after drop = %v, %v, want false", ok, err)
+	}
+
+	// adding the column back fills it for the existing rows
+	if err := mgr.SetOrderingColumn(ctx, schema, name, true); err != nil {
+		t.Fatalf("SetOrderingColumn(true) error = %v", err)
+	}
+	insert()
+	if got := seqs(); len(got) != 4 || got[3] <= got[2] {
+		t.Errorf("seq after re-adding = %v, want every row numbered and the new one last", got)
+	}
+}
@@ -0,0 +1,407 @@
+package pgq
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is a PostgreSQL interval. Months, days and microseconds are
+// kept apart like the server does, since months and days vary in length.
+type Interval struct {
+	Months       int32
+	Days         int32
+	Microseconds int64
+}
+
+const (
+	usPerSecond = 1_000_000
+	usPerMinute = 60 * usPerSecond
+	usPerHour   = 60 * usPerMinute
+	usPerDay    = 24 * usPerHour
+
+	daysPerMonth = 30
+)
+
+type (
+	intervalField int
+
+	intervalUnit struct {
+		field intervalField
+		// n is the unit in months, days or microseconds, by field
+		n int64
+	}
+)
+
+const (
+	fieldMonths intervalField = iota
+	fieldDays
+	fieldMicroseconds
+)
+
+// intervalUnits are the unit names PostgreSQL accepts in interval input
+var intervalUnits = map[string]intervalUnit{}
+
+func init() {
+	for names, u := range map[string]intervalUnit{
+		"millennium millennia millenniums":       {fieldMonths, 12000},
+		"century centuries":                      {fieldMonths, 1200},
+		"decade decades":                         {fieldMonths, 120},
+		"year years yr yrs y":                    {fieldMonths, 12},
+		"month months mon mons":                  {fieldMonths, 1},
+		"week weeks w":                           {fieldDays, 7},
+		"day days d":                             {fieldDays, 1},
+		"hour hours hr hrs h":                    {fieldMicroseconds, usPerHour},
+		"minute minutes min mins m":              {fieldMicroseconds, usPerMinute},
+		"second seconds sec secs s":              {fieldMicroseconds, usPerSecond},
+		"millisecond milliseconds msec msecs ms": {fieldMicroseconds, 1000},
+		"microsecond microseconds usec usecs us": {fieldMicroseconds, 1},
+	} {
+		for _, name := range strings.Fields(names) {
+			intervalUnits[name] = u
+		}
+	}
+}
+
+// ParseInterval parses PostgreSQL interval input like '1 day',
+// '1 hour 30 minutes', '2 weeks ago', '01:30:00' or the ISO 8601 'P1DT12H'
+func ParseInterval(s string) (Interval, error) {
+	in := strings.ToLower(strings.TrimSpace(s))
+	if in == "" {
+		return Interval{}, fmt.Errorf("invalid interval %q: empty", s)
+	}
+
+	var (
+		acc intervalAccumulator
+		err error
+	)
+	if in[0] == 'p' {
+		err = acc.parseISO(in[1:])
+	} else {
+		err = acc.parsePostgres(in)
+	}
+	if err != nil {
+		return Interval{}, fmt.Errorf("invalid interval %q: %w", s, err)
+	}
+
+	iv, err := acc.interval()
+	if err != nil {
+		return Interval{}, fmt.Errorf("invalid interval %q: %w", s, err)
+	}
+	return iv, nil
+}
+
+// intervalAccumulator sums interval fields while parsing, with room to
+// detect overflow before narrowing to Interval
+type intervalAccumulator struct {
+	months, days, micros float64
+	// seen rejects units given twice, like the server
+	seen map[string]bool
+}
+
+func (a *intervalAccumulator) once(unit string) error {
+	if a.seen == nil {
+		a.seen = make(map[string]bool)
+	}
+	if a.seen[unit] {
+		return fmt.Errorf("%s specified more than once", unit)
+	}
+	a.seen[unit] = true
+	return nil
+}
+
+// add adds quantity units, carrying fractions down like PostgreSQL:
+// fractional years become whole months, fractional months 30-day days and
+// fractional days microseconds
+func (a *intervalAccumulator) add(quantity float64, u intervalUnit) {
+	whole, frac := math.Modf(quantity)
+	n := float64(u.n)
+
+	switch u.field {
+	case fieldMonths:
+		a.months += whole * n
+		if u.n > 1 {
+			a.months += math.RoundToEven(frac * n)
+			return
+		}
+		a.addFractionalDays(frac * daysPerMonth)
+	case fieldDays:
+		a.days += whole * n
+		a.addFractionalDays(frac * n)
+	case fieldMicroseconds:
+		a.micros += math.RoundToEven(quantity * n)
+	}
+}
+
+func (a *intervalAccumulator) addFractionalDays(days float64) {
+	whole, frac := math.Modf(days)
+	a.days += whole
+	a.micros += math.RoundToEven(frac * usPerDay)
+}
+
+func (a *intervalAccumulator) negate() {
+	a.months, a.days, a.micros = -a.months, -a.days, -a.micros
+}
+
+func (a *intervalAccumulator) interval() (Interval, error) {
+	if math.Abs(a.months) > math.MaxInt32 || math.Abs(a.days) > math.MaxInt32 || math.Abs(a.micros) >= math.MaxInt64 {
+		return Interval{}, fmt.Errorf("out of range")
+	}
+	return Interval{Months: int32(a.months), Days: int32(a.days), Microseconds: int64(a.micros)}, nil
+}
+
+// parsePostgres parses the 'postgres' and 'postgres_verbose' input forms
+func (a *intervalAccumulator) parsePostgres(in string) error {
+	tokens := tokenizeInterval(strings.TrimPrefix(in, "@"))
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok == "ago":
+			if i != len(tokens)-1 {
+				return fmt.Errorf("\"ago\" must come last")
+			}
+			a.negate()
+
+		case strings.Contains(tok, ":"):
+			micros, err := parseIntervalTime(tok)
+			if err != nil {
+				return err
+			}
+			for _, unit := range []string{"hour", "minute", "second"} {
+				if err := a.once(unit); err != nil {
+					return err
+				}
+			}
+			a.micros += micros
+
+		case isIntervalNumber(tok):
+			quantity, err := strconv.ParseFloat(tok, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", tok)
+			}
+			unitName := "second"
+			if i+1 < len(tokens) && !isIntervalNumber(tokens[i+1]) && !strings.Contains(tokens[i+1], ":") && tokens[i+1] != "ago" {
+				i++
+				unitName = tokens[i]
+			}
+			u, ok := intervalUnits[unitName]
+			if !ok {
+				return fmt.Errorf("unknown unit %q", unitName)
+			}
+			if err := a.once(canonicalUnit(u)); err != nil {
+				return err
+			}
+			a.add(quantity, u)
+
+		default:
+			if _, ok := intervalUnits[tok]; ok {
+				return fmt.Errorf("unit %q without a quantity", tok)
+			}
+			return fmt.Errorf("unexpected %q", tok)
+		}
+	}
+
+	if len(a.seen) == 0 {
+		return fmt.Errorf("no quantity")
+	}
+	return nil
+}
+
+// parseISO parses ISO 8601 durations after their 'P', e.g. '1Y2M' or
+// 'T1H30M', with designators in order and fractions allowed
+func (a *intervalAccumulator) parseISO(in string) error {
+	if in == "" {
+		return fmt.Errorf("no quantity")
+	}
+
+	datePart, timePart, hasTime := strings.Cut(in, "t")
+	if hasTime && timePart == "" {
+		return fmt.Errorf("\"T\" without time")
+	}
+
+	parse := func(part, designators string, units map[byte]string) error {
+		order := 0
+		for part != "" {
+			end := strings.IndexFunc(part, func(r rune) bool { return r >= 'a' && r <= 'z' })
+			if end <= 0 {
+				return fmt.Errorf("invalid ISO 8601 duration")
+			}
+			quantity, err := strconv.ParseFloat(part[:end], 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", part[:end])
+			}
+			pos := strings.IndexByte(designators[order:], part[end])
+			if pos < 0 {
+				return fmt.Errorf("unexpected designator %q", strings.ToUpper(part[end:end+1]))
+			}
+			order += pos + 1
+			a.add(quantity, intervalUnits[units[part[end]]])
+			part = part[end+1:]
+		}
+		return nil
+	}
+
+	if err := parse(datePart, "ymwd", map[byte]string{'y': "year", 'm': "month", 'w': "week", 'd': "day"}); err != nil {
+		return err
+	}
+	return parse(timePart, "hms", map[byte]string{'h': "hour", 'm': "minute", 's': "second"})
+}
+
+// tokenizeInterval splits input into numbers, words and times, also where
+// a quantity and its unit aren't separated, as in '1day'
+func tokenizeInterval(in string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(strings.ReplaceAll(in, ",", " ")) {
+		for field != "" {
+			end := 1
+			switch c := field[0]; {
+			case c >= 'a' && c <= 'z':
+				for end < len(field) && field[end] >= 'a' && field[end] <= 'z' {
+					end++
+				}
+			default:
+				for end < len(field) && !(field[end] >= 'a' && field[end] <= 'z') && field[end] != '+' && field[end] != '-' {
+					end++
+				}
+			}
+			tokens = append(tokens, field[:end])
+			field = field[end:]
+		}
+	}
+	return tokens
+}
+
+func isIntervalNumber(tok string) bool {
+	t := strings.TrimLeft(tok, "+-")
+	if t == "" || strings.Contains(tok[1:], "+") || strings.Contains(tok[1:], "-") {
+		return false
+	}
+	dot := false
+	digits := false
+	for _, c := range t {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits
+}
+
+// parseIntervalTime parses '[-]h:mm[:ss[.ffffff]]' into microseconds
+func parseIntervalTime(tok string) (float64, error) {
+	sign := 1.0
+	t := tok
+	if t[0] == '-' || t[0] == '+' {
+		if t[0] == '-' {
+			sign = -1
+		}
+		t = t[1:]
+	}
+
+	parts := strings.Split(t, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", tok)
+	}
+
+	hours, err := strconv.ParseUint(parts[0], 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", tok)
+	}
+	minutes, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil || minutes > 59 {
+		return 0, fmt.Errorf("invalid time %q", tok)
+	}
+	var seconds float64
+	if len(parts) == 3 {
+		seconds, err = strconv.ParseFloat(parts[2], 64)
+		if err != nil || seconds < 0 || seconds >= 60 || strings.ContainsAny(parts[2], "+-eE") {
+			return 0, fmt.Errorf("invalid time %q", tok)
+		}
+	}
+
+	micros := float64(hours)*usPerHour + float64(minutes)*usPerMinute + math.RoundToEven(seconds*usPerSecond)
+	return sign * micros, nil
+}
+
+// canonicalUnit names the field a unit sets, for duplicate detection
+func canonicalUnit(u intervalUnit) string {
+	for _, name := range []string{"millennium", "century", "decade", "year", "month", "week", "day", "hour", "minute", "second", "millisecond", "microsecond"} {
+		if intervalUnits[name] == u {
+			return name
+		}
+	}
+	return ""
+}
+
+// String formats the interval like PostgreSQL does with the default
+// IntervalStyle, e.g. '1 year 2 mons 3 days 04:05:06'
+func (iv Interval) String() string {
+	var sb strings.Builder
+	empty := true
+	// prevNegative makes a positive field after a negative one explicit
+	prevNegative := false
+
+	part := func(value int64, unit string) {
+		if value == 0 {
+			return
+		}
+		if !empty {
+			sb.WriteString(" ")
+		}
+		if prevNegative && value > 0 {
+			sb.WriteString("+")
+		}
+		sb.WriteString(strconv.FormatInt(value, 10))
+		sb.WriteString(" ")
+		sb.WriteString(unit)
+		if value != 1 {
+			sb.WriteString("s")
+		}
+		prevNegative = value < 0
+		empty = false
+	}
+
+	part(int64(iv.Months/12), "year")
+	part(int64(iv.Months%12), "mon")
+	part(int64(iv.Days), "day")
+
+	if empty || iv.Microseconds != 0 {
+		if !empty {
+			sb.WriteString(" ")
+		}
+		micros := iv.Microseconds
+		switch {
+		case micros < 0:
+			sb.WriteString("-")
+			micros = -micros
+		case prevNegative:
+			sb.WriteString("+")
+		}
+
+		hours := micros / usPerHour
+		minutes := micros % usPerHour / usPerMinute
+		seconds := micros % usPerMinute / usPerSecond
+		fmt.Fprintf(&sb, "%02d:%02d:%02d", hours, minutes, seconds)
+		if frac := micros % usPerSecond; frac != 0 {
+			sb.WriteString(strings.TrimRight(fmt.Sprintf(".%06d", frac), "0"))
+		}
+	}
+
+	return sb.String()
+}
+
+// Approximate returns the length of the interval counting months as 30
+// days and days as 24 hours, like justify_interval, for comparing
+// intervals with different fields
+func (iv Interval) Approximate() time.Duration {
+	micros := (int64(iv.Months)*daysPerMonth+int64(iv.Days))*usPerDay + iv.Microseconds
+	return time.Duration(micros) * time.Microsecond
+}
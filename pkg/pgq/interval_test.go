@@ -0,0 +1,90 @@
+package pgq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1 day", "1 day"},
+		{"1 Day", "1 day"},
+		{"2 weeks", "14 days"},
+		{"1 week 2 days", "9 days"},
+		{"1 hour 30 minutes", "01:30:00"},
+		{"1 month", "1 mon"},
+		{"14 months", "1 year 2 mons"},
+		{"1.5 days", "1 day 12:00:00"},
+		{"1.5 months", "1 mon 15 days"},
+		{"1.5 years", "1 year 6 mons"},
+		{"1.5 s", "00:00:01.5"},
+		{"30", "00:00:30"},
+		{"0", "00:00:00"},
+		{"1d12h", "1 day 12:00:00"},
+		{"1 day ago", "-1 days"},
+		{"@ 3 days 4 hours ago", "-3 days -04:00:00"},
+		{"-1 day +1 hour", "-1 days +01:00:00"},
+		{"1 day -01:30", "1 day -01:30:00"},
+		{"36:00:00", "36:00:00"},
+		{"1 decade", "10 years"},
+		{"P1Y2M3DT4H5M6S", "1 year 2 mons 3 days 04:05:06"},
+		{"PT36H", "36:00:00"},
+		{"P1W", "7 days"},
+		{"P0.5D", "12:00:00"},
+	}
+	for _, tt := range tests {
+		iv, err := ParseInterval(tt.in)
+		if err != nil {
+			t.Errorf("ParseInterval(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := iv.String(); got != tt.want {
+			t.Errorf("ParseInterval(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"day",
+		"1 fortnight",
+		"1 day 2 days",
+		"1 hour 01:00",
+		"1:75",
+		"1 ago day",
+		"P",
+		"P1H",
+		"PT1D",
+		"P1D2Y",
+		"1e400 days",
+		"99999999999 months",
+	} {
+		if iv, err := ParseInterval(in); err == nil {
+			t.Errorf("ParseInterval(%q) = %q, want error", in, iv)
+		}
+	}
+}
+
+func TestIntervalApproximate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"1 day", 24 * time.Hour},
+		{"2 weeks", 14 * 24 * time.Hour},
+		{"1 mon", 30 * 24 * time.Hour},
+		{"1 year 1 day 01:30:00", (360*24+24+1)*time.Hour + 30*time.Minute},
+		{"-1 hour", -time.Hour},
+	}
+	for _, tt := range tests {
+		iv, err := ParseInterval(tt.in)
+		if err != nil {
+			t.Fatalf("ParseInterval(%q) error = %v", tt.in, err)
+		}
+		if got := iv.Approximate(); got != tt.want {
+			t.Errorf("ParseInterval(%q).Approximate() = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// legalHoldMarker starts the comment of a held partition, followed by the
+// queue it was detached from and its partition bound
+const legalHoldMarker = "pgq legal hold of "
+
+func legalHoldComment(fqn FQN, bound string) string {
+	return legalHoldMarker + fqn.String() + "; " + bound
+}
+
+// HoldPartition exempts a partition of a partitioned queue from retention
+// by detaching it and moving it to holdSchema, which is created if needed.
+// The partition's bound is kept in its comment for ReleasePartition.
+func (m *Manager) HoldPartition(ctx context.Context, schema SchemaName, name QueueName, partition string, holdSchema SchemaName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	child := MakeFQN(schema, QueueName(partition))
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var bound string
+	err = tx.QueryRow(ctx, `
+		SELECT pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_class c
+		JOIN pg_inherits i ON i.inhrelid = c.oid
+		WHERE c.oid = to_regclass($1) AND i.inhparent = $2::regclass
+	`, child.Sanitize(), fqn.Sanitize()).Scan(&bound)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return wrapErr("hold_partition", fqn, fmt.Errorf("partition %s not found", child))
+	}
+	if err != nil {
+		return wrapErr("hold_partition", fqn, err)
+	}
+
+	stmts := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + holdSchema.Sanitize(),
+		"ALTER TABLE " + fqn.Sanitize() + " DETACH PARTITION " + child.Sanitize(),
+		"COMMENT ON TABLE " + child.Sanitize() + " IS " + quoteLiteral(legalHoldComment(fqn, bound)),
+		"ALTER TABLE " + child.Sanitize() + " SET SCHEMA " + holdSchema.Sanitize(),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("hold_partition", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// ReleasePartition moves a partition held by HoldPartition back to the
+// queue and reattaches it with its original bound, after which retention
+// applies to it again
+func (m *Manager) ReleasePartition(ctx context.Context, schema SchemaName, name QueueName, partition string, holdSchema SchemaName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	held := MakeFQN(holdSchema, QueueName(partition))
+	child := MakeFQN(schema, QueueName(partition))
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var comment *string
+	err = tx.QueryRow(ctx, `SELECT obj_description(to_regclass($1), 'pg_class')`, held.Sanitize()).Scan(&comment)
+	if err != nil {
+		return wrapErr("release_partition", fqn, err)
+	}
+
+	prefix := legalHoldMarker + fqn.String() + "; "
+	if comment == nil || !strings.HasPrefix(*comment, prefix) {
+		return wrapErr("release_partition", fqn, fmt.Errorf("%s is not a held partition of the queue", held))
+	}
+	bound := strings.TrimPrefix(*comment, prefix)
+
+	stmts := []string{
+		"ALTER TABLE " + held.Sanitize() + " SET SCHEMA " + schema.Sanitize(),
+		"COMMENT ON TABLE " + child.Sanitize() + " IS NULL",
+		"ALTER TABLE " + fqn.Sanitize() + " ATTACH PARTITION " + child.Sanitize() + " " + bound,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("release_partition", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// HeldPartitions returns the names of the queue's partitions held in
+// holdSchema, sorted
+func (m *Manager) HeldPartitions(ctx context.Context, schema SchemaName, name QueueName, holdSchema SchemaName) ([]string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relkind = 'r'
+		  AND starts_with(obj_description(c.oid, 'pg_class'), $2)
+		ORDER BY c.relname
+	`, holdSchema, legalHoldMarker+fqn.String()+"; ")
+	if err != nil {
+		return nil, wrapErr("held_partitions", fqn, err)
+	}
+
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrapErr("held_partitions", fqn, err)
+	}
+
+	return partitions, nil
+}
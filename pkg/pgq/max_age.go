@@ -0,0 +1,109 @@
+package pgq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxAgePrefix  = "pgq_max_age_"
+	maxAgeTrigger = "pgq_max_age"
+)
+
+func maxAgeFunctionFQN(schema SchemaName, name QueueName) FQN {
+	return FQN(fmt.Sprintf("%s.%s%s", schema, maxAgePrefix, name))
+}
+
+// SetMaxMessageAge installs a BEFORE INSERT trigger rejecting messages whose
+// created_at or scheduled_for is older than maxAge (an interval like
+// '1 day'), so they can't land in partitions already past retention.
+// An empty maxAge removes the trigger. Partitioned queues need
+// PostgreSQL 13 or later.
+func (m *Manager) SetMaxMessageAge(ctx context.Context, schema SchemaName, name QueueName, maxAge string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	fn := maxAgeFunctionFQN(schema, name)
+	trigger := pgx.Identifier{maxAgeTrigger}.Sanitize()
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, "DROP TRIGGER IF EXISTS "+trigger+" ON "+fqn.Sanitize()); err != nil {
+		return wrapErr("drop_max_age_trigger", fqn, err)
+	}
+
+	if maxAge == "" {
+		if _, err := tx.Exec(ctx, "DROP FUNCTION IF EXISTS "+fn.Sanitize()+"()"); err != nil {
+			return wrapErr("drop_max_age_function", fqn, err)
+		}
+	} else {
+		if _, err := tx.Exec(ctx, `SELECT $1::interval`, maxAge); err != nil {
+			return wrapErr("set_max_age", fqn, err)
+		}
+
+		body := `CREATE OR REPLACE FUNCTION ` + fn.Sanitize() + `() RETURNS trigger LANGUAGE plpgsql AS $pgq$
+BEGIN
+	IF NEW.created_at < now() - TG_ARGV[0]::interval
+	   OR NEW.scheduled_for < now() - TG_ARGV[0]::interval THEN
+		RAISE EXCEPTION 'pgq: message older than % rejected by %.%', TG_ARGV[0], TG_TABLE_SCHEMA, TG_TABLE_NAME
+			USING ERRCODE = 'check_violation';
+	END IF;
+	RETURN NEW;
+END
+$pgq$`
+		if _, err := tx.Exec(ctx, body); err != nil {
+			return wrapErr("create_max_age_function", fqn, err)
+		}
+
+		if _, err := tx.Exec(ctx, "CREATE TRIGGER "+trigger+" BEFORE INSERT ON "+fqn.Sanitize()+
+			" FOR EACH ROW EXECUTE FUNCTION "+fn.Sanitize()+"("+quoteLiteral(maxAge)+")"); err != nil {
+			return wrapErr("create_max_age_trigger", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// GetMaxMessageAge returns the interval enforced by the max age trigger,
+// or an empty string when the queue has none
+func (m *Manager) GetMaxMessageAge(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var args []byte
+	err := m.pool.QueryRow(ctx, `
+		SELECT tgargs FROM pg_trigger
+		WHERE tgrelid = $1::regclass AND tgname = $2
+	`, fqn.Sanitize(), maxAgeTrigger).Scan(&args)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_max_age", fqn, err)
+	}
+
+	// tgargs holds each argument NUL terminated
+	maxAge, _, _ := bytes.Cut(args, []byte{0})
+	return string(maxAge), nil
+}
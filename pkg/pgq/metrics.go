@@ -0,0 +1,177 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MetricView is a view exposing one per-queue metric in the
+// postgres_exporter custom query format: schema and queue label columns
+// followed by a single value column
+type MetricView struct {
+	Name        string
+	Column      string
+	Description string
+	// expr is the value expression evaluated against a queue table
+	expr string
+	// sqlType keeps the column type stable across CREATE OR REPLACE VIEW
+	sqlType string
+}
+
+// MetricViews are the views created by CreateMetricViews
+var MetricViews = []MetricView{
+	{
+		Name:        "pgq_backlog",
+		Column:      "messages",
+		Description: "Unprocessed messages in the queue",
+		expr:        "count(*) FILTER (WHERE processed_at IS NULL)",
+		sqlType:     "bigint",
+	},
+	{
+		Name:        "pgq_oldest_age_seconds",
+		Column:      "age",
+		Description: "Age in seconds of the oldest unprocessed message",
+		expr:        "coalesce(extract(epoch FROM now() - min(created_at) FILTER (WHERE processed_at IS NULL)), 0)",
+		sqlType:     "double precision",
+	},
+	{
+		Name:        "pgq_failed_total",
+		Column:      "messages",
+		Description: "Messages with an error_detail",
+		expr:        "count(*) FILTER (WHERE error_detail IS NOT NULL)",
+		sqlType:     "bigint",
+	},
+}
+
+// metricFunction is the function the metric views read the queues
+// through. Querying each queue with dynamic SQL keeps the views free of
+// dependencies on the queue tables, so dropping a queue neither fails nor
+// cascades to the views; a queue that no longer exists is left out.
+const metricFunction = "pgq_metric_values"
+
+// CreateMetricViews creates or replaces the metric views in schema, covering
+// the given queues. Calling it again with a different queue set keeps the
+// view columns unchanged, so exporter configuration stays valid.
+func (m *Manager) CreateMetricViews(ctx context.Context, schema SchemaName, queues []FQN) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fn := MakeFQN(schema, metricFunction)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, metricFunctionSQL(fn)); err != nil {
+		return wrapErr("create_metric_function", fn, err)
+	}
+
+	for _, v := range MetricViews {
+		if _, err := tx.Exec(ctx, metricViewSQL(schema, v, queues)); err != nil {
+			return wrapErr("create_metric_view", MakeFQN(schema, QueueName(v.Name)), err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fn, err)
+	}
+
+	return nil
+}
+
+// MetricViewsExist reports whether all metric views exist in schema
+func (m *Manager) MetricViewsExist(ctx context.Context, schema SchemaName) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	names := make([]string, len(MetricViews))
+	for i, v := range MetricViews {
+		names[i] = v.Name
+	}
+
+	var count int
+	err := m.pool.QueryRow(ctx, `
+		SELECT count(*) FROM pg_views
+		WHERE schemaname = $1 AND viewname = ANY($2)
+	`, schema, names).Scan(&count)
+	if err != nil {
+		return false, wrapErr("check_metric_views", MakeFQN(schema, metricFunction), err)
+	}
+
+	return count == len(MetricViews), nil
+}
+
+// DropMetricViews removes the metric views, and the function they read the
+// queues through, from schema
+func (m *Manager) DropMetricViews(ctx context.Context, schema SchemaName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	for _, v := range MetricViews {
+		view := MakeFQN(schema, QueueName(v.Name))
+		if _, err := m.pool.Exec(ctx, "DROP VIEW IF EXISTS "+view.Sanitize()); err != nil {
+			return wrapErr("drop_metric_view", view, err)
+		}
+	}
+
+	fn := MakeFQN(schema, metricFunction)
+	if _, err := m.pool.Exec(ctx, "DROP FUNCTION IF EXISTS "+fn.Sanitize()+"(text[], text[], text)"); err != nil {
+		return wrapErr("drop_metric_function", fn, err)
+	}
+	return nil
+}
+
+// ExporterQueries returns a postgres_exporter queries.yaml document reading
+// the metric views in schema
+func ExporterQueries(schema SchemaName) string {
+	var out strings.Builder
+	for _, v := range MetricViews {
+		fmt.Fprintf(&out, "%s:\n", v.Name)
+		fmt.Fprintf(&out, "  query: \"SELECT schema, queue, %s FROM %s\"\n", v.Column,
+			strings.ReplaceAll(MakeFQN(schema, QueueName(v.Name)).Sanitize(), `"`, `\"`))
+		out.WriteString("  metrics:\n")
+		out.WriteString("    - schema:\n        usage: \"LABEL\"\n        description: \"Queue schema\"\n")
+		out.WriteString("    - queue:\n        usage: \"LABEL\"\n        description: \"Queue name\"\n")
+		fmt.Fprintf(&out, "    - %s:\n        usage: \"GAUGE\"\n        description: %q\n", v.Column, v.Description)
+	}
+	return out.String()
+}
+
+func metricFunctionSQL(fn FQN) string {
+	return `CREATE OR REPLACE FUNCTION ` + fn.Sanitize() + `(p_schemas text[], p_queues text[], p_expr text)
+RETURNS TABLE (schema text, queue text, value numeric)
+LANGUAGE plpgsql STABLE AS $fn$
+BEGIN
+	FOR schema, queue IN SELECT * FROM unnest(p_schemas, p_queues) LOOP
+		CONTINUE WHEN to_regclass(format('%I.%I', schema, queue)) IS NULL;
+		EXECUTE format('SELECT (%s)::numeric FROM %I.%I', p_expr, schema, queue) INTO value;
+		RETURN NEXT;
+	END LOOP;
+END
+$fn$`
+}
+
+func metricViewSQL(schema SchemaName, v MetricView, queues []FQN) string {
+	schemas := make([]string, len(queues))
+	names := make([]string, len(queues))
+	for i, fqn := range queues {
+		qs, qn, _ := fqn.Split()
+		schemas[i] = quoteLiteral(qs.String())
+		names[i] = quoteLiteral(qn.String())
+	}
+
+	return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\nSELECT schema, queue, value::%s AS %s\nFROM %s(ARRAY[%s]::text[], ARRAY[%s]::text[], %s)",
+		MakeFQN(schema, QueueName(v.Name)).Sanitize(), v.sqlType, v.Column, MakeFQN(schema, metricFunction).Sanitize(),
+		strings.Join(schemas, ", "), strings.Join(names, ", "), quoteLiteral(v.expr))
+}
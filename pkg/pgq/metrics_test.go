@@ -0,0 +1,43 @@
+package pgq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricViewSQL(t *testing.T) {
+	v := MetricViews[0]
+
+	sql := metricViewSQL("monitoring", v, []FQN{"public.orders", "billing.invoices"})
+
+	for _, want := range []string{
+		`CREATE OR REPLACE VIEW "monitoring"."pgq_backlog" AS`,
+		`SELECT schema, queue, value::bigint AS messages`,
+		`FROM "monitoring"."pgq_metric_values"(ARRAY['public', 'billing']::text[], ARRAY['orders', 'invoices']::text[], 'count(*) FILTER (WHERE processed_at IS NULL)')`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("metricViewSQL() missing %q in:\n%s", want, sql)
+		}
+	}
+	if strings.Contains(sql, `"public"."orders"`) {
+		t.Errorf("metricViewSQL() should not reference queue tables:\n%s", sql)
+	}
+
+	empty := metricViewSQL("monitoring", v, nil)
+	if !strings.Contains(empty, "(ARRAY[]::text[], ARRAY[]::text[], ") {
+		t.Errorf("metricViewSQL() without queues = %s", empty)
+	}
+}
+
+func TestExporterQueries(t *testing.T) {
+	yaml := ExporterQueries("monitoring")
+
+	for _, v := range MetricViews {
+		if !strings.Contains(yaml, v.Name+":\n") {
+			t.Errorf("ExporterQueries() missing query %s", v.Name)
+		}
+	}
+	if !strings.Contains(yaml, `FROM \"monitoring\".\"pgq_backlog\""`) {
+		t.Errorf("ExporterQueries() has unexpected query:\n%s", yaml)
+	}
+}
@@ -0,0 +1,432 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// nativeOrigin aligns native partitions of fixed length; it is a Monday,
+// so weekly partitions start on Mondays
+var nativeOrigin = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+// nativeRange returns the start of the partition containing a time and
+// the start of the partition following one, for partitions of interval.
+// Partitions are either whole months long, aligned to years, or of a fixed
+// length, aligned to nativeOrigin.
+func nativeRange(interval string, loc *time.Location) (floor, next func(time.Time) time.Time, err error) {
+	iv, err := ParseInterval(interval)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case iv.Months > 0 && iv.Days == 0 && iv.Microseconds == 0:
+		months := int(iv.Months)
+		floor = func(t time.Time) time.Time {
+			t = t.In(loc)
+			n := (t.Year()-2000)*12 + int(t.Month()) - 1
+			n -= ((n % months) + months) % months
+			return time.Date(2000, time.Month(n+1), 1, 0, 0, 0, 0, loc)
+		}
+		next = func(t time.Time) time.Time { return t.AddDate(0, months, 0) }
+
+	case iv.Months == 0 && iv.Days >= 0 && iv.Microseconds >= 0 && (iv.Days > 0 || iv.Microseconds > 0):
+		d := time.Duration(iv.Days)*24*time.Hour + time.Duration(iv.Microseconds)*time.Microsecond
+		origin := time.Date(nativeOrigin.Year(), nativeOrigin.Month(), nativeOrigin.Day(), 0, 0, 0, 0, loc)
+		floor = func(t time.Time) time.Time {
+			start := origin.Add(t.Sub(origin) / d * d)
+			if start.After(t) {
+				start = start.Add(-d)
+			}
+			return start
+		}
+		next = func(t time.Time) time.Time { return t.Add(d) }
+
+	default:
+		return nil, nil, fmt.Errorf("native partitioning needs an interval of whole months or of a fixed length, not %q", interval)
+	}
+
+	return floor, next, nil
+}
+
+// MaintainNativePartitions creates the current and premade partitions of
+// a natively partitioned queue (see PartitionConfig.Native) and applies
+// retention, which pg_partman does for other partitioned queues. It
+// should run at least once per partition interval.
+func (m *Manager) MaintainNativePartitions(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	return m.retryTx(ctx, fqn, "commit_maintenance", func(tx pgx.Tx) error {
+		return m.maintainNative(ctx, tx, schema, name, cfg, false)
+	})
+}
+
+// PendingNativeMaintenance lists what MaintainNativePartitions would do
+// to a natively partitioned queue now, as 'create <partition>' and
+// 'retire <partition>' entries, without changing anything. It is empty
+// when the partitions are up to date.
+func (m *Manager) PendingNativeMaintenance(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig) ([]string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	tx, err := m.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	partitions, err := nativePartitions(ctx, tx, schema, name, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]FQN, len(partitions))
+	for i, p := range partitions {
+		children[i] = p.child(schema, name)
+	}
+	if cfg.DefaultPartition && partitionStrategy(cfg) != PartitionHash {
+		children = append(children, MakeFQN(schema, QueueName(name.String()+"_default")))
+	}
+
+	var pending []string
+	for _, child := range children {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, child.Sanitize()).Scan(&exists); err != nil {
+			return nil, wrapErr("check_partition", fqn, err)
+		}
+		if !exists {
+			pending = append(pending, "create "+child.String())
+		}
+	}
+
+	if partitionStrategy(cfg) == PartitionRange && cfg.Retention != "" {
+		expired, err := expiredPartitions(ctx, tx, fqn, cfg.Retention)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range expired {
+			pending = append(pending, "retire "+MakeFQN(schema, QueueName(p)).String())
+		}
+	}
+
+	return pending, nil
+}
+
+// nativePartition is a partition of a natively partitioned queue, named
+// <queue>_p<suffix>
+type nativePartition struct {
+	suffix string
+	// bounds is the FOR VALUES clause
+	bounds string
+}
+
+func (p nativePartition) child(schema SchemaName, name QueueName) FQN {
+	return MakeFQN(schema, QueueName(name.String()+"_p"+p.suffix))
+}
+
+// nativePartitions returns the partitions maintenance keeps for a natively
+// partitioned queue
+func nativePartitions(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig, created bool) ([]nativePartition, error) {
+	switch partitionStrategy(cfg) {
+	case PartitionHash:
+		return nativeHashPartitions(schema, name, cfg)
+	case PartitionList:
+		return nativeListPartitions(ctx, tx, schema, name, cfg, created)
+	default:
+		return nativeRangePartitions(ctx, tx, schema, name, cfg, created)
+	}
+}
+
+// maintainNative creates the partitions of a natively partitioned queue,
+// with the queue's replica identity, and, for range partitioning, drops,
+// detaches or archives those past retention
+func (m *Manager) maintainNative(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig, created bool) error {
+	fqn := MakeFQN(schema, name)
+
+	partitions, err := nativePartitions(ctx, tx, schema, name, cfg, created)
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		if err := createNativePartition(ctx, tx, schema, name, p.suffix, p.bounds); err != nil {
+			return err
+		}
+	}
+
+	// hash partitioned tables can't have a default partition
+	if cfg.DefaultPartition && partitionStrategy(cfg) != PartitionHash {
+		child := MakeFQN(schema, QueueName(name.String()+"_default"))
+		if _, err := tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+child.Sanitize()+" PARTITION OF "+fqn.Sanitize()+" DEFAULT"); err != nil {
+			return wrapErr("create_default_partition", fqn, err)
+		}
+	}
+
+	if err := syncReplicaIdentity(ctx, tx, schema, name); err != nil {
+		return err
+	}
+
+	if partitionStrategy(cfg) != PartitionRange || cfg.Retention == "" {
+		return nil
+	}
+	return m.nativeRetention(ctx, tx, schema, fqn, cfg)
+}
+
+// nativeRangePartitions returns the partitions from the current one through
+// premake, or from cfg.StartPartition when the queue is created
+func nativeRangePartitions(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig, created bool) ([]nativePartition, error) {
+	fqn := MakeFQN(schema, name)
+
+	loc := time.UTC
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+	}
+
+	floor, next, err := nativeRange(cfg.Interval, loc)
+	if err != nil {
+		return nil, wrapErr("maintain_partitions", fqn, err)
+	}
+
+	var now, from time.Time
+	if err := tx.QueryRow(ctx, `SELECT now()`).Scan(&now); err != nil {
+		return nil, wrapErr("maintain_partitions", fqn, err)
+	}
+	from = floor(now)
+	if created && cfg.StartPartition != "" {
+		var start time.Time
+		err := tx.QueryRow(ctx, `SELECT $1::timestamp AT TIME ZONE $2`, cfg.StartPartition, loc.String()).Scan(&start)
+		if err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+		if start.Before(from) {
+			from = floor(start)
+		}
+	}
+
+	var partitions []nativePartition
+	until := floor(now)
+	for i := 0; i <= cfg.Premake; i++ {
+		until = next(until)
+	}
+
+	for start := from; start.Before(until); start = next(start) {
+		var suffix string
+		err := tx.QueryRow(ctx, `SELECT to_char($1::timestamptz AT TIME ZONE $2, $3)`,
+			start, loc.String(), cfg.DatetimeString).Scan(&suffix)
+		if err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+
+		partitions = append(partitions, nativePartition{
+			suffix: suffix,
+			bounds: fmt.Sprintf("FROM (%s) TO (%s)", quoteLiteral(nativeBound(start)), quoteLiteral(nativeBound(next(start)))),
+		})
+	}
+
+	return partitions, nil
+}
+
+// nativeListPartitions returns one partition per key value, from the
+// highest value outside the default partition (or cfg.StartPartition when
+// the queue is created) through premake values above it. Values with rows
+// in the default partition are skipped, as a partition can't be created
+// for them until the rows are moved.
+func nativeListPartitions(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig, created bool) ([]nativePartition, error) {
+	fqn := MakeFQN(schema, name)
+	def := MakeFQN(schema, QueueName(name.String()+"_default"))
+
+	var top int64
+	err := tx.QueryRow(ctx, fmt.Sprintf("SELECT coalesce(max(%s), 0)::bigint FROM %s WHERE tableoid::regclass IS DISTINCT FROM to_regclass($1)",
+		pgx.Identifier{partitionKey(cfg)}.Sanitize(), fqn.Sanitize()), def.Sanitize()).Scan(&top)
+	if err != nil {
+		return nil, wrapErr("maintain_partitions", fqn, err)
+	}
+
+	from := top
+	if created && cfg.StartPartition != "" {
+		if err := tx.QueryRow(ctx, `SELECT least($1::bigint, $2)`, cfg.StartPartition, top).Scan(&from); err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+	}
+
+	var hasDefault bool
+	if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, def.Sanitize()).Scan(&hasDefault); err != nil {
+		return nil, wrapErr("maintain_partitions", fqn, err)
+	}
+
+	var stuck []int64
+	if hasDefault {
+		rows, err := tx.Query(ctx, fmt.Sprintf("SELECT DISTINCT %s::bigint FROM %s",
+			pgx.Identifier{partitionKey(cfg)}.Sanitize(), def.Sanitize()))
+		if err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+		if stuck, err = pgx.CollectRows(rows, pgx.RowTo[int64]); err != nil {
+			return nil, wrapErr("maintain_partitions", fqn, err)
+		}
+	}
+
+	var partitions []nativePartition
+	for v := from; v <= top+int64(cfg.Premake); v++ {
+		if slices.Contains(stuck, v) {
+			continue
+		}
+		partitions = append(partitions, nativePartition{suffix: strconv.FormatInt(v, 10), bounds: fmt.Sprintf("IN (%d)", v)})
+	}
+
+	return partitions, nil
+}
+
+// nativeHashPartitions returns the cfg.HashPartitions partitions
+func nativeHashPartitions(schema SchemaName, name QueueName, cfg *PartitionConfig) ([]nativePartition, error) {
+	if cfg.HashPartitions < 1 {
+		return nil, wrapErr("maintain_partitions", MakeFQN(schema, name), fmt.Errorf("hash partitioning needs at least one partition"))
+	}
+
+	partitions := make([]nativePartition, cfg.HashPartitions)
+	for i := range partitions {
+		partitions[i] = nativePartition{suffix: strconv.Itoa(i), bounds: fmt.Sprintf("WITH (MODULUS %d, REMAINDER %d)", cfg.HashPartitions, i)}
+	}
+
+	return partitions, nil
+}
+
+// createNativePartition creates the partition <name>_p<suffix> of a queue
+// for values bounds, unless it exists
+func createNativePartition(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, suffix, bounds string) error {
+	fqn := MakeFQN(schema, name)
+	child := nativePartition{suffix: suffix}.child(schema, name)
+
+	_, err := tx.Exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES %s",
+		child.Sanitize(), fqn.Sanitize(), bounds))
+	if err != nil {
+		return wrapErr("create_partition", fqn, fmt.Errorf("%s: %w", child, err))
+	}
+	return nil
+}
+
+// nativeBound formats a partition bound
+func nativeBound(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05-07:00")
+}
+
+// nativeRetention drops, detaches or archives the partitions of fqn
+// ending before retention
+func (m *Manager) nativeRetention(ctx context.Context, tx pgx.Tx, schema SchemaName, fqn FQN, cfg *PartitionConfig) error {
+	expired, err := expiredPartitions(ctx, tx, fqn, cfg.Retention)
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range expired {
+		child := MakeFQN(schema, QueueName(partition))
+
+		if !cfg.RetentionKeepTable && cfg.RetentionSchema == "" {
+			if _, err := tx.Exec(ctx, "DROP TABLE "+child.Sanitize()); err != nil {
+				return wrapErr("drop_partition", fqn, err)
+			}
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, "ALTER TABLE "+fqn.Sanitize()+" DETACH PARTITION "+child.Sanitize()); err != nil {
+			return wrapErr("detach_partition", fqn, err)
+		}
+
+		if cfg.RetentionDropIndexes {
+			if err := dropTableIndexes(ctx, tx, child); err != nil {
+				return wrapErr("drop_partition_indexes", fqn, err)
+			}
+		}
+
+		if cfg.RetentionSchema != "" {
+			archive := SchemaName(cfg.RetentionSchema)
+			for _, stmt := range []string{
+				"CREATE SCHEMA IF NOT EXISTS " + archive.Sanitize(),
+				"ALTER TABLE " + child.Sanitize() + " SET SCHEMA " + archive.Sanitize(),
+			} {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return wrapErr("archive_partition", fqn, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// expiredPartitions returns the range partitions of fqn whose upper bound
+// is older than retention, oldest first
+func expiredPartitions(ctx context.Context, tx pgx.Tx, fqn FQN, retention string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT relname FROM (
+			SELECT c.relname,
+			       (regexp_match(pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']+)''\)'))[1]::timestamptz AS upper
+			FROM pg_inherits i
+			JOIN pg_class c ON c.oid = i.inhrelid
+			WHERE i.inhparent = $1::regclass
+		) p
+		WHERE upper <= now() - $2::interval
+		ORDER BY upper
+	`, fqn.Sanitize(), retention)
+	if err != nil {
+		return nil, wrapErr("get_expired_partitions", fqn, err)
+	}
+	expired, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrapErr("get_expired_partitions", fqn, err)
+	}
+	return expired, nil
+}
+
+// dropTableIndexes drops the indexes of a table, with the constraints
+// they back
+func dropTableIndexes(ctx context.Context, tx pgx.Tx, table FQN) error {
+	rows, err := tx.Query(ctx, `
+		SELECT format('%I.%I', n.nspname, ci.relname), coalesce(con.conname, '')
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		LEFT JOIN pg_constraint con ON con.conindid = x.indexrelid AND con.conrelid = x.indrelid
+		WHERE x.indrelid = $1::regclass
+	`, table.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	var stmts []string
+	var index, constraint string
+	_, err = pgx.ForEachRow(rows, []any{&index, &constraint}, func() error {
+		if constraint != "" {
+			stmts = append(stmts, "ALTER TABLE "+table.Sanitize()+" DROP CONSTRAINT "+pgx.Identifier{constraint}.Sanitize())
+		} else {
+			stmts = append(stmts, "DROP INDEX "+index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package pgq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNativeRange(t *testing.T) {
+	at := time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	tests := []struct {
+		interval  string
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{"1 day", time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"1 hour", time.Date(2024, 3, 14, 15, 0, 0, 0, time.UTC), time.Date(2024, 3, 14, 16, 0, 0, 0, time.UTC)},
+		// weekly partitions start on Mondays
+		{"1 week", time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{"1 month", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"3 months", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"1 year", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			floor, next, err := nativeRange(tt.interval, time.UTC)
+			if err != nil {
+				t.Fatalf("nativeRange() error = %v", err)
+			}
+			start := floor(at)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("floor() = %v, want %v", start, tt.wantStart)
+			}
+			if got := next(start); !got.Equal(tt.wantNext) {
+				t.Errorf("next() = %v, want %v", got, tt.wantNext)
+			}
+		})
+	}
+
+	before := time.Date(1999, 11, 20, 0, 0, 0, 0, time.UTC)
+	floor, _, _ := nativeRange("1 day", time.UTC)
+	if got, want := floor(before), time.Date(1999, 11, 20, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("floor() before the origin = %v, want %v", got, want)
+	}
+	floor, _, _ = nativeRange("3 months", time.UTC)
+	if got, want := floor(before), time.Date(1999, 10, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("floor() before the origin = %v, want %v", got, want)
+	}
+
+	for _, interval := range []string{"1 month 2 days", "-1 day", "0", "soon"} {
+		if _, _, err := nativeRange(interval, time.UTC); err == nil {
+			t.Errorf("nativeRange(%q) should fail", interval)
+		}
+	}
+}
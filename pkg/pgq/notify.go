@@ -0,0 +1,114 @@
+package pgq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	notifyPrefix  = "pgq_notify_"
+	notifyTrigger = "pgq_notify"
+)
+
+func notifyFunctionFQN(schema SchemaName, name QueueName) FQN {
+	return FQN(fmt.Sprintf("%s.%s%s", schema, notifyPrefix, name))
+}
+
+// SetNotifyChannel installs an AFTER INSERT trigger on the queue, and its
+// template table, sending the id of every new message to channel with
+// pg_notify, so consumers can LISTEN instead of polling. An empty channel
+// removes the trigger.
+func (m *Manager) SetNotifyChannel(ctx context.Context, schema SchemaName, name QueueName, channel string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+	fn := notifyFunctionFQN(schema, name)
+	trigger := pgx.Identifier{notifyTrigger}.Sanitize()
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var template bool
+	if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, q.TemplateFQN().Sanitize()).Scan(&template); err != nil {
+		return wrapErr("check_template", fqn, err)
+	}
+	tables := []FQN{fqn}
+	if template {
+		tables = append(tables, q.TemplateFQN())
+	}
+
+	for _, table := range tables {
+		if _, err := tx.Exec(ctx, "DROP TRIGGER IF EXISTS "+trigger+" ON "+table.Sanitize()); err != nil {
+			return wrapErr("drop_notify_trigger", fqn, err)
+		}
+	}
+
+	if channel == "" {
+		if _, err := tx.Exec(ctx, "DROP FUNCTION IF EXISTS "+fn.Sanitize()+"()"); err != nil {
+			return wrapErr("drop_notify_function", fqn, err)
+		}
+	} else {
+		body := `CREATE OR REPLACE FUNCTION ` + fn.Sanitize() + `() RETURNS trigger LANGUAGE plpgsql AS $pgq$
+BEGIN
+	PERFORM pg_notify(TG_ARGV[0], NEW.id::text);
+	RETURN NULL;
+END
+$pgq$`
+		if _, err := tx.Exec(ctx, body); err != nil {
+			return wrapErr("create_notify_function", fqn, err)
+		}
+
+		for _, table := range tables {
+			if _, err := tx.Exec(ctx, "CREATE TRIGGER "+trigger+" AFTER INSERT ON "+table.Sanitize()+
+				" FOR EACH ROW EXECUTE FUNCTION "+fn.Sanitize()+"("+quoteLiteral(channel)+")"); err != nil {
+				return wrapErr("create_notify_trigger", fqn, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// GetNotifyChannel returns the channel of the queue's notify trigger, or
+// an empty string when the queue has none
+func (m *Manager) GetNotifyChannel(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var args []byte
+	err := m.pool.QueryRow(ctx, `
+		SELECT tgargs FROM pg_trigger
+		WHERE tgrelid = $1::regclass AND tgname = $2
+	`, fqn.Sanitize(), notifyTrigger).Scan(&args)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_notify_channel", fqn, err)
+	}
+
+	// tgargs holds each argument NUL terminated
+	channel, _, _ := bytes.Cut(args, []byte{0})
+	return string(channel), nil
+}
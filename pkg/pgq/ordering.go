@@ -0,0 +1,83 @@
+package pgq
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// orderingColumn is the sequence-backed column added by OrderingColumn
+const orderingColumn = "seq"
+
+// HasOrderingColumn reports whether the queue has the ordering column
+func (m *Manager) HasOrderingColumn(ctx context.Context, schema SchemaName, name QueueName) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var exists bool
+	err := m.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = $1 AND table_name = $2 AND column_name = $3
+		)
+	`, schema, name, orderingColumn).Scan(&exists)
+
+	if err != nil {
+		return false, wrapErr("check_ordering_column", fqn, err)
+	}
+
+	return exists, nil
+}
+
+// SetOrderingColumn adds or drops the ordering column and its index on an
+// existing queue (and its template table, if any). Adding the column fills
+// it for every existing row, which rewrites the table.
+func (m *Manager) SetOrderingColumn(ctx context.Context, schema SchemaName, name QueueName, enabled bool) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+	column := pgx.Identifier{orderingColumn}.Sanitize()
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var stmts []string
+	if enabled {
+		stmts = []string{
+			"ALTER TABLE " + fqn.Sanitize() + " ADD COLUMN IF NOT EXISTS " + column + " BIGSERIAL NOT NULL",
+			"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " ADD COLUMN IF NOT EXISTS " + column + " BIGINT",
+			"CREATE INDEX IF NOT EXISTS " + pgx.Identifier{name.String() + indexOrdering}.Sanitize() +
+				" ON " + fqn.Sanitize() + " (" + column + ")",
+		}
+	} else {
+		// dropping the column also drops its index and owned sequence
+		stmts = []string{
+			"ALTER TABLE " + fqn.Sanitize() + " DROP COLUMN IF EXISTS " + column,
+			"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " DROP COLUMN IF EXISTS " + column,
+		}
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_ordering_column", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetOwner returns the role owning the queue table
+func (m *Manager) GetOwner(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var owner string
+	err := m.pool.QueryRow(ctx, `
+		SELECT pg_get_userbyid(c.relowner)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, name).Scan(&owner)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", &QueueNotFoundError{Queue: fqn}
+	}
+	if err != nil {
+		return "", wrapErr("get_owner", fqn, err)
+	}
+
+	return owner, nil
+}
+
+// SetOwner makes role the owner of the queue table, its template table and
+// every existing partition. Indexes and the sequence of the ordering
+// column follow their table. The current user must be a member of role,
+// and own the tables or be a superuser.
+func (m *Manager) SetOwner(ctx context.Context, schema SchemaName, name QueueName, role string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	q := &Queue{Schema: schema, Name: name}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ALTER TABLE ... OWNER TO doesn't recurse into partitions
+	rows, err := tx.Query(ctx, `
+		SELECT format('%I.%I', n.nspname, c.relname)
+		FROM pg_partition_tree($1::regclass) t
+		JOIN pg_class c ON c.oid = t.relid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		UNION ALL
+		SELECT to_regclass($2::text)::text WHERE to_regclass($2::text) IS NOT NULL
+	`, fqn.Sanitize(), q.TemplateFQN().Sanitize())
+	if err != nil {
+		return wrapErr("get_owner_targets", fqn, err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return wrapErr("get_owner_targets", fqn, err)
+	}
+
+	for _, table := range tables {
+		if _, err := tx.Exec(ctx, "ALTER TABLE "+table+" OWNER TO "+pgx.Identifier{role}.Sanitize()); err != nil {
+			return wrapErr("set_owner", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,647 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	undoPartitionBatchSize = 20
+
+	// defaultPartmanSchema is where pg_partman's documentation installs it,
+	// assumed when the extension isn't installed yet
+	defaultPartmanSchema SchemaName = "partman"
+)
+
+// partmanSchema returns the schema of the pg_partman extension: the
+// configured one, else the one it is installed in, else 'partman'
+func (m *Manager) partmanSchema(ctx context.Context) (SchemaName, error) {
+	if m.opts.PartmanSchema != "" {
+		return m.opts.PartmanSchema, nil
+	}
+
+	m.partmanMu.Lock()
+	defer m.partmanMu.Unlock()
+	if m.partman != "" {
+		return m.partman, nil
+	}
+
+	var schema string
+	err := m.pool.QueryRow(ctx, `
+		SELECT n.nspname FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'pg_partman'
+	`).Scan(&schema)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultPartmanSchema, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to detect pg_partman schema: %w", err)
+	}
+
+	m.partman = SchemaName(schema)
+	return m.partman, nil
+}
+
+// partmanSQL qualifies the pg_partman objects of a query, written with a
+// {{partman}} placeholder for their schema, with schema
+func partmanSQL(schema SchemaName, sql string) string {
+	return strings.ReplaceAll(sql, "{{partman}}", schema.Sanitize())
+}
+
+// BackfillOptions controls how PartitionDefaultData moves rows
+type BackfillOptions struct {
+	// BatchInterval is the created_at range moved per batch (e.g. '1 hour').
+	// Empty uses the partition interval.
+	BatchInterval string
+	// Wait is the pause between batches, rounded up to whole seconds
+	Wait time.Duration
+	// MaxRuntime stops the backfill after this long; zero means no limit
+	MaxRuntime time.Duration
+}
+
+// Partitioning strategies, see PartitionConfig.Strategy
+const (
+	PartitionRange = "range"
+	PartitionList  = "list"
+	PartitionHash  = "hash"
+)
+
+type PartitionConfig struct {
+	// Strategy assigns rows to partitions by ranges of Key (PartitionRange,
+	// the default when empty), by single values of an integer Key
+	// (PartitionList) or by a hash of Key (PartitionHash, native only).
+	// Interval, Retention and DatetimeString apply to range partitioning.
+	Strategy string
+	// Key is the partition key column, created_at when empty
+	Key string
+	// HashPartitions is the number of partitions with PartitionHash
+	HashPartitions int
+
+	Interval           string
+	Premake            int
+	Retention          string
+	DatetimeString     string
+	OptimizeConstraint int
+	DefaultPartition   bool
+	// Timezone is the session timezone used while pg_partman computes the
+	// initial partition boundaries and names; empty uses the server's
+	Timezone string
+	// StartPartition is the timestamp (e.g. "2023-01-01") of the first
+	// partition created, for queues backfilled with older messages; empty
+	// starts at the current partition less premake
+	StartPartition string
+	// RetentionKeepTable detaches partitions past retention instead of
+	// dropping them
+	RetentionKeepTable bool
+	// RetentionDropIndexes drops the indexes of the partitions kept by
+	// RetentionKeepTable, which keep them by default
+	RetentionDropIndexes bool
+	// RetentionSchema moves partitions past retention to this schema,
+	// which is created if needed, instead of dropping or detaching them
+	// in place
+	RetentionSchema string
+	// InfiniteTimePartitions keeps premaking partitions while no new rows
+	// arrive, instead of stopping premake partitions past the newest row
+	InfiniteTimePartitions bool
+	// Jobmon logs maintenance runs to pg_jobmon; nil uses it if the
+	// extension is installed
+	Jobmon *bool
+	// ConstraintCols are columns pg_partman adds CHECK constraints for on
+	// partitions older than OptimizeConstraint, so queries filtering on
+	// them skip those partitions
+	ConstraintCols []string
+	// SubPartition partitions each partition again, nil for none
+	SubPartition *SubPartitionConfig
+	// Native partitions the queue without pg_partman: partitions are
+	// created and expire in MaintainNativePartitions, with the settings
+	// above that have a declarative equivalent. The others are ignored.
+	Native bool
+	// InheritPrivileges grants the privileges of the parent table on new
+	// partitions
+	InheritPrivileges bool
+
+	// Read-only part_config settings, populated by GetPartitionConfig
+	AutomaticMaintenance string
+}
+
+// partitionStrategy returns the strategy of cfg, PartitionRange if unset
+func partitionStrategy(cfg *PartitionConfig) string {
+	if cfg.Strategy == "" {
+		return PartitionRange
+	}
+	return cfg.Strategy
+}
+
+// partitionKey returns the partition key column of cfg
+func partitionKey(cfg *PartitionConfig) string {
+	if cfg.Key == "" {
+		return "created_at"
+	}
+	return cfg.Key
+}
+
+// checkStrategy rejects partitioning pg_partman can't maintain before any
+// table is created
+func checkStrategy(fqn FQN, cfg *PartitionConfig) error {
+	if partitionStrategy(cfg) == PartitionHash && !cfg.Native {
+		return wrapPartmanErr("create_parent", fqn, errors.New("pg_partman doesn't support hash partitioning, use native partitioning"))
+	}
+	return nil
+}
+
+// partmanInterval and partmanRetention return the interval and retention
+// of cfg in pg_partman's terms: list partitions hold one value each and
+// don't expire
+func partmanInterval(cfg *PartitionConfig) string {
+	if partitionStrategy(cfg) == PartitionList {
+		return "1"
+	}
+	return cfg.Interval
+}
+
+func partmanRetention(cfg *PartitionConfig) string {
+	if partitionStrategy(cfg) != PartitionRange {
+		return ""
+	}
+	return cfg.Retention
+}
+
+// constraintCols returns the constraint_cols of cfg, NULL (not an empty
+// array) when there are none
+func constraintCols(cfg *PartitionConfig) []string {
+	if len(cfg.ConstraintCols) == 0 {
+		return nil
+	}
+	return cfg.ConstraintCols
+}
+
+// createRetentionSchema creates the archive schema of cfg, if any
+func createRetentionSchema(ctx context.Context, tx pgx.Tx, fqn FQN, cfg *PartitionConfig) error {
+	if cfg.RetentionSchema == "" {
+		return nil
+	}
+	_, err := tx.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+SchemaName(cfg.RetentionSchema).Sanitize())
+	return wrapPartmanErr("create_retention_schema", fqn, err)
+}
+
+// CreatePartitioned creates a queue partitioned according to cfg, with
+// pg_partman or, when cfg.Native is set, by the provider. opts may be nil.
+func (m *Manager) CreatePartitioned(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig, opts *QueueOptions) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if opts == nil {
+		opts = &QueueOptions{}
+	}
+
+	if err := checkStrategy(fqn, cfg); err != nil {
+		return err
+	}
+
+	exists, err := m.Exists(ctx, schema, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &QueueExistsError{Queue: fqn}
+	}
+
+	if err := m.installExtensions(ctx, fqn, !cfg.Native); err != nil {
+		return err
+	}
+
+	err = m.retryTx(ctx, fqn, "commit_ddl", func(tx pgx.Tx) error {
+		if err := execHooks(ctx, tx, fqn, "before_create", opts.BeforeCreateSQL); err != nil {
+			return err
+		}
+
+		if err := m.createTable(ctx, tx, schema, name, cfg, opts); err != nil {
+			return err
+		}
+
+		if err := m.createIndexes(ctx, tx, schema, name, opts); err != nil {
+			return err
+		}
+
+		if cfg.Native {
+			if err := m.maintainNative(ctx, tx, schema, name, cfg, true); err != nil {
+				return err
+			}
+			return execHooks(ctx, tx, fqn, "after_create", opts.AfterCreateSQL)
+		}
+
+		return m.createTemplate(ctx, tx, schema, name, opts)
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.Native {
+		return nil
+	}
+
+	// after_create hooks run in the partman transaction so they see the
+	// fully provisioned queue, including its initial partitions
+	if err := m.setupPartman(ctx, schema, name, cfg, opts.AfterCreateSQL); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) createTemplate(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, opts *QueueOptions) error {
+	fqn := MakeFQN(schema, name)
+	templateName := name.String() + "_template"
+
+	// the indexes copied by LIKE are created in default_tablespace
+	if opts.Tablespace != "" {
+		if _, err := tx.Exec(ctx, `SELECT set_config('default_tablespace', $1, true)`, opts.Tablespace); err != nil {
+			return wrapErr("set_tablespace", fqn, err)
+		}
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE TABLE IF NOT EXISTS ")
+	sql.WriteString(schema.Sanitize())
+	sql.WriteString(".")
+	sql.WriteString(pgx.Identifier{templateName}.Sanitize())
+	sql.WriteString(" (LIKE ")
+	sql.WriteString(schema.Sanitize())
+	sql.WriteString(".")
+	sql.WriteString(name.Sanitize())
+	sql.WriteString(" INCLUDING ALL)")
+	sql.WriteString(accessMethodClause(opts.AccessMethod))
+	sql.WriteString(tablespaceClause(opts.Tablespace))
+
+	if _, err := tx.Exec(ctx, sql.String()); err != nil {
+		return wrapErr("create_template", fqn, err)
+	}
+
+	return nil
+}
+
+func (m *Manager) setupPartman(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig, afterSQL []string) error {
+	fqn := MakeFQN(schema, name)
+
+	return m.partmanTx(ctx, fqn, func(tx pgx.Tx) error {
+		if err := m.createParent(ctx, tx, schema, name, cfg); err != nil {
+			return err
+		}
+
+		return execHooks(ctx, tx, fqn, "after_create", afterSQL)
+	})
+}
+
+func (m *Manager) createParent(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig) error {
+	fqn := MakeFQN(schema, name)
+	parentTable := fqn.String()
+	templateTable := fmt.Sprintf("%s.%s_template", schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return wrapPartmanErr("create_parent", fqn, err)
+	}
+
+	if cfg.Timezone != "" {
+		if _, err := tx.Exec(ctx, `SELECT set_config('TimeZone', $1, true)`, cfg.Timezone); err != nil {
+			return wrapPartmanErr("set_timezone", fqn, err)
+		}
+	}
+
+	jobmon := cfg.Jobmon
+	if jobmon == nil {
+		var installed bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_jobmon')`).Scan(&installed)
+		if err != nil {
+			return wrapPartmanErr("check_jobmon", fqn, err)
+		}
+		jobmon = &installed
+	}
+
+	_, err = tx.Exec(ctx, partmanSQL(pm, `
+		SELECT {{partman}}.create_parent(
+			p_parent_table          := $1,
+			p_control               := $2,
+			p_interval              := $3,
+			p_type                  := $4,
+			p_premake               := $5,
+			p_start_partition       := NULLIF($6, ''),
+			p_default_table         := $7,
+			p_automatic_maintenance := $8,
+			p_constraint_cols       := $9,
+			p_template_table        := $10,
+			p_jobmon                := $11
+		)
+	`), parentTable, partitionKey(cfg), partmanInterval(cfg), partitionStrategy(cfg), cfg.Premake,
+		cfg.StartPartition, cfg.DefaultPartition, "on", constraintCols(cfg), templateTable, *jobmon)
+
+	if err != nil {
+		return wrapPartmanErr("create_parent", fqn, err)
+	}
+
+	if err := createRetentionSchema(ctx, tx, fqn, cfg); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, partmanSQL(pm, `
+		UPDATE {{partman}}.part_config
+		SET retention = NULLIF($2, ''),
+		    retention_keep_index = NOT $7,
+		    retention_keep_table = $5,
+		    retention_schema = NULLIF($6, ''),
+		    datetime_string = $3,
+		    optimize_constraint = $4,
+		    infinite_time_partitions = $8,
+		    inherit_privileges = $9,
+		    ignore_default_data = TRUE
+		WHERE parent_table = $1
+	`), parentTable, partmanRetention(cfg), cfg.DatetimeString, cfg.OptimizeConstraint,
+		cfg.RetentionKeepTable, cfg.RetentionSchema, cfg.RetentionDropIndexes,
+		cfg.InfiniteTimePartitions, cfg.InheritPrivileges)
+
+	if err != nil {
+		return wrapPartmanErr("update_config", fqn, err)
+	}
+
+	if cfg.SubPartition != nil {
+		return m.createSubParent(ctx, tx, pm, fqn, cfg, *jobmon)
+	}
+
+	return nil
+}
+
+func (m *Manager) GetPartitionConfig(ctx context.Context, schema SchemaName, name QueueName) (*PartitionConfig, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return nil, wrapPartmanErr("get_config", fqn, err)
+	}
+
+	var cfg PartitionConfig
+	err = m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, partmanSQL(pm, `
+			SELECT partition_interval::text, premake, coalesce(retention::text, ''),
+			       datetime_string, optimize_constraint,
+			       automatic_maintenance, infinite_time_partitions,
+			       retention_keep_table, NOT retention_keep_index,
+			       coalesce(retention_schema, ''), inherit_privileges,
+			       jobmon, constraint_cols
+			FROM {{partman}}.part_config
+			WHERE parent_table = $1
+		`), fqn.String()).Scan(
+			&cfg.Interval, &cfg.Premake, &cfg.Retention,
+			&cfg.DatetimeString, &cfg.OptimizeConstraint,
+			&cfg.AutomaticMaintenance, &cfg.InfiniteTimePartitions,
+			&cfg.RetentionKeepTable, &cfg.RetentionDropIndexes,
+			&cfg.RetentionSchema, &cfg.InheritPrivileges,
+			&cfg.Jobmon, &cfg.ConstraintCols,
+		)
+	})
+
+	if err != nil {
+		return nil, wrapPartmanErr("get_config", fqn, err)
+	}
+
+	// Check if default partition exists
+	var hasDefault bool
+	err = m.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_inherits i
+			JOIN pg_class parent ON i.inhparent = parent.oid
+			JOIN pg_class child ON i.inhrelid = child.oid
+			JOIN pg_namespace n ON parent.relnamespace = n.oid
+			WHERE n.nspname = $1
+			  AND parent.relname = $2
+			  AND child.relname LIKE '%_default'
+		)
+	`, schema, name).Scan(&hasDefault)
+
+	if err != nil {
+		return nil, wrapPartmanErr("check_default_partition", fqn, err)
+	}
+
+	cfg.DefaultPartition = hasDefault
+
+	cfg.SubPartition, err = m.getSubPartition(ctx, pm, fqn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// UpdatePartitionConfig changes the pg_partman settings of a queue; for
+// natively partitioned queues it applies cfg with MaintainNativePartitions
+func (m *Manager) UpdatePartitionConfig(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig) error {
+	if cfg.Native {
+		return m.MaintainNativePartitions(ctx, schema, name, cfg)
+	}
+
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return wrapPartmanErr("update_config", fqn, err)
+	}
+
+	return m.partmanTx(ctx, fqn, func(tx pgx.Tx) error {
+		if err := createRetentionSchema(ctx, tx, fqn, cfg); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, partmanSQL(pm, `
+			UPDATE {{partman}}.part_config
+			SET partition_interval = $2, premake = $3, retention = NULLIF($4, ''),
+			    datetime_string = $5, optimize_constraint = $6,
+			    retention_keep_table = $7, retention_keep_index = NOT $9,
+			    retention_schema = NULLIF($8, ''),
+			    infinite_time_partitions = $10,
+			    jobmon = coalesce($11, jobmon),
+			    constraint_cols = $12, inherit_privileges = $13
+			WHERE parent_table = $1
+		`), fqn.String(), partmanInterval(cfg), cfg.Premake, partmanRetention(cfg),
+			cfg.DatetimeString, cfg.OptimizeConstraint,
+			cfg.RetentionKeepTable, cfg.RetentionSchema, cfg.RetentionDropIndexes,
+			cfg.InfiniteTimePartitions, cfg.Jobmon, constraintCols(cfg),
+			cfg.InheritPrivileges)
+
+		return wrapPartmanErr("update_config", fqn, err)
+	})
+}
+
+// ReapplyPrivileges grants the privileges of a partitioned queue on all
+// its existing partitions, which InheritPrivileges only does for new ones
+func (m *Manager) ReapplyPrivileges(ctx context.Context, schema SchemaName, name QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return wrapPartmanErr("reapply_privileges", fqn, err)
+	}
+
+	return m.partmanTx(ctx, fqn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, partmanSQL(pm, `SELECT {{partman}}.reapply_privileges($1)`), fqn.String())
+		return wrapPartmanErr("reapply_privileges", fqn, err)
+	})
+}
+
+func (m *Manager) RemovePartmanConfig(ctx context.Context, schema SchemaName, name QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return wrapPartmanErr("undo_partition", fqn, err)
+	}
+
+	return m.partmanTx(ctx, fqn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, partmanSQL(pm, `SELECT {{partman}}.undo_partition($1, $2, p_keep_table := false)`), fqn.String(), undoPartitionBatchSize)
+		return wrapPartmanErr("undo_partition", fqn, err)
+	})
+}
+
+// PremakePartitions creates partitions covering now through until, beyond
+// the configured premake, without changing the queue's partman config.
+// It reports whether any new partition was created.
+func (m *Manager) PremakePartitions(ctx context.Context, schema SchemaName, name QueueName, until time.Time) (bool, error) {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return false, wrapPartmanErr("create_partition_time", fqn, err)
+	}
+
+	var created bool
+	err = m.partmanTx(ctx, fqn, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, partmanSQL(pm, `
+			SELECT {{partman}}.create_partition_time(
+				p_parent_table    := $1,
+				p_partition_times := ARRAY(
+					SELECT generate_series(now(), $2::timestamptz, pc.partition_interval::interval)
+					FROM {{partman}}.part_config pc
+					WHERE pc.parent_table = $1
+				)
+			)
+		`), fqn.String(), until).Scan(&created)
+
+		return wrapPartmanErr("create_partition_time", fqn, err)
+	})
+
+	return created, err
+}
+
+// PartitionDefaultData moves rows from the default partition into their
+// proper child partitions with partman.partition_data_proc, which commits
+// each batch, creating partitions as needed. It returns the number of rows
+// moved, which is also reported when the run stops early because of
+// MaxRuntime.
+func (m *Manager) PartitionDefaultData(ctx context.Context, schema SchemaName, name QueueName, opts *BackfillOptions) (int64, error) {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if opts == nil {
+		opts = &BackfillOptions{}
+	}
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return 0, wrapPartmanErr("partition_data_proc", fqn, err)
+	}
+
+	child, err := m.defaultPartition(ctx, fqn)
+	if err != nil {
+		return 0, err
+	}
+	if child == "" {
+		return 0, nil
+	}
+
+	countRows := func() (int64, error) {
+		var n int64
+		err := m.pool.QueryRow(ctx, "SELECT count(*) FROM "+child).Scan(&n)
+		return n, wrapErr("count_default_rows", fqn, err)
+	}
+
+	before, err := countRows()
+	if err != nil || before == 0 {
+		return 0, err
+	}
+
+	interval := "NULL"
+	if opts.BatchInterval != "" {
+		interval = quoteLiteral(opts.BatchInterval)
+	}
+	// p_wait is in whole seconds, round up so a configured pause is kept
+	wait := int((opts.Wait + time.Second - 1) / time.Second)
+
+	runCtx := ctx
+	if opts.MaxRuntime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.MaxRuntime)
+		defer cancel()
+	}
+
+	// a procedure that commits can't take bind parameters through the
+	// extended protocol, so the call is built from literals and Exec sends it
+	// with the simple protocol. Notices stay on so the server notices a
+	// connection closed by MaxRuntime at the next batch.
+	_, runErr := m.pool.Exec(runCtx, fmt.Sprintf(partmanSQL(pm, `
+		CALL {{partman}}.partition_data_proc(
+			p_parent_table := %s,
+			p_interval     := %s,
+			p_wait         := %d
+		)
+	`), quoteLiteral(fqn.String()), interval, wait))
+
+	// batches committed before MaxRuntime stopped the run stay moved
+	if runErr != nil && (ctx.Err() != nil || runCtx.Err() == nil) {
+		return 0, wrapPartmanErr("partition_data_proc", fqn, runErr)
+	}
+
+	after, err := countRows()
+	if err != nil {
+		return 0, err
+	}
+
+	return max(before-after, 0), nil
+}
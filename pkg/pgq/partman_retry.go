@@ -0,0 +1,91 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// partmanLockTimeout turns a wait on part_config or a parent table held
+	// by a maintenance run into an error that can be retried
+	partmanLockTimeout     = "5s"
+	partmanRetryFirstWait  = time.Second
+	partmanRetryMaxBackoff = 30 * time.Second
+)
+
+// partmanBusyCodes are the SQLSTATEs of pg_partman calls colliding with a
+// maintenance run or a restarting background worker
+var partmanBusyCodes = map[string]bool{
+	"55P03": true, // lock_not_available
+	"40P01": true, // deadlock_detected
+	"40001": true, // serialization_failure
+	"57P01": true, // admin_shutdown
+}
+
+// isPartmanBusy reports whether err is worth retrying once maintenance is over
+func isPartmanBusy(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	if partmanBusyCodes[pgErr.Code] {
+		return true
+	}
+	// pg_partman raises a plain exception when its advisory lock is taken
+	return strings.Contains(strings.ToLower(pgErr.Message), "unable to obtain lock")
+}
+
+// partmanTx runs fn in a transaction, retrying it while pg_partman is busy
+// for up to ManagerOptions.PartmanRetryWindow. With a window set, lock waits
+// are capped by partmanLockTimeout so a maintenance run holding locks fails
+// the attempt instead of stalling it.
+func (m *Manager) partmanTx(ctx context.Context, fqn FQN, fn func(tx pgx.Tx) error) error {
+	window := m.opts.PartmanRetryWindow
+	deadline := time.Now().Add(window)
+	wait := partmanRetryFirstWait
+
+	for {
+		err := m.partmanAttempt(ctx, fqn, window > 0, fn)
+		if err == nil || window <= 0 || !isPartmanBusy(err) || time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, partmanRetryMaxBackoff)
+	}
+}
+
+func (m *Manager) partmanAttempt(ctx context.Context, fqn FQN, lockTimeout bool, fn func(tx pgx.Tx) error) error {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapPartmanErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if lockTimeout {
+		if _, err := tx.Exec(ctx, `SELECT set_config('lock_timeout', $1, true)`, partmanLockTimeout); err != nil {
+			return wrapPartmanErr("set_lock_timeout", fqn, err)
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapPartmanErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package pgq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsPartmanBusy(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"lock timeout", &pgconn.PgError{Code: "55P03"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"wrapped", wrapPartmanErr("create_parent", "public.q", &pgconn.PgError{Code: "55P03"}), true},
+		{"advisory lock", &pgconn.PgError{Code: "P0001", Message: "Unable to obtain lock on parent table"}, true},
+		{"other exception", &pgconn.PgError{Code: "P0001", Message: "Given parent table not found"}, false},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, false},
+		{"not a server error", fmt.Errorf("dial: %w", errors.New("connection refused")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPartmanBusy(tt.err); got != tt.want {
+				t.Errorf("isPartmanBusy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
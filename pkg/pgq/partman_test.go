@@ -0,0 +1,59 @@
+package pgq
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPartmanSQL(t *testing.T) {
+	tests := []struct {
+		schema SchemaName
+		sql    string
+		want   string
+	}{
+		{"partman", "SELECT {{partman}}.create_parent($1)", `SELECT "partman".create_parent($1)`},
+		{"pgpartman", "SELECT 1 FROM {{partman}}.part_config WHERE parent_table = $1", `SELECT 1 FROM "pgpartman".part_config WHERE parent_table = $1`},
+		{"pgpartman", "SELECT to_regclass('{{partman}}.part_config')", `SELECT to_regclass('"pgpartman".part_config')`},
+		{"pgpartman", "SELECT p_parent_table FROM x", "SELECT p_parent_table FROM x"},
+		// only the placeholder is replaced, not identifiers that happen to
+		// end in "partman"
+		{"pgpartman", "SELECT 1 FROM app_partman.jobs, {{partman}}.part_config", `SELECT 1 FROM app_partman.jobs, "pgpartman".part_config`},
+	}
+
+	for _, tt := range tests {
+		if got := partmanSQL(tt.schema, tt.sql); got != tt.want {
+			t.Errorf("partmanSQL(%q, %q) = %q, want %q", tt.schema, tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestPartitionStrategy(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           PartitionConfig
+		wantKey       []string
+		wantInterval  string
+		wantRetention string
+	}{
+		{"range", PartitionConfig{Interval: "1 day", Retention: "14 days"}, []string{"id", "created_at"}, "1 day", "14 days"},
+		{"list", PartitionConfig{Strategy: PartitionList, Key: "tenant_id", Interval: "1 day", Retention: "14 days"},
+			[]string{"id", "tenant_id"}, "1", ""},
+		{"hash", PartitionConfig{Strategy: PartitionHash, Key: "tenant_id", Retention: "14 days"}, []string{"id", "tenant_id"}, "", ""},
+		{"sub_partition", PartitionConfig{SubPartition: &SubPartitionConfig{Column: "shard"}}, []string{"id", "created_at", "shard"}, "", ""},
+		{"sub_partition on the key", PartitionConfig{SubPartition: &SubPartitionConfig{Column: "created_at"}}, []string{"id", "created_at"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultPrimaryKey(&tt.cfg); !slices.Equal(got, tt.wantKey) {
+				t.Errorf("DefaultPrimaryKey() = %v, want %v", got, tt.wantKey)
+			}
+			if got := partmanInterval(&tt.cfg); got != tt.wantInterval {
+				t.Errorf("partmanInterval() = %q, want %q", got, tt.wantInterval)
+			}
+			if got := partmanRetention(&tt.cfg); got != tt.wantRetention {
+				t.Errorf("partmanRetention() = %q, want %q", got, tt.wantRetention)
+			}
+		})
+	}
+}
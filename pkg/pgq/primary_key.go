@@ -0,0 +1,145 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultPrimaryKey returns the primary key of a queue partitioned with
+// cfg (nil for simple queues): id and the partition key columns, which
+// every primary key must contain
+func DefaultPrimaryKey(cfg *PartitionConfig) []string {
+	if cfg == nil {
+		return []string{"id"}
+	}
+	cols := []string{"id", partitionKey(cfg)}
+	if cfg.SubPartition != nil && !slices.Contains(cols, cfg.SubPartition.Column) {
+		cols = append(cols, cfg.SubPartition.Column)
+	}
+	return cols
+}
+
+// primaryKey returns the primary key columns of a queue created with cfg
+// and opts
+func primaryKey(cfg *PartitionConfig, opts *QueueOptions) ([]string, error) {
+	required := DefaultPrimaryKey(cfg)
+	if len(opts.PrimaryKey) == 0 {
+		return required, nil
+	}
+	for _, col := range required {
+		if !slices.Contains(opts.PrimaryKey, col) {
+			return nil, fmt.Errorf("primary key (%s) must contain %s", strings.Join(opts.PrimaryKey, ", "), col)
+		}
+	}
+	return opts.PrimaryKey, nil
+}
+
+// GetPrimaryKey returns the primary key columns of a queue in order
+func (m *Manager) GetPrimaryKey(ctx context.Context, schema SchemaName, name QueueName) ([]string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var cols []string
+	err := m.retry(ctx, func() error {
+		rows, err := m.pool.Query(ctx, `
+			SELECT a.attname
+			FROM pg_constraint con
+			CROSS JOIN LATERAL unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			WHERE con.conrelid = $1::regclass AND con.contype = 'p'
+			ORDER BY k.ord
+		`, fqn.Sanitize())
+		if err != nil {
+			return err
+		}
+		cols, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr("get_primary_key", fqn, err)
+	}
+
+	return cols, nil
+}
+
+// SetPrimaryKey replaces the primary key of a queue, and of its template
+// table if any, with one on columns, keeping its tablespace. Rebuilding
+// the key locks the queue until its index is built on every partition.
+func (m *Manager) SetPrimaryKey(ctx context.Context, schema SchemaName, name QueueName, columns []string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if len(columns) == 0 {
+		return wrapErr("set_primary_key", fqn, fmt.Errorf("no primary key columns"))
+	}
+
+	key := make([]string, len(columns))
+	for i, col := range columns {
+		key[i] = pgx.Identifier{col}.Sanitize()
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	q := &Queue{Schema: schema, Name: name}
+	for _, table := range []FQN{fqn, q.TemplateFQN()} {
+		var constraint, tablespace string
+		var exists bool
+		err := tx.QueryRow(ctx, `
+			SELECT to_regclass($1) IS NOT NULL,
+			       coalesce((SELECT con.conname FROM pg_constraint con
+			                 WHERE con.conrelid = to_regclass($1) AND con.contype = 'p'), ''),
+			       coalesce((SELECT ts.spcname FROM pg_constraint con
+			                 JOIN pg_class i ON i.oid = con.conindid
+			                 JOIN pg_tablespace ts ON ts.oid = i.reltablespace
+			                 WHERE con.conrelid = to_regclass($1) AND con.contype = 'p'), '')
+		`, table.Sanitize()).Scan(&exists, &constraint, &tablespace)
+		if err != nil {
+			return wrapErr("get_primary_key", fqn, err)
+		}
+		if !exists {
+			continue
+		}
+
+		var sql strings.Builder
+		sql.WriteString("ALTER TABLE ")
+		sql.WriteString(table.Sanitize())
+		if constraint != "" {
+			sql.WriteString(" DROP CONSTRAINT ")
+			sql.WriteString(pgx.Identifier{constraint}.Sanitize())
+			sql.WriteString(",")
+		}
+		sql.WriteString(" ADD PRIMARY KEY (")
+		sql.WriteString(strings.Join(key, ", "))
+		sql.WriteString(")")
+		if tablespace != "" {
+			sql.WriteString(" USING INDEX")
+			sql.WriteString(tablespaceClause(tablespace))
+		}
+
+		if _, err := tx.Exec(ctx, sql.String()); err != nil {
+			return wrapErr("set_primary_key", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package pgq
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrimaryKey(t *testing.T) {
+	partitioned := &PartitionConfig{Strategy: PartitionList, Key: "tenant_id"}
+
+	tests := []struct {
+		name    string
+		cfg     *PartitionConfig
+		key     []string
+		want    []string
+		wantErr bool
+	}{
+		{"simple default", nil, nil, []string{"id"}, false},
+		{"partitioned default", partitioned, nil, []string{"id", "tenant_id"}, false},
+		{"reordered", partitioned, []string{"tenant_id", "id"}, []string{"tenant_id", "id"}, false},
+		{"extended", nil, []string{"tenant_id", "id"}, []string{"tenant_id", "id"}, false},
+		{"missing partition key", partitioned, []string{"id"}, nil, true},
+		{"missing id", nil, []string{"tenant_id"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := primaryKey(tt.cfg, &QueueOptions{PrimaryKey: tt.key})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("primaryKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("primaryKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
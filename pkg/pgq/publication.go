@@ -0,0 +1,97 @@
+package pgq
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetPublications returns the publications the queue table was added to,
+// by name. Publications FOR ALL TABLES or FOR TABLES IN SCHEMA aren't
+// included.
+func (m *Manager) GetPublications(ctx context.Context, schema SchemaName, name QueueName) ([]string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, publicationsSQL, schema, name)
+	if err != nil {
+		return nil, wrapErr("get_publications", fqn, err)
+	}
+	pubs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrapErr("get_publications", fqn, err)
+	}
+
+	return pubs, nil
+}
+
+const publicationsSQL = `
+	SELECT p.pubname
+	FROM pg_publication_rel pr
+	JOIN pg_publication p ON p.oid = pr.prpubid
+	WHERE pr.prrelid = format('%I.%I', $1, $2)::regclass
+	ORDER BY p.pubname
+`
+
+// SetPublications removes the queue table from the publications in from
+// that aren't in to, and adds it to the ones in to. Adding a partitioned
+// queue adds its partitions, current and future; with viaRoot the
+// publications also publish their changes as changes of the queue, so
+// subscribers don't need the same partitions.
+func (m *Manager) SetPublications(ctx context.Context, schema SchemaName, name QueueName, from, to []string, viaRoot bool) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	rows, err := tx.Query(ctx, publicationsSQL, schema, name)
+	if err != nil {
+		return wrapErr("get_publications", fqn, err)
+	}
+	current, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return wrapErr("get_publications", fqn, err)
+	}
+
+	var stmts []string
+	for _, pub := range from {
+		if !slices.Contains(to, pub) && slices.Contains(current, pub) {
+			stmts = append(stmts, "ALTER PUBLICATION "+pgx.Identifier{pub}.Sanitize()+" DROP TABLE "+fqn.Sanitize())
+		}
+	}
+	for _, pub := range to {
+		if slices.Contains(current, pub) {
+			continue
+		}
+		stmts = append(stmts, "ALTER PUBLICATION "+pgx.Identifier{pub}.Sanitize()+" ADD TABLE "+fqn.Sanitize())
+		if viaRoot {
+			stmts = append(stmts, "ALTER PUBLICATION "+pgx.Identifier{pub}.Sanitize()+" SET (publish_via_partition_root = true)")
+		}
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_publications", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,464 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Manager struct {
+	pool *pgxpool.Pool
+	opts ManagerOptions
+
+	// partman caches the detected pg_partman schema
+	partmanMu sync.Mutex
+	partman   SchemaName
+
+	// ddlSlots limits concurrent DDL operations, nil when unlimited
+	ddlSlots chan struct{}
+
+	// extMu guards installing extensions for InstallPartman
+	extMu            sync.Mutex
+	pgcryptoChecked  bool
+	partmanInstalled bool
+}
+
+// ManagerOptions tunes how a Manager talks to the database
+type ManagerOptions struct {
+	// OperationTimeout bounds each Manager call, e.g. a Create with all its
+	// statements, via a context deadline; zero means no limit.
+	// PartitionDefaultData is bounded by its own MaxRuntime instead.
+	OperationTimeout time.Duration
+	// PartmanRetryWindow is how long pg_partman calls are retried while
+	// they collide with a maintenance run; zero fails on the first error
+	PartmanRetryWindow time.Duration
+	// PartmanSchema is the schema of the pg_partman extension; empty
+	// detects it from pg_extension
+	PartmanSchema SchemaName
+	// Retry is applied to catalog reads and to DDL transactions, which a
+	// deadlock or serialization failure rolls back as a whole
+	Retry RetryPolicy
+	// MaxConcurrentDDL is how many operations changing the schema may run
+	// at once; the others wait for a free slot. Zero means no limit.
+	MaxConcurrentDDL int
+	// InstallPartman creates the pg_partman extension, in PartmanSchema or
+	// 'partman', before the first partitioned queue, and pgcrypto before
+	// the first queue on PostgreSQL 12
+	InstallPartman bool
+	// ReconnectWindow is how long a retried call that lost its connection,
+	// e.g. to a server restart, waits for the server to accept connections
+	// again before repeating once, independent of Retry. Zero disables it.
+	ReconnectWindow time.Duration
+}
+
+func NewManager(pool *pgxpool.Pool) *Manager {
+	return NewManagerWithOptions(pool, nil)
+}
+
+// NewManagerWithOptions creates a Manager; opts may be nil
+func NewManagerWithOptions(pool *pgxpool.Pool, opts *ManagerOptions) *Manager {
+	m := &Manager{pool: pool}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.MaxConcurrentDDL > 0 {
+		m.ddlSlots = make(chan struct{}, m.opts.MaxConcurrentDDL)
+	}
+	return m
+}
+
+// withTimeout applies the operation timeout to ctx. Nested calls keep the
+// outermost deadline since a child context can't outlive its parent.
+func (m *Manager) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.opts.OperationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.opts.OperationTimeout)
+}
+
+// ddlSlotKey marks contexts of operations holding a DDL slot
+type ddlSlotKey struct{}
+
+// beginDDL waits for a DDL slot, if MaxConcurrentDDL is set, and then
+// applies the operation timeout, so time spent waiting doesn't count
+// against it. Nested calls share the slot of the outermost one.
+// The returned cancel releases the slot.
+func (m *Manager) beginDDL(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if m.ddlSlots == nil || ctx.Value(ddlSlotKey{}) != nil {
+		ctx, cancel := m.withTimeout(ctx)
+		return ctx, cancel, nil
+	}
+
+	select {
+	case m.ddlSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("waiting for one of %d DDL slots: %w", cap(m.ddlSlots), ctx.Err())
+	}
+
+	ctx, cancel := m.withTimeout(context.WithValue(ctx, ddlSlotKey{}, true))
+	return ctx, func() {
+		cancel()
+		<-m.ddlSlots
+	}, nil
+}
+
+// Create creates a queue table. A nil cfg creates a simple queue,
+// otherwise the queue is partitioned with pg_partman using cfg.
+// opts may be nil.
+func (m *Manager) Create(ctx context.Context, schema SchemaName, name QueueName, cfg *PartitionConfig, opts *QueueOptions) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if cfg == nil {
+		return m.CreateSimple(ctx, schema, name, opts)
+	}
+	return m.CreatePartitioned(ctx, schema, name, cfg, opts)
+}
+
+// CreateSimple creates a queue table without partitioning. opts may be
+// nil.
+func (m *Manager) CreateSimple(ctx context.Context, schema SchemaName, name QueueName, opts *QueueOptions) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if opts == nil {
+		opts = &QueueOptions{}
+	}
+
+	exists, err := m.Exists(ctx, schema, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &QueueExistsError{Queue: fqn}
+	}
+
+	if err := m.installExtensions(ctx, fqn, false); err != nil {
+		return err
+	}
+
+	return m.retryTx(ctx, fqn, "commit", func(tx pgx.Tx) error {
+		if err := execHooks(ctx, tx, fqn, "before_create", opts.BeforeCreateSQL); err != nil {
+			return err
+		}
+
+		if err := m.createTable(ctx, tx, schema, name, nil, opts); err != nil {
+			return err
+		}
+
+		if err := m.createIndexes(ctx, tx, schema, name, opts); err != nil {
+			return err
+		}
+
+		return execHooks(ctx, tx, fqn, "after_create", opts.AfterCreateSQL)
+	})
+}
+
+// createTable creates the queue table, partitioned as cfg says unless cfg
+// is nil
+func (m *Manager) createTable(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, cfg *PartitionConfig, opts *QueueOptions) error {
+	fqn := MakeFQN(schema, name)
+
+	id, err := idColumn(ctx, tx, opts.IDType, cfg != nil)
+	if err != nil {
+		return wrapErr("create_table", fqn, err)
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE TABLE IF NOT EXISTS ")
+	sql.WriteString(schema.Sanitize())
+	sql.WriteString(".")
+	sql.WriteString(name.Sanitize())
+	sql.WriteString(" (\n\t\t")
+	sql.WriteString(id)
+	sql.WriteString(`,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		started_at     TIMESTAMPTZ,
+		locked_until   TIMESTAMPTZ,
+		scheduled_for  TIMESTAMPTZ,
+		processed_at   TIMESTAMPTZ,
+		consumed_count INTEGER     NOT NULL DEFAULT 0,
+		error_detail   TEXT`)
+	sql.WriteString(collateClause(opts.TextCollation))
+	sql.WriteString(`,
+		payload        JSONB       NOT NULL,
+		metadata       JSONB       NOT NULL,
+		`)
+
+	if opts.OrderingColumn {
+		sql.WriteString(orderingColumn)
+		sql.WriteString(" BIGSERIAL NOT NULL,\n\t\t")
+	}
+
+	for _, c := range opts.ExtraColumns {
+		sql.WriteString(c.definition(opts.TextCollation))
+		sql.WriteString(",\n\t\t")
+	}
+
+	for _, c := range opts.CheckConstraints {
+		sql.WriteString(c.definition())
+		sql.WriteString(",\n\t\t")
+	}
+
+	key, err := primaryKey(cfg, opts)
+	if err != nil {
+		return wrapErr("create_table", fqn, err)
+	}
+	sql.WriteString("PRIMARY KEY (")
+	for i, col := range key {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(pgx.Identifier{col}.Sanitize())
+	}
+	sql.WriteString(")")
+	if opts.Tablespace != "" {
+		sql.WriteString(" USING INDEX")
+		sql.WriteString(tablespaceClause(opts.Tablespace))
+	}
+	sql.WriteString(")")
+	if cfg != nil {
+		sql.WriteString(" PARTITION BY ")
+		sql.WriteString(strings.ToUpper(partitionStrategy(cfg)))
+		sql.WriteString(" (")
+		sql.WriteString(pgx.Identifier{partitionKey(cfg)}.Sanitize())
+		sql.WriteString(")")
+	}
+	sql.WriteString(accessMethodClause(opts.AccessMethod))
+	sql.WriteString(tablespaceClause(opts.Tablespace))
+
+	if _, err := tx.Exec(ctx, sql.String()); err != nil {
+		return wrapErr("create_table", fqn, err)
+	}
+
+	return nil
+}
+
+func (m *Manager) createIndexes(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName, opts *QueueOptions) error {
+	fqn := MakeFQN(schema, name)
+
+	indexes := defaultIndexDefs(name, opts.DefaultIndexes, opts.Tablespace)
+	if opts.OrderingColumn {
+		indexes = append(indexes, orderingIndexDef(name, opts.Tablespace))
+	}
+
+	for _, idx := range indexes {
+		if _, err := tx.Exec(ctx, createIndexSQL(schema, name, idx.Name, idx, false, false)); err != nil {
+			return wrapErr("create_index"+strings.TrimPrefix(idx.Name, name.String()), fqn, err)
+		}
+	}
+
+	return nil
+}
+
+// Exists checks if a queue table exists
+func (m *Manager) Exists(ctx context.Context, schema SchemaName, name QueueName) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var exists bool
+	err := m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM pg_tables
+				WHERE schemaname = $1 AND tablename = $2
+			)
+		`, schema, name).Scan(&exists)
+	})
+
+	if err != nil {
+		return false, wrapErr("check_exists", fqn, err)
+	}
+
+	return exists, nil
+}
+
+// IsPartitioned checks if a queue uses partitioning
+func (m *Manager) IsPartitioned(ctx context.Context, schema SchemaName, name QueueName) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var partitioned bool
+	err := m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM pg_partitioned_table pt
+				JOIN pg_class c ON pt.partrelid = c.oid
+				JOIN pg_namespace n ON c.relnamespace = n.oid
+				WHERE n.nspname = $1 AND c.relname = $2
+			)
+		`, schema, name).Scan(&partitioned)
+	})
+
+	if err != nil {
+		return false, wrapErr("check_partitioned", fqn, err)
+	}
+
+	return partitioned, nil
+}
+
+// Get retrieves queue information
+func (m *Manager) Get(ctx context.Context, schema SchemaName, name QueueName) (*Queue, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	exists, err := m.Exists(ctx, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &QueueNotFoundError{Queue: fqn}
+	}
+
+	partitioned, err := m.IsPartitioned(ctx, schema, name)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		Name:        name,
+		Schema:      schema,
+		Partitioned: partitioned,
+	}
+
+	err = m.retry(ctx, func() error {
+		q.Columns, err = m.queryColumns(ctx, schema, name, []string{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !partitioned {
+		return q, nil
+	}
+
+	// partattrs holds 0 for an expression, which has no pg_attribute row
+	var key *string
+	var keyDef string
+	err = m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, `
+			SELECT CASE pt.partstrat WHEN 'l' THEN 'list' WHEN 'h' THEN 'hash' ELSE 'range' END,
+			       CASE WHEN pt.partnatts = 1 THEN a.attname END,
+			       pg_get_partkeydef(pt.partrelid),
+			       (SELECT count(*) FROM pg_inherits i WHERE i.inhparent = pt.partrelid),
+			       pt.partdefid <> 0
+			FROM pg_partitioned_table pt
+			LEFT JOIN pg_attribute a ON a.attrelid = pt.partrelid AND a.attnum = pt.partattrs[0]
+			WHERE pt.partrelid = $1::regclass
+		`, fqn.Sanitize()).Scan(&q.Strategy, &key, &keyDef, &q.Partitions, &q.DefaultPartition)
+	})
+	if err != nil {
+		return nil, wrapErr("get_partition_key", fqn, err)
+	}
+	if key == nil {
+		return nil, wrapErr("get_partition_key", fqn, fmt.Errorf("partition key %s is not a single column, which queues don't support", keyDef))
+	}
+	q.Key = *key
+
+	return q, nil
+}
+
+// Drop removes a queue table entirely
+// This is destructive - caller should confirm
+func (m *Manager) Drop(ctx context.Context, schema SchemaName, name QueueName) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	sql := strings.Builder{}
+	sql.WriteString("DROP TABLE IF EXISTS ")
+	sql.WriteString(schema.Sanitize())
+	sql.WriteString(".")
+	sql.WriteString(name.Sanitize())
+	sql.WriteString(" CASCADE")
+
+	if _, err := m.pool.Exec(ctx, sql.String()); err != nil {
+		return wrapErr("drop", fqn, err)
+	}
+
+	return nil
+}
+
+// ExecHooks runs user supplied statements for a queue in a single transaction
+func (m *Manager) ExecHooks(ctx context.Context, schema SchemaName, name QueueName, op string, stmts []string) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := execHooks(ctx, tx, fqn, op, stmts); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit_"+op, fqn, err)
+	}
+
+	return nil
+}
+
+func execHooks(ctx context.Context, tx pgx.Tx, fqn FQN, op string, stmts []string) error {
+	for i, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr(fmt.Sprintf("%s_sql[%d]", op, i), fqn, err)
+		}
+	}
+	return nil
+}
+
+// ServerTimezone returns the TimeZone setting of new sessions, which is what
+// pg_partman maintenance uses for partition boundaries
+func (m *Manager) ServerTimezone(ctx context.Context) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var tz string
+	if err := m.pool.QueryRow(ctx, `SELECT reset_val FROM pg_settings WHERE name = 'TimeZone'`).Scan(&tz); err != nil {
+		return "", fmt.Errorf("failed to read server timezone: %w", err)
+	}
+	return tz, nil
+}
+
+// Pool returns the underlying connection pool
+// Sometimes you need raw access - don't hide it
+func (m *Manager) Pool() *pgxpool.Pool {
+	return m.pool
+}
@@ -0,0 +1,88 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerWithTimeout(t *testing.T) {
+	ctx, cancel := NewManager(nil).withTimeout(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("withTimeout() without OperationTimeout should not set a deadline")
+	}
+
+	m := NewManagerWithOptions(nil, &ManagerOptions{OperationTimeout: time.Minute})
+	ctx, cancel = m.withTimeout(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("withTimeout() deadline = %v, %v, want within a minute", deadline, ok)
+	}
+
+	// an earlier deadline of the caller wins
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = m.withTimeout(parent)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) > time.Second {
+		t.Errorf("withTimeout() deadline = %v, want the parent's", deadline)
+	}
+}
+
+func TestManagerBeginDDL(t *testing.T) {
+	m := NewManagerWithOptions(nil, &ManagerOptions{MaxConcurrentDDL: 1})
+
+	ctx, release, err := m.beginDDL(context.Background())
+	if err != nil {
+		t.Fatalf("beginDDL() error = %v", err)
+	}
+
+	// nested calls share the slot
+	_, releaseNested, err := m.beginDDL(ctx)
+	if err != nil {
+		t.Fatalf("nested beginDDL() error = %v", err)
+	}
+	releaseNested()
+
+	// other operations wait until it is released
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWait()
+	if _, _, err := m.beginDDL(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("beginDDL() with a held slot error = %v, want deadline exceeded", err)
+	}
+
+	release()
+	_, release, err = m.beginDDL(context.Background())
+	if err != nil {
+		t.Fatalf("beginDDL() after release error = %v", err)
+	}
+	release()
+
+	// without a limit nothing waits
+	unlimited := NewManager(nil)
+	for i := 0; i < 3; i++ {
+		if _, _, err := unlimited.beginDDL(context.Background()); err != nil {
+			t.Fatalf("beginDDL() without a limit error = %v", err)
+		}
+	}
+}
+
+func TestManagerExecHooksEmpty(t *testing.T) {
+	m := NewManagerWithOptions(nil, &ManagerOptions{MaxConcurrentDDL: 1})
+
+	_, release, err := m.beginDDL(context.Background())
+	if err != nil {
+		t.Fatalf("beginDDL() error = %v", err)
+	}
+	defer release()
+
+	// no statements: nothing to run, so no DDL slot is waited for
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.ExecHooks(ctx, "public", "jobs", "before_destroy", nil); err != nil {
+		t.Errorf("ExecHooks() without statements error = %v", err)
+	}
+}
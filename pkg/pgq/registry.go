@@ -0,0 +1,237 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RegistryTable is the table, created on first use, that records the
+// objects the provider created and the resources owning them
+const RegistryTable = "pgq_terraform_registry"
+
+// Registry object types
+const (
+	ObjectTable         = "table"
+	ObjectIndex         = "index"
+	ObjectView          = "view"
+	ObjectFunction      = "function"
+	ObjectTrigger       = "trigger"
+	ObjectCronJob       = "cron_job"
+	ObjectPartmanConfig = "partman_config"
+)
+
+// RegistryEntry is an object in the registry. Objects returned by
+// QueueObjects, AlertObjects and MetricViewObjects only have ObjectType and
+// ObjectName set.
+type RegistryEntry struct {
+	ObjectType string
+	// ObjectName is schema-qualified; triggers are named 'trigger ON table'
+	// and cron jobs by their job name
+	ObjectName   string
+	ResourceType string
+	ResourceID   string
+	// Owner labels the Terraform configuration, e.g. a workspace name
+	Owner      string
+	RecordedAt time.Time
+}
+
+const registryDDL = `
+	CREATE TABLE IF NOT EXISTS %s (
+		object_type   TEXT        NOT NULL,
+		object_name   TEXT        NOT NULL,
+		resource_type TEXT        NOT NULL,
+		resource_id   TEXT        NOT NULL,
+		owner         TEXT        NOT NULL DEFAULT '',
+		recorded_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (object_type, object_name)
+	)
+`
+
+func registryFQN(schema SchemaName) FQN {
+	return MakeFQN(schema, RegistryTable)
+}
+
+// SyncRegistry replaces the registry entries of a resource with objects,
+// creating the registry table in schema if needed. Empty objects remove
+// the resource from the registry.
+func (m *Manager) SyncRegistry(ctx context.Context, schema SchemaName, resourceType, resourceID, owner string, objects []RegistryEntry) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := registryFQN(schema)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(registryDDL, fqn.Sanitize())); err != nil {
+		return wrapErr("create_registry", fqn, err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM "+fqn.Sanitize()+" WHERE resource_type = $1 AND resource_id = $2",
+		resourceType, resourceID); err != nil {
+		return wrapErr("sync_registry", fqn, err)
+	}
+
+	for _, o := range objects {
+		// an object moves to the resource that last recorded it
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO `+fqn.Sanitize()+` (object_type, object_name, resource_type, resource_id, owner)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (object_type, object_name) DO UPDATE
+			SET resource_type = EXCLUDED.resource_type, resource_id = EXCLUDED.resource_id,
+			    owner = EXCLUDED.owner, recorded_at = EXCLUDED.recorded_at
+		`, o.ObjectType, o.ObjectName, resourceType, resourceID, owner); err != nil {
+			return wrapErr("sync_registry", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
+
+// ListRegistry returns the registry entries in schema, or nothing when the
+// registry doesn't exist
+func (m *Manager) ListRegistry(ctx context.Context, schema SchemaName) ([]RegistryEntry, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := registryFQN(schema)
+
+	var exists bool
+	if err := m.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, fqn.Sanitize()).Scan(&exists); err != nil {
+		return nil, wrapErr("list_registry", fqn, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT object_type, object_name, resource_type, resource_id, owner, recorded_at
+		FROM `+fqn.Sanitize()+`
+		ORDER BY resource_type, resource_id, object_type, object_name
+	`)
+	if err != nil {
+		return nil, wrapErr("list_registry", fqn, err)
+	}
+
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[RegistryEntry])
+	if err != nil {
+		return nil, wrapErr("list_registry", fqn, err)
+	}
+
+	return entries, nil
+}
+
+// QueueObjects returns the objects making up a queue: its table and
+// template, their indexes and triggers, the per-queue functions, the
+// CLUSTER job and the pg_partman config. Partitions are left out since
+// pg_partman creates and drops them.
+func (m *Manager) QueueObjects(ctx context.Context, schema SchemaName, name QueueName) ([]RegistryEntry, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	rows, err := m.pool.Query(ctx, `
+		WITH tables AS (
+			SELECT c.oid AS relid, format('%I.%I', n.nspname, c.relname) AS name
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname IN ($2, $2 || '_template')
+		)
+		SELECT 'table', t.name FROM tables t
+		UNION ALL
+		SELECT 'index', format('%I.%I', $1, ci.relname)
+		FROM tables t
+		JOIN pg_index x ON x.indrelid = t.relid
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		UNION ALL
+		SELECT 'trigger', format('%I ON %s', tg.tgname, t.name)
+		FROM tables t JOIN pg_trigger tg ON tg.tgrelid = t.relid
+		WHERE NOT tg.tgisinternal
+		UNION ALL
+		SELECT 'function', format('%I.%I', n.nspname, p.proname)
+		FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.proname IN ($3 || $2, $4 || $2, $5 || $2)
+		ORDER BY 1, 2
+	`, schema, name, clusterPrefix, maxAgePrefix, notifyPrefix)
+	if err != nil {
+		return nil, wrapErr("queue_objects", fqn, err)
+	}
+
+	var objects []RegistryEntry
+	var o RegistryEntry
+	_, err = pgx.ForEachRow(rows, []any{&o.ObjectType, &o.ObjectName}, func() error {
+		objects = append(objects, o)
+		return nil
+	})
+	if err != nil {
+		return nil, wrapErr("queue_objects", fqn, err)
+	}
+
+	pm, err := m.partmanSchema(ctx)
+	if err != nil {
+		return nil, wrapErr("queue_objects", fqn, err)
+	}
+
+	var cron, partman bool
+	err = m.pool.QueryRow(ctx, partmanSQL(pm, `
+		SELECT to_regclass('cron.job') IS NOT NULL, to_regclass('{{partman}}.part_config') IS NOT NULL
+	`)).Scan(&cron, &partman)
+	if err != nil {
+		return nil, wrapErr("queue_objects", fqn, err)
+	}
+
+	if cron {
+		job := clusterFunctionFQN(schema, name).String()
+		var scheduled bool
+		if err := m.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM cron.job WHERE jobname = $1)`, job).Scan(&scheduled); err != nil {
+			return nil, wrapErr("queue_objects", fqn, err)
+		}
+		if scheduled {
+			objects = append(objects, RegistryEntry{ObjectType: ObjectCronJob, ObjectName: job})
+		}
+	}
+
+	if partman {
+		var configured bool
+		if err := m.pool.QueryRow(ctx, partmanSQL(pm, `SELECT EXISTS (SELECT 1 FROM {{partman}}.part_config WHERE parent_table = $1)`), fqn.String()).Scan(&configured); err != nil {
+			return nil, wrapErr("queue_objects", fqn, err)
+		}
+		if configured {
+			objects = append(objects, RegistryEntry{ObjectType: ObjectPartmanConfig, ObjectName: fqn.String()})
+		}
+	}
+
+	return objects, nil
+}
+
+// AlertObjects returns the objects created by CreateAlert for a queue in
+// schema. The alerts table is left out as alerts may share it.
+func AlertObjects(schema SchemaName, a *Alert) []RegistryEntry {
+	fn := a.FunctionFQN(schema).String()
+	return []RegistryEntry{
+		{ObjectType: ObjectFunction, ObjectName: fn},
+		{ObjectType: ObjectCronJob, ObjectName: fn},
+	}
+}
+
+// MetricViewObjects returns the views and the function created by
+// CreateMetricViews
+func MetricViewObjects(schema SchemaName) []RegistryEntry {
+	objects := make([]RegistryEntry, len(MetricViews), len(MetricViews)+1)
+	for i, v := range MetricViews {
+		objects[i] = RegistryEntry{ObjectType: ObjectView, ObjectName: MakeFQN(schema, QueueName(v.Name)).String()}
+	}
+	return append(objects, RegistryEntry{ObjectType: ObjectFunction, ObjectName: MakeFQN(schema, metricFunction).String()})
+}
@@ -0,0 +1,249 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Rename renames a queue in place, keeping its messages: the table, its
+// partitions and template table (all named after the queue), its built-in
+// indexes and ordering sequence, the template copies of its custom indexes
+// and its trigger functions. For pg_partman queues (partman true) the
+// part_config entries of the queue and its sub-partitioned partitions
+// follow. Custom indexes keep their names.
+func (m *Manager) Rename(ctx context.Context, schema SchemaName, from, to QueueName, partman bool) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, from)
+	newFQN := MakeFQN(schema, to)
+
+	customIndexes, err := m.queryCustomIndexes(ctx, schema, from)
+	if err != nil {
+		return err
+	}
+
+	var pm SchemaName
+	if partman {
+		if pm, err = m.partmanSchema(ctx); err != nil {
+			return wrapPartmanErr("rename", fqn, err)
+		}
+	}
+
+	return m.retryTx(ctx, fqn, "commit_rename", func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, newFQN.Sanitize()).Scan(&exists); err != nil {
+			return wrapErr("check_exists", newFQN, err)
+		}
+		if exists {
+			return &QueueExistsError{Queue: newFQN}
+		}
+
+		partitions, err := queuePartitions(ctx, tx, schema, from)
+		if err != nil {
+			return err
+		}
+
+		var stmts []string
+		rename := func(kind, old, new string) error {
+			if len(new) > maxIdentifierLength {
+				return wrapErr("rename", fqn, fmt.Errorf("%s exceeds the identifier length limit of %d characters", new, maxIdentifierLength))
+			}
+			stmts = append(stmts, "ALTER "+kind+" IF EXISTS "+MakeFQN(schema, QueueName(old)).Sanitize()+
+				" RENAME TO "+pgx.Identifier{new}.Sanitize())
+			return nil
+		}
+
+		// partitions, sub-partitions and the default partition are all
+		// named <queue>_<suffix>
+		for _, p := range partitions {
+			if !strings.HasPrefix(p, from.String()+"_") {
+				continue
+			}
+			if err := rename("TABLE", p, to.String()+p[len(from):]); err != nil {
+				return err
+			}
+		}
+
+		oldTemplate := QueueName(from.String() + "_template")
+		newTemplate := QueueName(to.String() + "_template")
+		for _, idx := range customIndexes {
+			if err := rename("INDEX", childIndexName(oldTemplate, idx.Name), childIndexName(newTemplate, idx.Name)); err != nil {
+				return err
+			}
+		}
+		if err := rename("TABLE", oldTemplate.String(), newTemplate.String()); err != nil {
+			return err
+		}
+
+		for _, index := range defaultIndexes(from) {
+			if err := rename("INDEX", index, to.String()+index[len(from):]); err != nil {
+				return err
+			}
+		}
+		if err := rename("SEQUENCE", from.String()+"_"+orderingColumn+"_seq", to.String()+"_"+orderingColumn+"_seq"); err != nil {
+			return err
+		}
+		if err := rename("TABLE", from.String(), to.String()); err != nil {
+			return err
+		}
+
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return wrapErr("rename", fqn, err)
+			}
+		}
+
+		for _, prefix := range triggerFunctionPrefixes {
+			fn := FQN(fmt.Sprintf("%s.%s%s", schema, prefix, from))
+			err := alterFunctionIfExists(ctx, tx, fn, "RENAME TO "+pgx.Identifier{prefix + to.String()}.Sanitize())
+			if err != nil {
+				return wrapErr("rename_function", fqn, err)
+			}
+		}
+
+		if !partman {
+			return nil
+		}
+
+		return updatePartConfig(ctx, tx, pm, fqn, newFQN, partitions)
+	})
+}
+
+// MoveSchema moves a queue to another existing schema, keeping its
+// messages: the table with its indexes and sequences, its partitions,
+// template table and trigger functions. For pg_partman queues (partman
+// true) the part_config entries of the queue and its sub-partitioned
+// partitions follow.
+func (m *Manager) MoveSchema(ctx context.Context, from SchemaName, name QueueName, to SchemaName, partman bool) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(from, name)
+	newFQN := MakeFQN(to, name)
+
+	var pm SchemaName
+	if partman {
+		if pm, err = m.partmanSchema(ctx); err != nil {
+			return wrapPartmanErr("move_schema", fqn, err)
+		}
+	}
+
+	return m.retryTx(ctx, fqn, "commit_move_schema", func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, newFQN.Sanitize()).Scan(&exists); err != nil {
+			return wrapErr("check_exists", newFQN, err)
+		}
+		if exists {
+			return &QueueExistsError{Queue: newFQN}
+		}
+
+		partitions, err := queuePartitions(ctx, tx, from, name)
+		if err != nil {
+			return err
+		}
+
+		// SET SCHEMA takes indexes, constraints and owned sequences along,
+		// but not partitions
+		tables := append([]string{name.String() + "_template", name.String()}, partitions...)
+		for _, table := range tables {
+			if _, err := tx.Exec(ctx, "ALTER TABLE IF EXISTS "+MakeFQN(from, QueueName(table)).Sanitize()+
+				" SET SCHEMA "+to.Sanitize()); err != nil {
+				return wrapErr("move_schema", fqn, err)
+			}
+		}
+
+		for _, prefix := range triggerFunctionPrefixes {
+			fn := FQN(fmt.Sprintf("%s.%s%s", from, prefix, name))
+			if err := alterFunctionIfExists(ctx, tx, fn, "SET SCHEMA "+to.Sanitize()); err != nil {
+				return wrapErr("move_function", fqn, err)
+			}
+		}
+
+		if !partman {
+			return nil
+		}
+		return updatePartConfig(ctx, tx, pm, fqn, newFQN, partitions)
+	})
+}
+
+// triggerFunctionPrefixes name the trigger functions of a queue, which
+// are named after it
+var triggerFunctionPrefixes = []string{maxAgePrefix, notifyPrefix}
+
+// alterFunctionIfExists alters the trigger function fn, if it exists,
+// since ALTER FUNCTION has no IF EXISTS
+func alterFunctionIfExists(ctx context.Context, tx pgx.Tx, fn FQN, action string) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT to_regprocedure($1) IS NOT NULL`, fn.Sanitize()+"()").Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	_, err := tx.Exec(ctx, "ALTER FUNCTION "+fn.Sanitize()+"() "+action)
+	return err
+}
+
+// queuePartitions returns the partitions at all levels of a queue that
+// are in the queue's schema, deepest first. Detached partitions, such as
+// those under legal hold, aren't included.
+func queuePartitions(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName) ([]string, error) {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := tx.Query(ctx, `
+		SELECT c.relname
+		FROM pg_partition_tree(format('%I.%I', $1::text, $2::text)::regclass) t
+		JOIN pg_class c ON c.oid = t.relid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE t.level > 0 AND n.nspname = $1
+		ORDER BY t.level DESC, c.relname
+	`, schema, name)
+	if err != nil {
+		return nil, wrapErr("get_partitions", fqn, err)
+	}
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrapErr("get_partitions", fqn, err)
+	}
+	return partitions, nil
+}
+
+// updatePartConfig points the part_config entries of a queue moved or
+// renamed from fqn to newFQN, and those of its sub-partitioned partitions
+// (by their old names), at the new names. Every name starts with the
+// queue's fully qualified name, so replacing that prefix renames them all.
+func updatePartConfig(ctx context.Context, tx pgx.Tx, pm SchemaName, fqn, newFQN FQN, partitions []string) error {
+	schema, _, _ := fqn.Split()
+
+	parents := []string{fqn.String()}
+	for _, p := range partitions {
+		parents = append(parents, MakeFQN(schema, QueueName(p)).String())
+	}
+
+	_, err := tx.Exec(ctx, partmanSQL(pm, `
+		UPDATE {{partman}}.part_config
+		SET parent_table = $2 || substr(parent_table, length($1) + 1),
+		    template_table = CASE WHEN template_table = $1 || '_template' THEN $2 || '_template' ELSE template_table END
+		WHERE parent_table = ANY($3)
+	`), fqn.String(), newFQN.String(), parents)
+	if err != nil {
+		return wrapPartmanErr("update_part_config", fqn, err)
+	}
+
+	_, err = tx.Exec(ctx, partmanSQL(pm, `
+		UPDATE {{partman}}.part_config_sub
+		SET sub_parent = $2 || substr(sub_parent, length($1) + 1)
+		WHERE sub_parent = ANY($3)
+	`), fqn.String(), newFQN.String(), parents)
+	return wrapPartmanErr("update_part_config_sub", fqn, err)
+}
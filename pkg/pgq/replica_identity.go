@@ -0,0 +1,165 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReplicaIdentityIndexPrefix prefixes the index name of a replica identity
+// using an index, e.g. index:orders_queue_pkey
+const ReplicaIdentityIndexPrefix = "index:"
+
+// replicaIdentities maps pg_class.relreplident to replica identities
+var replicaIdentities = map[string]string{
+	"d": "default",
+	"f": "full",
+	"n": "nothing",
+}
+
+// replicaIdentityClause returns the REPLICA IDENTITY clause of ALTER TABLE
+// for identity, one of default, full, nothing or index:<name>
+func replicaIdentityClause(identity string) (string, error) {
+	if index, ok := strings.CutPrefix(identity, ReplicaIdentityIndexPrefix); ok && index != "" {
+		return "REPLICA IDENTITY USING INDEX " + pgx.Identifier{index}.Sanitize(), nil
+	}
+	for _, id := range replicaIdentities {
+		if id == identity {
+			return "REPLICA IDENTITY " + strings.ToUpper(identity), nil
+		}
+	}
+	return "", fmt.Errorf("invalid replica identity %q", identity)
+}
+
+// replicaIdentitySyncSQL lists the partitions of a queue and its template
+// whose replica identity differs from the queue's, with the identity to
+// set: the replident and, for an index, their index equivalent to the
+// queue's. Partition indexes get generated names, so indexes are matched
+// on the table independent part of their definition.
+const replicaIdentitySyncSQL = `
+	WITH parent AS (
+		SELECT c.oid, c.relreplident,
+		       (SELECT substring(pg_get_indexdef(x.indexrelid) from ' USING .*$')
+		        FROM pg_index x WHERE x.indrelid = c.oid AND x.indisreplident) AS def
+		FROM pg_class c
+		WHERE c.oid = format('%I.%I', $1, $2)::regclass
+	),
+	tables AS (
+		SELECT relid FROM pg_partition_tree(format('%I.%I', $1, $2)::regclass)
+		UNION ALL
+		SELECT to_regclass(format('%I.%I', $1, $2 || '_template'))
+	)
+	SELECT format('%I.%I', n.nspname, c.relname), p.relreplident::text, coalesce(ci.relname, '')
+	FROM tables t
+	CROSS JOIN parent p
+	JOIN pg_class c ON c.oid = t.relid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN LATERAL (
+		SELECT ci.relname, x.indisreplident
+		FROM pg_index x
+		JOIN pg_class ci ON ci.oid = x.indexrelid
+		WHERE x.indrelid = c.oid AND substring(pg_get_indexdef(x.indexrelid) from ' USING .*$') = p.def
+		LIMIT 1
+	) ci ON true
+	WHERE c.oid <> p.oid
+	  AND (c.relreplident <> p.relreplident OR (p.relreplident = 'i' AND ci.indisreplident IS NOT TRUE))
+`
+
+// syncReplicaIdentity gives the partitions and the template of a queue
+// the replica identity of the queue, which they don't inherit. Logical
+// decoding uses the identity of the partition a row is in.
+func syncReplicaIdentity(ctx context.Context, tx pgx.Tx, schema SchemaName, name QueueName) error {
+	fqn := MakeFQN(schema, name)
+
+	rows, err := tx.Query(ctx, replicaIdentitySyncSQL, schema, name)
+	if err != nil {
+		return wrapErr("get_replica_identity_targets", fqn, err)
+	}
+
+	var stmts []string
+	var table, replident, index string
+	_, err = pgx.ForEachRow(rows, []any{&table, &replident, &index}, func() error {
+		identity := replicaIdentities[replident]
+		if replident == "i" {
+			if index == "" {
+				return fmt.Errorf("%s has no index matching the replica identity index", table)
+			}
+			identity = ReplicaIdentityIndexPrefix + index
+		}
+		clause, err := replicaIdentityClause(identity)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, "ALTER TABLE "+table+" "+clause)
+		return nil
+	})
+	if err != nil {
+		return wrapErr("get_replica_identity_targets", fqn, err)
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_replica_identity", fqn, err)
+		}
+	}
+	return nil
+}
+
+// SetReplicaIdentity sets the replica identity of the queue, its existing
+// partitions and its template table; see replicaIdentityClause for the
+// values. An index identity names an index on the queue, which must be
+// unique, not partial and on NOT NULL columns, such as the primary key.
+func (m *Manager) SetReplicaIdentity(ctx context.Context, schema SchemaName, name QueueName, identity string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	clause, err := replicaIdentityClause(identity)
+	if err != nil {
+		return wrapErr("set_replica_identity", fqn, err)
+	}
+
+	return m.retryTx(ctx, fqn, "commit", func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "ALTER TABLE "+fqn.Sanitize()+" "+clause); err != nil {
+			return wrapErr("set_replica_identity", fqn, err)
+		}
+		return syncReplicaIdentity(ctx, tx, schema, name)
+	})
+}
+
+// GetReplicaIdentity returns the replica identity of the queue table, as
+// accepted by SetReplicaIdentity
+func (m *Manager) GetReplicaIdentity(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var replident, index string
+	err := m.retry(ctx, func() error {
+		err := m.pool.QueryRow(ctx, `
+			SELECT c.relreplident::text,
+			       coalesce((SELECT ci.relname
+			                 FROM pg_index x
+			                 JOIN pg_class ci ON ci.oid = x.indexrelid
+			                 WHERE x.indrelid = c.oid AND x.indisreplident), '')
+			FROM pg_class c
+			WHERE c.oid = format('%I.%I', $1, $2)::regclass
+		`, schema, name).Scan(&replident, &index)
+		return wrapErr("get_replica_identity", fqn, err)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if replident == "i" {
+		return ReplicaIdentityIndexPrefix + index, nil
+	}
+	return replicaIdentities[replident], nil
+}
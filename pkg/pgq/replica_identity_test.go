@@ -0,0 +1,29 @@
+package pgq
+
+import "testing"
+
+func TestReplicaIdentityClause(t *testing.T) {
+	tests := []struct {
+		identity string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "REPLICA IDENTITY DEFAULT", false},
+		{"full", "REPLICA IDENTITY FULL", false},
+		{"nothing", "REPLICA IDENTITY NOTHING", false},
+		{"index:orders_queue_pkey", `REPLICA IDENTITY USING INDEX "orders_queue_pkey"`, false},
+		{"index:", "", true},
+		{"FULL", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := replicaIdentityClause(tt.identity)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("replicaIdentityClause(%q) error = %v, wantErr %v", tt.identity, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("replicaIdentityClause(%q) = %q, want %q", tt.identity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy retries Manager calls that are safe to repeat, i.e. catalog
+// reads and DDL transactions that were rolled back as a whole, when they
+// fail on a transient error
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; below 2 disables retries
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each next one
+	Backoff time.Duration
+	// Codes are the SQLSTATEs worth retrying; nil uses DefaultRetryCodes
+	Codes []string
+}
+
+// DefaultRetryCodes are the SQLSTATEs of errors that go away on their own
+var DefaultRetryCodes = []string{
+	"40001", // serialization_failure
+	"40P01", // deadlock_detected
+	"55P03", // lock_not_available
+	"57P01", // admin_shutdown
+	"57P03", // cannot_connect_now
+	"08006", // connection_failure
+}
+
+// connLostCodes are the SQLSTATEs of a server shutting down or not
+// accepting connections yet, besides the connection exception class 08
+var connLostCodes = []string{
+	"57P01", // admin_shutdown
+	"57P02", // crash_shutdown
+	"57P03", // cannot_connect_now
+}
+
+const (
+	retryMaxBackoff = 30 * time.Second
+
+	reconnectBackoff    = 250 * time.Millisecond
+	reconnectMaxBackoff = 5 * time.Second
+)
+
+// isRetryable reports whether err is a server error with one of codes, or a
+// connection error pgx raised before the statement was sent
+func isRetryable(err error, codes []string) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return slices.Contains(codes, pgErr.Code)
+	}
+	return pgconn.SafeToRetry(err)
+}
+
+// isConnectionLost reports whether err means the connection to the server
+// broke or couldn't be used, as when the server restarts
+func isConnectionLost(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return slices.Contains(connLostCodes, pgErr.Code) || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var netErr net.Error
+	return pgconn.SafeToRetry(err) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retry runs fn until it succeeds, fails with an error that isn't
+// retryable, or the attempts of the RetryPolicy are used up. fn must be
+// safe to repeat. The first time fn loses its connection it is repeated
+// once more after reconnecting, without counting as an attempt.
+func (m *Manager) retry(ctx context.Context, fn func() error) error {
+	policy := m.opts.Retry
+	codes := policy.Codes
+	if codes == nil {
+		codes = DefaultRetryCodes
+	}
+	wait := policy.Backoff
+	reconnected := false
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !reconnected && m.opts.ReconnectWindow > 0 && ctx.Err() == nil && isConnectionLost(err) {
+			reconnected = true
+			if m.reconnect(ctx) != nil {
+				return err
+			}
+			attempt--
+			continue
+		}
+
+		if attempt >= policy.MaxAttempts || !isRetryable(err, codes) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, retryMaxBackoff)
+	}
+}
+
+// reconnect closes the pool's connections, which a server restart severs
+// all at once, and waits up to ReconnectWindow for the server to accept a
+// new connection
+func (m *Manager) reconnect(ctx context.Context) error {
+	m.pool.Reset()
+
+	ctx, cancel := context.WithTimeout(ctx, m.opts.ReconnectWindow)
+	defer cancel()
+
+	wait := reconnectBackoff
+	for {
+		err := m.pool.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, reconnectMaxBackoff)
+	}
+}
+
+// retryTx runs fn in a transaction, retrying the whole transaction per the
+// RetryPolicy. commitOp names the commit in the returned error.
+func (m *Manager) retryTx(ctx context.Context, fqn FQN, commitOp string, fn func(tx pgx.Tx) error) error {
+	return m.retry(ctx, func() error {
+		tx, err := m.pool.Begin(ctx)
+		if err != nil {
+			return wrapErr("begin_tx", fqn, err)
+		}
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return wrapErr(commitOp, fqn, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		codes []string
+		want  bool
+	}{
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, DefaultRetryCodes, true},
+		{"wrapped", wrapErr("create_table", "public.q", &pgconn.PgError{Code: "40001"}), DefaultRetryCodes, true},
+		{"custom codes", &pgconn.PgError{Code: "53300"}, []string{"53300"}, true},
+		{"not in codes", &pgconn.PgError{Code: "40P01"}, []string{"53300"}, false},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, DefaultRetryCodes, false},
+		{"plain error", fmt.Errorf("scan: %w", errors.New("boom")), DefaultRetryCodes, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err, tt.codes); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerRetry(t *testing.T) {
+	deadlock := &pgconn.PgError{Code: "40P01"}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		errs     []error
+		wantErr  bool
+		wantRuns int
+	}{
+		{"disabled", RetryPolicy{}, []error{deadlock, nil}, true, 1},
+		{"recovers", RetryPolicy{MaxAttempts: 3}, []error{deadlock, deadlock, nil}, false, 3},
+		{"attempts used up", RetryPolicy{MaxAttempts: 2}, []error{deadlock, deadlock, nil}, true, 2},
+		{"not retryable", RetryPolicy{MaxAttempts: 3}, []error{&pgconn.PgError{Code: "42P01"}, nil}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManagerWithOptions(nil, &ManagerOptions{Retry: tt.policy})
+
+			runs := 0
+			err := m.retry(context.Background(), func() error {
+				err := tt.errs[runs]
+				runs++
+				return err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("retry() ran fn %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestIsConnectionLost(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection exception", wrapErr("check_exists", "public.q", &pgconn.PgError{Code: "08006"}), true},
+		{"reset", fmt.Errorf("read: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{"eof", fmt.Errorf("receive message: %w", io.ErrUnexpectedEOF), true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionLost(tt.err); got != tt.want {
+				t.Errorf("isConnectionLost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerRetryReconnect(t *testing.T) {
+	lost := &pgconn.PgError{Code: "57P01"}
+
+	t.Run("server back", func(t *testing.T) {
+		m := NewManagerWithOptions(fakePool(t, fakeServer(t)), &ManagerOptions{ReconnectWindow: 5 * time.Second})
+
+		runs := 0
+		err := m.retry(context.Background(), func() error {
+			runs++
+			if runs == 1 {
+				return lost
+			}
+			return nil
+		})
+		if err != nil || runs != 2 {
+			t.Errorf("retry() = %v after %d runs, want success after 2", err, runs)
+		}
+	})
+
+	t.Run("once", func(t *testing.T) {
+		m := NewManagerWithOptions(fakePool(t, fakeServer(t)), &ManagerOptions{ReconnectWindow: 5 * time.Second})
+
+		runs := 0
+		err := m.retry(context.Background(), func() error {
+			runs++
+			return lost
+		})
+		if err == nil || runs != 2 {
+			t.Errorf("retry() = %v after %d runs, want an error after 2", err, runs)
+		}
+	})
+
+	t.Run("server gone", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := l.Addr().String()
+		l.Close()
+		m := NewManagerWithOptions(fakePool(t, addr), &ManagerOptions{ReconnectWindow: 300 * time.Millisecond})
+
+		runs := 0
+		err = m.retry(context.Background(), func() error {
+			runs++
+			return lost
+		})
+		if !errors.Is(err, lost) || runs != 1 {
+			t.Errorf("retry() = %v after %d runs, want the original error after 1", err, runs)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		m := NewManagerWithOptions(nil, &ManagerOptions{})
+
+		runs := 0
+		_ = m.retry(context.Background(), func() error {
+			runs++
+			return lost
+		})
+		if runs != 1 {
+			t.Errorf("retry() ran fn %d times, want 1", runs)
+		}
+	})
+}
+
+func fakePool(t *testing.T, addr string) *pgxpool.Pool {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(addr)
+	pool, err := pgxpool.New(context.Background(), "host="+host+" port="+port+" user=test sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// fakeServer accepts connections without authentication and answers every
+// simple query, like the pool's ping, with an empty result
+func fakeServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				backend := pgproto3.NewBackend(conn, conn)
+				if _, err := backend.ReceiveStartupMessage(); err != nil {
+					return
+				}
+				backend.Send(&pgproto3.AuthenticationOk{})
+				backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+				if backend.Flush() != nil {
+					return
+				}
+				for {
+					msg, err := backend.Receive()
+					if err != nil {
+						return
+					}
+					if _, ok := msg.(*pgproto3.Terminate); ok {
+						return
+					}
+					backend.Send(&pgproto3.EmptyQueryResponse{})
+					backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+					if backend.Flush() != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
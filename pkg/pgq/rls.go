@@ -0,0 +1,156 @@
+package pgq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Policy is a row-level security policy on a queue table
+type Policy struct {
+	Name string
+	// Command is ALL, SELECT, INSERT, UPDATE or DELETE
+	Command string
+	// Using filters the rows a command sees, empty for none
+	Using string
+	// WithCheck restricts the rows a command may write, empty for none
+	WithCheck string
+}
+
+// policyCommands maps pg_policy.polcmd to the command of CREATE POLICY
+var policyCommands = map[string]string{
+	"*": "ALL",
+	"r": "SELECT",
+	"a": "INSERT",
+	"w": "UPDATE",
+	"d": "DELETE",
+}
+
+// definition returns the CREATE POLICY statement for table
+func (p Policy) definition(table FQN) string {
+	var sql strings.Builder
+	sql.WriteString("CREATE POLICY ")
+	sql.WriteString(pgx.Identifier{p.Name}.Sanitize())
+	sql.WriteString(" ON ")
+	sql.WriteString(table.Sanitize())
+	if p.Command != "" {
+		sql.WriteString(" FOR ")
+		sql.WriteString(p.Command)
+	}
+	if p.Using != "" {
+		sql.WriteString(" USING (" + p.Using + ")")
+	}
+	if p.WithCheck != "" {
+		sql.WriteString(" WITH CHECK (" + p.WithCheck + ")")
+	}
+	return sql.String()
+}
+
+// GetRowLevelSecurity reports whether row-level security is enabled on the
+// queue table and returns its policies, by name. Expressions are as the
+// server deparses them.
+func (m *Manager) GetRowLevelSecurity(ctx context.Context, schema SchemaName, name QueueName) (bool, []Policy, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var enabled bool
+	err := m.pool.QueryRow(ctx, `
+		SELECT c.relrowsecurity
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, name).Scan(&enabled)
+	if err != nil {
+		return false, nil, wrapErr("get_row_level_security", fqn, err)
+	}
+
+	rows, err := m.pool.Query(ctx, `
+		SELECT p.polname, p.polcmd::text,
+		       coalesce(pg_get_expr(p.polqual, p.polrelid), ''),
+		       coalesce(pg_get_expr(p.polwithcheck, p.polrelid), '')
+		FROM pg_policy p
+		WHERE p.polrelid = format('%I.%I', $1, $2)::regclass
+		ORDER BY p.polname
+	`, schema, name)
+	if err != nil {
+		return false, nil, wrapErr("get_policies", fqn, err)
+	}
+
+	policies, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Policy, error) {
+		var p Policy
+		var cmd string
+		err := row.Scan(&p.Name, &cmd, &p.Using, &p.WithCheck)
+		p.Command = policyCommands[cmd]
+		return p, err
+	})
+	if err != nil {
+		return false, nil, wrapErr("get_policies", fqn, err)
+	}
+
+	return enabled, policies, nil
+}
+
+// SetRowLevelSecurity enables or disables row-level security on the queue
+// table and changes its policies from the from set to the to set, matching
+// them by name. Changed policies are dropped and created again. Partitions
+// don't inherit either: rows read through the queue are filtered, rows
+// read from a partition directly are not.
+func (m *Manager) SetRowLevelSecurity(ctx context.Context, schema SchemaName, name QueueName, enabled bool, from, to []Policy) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	old := make(map[string]Policy, len(from))
+	for _, p := range from {
+		old[p.Name] = p
+	}
+	kept := make(map[string]bool, len(to))
+
+	var drops, creates []string
+	for _, p := range to {
+		if prev, ok := old[p.Name]; ok && prev == p {
+			kept[p.Name] = true
+			continue
+		}
+		creates = append(creates, p.definition(fqn))
+	}
+	for _, p := range from {
+		if !kept[p.Name] {
+			drops = append(drops, "DROP POLICY IF EXISTS "+pgx.Identifier{p.Name}.Sanitize()+" ON "+fqn.Sanitize())
+		}
+	}
+
+	toggle := "DISABLE"
+	if enabled {
+		toggle = "ENABLE"
+	}
+	stmts := append([]string{"ALTER TABLE " + fqn.Sanitize() + " " + toggle + " ROW LEVEL SECURITY"}, drops...)
+	stmts = append(stmts, creates...)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_row_level_security", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
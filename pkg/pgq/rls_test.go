@@ -0,0 +1,29 @@
+package pgq
+
+import "testing"
+
+func TestPolicyDefinition(t *testing.T) {
+	table := MakeFQN("public", "events")
+	tests := []struct {
+		policy Policy
+		want   string
+	}{
+		{
+			Policy{Name: "tenant", Command: "ALL", Using: "tenant_id = current_setting('app.tenant')::int"},
+			`CREATE POLICY "tenant" ON "public"."events" FOR ALL USING (tenant_id = current_setting('app.tenant')::int)`,
+		},
+		{
+			Policy{Name: "producer", Command: "INSERT", WithCheck: "tenant_id = 1"},
+			`CREATE POLICY "producer" ON "public"."events" FOR INSERT WITH CHECK (tenant_id = 1)`,
+		},
+		{
+			Policy{Name: "all", Using: "true", WithCheck: "false"},
+			`CREATE POLICY "all" ON "public"."events" USING (true) WITH CHECK (false)`,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.definition(table); got != tt.want {
+			t.Errorf("definition() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package pgq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// builtinColumns are the built-in columns as the server describes them,
+// except id, whose default follows the id type, and the optional ordering
+// column
+var builtinColumns = []Column{
+	{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Default: "CURRENT_TIMESTAMP"},
+	{Name: "started_at", Type: "timestamp with time zone"},
+	{Name: "locked_until", Type: "timestamp with time zone"},
+	{Name: "scheduled_for", Type: "timestamp with time zone"},
+	{Name: "processed_at", Type: "timestamp with time zone"},
+	{Name: "consumed_count", Type: "integer", NotNull: true, Default: "0"},
+	{Name: "error_detail", Type: "text"},
+	{Name: "payload", Type: "jsonb", NotNull: true},
+	{Name: "metadata", Type: "jsonb", NotNull: true},
+}
+
+// Drift lists the differences between the built-in columns of q, as read
+// by Get, and those a queue is created with, e.g. after a manual ALTER
+// TABLE. Extra columns aren't checked.
+func (q *Queue) Drift() []string {
+	columns := make(map[string]Column, len(q.Columns))
+	for _, c := range q.Columns {
+		columns[c.Name] = c
+	}
+
+	var drift []string
+	check := func(want Column, types []string, checkDefault bool) {
+		got, ok := columns[want.Name]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("column %s is missing", want.Name))
+			return
+		}
+		if !slices.Contains(types, got.Type) {
+			drift = append(drift, fmt.Sprintf("column %s has type %s, expected %s", want.Name, got.Type, strings.Join(types, " or ")))
+		}
+		if got.NotNull != want.NotNull {
+			if want.NotNull {
+				drift = append(drift, fmt.Sprintf("column %s is nullable, expected NOT NULL", want.Name))
+			} else {
+				drift = append(drift, fmt.Sprintf("column %s is NOT NULL, expected nullable", want.Name))
+			}
+		}
+		if checkDefault && got.Default != want.Default {
+			switch {
+			case want.Default == "":
+				drift = append(drift, fmt.Sprintf("column %s has default %s, expected none", want.Name, got.Default))
+			case got.Default == "":
+				drift = append(drift, fmt.Sprintf("column %s has no default, expected %s", want.Name, want.Default))
+			default:
+				drift = append(drift, fmt.Sprintf("column %s has default %s, expected %s", want.Name, got.Default, want.Default))
+			}
+		}
+	}
+
+	check(Column{Name: "id", NotNull: true}, []string{"uuid", "bigint"}, false)
+	for _, c := range builtinColumns {
+		check(c, []string{c.Type}, true)
+	}
+	if _, ok := columns[orderingColumn]; ok {
+		check(Column{Name: orderingColumn, NotNull: true}, []string{"bigint"}, false)
+	}
+
+	return drift
+}
+
+// StructureHash is a hash of the built-in columns of q, as read by Get,
+// changing whenever their names, types, nullability or defaults do. Extra
+// columns aren't included, nor the defaults of id and the ordering column,
+// which name a sequence that follows the queue's name.
+func (q *Queue) StructureHash() string {
+	columns := slices.Clone(q.Columns)
+	columns = slices.DeleteFunc(columns, func(c Column) bool {
+		return !slices.Contains(ReservedColumns, c.Name)
+	})
+	slices.SortFunc(columns, func(a, b Column) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	h := sha256.New()
+	for _, c := range columns {
+		if c.Name == "id" || c.Name == orderingColumn {
+			c.Default = ""
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%t\x00%s\n", c.Name, c.Type, c.NotNull, c.Default)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,62 @@
+package pgq
+
+import (
+	"slices"
+	"testing"
+)
+
+func createdColumns() []Column {
+	return append([]Column{{Name: "id", Type: "uuid", NotNull: true, Default: "gen_random_uuid()"}}, builtinColumns...)
+}
+
+func TestQueueDrift(t *testing.T) {
+	q := &Queue{Columns: append(createdColumns(), Column{Name: "tenant_id", Type: "uuid"})}
+	if drift := q.Drift(); len(drift) != 0 {
+		t.Errorf("Drift() of a queue as created = %v, want none", drift)
+	}
+
+	columns := createdColumns()
+	columns = slices.DeleteFunc(columns, func(c Column) bool { return c.Name == "metadata" })
+	for i, c := range columns {
+		switch c.Name {
+		case "created_at":
+			columns[i].Type = "timestamp without time zone"
+		case "payload":
+			columns[i].NotNull = false
+		case "consumed_count":
+			columns[i].Default = ""
+		}
+	}
+
+	want := []string{
+		"column created_at has type timestamp without time zone, expected timestamp with time zone",
+		"column consumed_count has no default, expected 0",
+		"column payload is nullable, expected NOT NULL",
+		"column metadata is missing",
+	}
+	if got := (&Queue{Columns: columns}).Drift(); !slices.Equal(got, want) {
+		t.Errorf("Drift() = %q, want %q", got, want)
+	}
+}
+
+func TestQueueStructureHash(t *testing.T) {
+	q := &Queue{Columns: createdColumns()}
+	hash := q.StructureHash()
+
+	extra := &Queue{Columns: append(createdColumns(), Column{Name: "tenant_id", Type: "uuid"})}
+	if got := extra.StructureHash(); got != hash {
+		t.Error("StructureHash() changed with an extra column")
+	}
+
+	reordered := &Queue{Columns: slices.Clone(q.Columns)}
+	slices.Reverse(reordered.Columns)
+	if got := reordered.StructureHash(); got != hash {
+		t.Error("StructureHash() depends on column order")
+	}
+
+	altered := &Queue{Columns: createdColumns()}
+	altered.Columns[1].NotNull = false
+	if got := altered.StructureHash(); got == hash {
+		t.Error("StructureHash() didn't change with a dropped NOT NULL")
+	}
+}
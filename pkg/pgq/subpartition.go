@@ -0,0 +1,69 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SubPartitionConfig partitions each partition of a queue again, by a
+// second column. pg_partman supports range and list sub-partitioning
+// only, not hash.
+type SubPartitionConfig struct {
+	// Column is the sub-partition key. It becomes part of the primary
+	// key, so it must be NOT NULL.
+	Column string
+	// Interval is the range covered by each sub-partition, an interval for
+	// time columns (e.g. '1 hour') or a number for integer columns
+	Interval string
+	// Type is the pg_partman partition type, range or list; empty is range
+	Type    string
+	Premake int
+}
+
+// createSubParent turns the partitions of a queue, existing and future,
+// into parents partitioned by cfg.SubPartition. Existing partitions must
+// be empty, which they are right after create_parent.
+func (m *Manager) createSubParent(ctx context.Context, tx pgx.Tx, pm SchemaName, fqn FQN, cfg *PartitionConfig, jobmon bool) error {
+	sub := cfg.SubPartition
+	typ := sub.Type
+	if typ == "" {
+		typ = "range"
+	}
+
+	_, err := tx.Exec(ctx, partmanSQL(pm, `
+		SELECT {{partman}}.create_sub_parent(
+			p_top_parent        := $1,
+			p_control           := $2,
+			p_interval          := $3,
+			p_type              := $4,
+			p_default_table     := $5,
+			p_declarative_check := 'yes',
+			p_premake           := $6,
+			p_jobmon            := $7
+		)
+	`), fqn.String(), sub.Column, sub.Interval, typ, cfg.DefaultPartition, sub.Premake, jobmon)
+
+	return wrapPartmanErr("create_sub_parent", fqn, err)
+}
+
+// getSubPartition returns the sub-partitioning of a queue, nil if its
+// partitions aren't partitioned again
+func (m *Manager) getSubPartition(ctx context.Context, pm SchemaName, fqn FQN) (*SubPartitionConfig, error) {
+	var sub SubPartitionConfig
+	err := m.pool.QueryRow(ctx, partmanSQL(pm, `
+		SELECT sub_control, sub_partition_interval::text, sub_partition_type, sub_premake
+		FROM {{partman}}.part_config_sub
+		WHERE sub_parent = $1
+	`), fqn.String()).Scan(&sub.Column, &sub.Interval, &sub.Type, &sub.Premake)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapPartmanErr("get_sub_partition", fqn, err)
+	}
+
+	return &sub, nil
+}
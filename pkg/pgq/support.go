@@ -0,0 +1,151 @@
+package pgq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minServerVersion is the oldest PostgreSQL supported (pg_partition_tree)
+	minServerVersion = 120000
+	// minPartmanMajor is the oldest pg_partman supported (create_parent API)
+	minPartmanMajor = 5
+)
+
+// CheckSupport returns an *UnsupportedError when the server can't host a
+// queue, or a partitioned queue when partitioned is set
+func (m *Manager) CheckSupport(ctx context.Context, partitioned bool) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var serverVersion int
+	var partmanVersion *string
+	err := m.pool.QueryRow(ctx, `
+		SELECT current_setting('server_version_num')::int,
+		       (SELECT extversion FROM pg_extension WHERE extname = 'pg_partman')
+	`).Scan(&serverVersion, &partmanVersion)
+	if err != nil {
+		return fmt.Errorf("failed to check server capabilities: %w", err)
+	}
+
+	// a missing pg_partman is installed before the queue is created
+	if partmanVersion == nil && m.opts.InstallPartman {
+		partitioned = false
+	}
+
+	return checkSupport(serverVersion, partmanVersion, partitioned)
+}
+
+func checkSupport(serverVersion int, partmanVersion *string, partitioned bool) error {
+	if serverVersion < minServerVersion {
+		return &UnsupportedError{Reason: fmt.Sprintf("PostgreSQL %d.%d is older than %d",
+			serverVersion/10000, serverVersion%10000, minServerVersion/10000)}
+	}
+
+	if !partitioned {
+		return nil
+	}
+
+	if partmanVersion == nil {
+		return &UnsupportedError{Reason: "pg_partman extension is not installed", Missing: "pg_partman"}
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(*partmanVersion, ".", 2)[0])
+	if err != nil || major < minPartmanMajor {
+		return &UnsupportedError{Reason: fmt.Sprintf("pg_partman %s is older than %d.0", *partmanVersion, minPartmanMajor)}
+	}
+
+	return nil
+}
+
+// Ping checks that a connection to the server can be established
+func (m *Manager) Ping(ctx context.Context) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.pool.Ping(ctx)
+}
+
+// ServerVersions returns the PostgreSQL version, like "16.2", and the
+// pg_partman version, empty when the extension isn't installed
+func (m *Manager) ServerVersions(ctx context.Context) (postgres, partman string, err error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var serverVersion int
+	var partmanVersion *string
+	err = m.retry(ctx, func() error {
+		return m.pool.QueryRow(ctx, `
+			SELECT current_setting('server_version_num')::int,
+			       (SELECT extversion FROM pg_extension WHERE extname = 'pg_partman')
+		`).Scan(&serverVersion, &partmanVersion)
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read server versions: %w", err)
+	}
+
+	postgres = fmt.Sprintf("%d.%d", serverVersion/10000, serverVersion%10000)
+	if partmanVersion != nil {
+		partman = *partmanVersion
+	}
+	return postgres, partman, nil
+}
+
+// CheckMinVersion returns an *UnsupportedError when version of component
+// is empty (not installed) or older than minimum. Versions are compared as
+// dot separated numbers, with missing parts counting as zero.
+func CheckMinVersion(component, version, minimum string) error {
+	if version == "" {
+		return &UnsupportedError{Reason: component + " is not installed", Missing: component}
+	}
+
+	older, err := versionLess(version, minimum)
+	if err != nil {
+		return err
+	}
+	if older {
+		return &UnsupportedError{Reason: fmt.Sprintf("%s %s is older than the required %s", component, version, minimum)}
+	}
+
+	return nil
+}
+
+func versionLess(a, b string) (bool, error) {
+	pa, err := versionParts(a)
+	if err != nil {
+		return false, err
+	}
+	pb, err := versionParts(b)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x < y, nil
+		}
+	}
+	return false, nil
+}
+
+func versionParts(v string) ([]int, error) {
+	fields := strings.Split(v, ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
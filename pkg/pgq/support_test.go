@@ -0,0 +1,67 @@
+package pgq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSupport(t *testing.T) {
+	v := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		server      int
+		partman     *string
+		partitioned bool
+		unsupported bool
+	}{
+		{"simple on pg16", 160002, nil, false, false},
+		{"simple on pg11", 110022, nil, false, true},
+		{"partitioned without partman", 160002, nil, true, true},
+		{"partitioned on partman 4", 160002, v("4.7.4"), true, true},
+		{"partitioned on partman 5", 160002, v("5.1.0"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSupport(tt.server, tt.partman, tt.partitioned)
+
+			var unsupported *UnsupportedError
+			if got := errors.As(err, &unsupported); got != tt.unsupported {
+				t.Errorf("checkSupport() = %v, want unsupported %v", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		minimum     string
+		unsupported bool
+		wantErr     bool
+	}{
+		{"equal", "16.2", "16.2", false, false},
+		{"newer major", "16.2", "14", false, false},
+		{"older minor", "16.2", "16.4", true, true},
+		{"missing part is zero", "5.1", "5.1.0", false, false},
+		{"numeric not lexical", "5.10.0", "5.9", false, false},
+		{"not installed", "", "5.0", true, true},
+		{"invalid minimum", "16.2", "sixteen", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMinVersion("pg_partman", tt.version, tt.minimum)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckMinVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var unsupported *UnsupportedError
+			if got := errors.As(err, &unsupported); got != tt.unsupported {
+				t.Errorf("CheckMinVersion() = %v, want unsupported %v", err, tt.unsupported)
+			}
+		})
+	}
+}
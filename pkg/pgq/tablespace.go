@@ -0,0 +1,106 @@
+package pgq
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultTablespace is what SET TABLESPACE calls the database default
+const defaultTablespace = "pg_default"
+
+// defaultIndexes returns the names of the indexes every queue gets,
+// including the primary key and the ordering index
+func defaultIndexes(name QueueName) []string {
+	return []string{
+		name.String() + "_pkey",
+		name.String() + indexCreatedAt,
+		name.String() + indexProcessedAtNull,
+		name.String() + indexScheduledFor,
+		name.String() + indexMetadata,
+		name.String() + indexOrdering,
+	}
+}
+
+func tablespaceClause(tablespace string) string {
+	if tablespace == "" {
+		return ""
+	}
+	return " TABLESPACE " + pgx.Identifier{tablespace}.Sanitize()
+}
+
+// GetTablespace returns the tablespace of the queue table, or an empty
+// string when it uses the database default
+func (m *Manager) GetTablespace(ctx context.Context, schema SchemaName, name QueueName) (string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+
+	var tablespace string
+	err := m.pool.QueryRow(ctx, `
+		SELECT coalesce(ts.spcname, '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_tablespace ts ON ts.oid = c.reltablespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, name).Scan(&tablespace)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapErr("get_tablespace", fqn, err)
+	}
+
+	return tablespace, nil
+}
+
+// SetTablespace moves the queue table, its template table and its default
+// indexes to tablespace; empty moves them to the database default.
+// Simple queues are rewritten under an ACCESS EXCLUSIVE lock. For
+// partitioned queues only the defaults of future partitions change,
+// existing partitions stay where they are.
+func (m *Manager) SetTablespace(ctx context.Context, schema SchemaName, name QueueName, tablespace string) error {
+	ctx, cancel, err := m.beginDDL(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	fqn := MakeFQN(schema, name)
+	if tablespace == "" {
+		tablespace = defaultTablespace
+	}
+	target := pgx.Identifier{tablespace}.Sanitize()
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr("begin_tx", fqn, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	q := &Queue{Schema: schema, Name: name}
+	stmts := []string{
+		"ALTER TABLE " + fqn.Sanitize() + " SET TABLESPACE " + target,
+		"ALTER TABLE IF EXISTS " + q.TemplateFQN().Sanitize() + " SET TABLESPACE " + target,
+	}
+	for _, index := range defaultIndexes(name) {
+		stmts = append(stmts, "ALTER INDEX IF EXISTS "+MakeFQN(schema, QueueName(index)).Sanitize()+" SET TABLESPACE "+target)
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return wrapErr("set_tablespace", fqn, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapErr("commit", fqn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package pgq
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTablespaceClause(t *testing.T) {
+	if got := tablespaceClause(""); got != "" {
+		t.Errorf("tablespaceClause(\"\") = %q, want none", got)
+	}
+	if got, want := tablespaceClause("fast nvme"), ` TABLESPACE "fast nvme"`; got != want {
+		t.Errorf("tablespaceClause() = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultIndexesMovedWithTablespace(t *testing.T) {
+	got := defaultIndexes("orders")
+	for _, want := range []string{"orders_pkey", "orders_created_at_idx", "orders_metadata_idx", "orders" + indexOrdering} {
+		if !slices.Contains(got, want) {
+			t.Errorf("defaultIndexes() = %v, missing %s", got, want)
+		}
+	}
+}
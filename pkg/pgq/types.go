@@ -0,0 +1,149 @@
+package pgq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// PostgreSQL identifier length limit
+	maxIdentifierLength = 63
+)
+
+// Domain types - wrap primitives for type safety and domain clarity
+// This prevents mixing up queue names with schema names, etc.
+type (
+	QueueName  string
+	SchemaName string
+	FQN        string // Fully Qualified Name
+)
+
+// String returns the string representation
+func (q QueueName) String() string  { return string(q) }
+func (s SchemaName) String() string { return string(s) }
+func (f FQN) String() string        { return string(f) }
+
+// Valid checks if the name is a valid PostgreSQL identifier
+func (q QueueName) Valid() bool {
+	if q == "" {
+		return false
+	}
+	s := string(q)
+	if len(s) > maxIdentifierLength {
+		return false
+	}
+	// Must start with letter or underscore
+	first := s[0]
+	return (first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z') || first == '_'
+}
+
+func (s SchemaName) Valid() bool { return QueueName(s).Valid() }
+
+// Sanitize returns a safely quoted identifier for use in SQL
+func (q QueueName) Sanitize() string  { return pgx.Identifier{q.String()}.Sanitize() }
+func (s SchemaName) Sanitize() string { return pgx.Identifier{s.String()}.Sanitize() }
+
+// Sanitize returns the FQN as a safely quoted schema-qualified identifier
+func (f FQN) Sanitize() string {
+	schema, name, err := f.Split()
+	if err != nil {
+		return pgx.Identifier{f.String()}.Sanitize()
+	}
+	return pgx.Identifier{schema.String(), name.String()}.Sanitize()
+}
+
+// quoteLiteral returns s as a SQL string literal, for the places where
+// statements can't take bind parameters (DDL, function bodies)
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// FQN creates a fully qualified name from schema and queue
+func MakeFQN(schema SchemaName, queue QueueName) FQN {
+	return FQN(fmt.Sprintf("%s.%s", schema, queue))
+}
+
+// Split breaks an FQN into schema and queue name
+func (f FQN) Split() (SchemaName, QueueName, error) {
+	parts := strings.SplitN(string(f), ".", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid FQN format: %s (expected schema.queue)", f)
+	}
+	return SchemaName(parts[0]), QueueName(parts[1]), nil
+}
+
+// Queue represents a pgq queue - keep it simple, stupid
+type Queue struct {
+	Name        QueueName
+	Schema      SchemaName
+	Partitioned bool
+	// Strategy and Key are the partitioning strategy (see
+	// PartitionConfig.Strategy) and key column of partitioned queues
+	Strategy string
+	Key      string
+	// Partitions is the number of partitions of partitioned queues
+	Partitions int
+	// DefaultPartition reports whether a partitioned queue has a DEFAULT
+	// partition, counted in Partitions
+	DefaultPartition bool
+	// Columns are all columns of the queue table in table order, with
+	// types and defaults as the server formats them
+	Columns []Column
+}
+
+// QueueOptions holds optional settings applied when a queue is created
+type QueueOptions struct {
+	// BeforeCreateSQL runs in the DDL transaction before the table is created
+	BeforeCreateSQL []string
+	// AfterCreateSQL runs in the last creation transaction, once the queue
+	// (and its partman config, if partitioned) exists
+	AfterCreateSQL []string
+	// TextCollation is the collation of the queue's text columns; empty
+	// uses the database default
+	TextCollation string
+	// OrderingColumn adds a sequence-backed bigint column, giving consumers
+	// a monotonic ordering key alongside the UUID id
+	OrderingColumn bool
+	// PrimaryKey are the primary key columns in order; they must include
+	// those of DefaultPrimaryKey, which is used when empty
+	PrimaryKey []string
+	// IDType is the type of the id column: IDUUIDv4 (the default, when
+	// empty), IDUUIDv7, which keeps index inserts local, or
+	// IDBigintIdentity
+	IDType string
+	// Tablespace holds the table, its template table and the default
+	// indexes; empty uses the database default
+	Tablespace string
+	// AccessMethod is the table access method of the table and its
+	// template table; empty uses default_table_access_method. Partitioned
+	// queues need PostgreSQL 17 or later.
+	AccessMethod string
+	// ExtraColumns are added after the built-in columns
+	ExtraColumns []Column
+	// CheckConstraints are created with the table
+	CheckConstraints []CheckConstraint
+	// DefaultIndexes selects the built-in indexes; nil creates all of them
+	DefaultIndexes *DefaultIndexes
+}
+
+// FQN returns the fully qualified name
+func (q *Queue) FQN() FQN {
+	return MakeFQN(q.Schema, q.Name)
+}
+
+// TemplateName returns the template table name for partitioned queues
+func (q *Queue) TemplateName() QueueName {
+	return QueueName(string(q.Name) + "_template")
+}
+
+// DeadLetterName returns the name of the queue's dead-letter queue
+func (q *Queue) DeadLetterName() QueueName {
+	return QueueName(string(q.Name) + "_dlq")
+}
+
+// TemplateFQN returns the fully qualified template table name
+func (q *Queue) TemplateFQN() FQN {
+	return MakeFQN(q.Schema, q.TemplateName())
+}
@@ -84,3 +84,15 @@ func TestTemplateName(t *testing.T) {
 		t.Errorf("TemplateFQN() = %q, want %q", tmplFQN, "public.test_template")
 	}
 }
+
+func TestFQNSanitize(t *testing.T) {
+	if got := FQN("public.my_queue").Sanitize(); got != `"public"."my_queue"` {
+		t.Errorf("Sanitize() = %s, want %s", got, `"public"."my_queue"`)
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	if got := quoteLiteral("it's"); got != `'it''s'` {
+		t.Errorf("quoteLiteral() = %s, want %s", got, `'it''s'`)
+	}
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// rdsTokenLifetime is the validity of an RDS IAM auth token; RDS
+	// rejects anything longer than 15 minutes
+	rdsTokenLifetime = 15 * time.Minute
+	// emptyPayloadHash is the SHA-256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type (
+	awsRDSIAMAuthModel struct {
+		Region      types.String `tfsdk:"region"`
+		Profile     types.String `tfsdk:"profile"`
+		RoleARN     types.String `tfsdk:"role_arn"`
+		SessionName types.String `tfsdk:"session_name"`
+	}
+
+	// rdsIAMAuth generates RDS IAM auth tokens with credentials from the
+	// AWS SDK default chain, assuming role_arn first when set
+	rdsIAMAuth struct {
+		region string
+		creds  aws.CredentialsProvider
+		signer *v4.Signer
+	}
+)
+
+func newRDSIAMAuth(m *awsRDSIAMAuthModel) (*rdsIAMAuth, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region := m.Region.ValueString(); region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	if profile := m.Profile.ValueString(); profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+
+	// the default chain covers environment variables, shared config and
+	// credentials files (SSO, credential_process, source profiles), web
+	// identity and container or instance roles
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("aws_rds_iam_auth: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("aws_rds_iam_auth: region is required (or AWS_REGION)")
+	}
+
+	creds := cfg.Credentials
+	if roleARN := m.RoleARN.ValueString(); roleARN != "" {
+		sessionName := m.SessionName.ValueString()
+		if sessionName == "" {
+			sessionName = "terraform-provider-pgq"
+		}
+		// the cache renews the assumed credentials before they expire
+		creds = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = sessionName
+			}))
+	}
+
+	return &rdsIAMAuth{
+		region: cfg.Region,
+		creds:  creds,
+		signer: v4.NewSigner(),
+	}, nil
+}
+
+// beforeConnect sets a fresh auth token as the password of every new
+// connection, so pools outliving the 15 minute token lifetime keep working
+func (a *rdsIAMAuth) beforeConnect(ctx context.Context, cc *pgx.ConnConfig) error {
+	creds, err := a.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("aws_rds_iam_auth: %w", err)
+	}
+
+	token, err := rdsAuthToken(ctx, a.signer, creds, a.region, cc.Host, cc.Port, cc.User, time.Now())
+	if err != nil {
+		return fmt.Errorf("aws_rds_iam_auth: %w", err)
+	}
+	cc.Password = token
+	return nil
+}
+
+func (a *rdsIAMAuth) install(poolCfg *pgxpool.Config) {
+	poolCfg.BeforeConnect = a.beforeConnect
+}
+
+// rdsAuthToken builds an RDS IAM auth token: a presigned rds-db:connect
+// URL without its scheme, used as the connection password
+func rdsAuthToken(ctx context.Context, signer *v4.Signer, creds aws.Credentials, region, host string, port uint16, user string, now time.Time) (string, error) {
+	query := url.Values{
+		"Action":        {"connect"},
+		"DBUser":        {user},
+		"X-Amz-Expires": {strconv.Itoa(int(rdsTokenLifetime.Seconds()))},
+	}
+	endpoint := "https://" + host + ":" + strconv.Itoa(int(port)) + "/?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	signed, _, err := signer.PresignHTTP(ctx, creds, req, emptyPayloadHash, "rds-db", region, now)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(signed, "https://"), nil
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRDSAuthToken(t *testing.T) {
+	creds := aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "tok en"}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	token, err := rdsAuthToken(context.Background(), v4.NewSigner(), creds, "eu-west-1",
+		"db.abc.eu-west-1.rds.amazonaws.com", 5432, "pgq_admin", now)
+	if err != nil {
+		t.Fatalf("rdsAuthToken() error = %v", err)
+	}
+
+	if !strings.HasPrefix(token, "db.abc.eu-west-1.rds.amazonaws.com:5432/?") {
+		t.Errorf("rdsAuthToken() = %s, want the endpoint without a scheme", token)
+	}
+	for _, want := range []string{
+		"Action=connect",
+		"DBUser=pgq_admin",
+		"X-Amz-Credential=AKID%2F20240102%2Feu-west-1%2Frds-db%2Faws4_request",
+		"X-Amz-Expires=900",
+		"X-Amz-Security-Token=tok%20en",
+		"&X-Amz-Signature=",
+	} {
+		if !strings.Contains(token, want) {
+			t.Errorf("rdsAuthToken() missing %q in %s", want, token)
+		}
+	}
+}
+
+func TestNewRDSIAMAuthProfile(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	file := `
+[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = secretdefault
+
+[staging]
+aws_access_key_id=AKIDSTAGING
+aws_secret_access_key=secretstaging
+aws_session_token=token
+`
+	if err := os.WriteFile(credsFile, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	auth, err := newRDSIAMAuth(&awsRDSIAMAuthModel{
+		Region:  types.StringValue("eu-west-1"),
+		Profile: types.StringValue("staging"),
+	})
+	if err != nil {
+		t.Fatalf("newRDSIAMAuth() error = %v", err)
+	}
+
+	creds, err := auth.creds.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if creds.AccessKeyID != "AKIDSTAGING" || creds.SecretAccessKey != "secretstaging" || creds.SessionToken != "token" {
+		t.Errorf("Retrieve() = %+v", creds)
+	}
+
+	if _, err := newRDSIAMAuth(&awsRDSIAMAuthModel{Profile: types.StringValue("staging")}); err == nil {
+		t.Error("newRDSIAMAuth() should fail without a region")
+	}
+}
@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// azurePostgresResource is the Entra ID resource of Azure Database for PostgreSQL
+	azurePostgresResource = "https://ossrdbms-aad.database.windows.net"
+	azureAuthorityHost    = "https://login.microsoftonline.com"
+	azureIMDSEndpoint     = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+type (
+	azureADAuthModel struct {
+		TenantID           types.String `tfsdk:"tenant_id"`
+		ClientID           types.String `tfsdk:"client_id"`
+		ClientSecret       types.String `tfsdk:"client_secret"`
+		UseManagedIdentity types.Bool   `tfsdk:"use_managed_identity"`
+	}
+
+	// azureADAuth fetches Entra ID access tokens for Azure Database for
+	// PostgreSQL, caching each until shortly before it expires
+	azureADAuth struct {
+		tenantID        string
+		clientID        string
+		clientSecret    string
+		managedIdentity bool
+		authorityHost   string
+		imdsEndpoint    string
+		client          *http.Client
+
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	}
+
+	azureTokenResponse struct {
+		AccessToken string       `json:"access_token"`
+		ExpiresIn   azureSeconds `json:"expires_in"`
+	}
+
+	// azureSeconds accepts both the number Entra ID returns and the
+	// string the instance metadata service returns
+	azureSeconds int64
+)
+
+func (s *azureSeconds) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*s = azureSeconds(n)
+	return nil
+}
+
+func newAzureADAuth(m *azureADAuthModel) (*azureADAuth, error) {
+	a := &azureADAuth{
+		tenantID:        stringOrEnv(m.TenantID, "AZURE_TENANT_ID"),
+		clientID:        stringOrEnv(m.ClientID, "AZURE_CLIENT_ID"),
+		clientSecret:    stringOrEnv(m.ClientSecret, "AZURE_CLIENT_SECRET"),
+		managedIdentity: m.UseManagedIdentity.ValueBool(),
+		authorityHost:   azureAuthorityHost,
+		imdsEndpoint:    azureIMDSEndpoint,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if !a.managedIdentity && (a.tenantID == "" || a.clientID == "" || a.clientSecret == "") {
+		return nil, errors.New("azure_ad_auth: tenant_id, client_id and client_secret are required unless use_managed_identity is set")
+	}
+
+	return a, nil
+}
+
+func (a *azureADAuth) install(poolCfg *pgxpool.Config) {
+	poolCfg.BeforeConnect = a.beforeConnect
+}
+
+// beforeConnect sets a valid access token as the password of every new
+// connection
+func (a *azureADAuth) beforeConnect(ctx context.Context, cc *pgx.ConnConfig) error {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	cc.Password = token
+	return nil
+}
+
+func (a *azureADAuth) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Add(5*time.Minute).Before(a.expires) {
+		return a.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	if a.managedIdentity {
+		req, err = a.managedIdentityRequest(ctx)
+	} else {
+		req, err = a.clientCredentialsRequest(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("azure_ad_auth: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("azure_ad_auth: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("azure_ad_auth: token request failed: %s: %s", resp.Status, body)
+	}
+
+	var out azureTokenResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("azure_ad_auth: %w", err)
+	}
+
+	a.token = out.AccessToken
+	a.expires = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	return a.token, nil
+}
+
+func (a *azureADAuth) clientCredentialsRequest(ctx context.Context) (*http.Request, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {a.clientID},
+		"client_secret": {a.clientSecret},
+		"scope":         {azurePostgresResource + "/.default"},
+	}
+
+	endpoint := a.authorityHost + "/" + url.PathEscape(a.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+func (a *azureADAuth) managedIdentityRequest(ctx context.Context) (*http.Request, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {azurePostgresResource},
+	}
+	// a client ID selects a user-assigned identity
+	if a.clientID != "" {
+		query.Set("client_id", a.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.imdsEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	return req, nil
+}
+
+func stringOrEnv(val types.String, env string) string {
+	if isSet(val) {
+		return val.ValueString()
+	}
+	return os.Getenv(env)
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestAzureADAuthClientCredentials(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("token request path = %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.PostForm.Get("scope"); got != azurePostgresResource+"/.default" {
+			t.Errorf("scope = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"sp-token"}`))
+	}))
+	defer srv.Close()
+
+	auth, err := newAzureADAuth(&azureADAuthModel{
+		TenantID:     types.StringValue("tenant"),
+		ClientID:     types.StringValue("client"),
+		ClientSecret: types.StringValue("secret"),
+	})
+	if err != nil {
+		t.Fatalf("newAzureADAuth() error = %v", err)
+	}
+	auth.authorityHost = srv.URL
+
+	for i := 0; i < 2; i++ {
+		cc := &pgx.ConnConfig{}
+		if err := auth.beforeConnect(context.Background(), cc); err != nil {
+			t.Fatalf("beforeConnect() error = %v", err)
+		}
+		if cc.Password != "sp-token" {
+			t.Errorf("password = %q, want sp-token", cc.Password)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("token requests = %d, want 1 (cached)", requests)
+	}
+}
+
+func TestAzureADAuthManagedIdentity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			t.Error("missing Metadata header")
+		}
+		if got := r.URL.Query().Get("resource"); got != azurePostgresResource {
+			t.Errorf("resource = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"mi-token","expires_in":"86399"}`))
+	}))
+	defer srv.Close()
+
+	auth, err := newAzureADAuth(&azureADAuthModel{UseManagedIdentity: types.BoolValue(true)})
+	if err != nil {
+		t.Fatalf("newAzureADAuth() error = %v", err)
+	}
+	auth.imdsEndpoint = srv.URL
+
+	token, err := auth.accessToken(context.Background())
+	if err != nil {
+		t.Fatalf("accessToken() error = %v", err)
+	}
+	if token != "mi-token" {
+		t.Errorf("accessToken() = %q, want mi-token", token)
+	}
+}
+
+func TestNewAzureADAuthRequiresCredentials(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+
+	if _, err := newAzureADAuth(&azureADAuthModel{}); err == nil {
+		t.Error("newAzureADAuth() should require a service principal or managed identity")
+	}
+}
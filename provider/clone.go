@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// clonedValues are the partitioning attributes clone_from copies from the
+// source queue where the configuration doesn't set them
+func (m queueModel) clonedValues() map[string]attr.Value {
+	return map[string]attr.Value{
+		"enable_partitioning": m.EnablePartitioning,
+		"partitioning_mode":   m.PartitioningMode,
+		"partition_interval":  m.PartitionInterval,
+		"partition_premake":   m.PartitionPremake,
+		"retention_period":    m.RetentionPeriod,
+		"datetime_string":     m.DatetimeString,
+		"optimize_constraint": m.OptimizeConstraint,
+		"default_partition":   m.DefaultPartition,
+	}
+}
+
+// cloneSource reads the partitioning of the clone_from queue. Natively
+// partitioned queues keep their settings in the provider's state, so only
+// enable_partitioning and partitioning_mode are known for them.
+func (r *queueResource) cloneSource(ctx context.Context, source pgq.FQN) (queueModel, error) {
+	var m queueModel
+
+	schema, name, err := source.Split()
+	if err != nil {
+		return m, err
+	}
+
+	q, err := r.mgr.Get(ctx, schema, name)
+	if err != nil {
+		return m, err
+	}
+	m.EnablePartitioning = types.BoolValue(q.Partitioned)
+	if !q.Partitioned {
+		return m, nil
+	}
+
+	cfg, err := r.mgr.GetPartitionConfig(ctx, schema, name)
+	if notPartman(err) {
+		m.PartitioningMode = types.StringValue(partitioningNative)
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+
+	m.PartitioningMode = types.StringValue(partitioningPartman)
+	if q.Strategy == pgq.PartitionRange {
+		m.PartitionInterval = types.StringValue(cfg.Interval)
+		m.RetentionPeriod = types.StringValue(cfg.Retention)
+		m.DatetimeString = types.StringValue(cfg.DatetimeString)
+	}
+	m.PartitionPremake = types.Int64Value(int64(cfg.Premake))
+	m.OptimizeConstraint = types.Int64Value(int64(cfg.OptimizeConstraint))
+	m.DefaultPartition = types.BoolValue(cfg.DefaultPartition)
+	return m, nil
+}
+
+// planClone plans the partitioning attributes the configuration leaves
+// unset from the clone_from queue when the queue is created. Later plans
+// keep them at their state values, so a clone neither follows its source
+// nor falls back to the schema defaults.
+func (r *queueResource) planClone(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var source types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("clone_from"), &source)...)
+	if resp.Diagnostics.HasError() || !isSet(source) {
+		return
+	}
+
+	var configured map[string]tftypes.Value
+	if err := req.Config.Raw.As(&configured); err != nil {
+		resp.Diagnostics.AddError("Failed to read configuration", err.Error())
+		return
+	}
+
+	var from queueModel
+	if req.State.Raw.IsNull() {
+		var endpoint *endpointModel
+		resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("endpoint"), &endpoint)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if r.mgr == nil || !endpoint.known() {
+			resp.Diagnostics.AddAttributeError(path.Root("clone_from"), "Queue to clone can't be read",
+				"clone_from is read while planning, which needs the provider configuration and endpoint to be known.")
+			return
+		}
+		scoped, err := r.atEndpoint(ctx, endpoint)
+		if err != nil {
+			tflog.Warn(ctx, "failed to connect to endpoint", map[string]any{"error": err})
+			return
+		}
+		from, err = scoped.cloneSource(ctx, pgq.FQN(source.ValueString()))
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("clone_from"), "Failed to read the queue to clone",
+				fmt.Sprintf("Reading %s: %s", source.ValueString(), errorDetail(err)))
+			return
+		}
+	} else if diags := req.State.Get(ctx, &from); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	for attribute, val := range from.clonedValues() {
+		if val.IsNull() || !configured[attribute].IsNull() {
+			continue
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribute), val)...)
+		// unchanged from state, so not replaced either
+		resp.RequiresReplace = slices.DeleteFunc(resp.RequiresReplace, func(p path.Path) bool {
+			return !req.State.Raw.IsNull() && p.Equal(path.Root(attribute))
+		})
+	}
+}
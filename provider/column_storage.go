@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// columnStorageModel is the TOAST setting of a pgq_queue column
+type columnStorageModel struct {
+	Column      types.String `tfsdk:"column"`
+	Compression types.String `tfsdk:"compression"`
+	Storage     types.String `tfsdk:"storage"`
+}
+
+// columnStorageBlock is the schema of the column_storage blocks of
+// pgq_queue
+func columnStorageBlock() schema.SetNestedBlock {
+	return schema.SetNestedBlock{
+		Description: "TOAST compression and storage of large columns such as payload, metadata and error_detail",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"column": schema.StringAttribute{
+					Description: "Column name, built-in or extra",
+					Required:    true,
+					Validators:  []validator.String{identifierValidator()},
+				},
+				"compression": schema.StringAttribute{
+					Description: "Compression method of new values: lz4 (PostgreSQL 14 or later, built with lz4) or pglz; default_toast_compression if unset",
+					Optional:    true,
+					Validators:  []validator.String{stringvalidator.OneOf("lz4", "pglz")},
+				},
+				"storage": schema.StringAttribute{
+					Description: "Storage mode: extended (compressed, out of line), external (out of line, uncompressed) or main (compressed, in line); the type's default if unset",
+					Optional:    true,
+					Validators:  []validator.String{stringvalidator.OneOf("extended", "external", "main")},
+				},
+			},
+		},
+	}
+}
+
+func columnStorageObjectType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"column":      types.StringType,
+			"compression": types.StringType,
+			"storage":     types.StringType,
+		},
+	}
+}
+
+// columnStorage returns the column_storage blocks as pgq settings
+func (m queueModel) columnStorage(ctx context.Context) ([]pgq.ColumnStorage, diag.Diagnostics) {
+	var models []columnStorageModel
+	if m.ColumnStorage.IsNull() || m.ColumnStorage.IsUnknown() {
+		return nil, nil
+	}
+	if diags := m.ColumnStorage.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, diags
+	}
+
+	settings := make([]pgq.ColumnStorage, len(models))
+	for i, s := range models {
+		settings[i] = pgq.ColumnStorage{
+			Column:      s.Column.ValueString(),
+			Compression: s.Compression.ValueString(),
+			Storage:     s.Storage.ValueString(),
+		}
+	}
+	return settings, nil
+}
+
+// readColumnStorage refreshes the configured column_storage blocks from
+// the table. Settings left unset stay unset, whatever the column uses, and
+// columns that no longer exist drop out of state.
+func (r *queueResource) readColumnStorage(ctx context.Context, m *queueModel) diag.Diagnostics {
+	configured, diags := m.columnStorage(ctx)
+	if diags.HasError() || len(configured) == 0 {
+		return diags
+	}
+
+	columns := make([]string, len(configured))
+	for i, s := range configured {
+		columns[i] = s.Column
+	}
+
+	actual, err := r.mgr.GetColumnStorage(ctx, pgq.SchemaName(m.Schema.ValueString()), pgq.QueueName(m.Name.ValueString()), columns)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read column storage", map[string]any{"error": err})
+		return diags
+	}
+
+	byColumn := make(map[string]pgq.ColumnStorage, len(actual))
+	for _, s := range actual {
+		byColumn[s.Column] = s
+	}
+
+	var models []columnStorageModel
+	for _, s := range configured {
+		got, ok := byColumn[s.Column]
+		if !ok {
+			continue
+		}
+		model := columnStorageModel{Column: types.StringValue(s.Column), Compression: types.StringNull(), Storage: types.StringNull()}
+		if s.Compression != "" {
+			model.Compression = stringOrNull(got.Compression)
+		}
+		if s.Storage != "" {
+			model.Storage = stringOrNull(got.Storage)
+		}
+		models = append(models, model)
+	}
+
+	if len(models) == 0 {
+		m.ColumnStorage = types.SetNull(columnStorageObjectType())
+		return diags
+	}
+	m.ColumnStorage, diags = types.SetValueFrom(ctx, columnStorageObjectType(), models)
+	return diags
+}
+
+// applyColumnStorage changes the TOAST settings from state to plan
+func (r *queueResource) applyColumnStorage(ctx context.Context, plan, state queueModel) diag.Diagnostics {
+	if plan.ColumnStorage.Equal(state.ColumnStorage) {
+		return nil
+	}
+
+	from, diags := state.columnStorage(ctx)
+	to, d := plan.columnStorage(ctx)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := r.mgr.SetColumnStorage(ctx, pgq.SchemaName(plan.Schema.ValueString()), pgq.QueueName(plan.Name.ValueString()), from, to); err != nil {
+		errorDiag(&diags, "Failed to set column storage", err)
+	}
+	return diags
+}
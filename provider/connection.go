@@ -0,0 +1,339 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// connParam is a libpq connection parameter added on top of the base
+// connection string
+type connParam struct {
+	key   string
+	value string
+}
+
+// poolConfig parses the connection settings into a pool config
+func (p *pgqProvider) poolConfig(cfg config) (*pgxpool.Config, error) {
+	connStr := withParams(p.buildConnString(cfg), cfg.connParams())
+
+	poolCfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// pgx reads the other PG* variables for URIs and services itself, but
+	// not PGOPTIONS, which libpq applies unless the string sets options
+	if _, ok := poolCfg.ConnConfig.RuntimeParams["options"]; !ok {
+		if v := os.Getenv("PGOPTIONS"); v != "" {
+			poolCfg.ConnConfig.RuntimeParams["options"] = v
+		}
+	}
+
+	// application_name from the connection string or PGAPPNAME wins over
+	// the default
+	if poolCfg.ConnConfig.RuntimeParams["application_name"] == "" {
+		poolCfg.ConnConfig.RuntimeParams["application_name"] = p.applicationName()
+	}
+
+	if err := applyTLSPEM(poolCfg, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := applyPoolSettings(poolCfg, cfg); err != nil {
+		return nil, err
+	}
+
+	// transaction pooling hands each transaction to any server connection,
+	// where statements prepared on another one don't exist
+	if cfg.PgBouncerCompatible.ValueBool() {
+		poolCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+		poolCfg.ConnConfig.StatementCacheCapacity = 0
+		poolCfg.ConnConfig.DescriptionCacheCapacity = 0
+	}
+
+	// a server-side backstop for read_only, which resources enforce first
+	if cfg.ReadOnly.ValueBool() {
+		poolCfg.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+
+	if cfg.LogSQL.ValueBool() {
+		poolCfg.ConnConfig.Tracer = sqlTracer{}
+	}
+
+	if isSet(cfg.AssumeRole) {
+		setRole(poolCfg, cfg.AssumeRole.ValueString())
+	}
+
+	if params := cfg.sessionParameters(); len(params) > 0 {
+		setSessionParameters(poolCfg, params)
+	}
+
+	if cfg.AWSRDSIAMAuth != nil {
+		auth, err := newRDSIAMAuth(cfg.AWSRDSIAMAuth)
+		if err != nil {
+			return nil, err
+		}
+		auth.install(poolCfg)
+	}
+
+	if cfg.AzureADAuth != nil {
+		auth, err := newAzureADAuth(cfg.AzureADAuth)
+		if err != nil {
+			return nil, err
+		}
+		auth.install(poolCfg)
+	}
+
+	if cfg.GSSAPI != nil {
+		auth, err := newGSSAPIAuth(cfg.GSSAPI)
+		if err != nil {
+			return nil, err
+		}
+		auth.install(poolCfg)
+	}
+
+	k, err := newKeepalive(poolCfg, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if k != nil {
+		k.install(poolCfg)
+	}
+
+	if proxyURL := valOrEnv(cfg.ProxyURL, "PGQ_PROXY_URL", ""); proxyURL != "" && cfg.SSHTunnel == nil {
+		d, err := newProxyDialer(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		d.install(poolCfg)
+	}
+
+	if cfg.SSHTunnel != nil {
+		tunnel, err := newSSHTunnel(cfg.SSHTunnel)
+		if err != nil {
+			return nil, err
+		}
+		tunnel.install(poolCfg)
+	}
+
+	return poolCfg, nil
+}
+
+// afterConnect runs fn on every new connection, after the functions
+// added before it
+func afterConnect(poolCfg *pgxpool.Config, fn func(context.Context, *pgx.Conn) error) {
+	prev := poolCfg.AfterConnect
+	if prev == nil {
+		poolCfg.AfterConnect = fn
+		return
+	}
+	poolCfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		if err := prev(ctx, conn); err != nil {
+			return err
+		}
+		return fn(ctx, conn)
+	}
+}
+
+// setRole switches every new connection to role, so the objects the
+// provider creates are owned by it rather than by the login user
+func setRole(poolCfg *pgxpool.Config, role string) {
+	stmt := "SET ROLE " + pgx.Identifier{role}.Sanitize()
+	afterConnect(poolCfg, func(ctx context.Context, conn *pgx.Conn) error {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("assume_role %s: %w", role, err)
+		}
+		return nil
+	})
+}
+
+// setSessionParameters sets the session_parameters on every new
+// connection, in name order. set_config takes the values as they would be
+// written after SET name TO, without quoting.
+func setSessionParameters(poolCfg *pgxpool.Config, params map[string]string) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	afterConnect(poolCfg, func(ctx context.Context, conn *pgx.Conn) error {
+		for _, name := range names {
+			if _, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", name, params[name]); err != nil {
+				return fmt.Errorf("session_parameters %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// sessionParameters returns the known session_parameters
+func (c config) sessionParameters() map[string]string {
+	params := make(map[string]string)
+	for name, v := range c.SessionParameters.Elements() {
+		if s, ok := v.(types.String); ok && isSet(s) {
+			params[name] = s.ValueString()
+		}
+	}
+	return params
+}
+
+// applicationName identifies the provider's sessions in pg_stat_activity
+func (p *pgqProvider) applicationName() string {
+	return "terraform-provider-pgq/" + p.version
+}
+
+// connParams returns the optional parameters that are configured
+func (c config) connParams() []connParam {
+	var params []connParam
+	for _, p := range []struct {
+		key string
+		val types.String
+	}{
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+		{"sslrootcert", c.SSLRootCert},
+		{"application_name", c.ApplicationName},
+		{"target_session_attrs", c.TargetSessionAttrs},
+	} {
+		if isSet(p.val) {
+			params = append(params, connParam{p.key, p.val.ValueString()})
+		}
+	}
+	return params
+}
+
+// withParams adds params to either a URI or a keyword/value connection string
+func withParams(connStr string, params []connParam) string {
+	if len(params) == 0 {
+		return connStr
+	}
+
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		if u, err := url.Parse(connStr); err == nil {
+			q := u.Query()
+			for _, p := range params {
+				q.Set(p.key, p.value)
+			}
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(connStr)
+	for _, p := range params {
+		sb.WriteString(" ")
+		sb.WriteString(p.key)
+		sb.WriteString("=")
+		sb.WriteString(quoteConnValue(p.value))
+	}
+	return sb.String()
+}
+
+// quoteConnValue quotes a keyword/value connection string value
+func quoteConnValue(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
+}
+
+// applyTLSPEM installs the inline PEM client certificate and root CA on
+// every TLS config pgx derived from sslmode, including fallbacks
+func applyTLSPEM(poolCfg *pgxpool.Config, cfg config) error {
+	certPEM := cfg.SSLClientCertPEM.ValueString()
+	keyPEM := cfg.SSLClientKeyPEM.ValueString()
+	rootPEM := cfg.SSLRootCertPEM.ValueString()
+
+	if certPEM == "" && keyPEM == "" && rootPEM == "" {
+		return nil
+	}
+
+	var cert *tls.Certificate
+	if certPEM != "" || keyPEM != "" {
+		pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cert = &pair
+	}
+
+	var roots *x509.CertPool
+	if rootPEM != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(rootPEM)) {
+			return errors.New("invalid root certificate: no PEM certificates found")
+		}
+	}
+
+	tlsConfigs := []*tls.Config{poolCfg.ConnConfig.TLSConfig}
+	for _, fb := range poolCfg.ConnConfig.Fallbacks {
+		tlsConfigs = append(tlsConfigs, fb.TLSConfig)
+	}
+
+	applied := false
+	for _, tc := range tlsConfigs {
+		if tc == nil {
+			continue
+		}
+		if cert != nil {
+			tc.Certificates = []tls.Certificate{*cert}
+		}
+		if roots != nil {
+			tc.RootCAs = roots
+		}
+		applied = true
+	}
+
+	if !applied {
+		return errors.New("TLS certificates are configured but sslmode disables TLS")
+	}
+
+	return nil
+}
+
+// applyPoolSettings overrides the pgxpool defaults that are configured
+func applyPoolSettings(poolCfg *pgxpool.Config, cfg config) error {
+	if !cfg.MaxConnections.IsNull() {
+		poolCfg.MaxConns = int32(cfg.MaxConnections.ValueInt64())
+	}
+	if !cfg.MinConnections.IsNull() {
+		poolCfg.MinConns = int32(cfg.MinConnections.ValueInt64())
+	}
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return fmt.Errorf("min_connections (%d) exceeds max_connections (%d)", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+
+	for _, d := range []struct {
+		name   string
+		val    types.String
+		target *time.Duration
+	}{
+		{"max_conn_lifetime", cfg.MaxConnLifetime, &poolCfg.MaxConnLifetime},
+		{"max_conn_idle_time", cfg.MaxConnIdleTime, &poolCfg.MaxConnIdleTime},
+		{"health_check_period", cfg.HealthCheckPeriod, &poolCfg.HealthCheckPeriod},
+		{"connect_timeout", cfg.ConnectTimeout, &poolCfg.ConnConfig.ConnectTimeout},
+	} {
+		if !isSet(d.val) {
+			continue
+		}
+		dur, err := time.ParseDuration(d.val.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.target = dur
+	}
+
+	return nil
+}
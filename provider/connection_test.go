@@ -0,0 +1,377 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestWithParams(t *testing.T) {
+	params := []connParam{{"sslcert", "/etc/pg/client.crt"}, {"sslkey", "/etc/pg/it's.key"}}
+
+	tests := []struct {
+		name    string
+		connStr string
+		want    string
+	}{
+		{
+			name:    "keyword/value",
+			connStr: "host=localhost",
+			want:    `host=localhost sslcert='/etc/pg/client.crt' sslkey='/etc/pg/it\'s.key'`,
+		},
+		{
+			name:    "uri",
+			connStr: "postgres://app@db:5432/app?sslmode=verify-full",
+			want:    "postgres://app@db:5432/app?sslcert=%2Fetc%2Fpg%2Fclient.crt&sslkey=%2Fetc%2Fpg%2Fit%27s.key&sslmode=verify-full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withParams(tt.connStr, params); got != tt.want {
+				t.Errorf("withParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolConfigTLSPEM(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	p := &pgqProvider{}
+
+	cfg := config{
+		Host:             types.StringValue("db.example.com"),
+		SSLMode:          types.StringValue("verify-full"),
+		SSLClientCertPEM: types.StringValue(certPEM),
+		SSLClientKeyPEM:  types.StringValue(keyPEM),
+		SSLRootCertPEM:   types.StringValue(certPEM),
+	}
+
+	poolCfg, err := p.poolConfig(cfg)
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+
+	tc := poolCfg.ConnConfig.TLSConfig
+	if tc == nil || len(tc.Certificates) != 1 || tc.RootCAs == nil {
+		t.Errorf("poolConfig() TLS config = %+v, want client certificate and root CAs", tc)
+	}
+
+	cfg.SSLMode = types.StringValue("disable")
+	if _, err := p.poolConfig(cfg); err == nil {
+		t.Error("poolConfig() should reject certificates with sslmode=disable")
+	}
+}
+
+func testCertificate(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pgq"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestPoolConfigPoolSettings(t *testing.T) {
+	p := &pgqProvider{}
+
+	cfg := config{
+		MaxConnections:    types.Int64Value(20),
+		MinConnections:    types.Int64Value(2),
+		MaxConnLifetime:   types.StringValue("15m"),
+		MaxConnIdleTime:   types.StringValue("1m"),
+		HealthCheckPeriod: types.StringValue("10s"),
+		ConnectTimeout:    types.StringValue("5s"),
+	}
+
+	poolCfg, err := p.poolConfig(cfg)
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+
+	if poolCfg.MaxConns != 20 || poolCfg.MinConns != 2 {
+		t.Errorf("pool size = %d..%d, want 2..20", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+	if poolCfg.MaxConnLifetime != 15*time.Minute || poolCfg.MaxConnIdleTime != time.Minute || poolCfg.HealthCheckPeriod != 10*time.Second {
+		t.Errorf("pool durations = %v/%v/%v", poolCfg.MaxConnLifetime, poolCfg.MaxConnIdleTime, poolCfg.HealthCheckPeriod)
+	}
+	if poolCfg.ConnConfig.ConnectTimeout != 5*time.Second {
+		t.Errorf("ConnectTimeout = %v, want 5s", poolCfg.ConnConfig.ConnectTimeout)
+	}
+
+	cfg.MinConnections = types.Int64Value(50)
+	if _, err := p.poolConfig(cfg); err == nil {
+		t.Error("poolConfig() should reject min_connections above max_connections")
+	}
+}
+
+func TestPoolConfigApplicationName(t *testing.T) {
+	t.Setenv("PGAPPNAME", "")
+	p := &pgqProvider{version: "1.2.3"}
+
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["application_name"]; got != "terraform-provider-pgq/1.2.3" {
+		t.Errorf("default application_name = %q", got)
+	}
+
+	poolCfg, err = p.poolConfig(config{
+		Host:            types.StringValue("localhost"),
+		ApplicationName: types.StringValue("ci deploy"),
+	})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["application_name"]; got != "ci deploy" {
+		t.Errorf("application_name = %q, want %q", got, "ci deploy")
+	}
+
+	poolCfg, err = p.poolConfig(config{ConnectionString: types.StringValue("postgres://localhost/db?application_name=from_uri")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["application_name"]; got != "from_uri" {
+		t.Errorf("application_name = %q, want the connection string's", got)
+	}
+}
+
+func TestPoolConfigEnvParams(t *testing.T) {
+	t.Setenv("PGAPPNAME", "from_env")
+	t.Setenv("PGCONNECT_TIMEOUT", "7")
+	t.Setenv("PGOPTIONS", "-c statement_timeout=0")
+	p := &pgqProvider{}
+
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["application_name"]; got != "from_env" {
+		t.Errorf("application_name = %q, want PGAPPNAME", got)
+	}
+	if got := poolCfg.ConnConfig.ConnectTimeout; got != 7*time.Second {
+		t.Errorf("ConnectTimeout = %v, want PGCONNECT_TIMEOUT", got)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["options"]; got != "-c statement_timeout=0" {
+		t.Errorf("options = %q, want PGOPTIONS", got)
+	}
+
+	poolCfg, err = p.poolConfig(config{
+		Host:            types.StringValue("localhost"),
+		ApplicationName: types.StringValue("ci deploy"),
+		ConnectTimeout:  types.StringValue("2s"),
+	})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["application_name"]; got != "ci deploy" {
+		t.Errorf("application_name = %q, want the attribute over PGAPPNAME", got)
+	}
+	if got := poolCfg.ConnConfig.ConnectTimeout; got != 2*time.Second {
+		t.Errorf("ConnectTimeout = %v, want the attribute over PGCONNECT_TIMEOUT", got)
+	}
+
+	poolCfg, err = p.poolConfig(config{ConnectionString: types.StringValue("postgres://localhost/db")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["options"]; got != "-c statement_timeout=0" {
+		t.Errorf("options = %q, want PGOPTIONS for connection strings too", got)
+	}
+
+	poolCfg, err = p.poolConfig(config{ConnectionString: types.StringValue("postgres://localhost/db?options=-cwork_mem%3D64MB")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["options"]; got != "-cwork_mem=64MB" {
+		t.Errorf("options = %q, want the connection string's over PGOPTIONS", got)
+	}
+}
+
+func TestPoolConfigAssumeRole(t *testing.T) {
+	p := &pgqProvider{}
+
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if poolCfg.AfterConnect != nil {
+		t.Error("AfterConnect should be unset without assume_role")
+	}
+
+	poolCfg, err = p.poolConfig(config{Host: types.StringValue("localhost"), AssumeRole: types.StringValue("pgq_owner")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if poolCfg.AfterConnect == nil {
+		t.Error("AfterConnect should set the role with assume_role")
+	}
+}
+
+func TestPoolConfigSessionParameters(t *testing.T) {
+	p := &pgqProvider{}
+
+	params := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"search_path":          types.StringValue("queues, public"),
+		"maintenance_work_mem": types.StringValue("1GB"),
+	})
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost"), SessionParameters: params})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if poolCfg.AfterConnect == nil {
+		t.Error("AfterConnect should set the session_parameters")
+	}
+}
+
+func TestAfterConnect(t *testing.T) {
+	poolCfg, err := pgxpool.ParseConfig("host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	step := func(name string, fail bool) func(context.Context, *pgx.Conn) error {
+		return func(context.Context, *pgx.Conn) error {
+			calls = append(calls, name)
+			if fail {
+				return errors.New(name + " failed")
+			}
+			return nil
+		}
+	}
+
+	afterConnect(poolCfg, step("role", false))
+	afterConnect(poolCfg, step("params", false))
+	if err := poolCfg.AfterConnect(context.Background(), nil); err != nil {
+		t.Fatalf("AfterConnect() error = %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "role,params" {
+		t.Errorf("calls = %s, want role,params", got)
+	}
+
+	calls = nil
+	poolCfg.AfterConnect = nil
+	afterConnect(poolCfg, step("role", true))
+	afterConnect(poolCfg, step("params", false))
+	if err := poolCfg.AfterConnect(context.Background(), nil); err == nil {
+		t.Error("AfterConnect() should fail with the first function")
+	}
+	if got := strings.Join(calls, ","); got != "role" {
+		t.Errorf("calls = %s, want only role", got)
+	}
+}
+
+func TestPoolConfigReadOnly(t *testing.T) {
+	p := &pgqProvider{}
+
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost"), ReadOnly: types.BoolValue(true)})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.RuntimeParams["default_transaction_read_only"]; got != "on" {
+		t.Errorf("default_transaction_read_only = %q, want on", got)
+	}
+}
+
+func TestPoolConfigTargetSessionAttrs(t *testing.T) {
+	p := &pgqProvider{}
+
+	poolCfg, err := p.poolConfig(config{
+		Host:               types.StringValue("db1,db2"),
+		SSLMode:            types.StringValue("disable"),
+		TargetSessionAttrs: types.StringValue("read-write"),
+	})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if poolCfg.ConnConfig.Host != "db1" || len(poolCfg.ConnConfig.Fallbacks) != 1 || poolCfg.ConnConfig.Fallbacks[0].Host != "db2" {
+		t.Errorf("hosts = %s + %d fallbacks, want db1 then db2", poolCfg.ConnConfig.Host, len(poolCfg.ConnConfig.Fallbacks))
+	}
+	if poolCfg.ConnConfig.ValidateConnect == nil {
+		t.Error("ValidateConnect is nil, want a read-write check")
+	}
+}
+
+func TestPoolConfigPgBouncerCompatible(t *testing.T) {
+	p := &pgqProvider{}
+
+	poolCfg, err := p.poolConfig(config{Host: types.StringValue("localhost"), PgBouncerCompatible: types.BoolValue(true)})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if got := poolCfg.ConnConfig.DefaultQueryExecMode; got != pgx.QueryExecModeSimpleProtocol {
+		t.Errorf("DefaultQueryExecMode = %v, want simple protocol", got)
+	}
+	if poolCfg.ConnConfig.StatementCacheCapacity != 0 || poolCfg.ConnConfig.DescriptionCacheCapacity != 0 {
+		t.Errorf("statement caches = %d/%d, want disabled",
+			poolCfg.ConnConfig.StatementCacheCapacity, poolCfg.ConnConfig.DescriptionCacheCapacity)
+	}
+}
+
+func TestPoolConfigServiceAndPassfile(t *testing.T) {
+	dir := t.TempDir()
+	serviceFile := filepath.Join(dir, "pg_service.conf")
+	passFile := filepath.Join(dir, "pgpass")
+
+	if err := os.WriteFile(serviceFile, []byte("[queues]\nhost=db.example.com\nport=6543\ndbname=app\nuser=svc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passFile, []byte("db.example.com:6543:app:svc:from-pgpass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PGSERVICEFILE", serviceFile)
+	t.Setenv("PGPASSFILE", passFile)
+	t.Setenv("PGPASSWORD", "")
+
+	p := &pgqProvider{}
+	poolCfg, err := p.poolConfig(config{Service: types.StringValue("queues"), SSLMode: types.StringValue("disable")})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+
+	cc := poolCfg.ConnConfig
+	if cc.Host != "db.example.com" || cc.Port != 6543 || cc.Database != "app" || cc.User != "svc" {
+		t.Errorf("connection = %s@%s:%d/%s, want svc@db.example.com:6543/app", cc.User, cc.Host, cc.Port, cc.Database)
+	}
+	if cc.Password != "from-pgpass" {
+		t.Errorf("password = %q, want the pgpass entry", cc.Password)
+	}
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = (*fleetHealthDataSource)(nil)
+	_ datasource.DataSourceWithConfigure = (*fleetHealthDataSource)(nil)
+)
+
+type (
+	fleetHealthDataSource struct {
+		mgr *pgq.Manager
+	}
+
+	fleetHealthModel struct {
+		ID                       types.String `tfsdk:"id"`
+		Schema                   types.String `tfsdk:"schema"`
+		QueueCount               types.Int64  `tfsdk:"queue_count"`
+		TotalBacklog             types.Int64  `tfsdk:"total_backlog"`
+		QueuesWithDefaultRows    types.List   `tfsdk:"queues_with_default_rows"`
+		QueuesMissingMaintenance types.List   `tfsdk:"queues_missing_maintenance"`
+		InvalidIndexes           types.List   `tfsdk:"invalid_indexes"`
+	}
+)
+
+func NewFleetHealthDataSource() datasource.DataSource {
+	return &fleetHealthDataSource{}
+}
+
+func (d *fleetHealthDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_fleet_health"
+}
+
+func (d *fleetHealthDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Health summary of every pgq queue in the database",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Schema inspected, or * for all schemas",
+				Computed:    true,
+			},
+			"schema": schema.StringAttribute{
+				Description: "Only inspect queues in this schema. Default: all schemas",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"queue_count": schema.Int64Attribute{
+				Description: "Number of pgq queues found",
+				Computed:    true,
+			},
+			"total_backlog": schema.Int64Attribute{
+				Description: "Unprocessed messages across all queues",
+				Computed:    true,
+			},
+			"queues_with_default_rows": schema.ListAttribute{
+				Description: "Partitioned queues whose default partition holds rows",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"queues_missing_maintenance": schema.ListAttribute{
+				Description: "pg_partman managed queues with automatic maintenance off",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"invalid_indexes": schema.ListAttribute{
+				Description: "Invalid indexes on queues or their partitions (schema.index)",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *fleetHealthDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	d.mgr = data.mgr
+}
+
+func (d *fleetHealthDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data fleetHealthModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	schemaName := pgq.SchemaName(data.Schema.ValueString())
+
+	health, err := d.mgr.FleetHealth(ctx, schemaName)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to inspect queues", err)
+		return
+	}
+
+	data.ID = types.StringValue("*")
+	if schemaName != "" {
+		data.ID = types.StringValue(schemaName.String())
+	}
+	data.QueueCount = types.Int64Value(int64(health.Queues))
+	data.TotalBacklog = types.Int64Value(health.TotalBacklog)
+
+	for _, item := range []struct {
+		target *types.List
+		fqns   []pgq.FQN
+	}{
+		{&data.QueuesWithDefaultRows, health.QueuesWithDefaultRows},
+		{&data.QueuesMissingMaintenance, health.QueuesMissingMaintenance},
+		{&data.InvalidIndexes, health.InvalidIndexes},
+	} {
+		list, diags := types.ListValueFrom(ctx, types.StringType, fqnStrings(item.fqns))
+		resp.Diagnostics.Append(diags...)
+		*item.target = list
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func fqnStrings(fqns []pgq.FQN) []string {
+	out := make([]string, len(fqns))
+	for i, fqn := range fqns {
+		out[i] = fqn.String()
+	}
+	return out
+}
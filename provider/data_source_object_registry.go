@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = (*objectRegistryDataSource)(nil)
+	_ datasource.DataSourceWithConfigure = (*objectRegistryDataSource)(nil)
+)
+
+type (
+	objectRegistryDataSource struct {
+		mgr      *pgq.Manager
+		registry *objectRegistry
+	}
+
+	objectRegistryDataModel struct {
+		ID           types.String          `tfsdk:"id"`
+		Schema       types.String          `tfsdk:"schema"`
+		ResourceType types.String          `tfsdk:"resource_type"`
+		Owner        types.String          `tfsdk:"owner"`
+		Objects      []registryObjectModel `tfsdk:"objects"`
+	}
+
+	registryObjectModel struct {
+		ObjectType   types.String `tfsdk:"object_type"`
+		ObjectName   types.String `tfsdk:"object_name"`
+		ResourceType types.String `tfsdk:"resource_type"`
+		ResourceID   types.String `tfsdk:"resource_id"`
+		Owner        types.String `tfsdk:"owner"`
+		RecordedAt   types.String `tfsdk:"recorded_at"`
+	}
+)
+
+func NewObjectRegistryDataSource() datasource.DataSource {
+	return &objectRegistryDataSource{}
+}
+
+func (d *objectRegistryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_object_registry"
+}
+
+func (d *objectRegistryDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the objects recorded in the pgq_terraform_registry table, e.g. to reconcile lost or split states",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Fully qualified name of the registry table",
+				Computed:    true,
+			},
+			"schema": schema.StringAttribute{
+				Description: "Schema of the registry table. Default: the provider's object_registry schema, or public",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"resource_type": schema.StringAttribute{
+				Description: "Only list objects owned by resources of this type, e.g. 'pgq_queue'",
+				Optional:    true,
+			},
+			"owner": schema.StringAttribute{
+				Description: "Only list objects recorded with this owner label",
+				Optional:    true,
+			},
+			"objects": schema.ListNestedAttribute{
+				Description: "Recorded objects",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"object_type": schema.StringAttribute{
+							Description: "table, index, view, function, trigger, cron_job or partman_config",
+							Computed:    true,
+						},
+						"object_name": schema.StringAttribute{
+							Description: "Schema-qualified object name; triggers are named 'trigger ON table', cron jobs by job name",
+							Computed:    true,
+						},
+						"resource_type": schema.StringAttribute{
+							Description: "Type of the owning resource",
+							Computed:    true,
+						},
+						"resource_id": schema.StringAttribute{
+							Description: "ID of the owning resource",
+							Computed:    true,
+						},
+						"owner": schema.StringAttribute{
+							Description: "Owner label of the configuration that recorded the object",
+							Computed:    true,
+						},
+						"recorded_at": schema.StringAttribute{
+							Description: "When the object was last recorded (RFC 3339)",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *objectRegistryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	d.mgr = data.mgr
+	d.registry = data.registry
+}
+
+func (d *objectRegistryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data objectRegistryDataModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	schemaName := pgq.SchemaName(defaultRegistrySchema)
+	if isSet(data.Schema) {
+		schemaName = pgq.SchemaName(data.Schema.ValueString())
+	} else if d.registry != nil {
+		schemaName = d.registry.schema
+	}
+
+	entries, err := d.mgr.ListRegistry(ctx, schemaName)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to read object registry", err)
+		return
+	}
+
+	data.ID = types.StringValue(pgq.MakeFQN(schemaName, pgq.RegistryTable).String())
+	data.Objects = make([]registryObjectModel, 0, len(entries))
+	for _, e := range entries {
+		if isSet(data.ResourceType) && e.ResourceType != data.ResourceType.ValueString() {
+			continue
+		}
+		if isSet(data.Owner) && e.Owner != data.Owner.ValueString() {
+			continue
+		}
+		data.Objects = append(data.Objects, registryObjectModel{
+			ObjectType:   types.StringValue(e.ObjectType),
+			ObjectName:   types.StringValue(e.ObjectName),
+			ResourceType: types.StringValue(e.ResourceType),
+			ResourceID:   types.StringValue(e.ResourceID),
+			Owner:        types.StringValue(e.Owner),
+			RecordedAt:   types.StringValue(e.RecordedAt.UTC().Format(time.RFC3339)),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = (*queuesDataSource)(nil)
+	_ datasource.DataSourceWithConfigure = (*queuesDataSource)(nil)
+)
+
+type (
+	queuesDataSource struct {
+		mgr *pgq.Manager
+	}
+
+	queuesModel struct {
+		ID     types.String        `tfsdk:"id"`
+		Schema types.String        `tfsdk:"schema"`
+		IDs    types.List          `tfsdk:"ids"`
+		Queues []queueSummaryModel `tfsdk:"queues"`
+	}
+
+	queueSummaryModel struct {
+		ID          types.String `tfsdk:"id"`
+		Schema      types.String `tfsdk:"schema"`
+		Name        types.String `tfsdk:"name"`
+		Partitioned types.Bool   `tfsdk:"partitioned"`
+	}
+)
+
+func NewQueuesDataSource() datasource.DataSource {
+	return &queuesDataSource{}
+}
+
+func (d *queuesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_queues"
+}
+
+func (d *queuesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the pgq queues in the database, e.g. to generate import blocks",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Schema listed, or * for all schemas",
+				Computed:    true,
+			},
+			"schema": schema.StringAttribute{
+				Description: "Only list queues in this schema. Default: all schemas",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"ids": schema.ListAttribute{
+				Description: "Fully qualified names (schema.name) of the queues, usable as pgq_queue import IDs",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"queues": schema.ListNestedAttribute{
+				Description: "Queues found",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Description: "Fully qualified name (schema.name)",
+							Computed:    true,
+						},
+						"schema": schema.StringAttribute{
+							Description: "Queue schema",
+							Computed:    true,
+						},
+						"name": schema.StringAttribute{
+							Description: "Queue name",
+							Computed:    true,
+						},
+						"partitioned": schema.BoolAttribute{
+							Description: "Whether the queue is partitioned",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *queuesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	d.mgr = data.mgr
+}
+
+func (d *queuesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data queuesModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	schemaName := pgq.SchemaName(data.Schema.ValueString())
+
+	queues, err := d.mgr.ListQueues(ctx, schemaName)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to list queues", err)
+		return
+	}
+
+	data.ID = types.StringValue("*")
+	if schemaName != "" {
+		data.ID = types.StringValue(schemaName.String())
+	}
+
+	ids := make([]string, 0, len(queues))
+	data.Queues = make([]queueSummaryModel, 0, len(queues))
+	for _, q := range queues {
+		ids = append(ids, q.FQN().String())
+		data.Queues = append(data.Queues, queueSummaryModel{
+			ID:          types.StringValue(q.FQN().String()),
+			Schema:      types.StringValue(q.Schema.String()),
+			Name:        types.StringValue(q.Name.String()),
+			Partitioned: types.BoolValue(q.Partitioned),
+		})
+	}
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, ids)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.IDs = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// deadLetterModel configures the dead-letter queue of a pgq_queue
+type deadLetterModel struct {
+	EnablePartitioning types.Bool   `tfsdk:"enable_partitioning"`
+	PartitionInterval  types.String `tfsdk:"partition_interval"`
+	RetentionPeriod    types.String `tfsdk:"retention_period"`
+}
+
+// deadLetterBlock is the schema of the dead_letter block of pgq_queue
+func deadLetterBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Create a sibling <name>_dlq queue with the same columns, for messages consumers give up on",
+		Attributes: map[string]schema.Attribute{
+			"enable_partitioning": schema.BoolAttribute{
+				Description: "Partition the dead-letter queue with pg_partman; can't change once it exists",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"partition_interval": schema.StringAttribute{
+				Description: "Partition interval of the dead-letter queue (e.g. '1 week')",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("1 day"),
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1), intervalValidator()},
+			},
+			"retention_period": schema.StringAttribute{
+				Description: "How long to keep dead-letter partitions (e.g. '90 days')",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("30 days"),
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1), intervalValidator()},
+			},
+		},
+	}
+}
+
+// deadLetterName returns the name of the queue's dead-letter queue
+func (m queueModel) deadLetterName() pgq.QueueName {
+	q := &pgq.Queue{Name: pgq.QueueName(m.Name.ValueString())}
+	return q.DeadLetterName()
+}
+
+// deadLetterFQN is where the dead-letter queue of m is: dead_letter_queue,
+// which lags behind the queue until relocateDeadLetter has moved it, or
+// the name derived from the queue
+func (m queueModel) deadLetterFQN() pgq.FQN {
+	if isSet(m.DeadLetterQueue) {
+		return pgq.FQN(m.DeadLetterQueue.ValueString())
+	}
+	return pgq.MakeFQN(pgq.SchemaName(m.Schema.ValueString()), m.deadLetterName())
+}
+
+// deadLetterOptions are the options shaping the columns of the queue,
+// which its dead-letter queue shares. Hooks and storage settings are the
+// queue's own.
+func deadLetterOptions(opts *pgq.QueueOptions) *pgq.QueueOptions {
+	return &pgq.QueueOptions{
+		TextCollation:    opts.TextCollation,
+		OrderingColumn:   opts.OrderingColumn,
+		IDType:           opts.IDType,
+		ExtraColumns:     opts.ExtraColumns,
+		CheckConstraints: opts.CheckConstraints,
+	}
+}
+
+// deadLetterPartitionConfig returns the partitioning of the dead-letter
+// queue, nil when it isn't partitioned. Settings without a dead_letter
+// attribute follow the queue's, except partition_start, sub_partition and
+// the partition key: dead letters are never backfilled nor hot, and expire
+// by time.
+func (m queueModel) deadLetterPartitionConfig() *pgq.PartitionConfig {
+	if m.DeadLetter == nil || !m.DeadLetter.EnablePartitioning.ValueBool() {
+		return nil
+	}
+	cfg := m.partitionConfig()
+	cfg.Interval = m.DeadLetter.PartitionInterval.ValueString()
+	cfg.Retention = m.DeadLetter.RetentionPeriod.ValueString()
+	cfg.StartPartition = ""
+	cfg.SubPartition = nil
+	cfg.Strategy, cfg.Key, cfg.HashPartitions = "", "", 0
+	return cfg
+}
+
+// queueNames returns the queue and its dead-letter queue, if any
+func (m queueModel) queueNames() []pgq.QueueName {
+	names := []pgq.QueueName{pgq.QueueName(m.Name.ValueString())}
+	if m.DeadLetter != nil {
+		names = append(names, m.deadLetterName())
+	}
+	return names
+}
+
+// setDeadLetterQueue sets dead_letter_queue from the dead_letter block
+func (m *queueModel) setDeadLetterQueue() {
+	if m.DeadLetter == nil {
+		m.DeadLetterQueue = types.StringNull()
+		return
+	}
+	m.DeadLetterQueue = types.StringValue(pgq.MakeFQN(pgq.SchemaName(m.Schema.ValueString()), m.deadLetterName()).String())
+}
+
+// createDeadLetter creates the dead-letter queue of m with the columns of
+// opts
+func (r *queueResource) createDeadLetter(ctx context.Context, m queueModel, opts *pgq.QueueOptions) error {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := m.deadLetterName()
+
+	if err := r.mgr.Create(ctx, schema, name, m.deadLetterPartitionConfig(), deadLetterOptions(opts)); err != nil {
+		return err
+	}
+
+	if isSet(m.Owner) {
+		return r.mgr.SetOwner(ctx, schema, name, m.Owner.ValueString())
+	}
+	return nil
+}
+
+// dropDeadLetter drops the dead-letter queue of m with its messages
+func (r *queueResource) dropDeadLetter(ctx context.Context, m queueModel) error {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := m.deadLetterName()
+
+	if m.DeadLetter.EnablePartitioning.ValueBool() && !m.nativePartitioning() {
+		if err := r.mgr.RemovePartmanConfig(ctx, schema, name); err != nil {
+			tflog.Warn(ctx, "failed to remove dead-letter queue partman config", map[string]any{"error": err})
+		}
+	}
+
+	return r.mgr.Drop(ctx, schema, name)
+}
+
+// updateDeadLetter creates, drops or repartitions the dead-letter queue
+// as the dead_letter block is added, removed or changed
+func (r *queueResource) updateDeadLetter(ctx context.Context, plan, state queueModel, opts *pgq.QueueOptions) error {
+	switch {
+	case plan.DeadLetter == nil && state.DeadLetter == nil:
+		return nil
+	case plan.DeadLetter == nil:
+		return r.dropDeadLetter(ctx, state)
+	case state.DeadLetter == nil:
+		return r.createDeadLetter(ctx, plan, opts)
+	}
+
+	cfg := plan.deadLetterPartitionConfig()
+	if cfg == nil || (plan.DeadLetter.PartitionInterval.Equal(state.DeadLetter.PartitionInterval) &&
+		plan.DeadLetter.RetentionPeriod.Equal(state.DeadLetter.RetentionPeriod)) {
+		return nil
+	}
+	return r.mgr.UpdatePartitionConfig(ctx, pgq.SchemaName(plan.Schema.ValueString()), plan.deadLetterName(), cfg)
+}
+
+// readDeadLetter refreshes the dead_letter block. A dead-letter queue
+// dropped outside Terraform removes the block from state, so the next
+// plan creates it again.
+func (r *queueResource) readDeadLetter(ctx context.Context, m *queueModel) {
+	if m.DeadLetter == nil {
+		return
+	}
+
+	schema, name, err := m.deadLetterFQN().Split()
+	if err != nil {
+		tflog.Warn(ctx, "failed to read dead-letter queue", map[string]any{"error": err})
+		return
+	}
+
+	q, err := r.mgr.Get(ctx, schema, name)
+	if _, ok := err.(*pgq.QueueNotFoundError); ok {
+		m.DeadLetter = nil
+		m.setDeadLetterQueue()
+		return
+	}
+	if err != nil {
+		tflog.Warn(ctx, "failed to read dead-letter queue", map[string]any{"error": err})
+		return
+	}
+
+	m.DeadLetter.EnablePartitioning = types.BoolValue(q.Partitioned)
+	if q.Partitioned && !m.nativePartitioning() {
+		cfg, err := r.mgr.GetPartitionConfig(ctx, schema, name)
+		if err != nil {
+			tflog.Warn(ctx, "failed to read dead-letter queue partition config", map[string]any{"error": err})
+			return
+		}
+		m.DeadLetter.PartitionInterval = keepInterval(m.DeadLetter.PartitionInterval, cfg.Interval)
+		m.DeadLetter.RetentionPeriod = keepInterval(m.DeadLetter.RetentionPeriod, cfg.Retention)
+	}
+}
+
+// planDeadLetter fills dead_letter_queue and rejects dead_letter changes
+// the dead-letter queue can't follow in place
+func planDeadLetter(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if plan.Name.IsUnknown() || plan.Schema.IsUnknown() {
+		return
+	}
+
+	if plan.DeadLetter != nil && !plan.deadLetterName().Valid() {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Queue name too long for a dead-letter queue",
+			fmt.Sprintf("The dead-letter queue name %s exceeds the PostgreSQL identifier length limit of 63 characters.", plan.deadLetterName()))
+		return
+	}
+
+	plan.setDeadLetterQueue()
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("dead_letter_queue"), plan.DeadLetterQueue)...)
+
+	if req.State.Raw.IsNull() || plan.DeadLetter == nil {
+		return
+	}
+	var state queueModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if state.DeadLetter != nil && !state.DeadLetter.EnablePartitioning.Equal(plan.DeadLetter.EnablePartitioning) {
+		resp.Diagnostics.AddAttributeError(path.Root("dead_letter").AtName("enable_partitioning"), "Dead-letter partitioning can't change",
+			"Partitioning an existing dead-letter queue, or undoing it, would drop its messages. "+
+				"Remove the dead_letter block in one apply and add it back in another to recreate the dead-letter queue.")
+	}
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultIndexesModel selects the built-in indexes of a pgq_queue
+type defaultIndexesModel struct {
+	CreatedAt       types.Bool   `tfsdk:"created_at"`
+	ProcessedAtNull types.Bool   `tfsdk:"processed_at_null"`
+	ScheduledFor    types.Bool   `tfsdk:"scheduled_for"`
+	Metadata        types.Bool   `tfsdk:"metadata"`
+	MetadataOpclass types.String `tfsdk:"metadata_opclass"`
+}
+
+// defaultIndexesBlock is the schema of the default_indexes block of pgq_queue
+func defaultIndexesBlock() schema.SingleNestedBlock {
+	enabled := func(description string) schema.BoolAttribute {
+		return schema.BoolAttribute{
+			Description: description,
+			Optional:    true,
+			Computed:    true,
+			Default:     booldefault.StaticBool(true),
+		}
+	}
+	return schema.SingleNestedBlock{
+		Description: "Built-in indexes of the queue; all of them are created without the block",
+		Attributes: map[string]schema.Attribute{
+			"created_at":        enabled("Index on created_at"),
+			"processed_at_null": enabled("Partial index on processed_at of unprocessed messages"),
+			"scheduled_for":     enabled("Partial index on scheduled_for of unprocessed messages"),
+			"metadata":          enabled("Partial GIN index on metadata of unprocessed messages"),
+			"metadata_opclass": schema.StringAttribute{
+				Description: "Operator class of the metadata index, e.g. jsonb_path_ops for a smaller index supporting only @>; jsonb_ops if unset",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+		},
+	}
+}
+
+// indexes returns the selected built-in indexes, all of them without the
+// block
+func (m *defaultIndexesModel) indexes() *pgq.DefaultIndexes {
+	if m == nil {
+		return pgq.AllDefaultIndexes()
+	}
+	return &pgq.DefaultIndexes{
+		CreatedAt:       m.CreatedAt.ValueBool(),
+		ProcessedAtNull: m.ProcessedAtNull.ValueBool(),
+		ScheduledFor:    m.ScheduledFor.ValueBool(),
+		Metadata:        m.Metadata.ValueBool(),
+		MetadataOpclass: m.MetadataOpclass.ValueString(),
+	}
+}
+
+// setDefaultIndexes refreshes default_indexes from the queue's built-in
+// indexes, leaving the block out while they are all there. The operator
+// class of a disabled metadata index can't be read and is kept.
+func (m *queueModel) setDefaultIndexes(d *pgq.DefaultIndexes) {
+	if m.DefaultIndexes == nil && *d == *pgq.AllDefaultIndexes() {
+		return
+	}
+	opclass := stringOrNull(d.MetadataOpclass)
+	if !d.Metadata && m.DefaultIndexes != nil {
+		opclass = m.DefaultIndexes.MetadataOpclass
+	}
+	m.DefaultIndexes = &defaultIndexesModel{
+		CreatedAt:       types.BoolValue(d.CreatedAt),
+		ProcessedAtNull: types.BoolValue(d.ProcessedAtNull),
+		ScheduledFor:    types.BoolValue(d.ScheduledFor),
+		Metadata:        types.BoolValue(d.Metadata),
+		MetadataOpclass: opclass,
+	}
+}
+
+// readMissingIndexes sets missing_indexes and warns about built-in indexes
+// dropped or left invalid outside Terraform. It checks the selection in
+// state, so has to run before default_indexes is refreshed.
+func (r *queueResource) readMissingIndexes(ctx context.Context, m *queueModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	missing, err := r.mgr.MissingDefaultIndexes(ctx, schema, name, m.DefaultIndexes.indexes())
+	if err != nil {
+		tflog.Warn(ctx, "failed to check default indexes", map[string]any{"error": err})
+		if m.MissingIndexes.IsNull() {
+			m.MissingIndexes = types.ListValueMust(types.StringType, []attr.Value{})
+		}
+		return diags
+	}
+
+	if missing == nil {
+		missing = []string{}
+	}
+	list, d := types.ListValueFrom(ctx, types.StringType, missing)
+	diags.Append(d...)
+	m.MissingIndexes = list
+
+	if len(missing) > 0 {
+		diags.AddAttributeWarning(path.Root("missing_indexes"), "Queue indexes missing",
+			fmt.Sprintf("%s lacks built-in indexes, or has them invalid, which slows down consumers; the next apply recreates them:\n\n%s",
+				pgq.MakeFQN(schema, name), strings.Join(missing, "\n")))
+	}
+	return diags
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestQueueModelSetDefaultIndexes(t *testing.T) {
+	var m queueModel
+	m.setDefaultIndexes(pgq.AllDefaultIndexes())
+	if m.DefaultIndexes != nil {
+		t.Error("default_indexes is set, want it left out while all indexes exist")
+	}
+	if *m.DefaultIndexes.indexes() != *pgq.AllDefaultIndexes() {
+		t.Error("indexes() without the block should select all built-in indexes")
+	}
+
+	m.setDefaultIndexes(&pgq.DefaultIndexes{CreatedAt: true, ProcessedAtNull: true, ScheduledFor: true})
+	if m.DefaultIndexes == nil || m.DefaultIndexes.Metadata.ValueBool() || !m.DefaultIndexes.MetadataOpclass.IsNull() {
+		t.Fatalf("default_indexes = %+v, want the metadata index disabled", m.DefaultIndexes)
+	}
+
+	m.DefaultIndexes.MetadataOpclass = types.StringValue("jsonb_path_ops")
+	m.setDefaultIndexes(&pgq.DefaultIndexes{CreatedAt: true})
+	if m.DefaultIndexes.MetadataOpclass.ValueString() != "jsonb_path_ops" {
+		t.Errorf("metadata_opclass = %v, want it kept for the disabled index", m.DefaultIndexes.MetadataOpclass)
+	}
+	if got := m.DefaultIndexes.indexes(); got.ScheduledFor || !got.CreatedAt {
+		t.Errorf("indexes() = %+v, want only created_at", got)
+	}
+}
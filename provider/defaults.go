@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// partitionDefaultsModel is the provider's partition_defaults block
+type partitionDefaultsModel struct {
+	Interval           types.String `tfsdk:"interval"`
+	Premake            types.Int64  `tfsdk:"premake"`
+	Retention          types.String `tfsdk:"retention"`
+	DatetimeString     types.String `tfsdk:"datetime_string"`
+	OptimizeConstraint types.Int64  `tfsdk:"optimize_constraint"`
+	DefaultPartition   types.Bool   `tfsdk:"default_partition"`
+}
+
+// applyDefaultSchema plans default_queue_schema as the schema of a
+// resource whose configuration doesn't set one. Like a changed schema
+// attribute, a changed default replaces existing queues.
+func applyDefaultSchema(ctx context.Context, defaultSchema string, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if defaultSchema == "" {
+		return
+	}
+
+	var schema types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if !schema.IsNull() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("schema"), defaultSchema)...)
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var state types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("schema"), &state)...)
+	if state.ValueString() != defaultSchema {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("schema"))
+	}
+}
+
+// applyPartitionDefaults replaces the schema (or environment profile)
+// defaults of the partitioning attributes with the provider's
+// partition_defaults wherever the configuration doesn't set them
+func applyPartitionDefaults(ctx context.Context, defaults *partitionDefaultsModel, cfg tfsdk.Config, plan *tfsdk.Plan) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if defaults == nil {
+		return diags
+	}
+
+	var configured map[string]tftypes.Value
+	if err := cfg.Raw.As(&configured); err != nil {
+		diags.AddError("Failed to read configuration", err.Error())
+		return diags
+	}
+
+	for _, d := range []struct {
+		attr string
+		val  attr.Value
+	}{
+		{"partition_interval", defaults.Interval},
+		{"partition_premake", defaults.Premake},
+		{"retention_period", defaults.Retention},
+		{"datetime_string", defaults.DatetimeString},
+		{"optimize_constraint", defaults.OptimizeConstraint},
+		{"default_partition", defaults.DefaultPartition},
+	} {
+		if d.val.IsNull() || d.val.IsUnknown() || !configured[d.attr].IsNull() {
+			continue
+		}
+		diags.Append(plan.SetAttribute(ctx, path.Root(d.attr), d.val)...)
+	}
+
+	return diags
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestApplyDefaultSchema(t *testing.T) {
+	ctx := context.Background()
+
+	s := schema.Schema{Attributes: map[string]schema.Attribute{
+		"schema": schema.StringAttribute{Optional: true, Computed: true},
+	}}
+	typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"schema": tftypes.String}}
+	value := func(v any) tftypes.Value {
+		return tftypes.NewValue(typ, map[string]tftypes.Value{"schema": tftypes.NewValue(tftypes.String, v)})
+	}
+
+	tests := []struct {
+		name        string
+		config      any
+		state       tftypes.Value
+		want        string
+		wantReplace bool
+	}{
+		{name: "configured", config: "app", state: tftypes.NewValue(typ, nil), want: "app"},
+		{name: "create", config: nil, state: tftypes.NewValue(typ, nil), want: "queues"},
+		{name: "unchanged", config: nil, state: value("queues"), want: "queues"},
+		{name: "changed default", config: nil, state: value("public"), want: "queues", wantReplace: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planned := tt.config
+			if planned == nil {
+				planned = "public"
+			}
+			req := resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: s, Raw: value(tt.config)},
+				State:  tfsdk.State{Schema: s, Raw: tt.state},
+			}
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: s, Raw: value(planned)}}
+
+			applyDefaultSchema(ctx, "queues", req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("applyDefaultSchema() diags = %v", resp.Diagnostics)
+			}
+
+			var got struct {
+				Schema string `tfsdk:"schema"`
+			}
+			if diags := resp.Plan.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("plan.Get() diags = %v", diags)
+			}
+			if got.Schema != tt.want {
+				t.Errorf("schema = %q, want %q", got.Schema, tt.want)
+			}
+			if replace := len(resp.RequiresReplace) > 0; replace != tt.wantReplace {
+				t.Errorf("requires replace = %v, want %v", replace, tt.wantReplace)
+			}
+		})
+	}
+}
+
+func TestApplyPartitionDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	s := schema.Schema{Attributes: map[string]schema.Attribute{
+		"partition_interval": schema.StringAttribute{Optional: true, Computed: true},
+		"partition_premake":  schema.Int64Attribute{Optional: true, Computed: true},
+		"retention_period":   schema.StringAttribute{Optional: true, Computed: true},
+		"datetime_string":    schema.StringAttribute{Optional: true, Computed: true},
+		"default_partition":  schema.BoolAttribute{Optional: true, Computed: true},
+	}}
+	typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"partition_interval": tftypes.String,
+		"partition_premake":  tftypes.Number,
+		"retention_period":   tftypes.String,
+		"datetime_string":    tftypes.String,
+		"default_partition":  tftypes.Bool,
+	}}
+
+	// retention is configured, the rest falls back to the schema defaults
+	cfg := tfsdk.Config{Schema: s, Raw: tftypes.NewValue(typ, map[string]tftypes.Value{
+		"partition_interval": tftypes.NewValue(tftypes.String, nil),
+		"partition_premake":  tftypes.NewValue(tftypes.Number, nil),
+		"retention_period":   tftypes.NewValue(tftypes.String, "365 days"),
+		"datetime_string":    tftypes.NewValue(tftypes.String, nil),
+		"default_partition":  tftypes.NewValue(tftypes.Bool, nil),
+	})}
+	plan := tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(typ, map[string]tftypes.Value{
+		"partition_interval": tftypes.NewValue(tftypes.String, "1 day"),
+		"partition_premake":  tftypes.NewValue(tftypes.Number, 7),
+		"retention_period":   tftypes.NewValue(tftypes.String, "365 days"),
+		"datetime_string":    tftypes.NewValue(tftypes.String, "YYYYMMDD"),
+		"default_partition":  tftypes.NewValue(tftypes.Bool, true),
+	})}
+
+	defaults := &partitionDefaultsModel{
+		Interval:           types.StringValue("1 week"),
+		Premake:            types.Int64Value(4),
+		Retention:          types.StringValue("30 days"),
+		DatetimeString:     types.StringNull(),
+		OptimizeConstraint: types.Int64Null(),
+		DefaultPartition:   types.BoolValue(false),
+	}
+	if diags := applyPartitionDefaults(ctx, defaults, cfg, &plan); diags.HasError() {
+		t.Fatalf("applyPartitionDefaults() diags = %v", diags)
+	}
+
+	var got struct {
+		Interval         string `tfsdk:"partition_interval"`
+		Premake          int64  `tfsdk:"partition_premake"`
+		Retention        string `tfsdk:"retention_period"`
+		DatetimeString   string `tfsdk:"datetime_string"`
+		DefaultPartition bool   `tfsdk:"default_partition"`
+	}
+	if diags := plan.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("plan.Get() diags = %v", diags)
+	}
+
+	if got.Interval != "1 week" || got.Premake != 4 || got.DefaultPartition {
+		t.Errorf("plan = %+v, want the partition_defaults", got)
+	}
+	if got.Retention != "365 days" {
+		t.Errorf("retention_period = %q, want configured %q", got.Retention, "365 days")
+	}
+	if got.DatetimeString != "YYYYMMDD" {
+		t.Errorf("datetime_string = %q, want schema default %q", got.DatetimeString, "YYYYMMDD")
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// partmanHint is the hint for errors caused by pg_partman not being installed
+const partmanHint = "The pg_partman extension is not installed in this database. Create it with CREATE EXTENSION pg_partman SCHEMA partman, or set install_partman = true on the provider."
+
+// sqlStateHints say what to do about the server errors users run into most
+var sqlStateHints = map[string]string{
+	"28P01": "Check the password, password_command, or the aws_rds_iam_auth or azure_ad_auth settings.",
+	"28000": "pg_hba.conf on the server rejects this user, database or client address, or requires a different sslmode.",
+	"3D000": "The database doesn't exist. Check database, PGDATABASE or the connection string.",
+	"3F000": "The schema doesn't exist. Create it before the queue, or check schema and default_queue_schema.",
+	"42501": "The role lacks a privilege on the object. Grant it, or set assume_role to the role owning the queues.",
+	"42P07": "The object already exists. Import it with terraform import instead of creating it.",
+	"53300": "The server has no free connection slots. Lower max_connections or connect through PgBouncer.",
+	"55P03": "Another session holds a lock on the table. Try again once it finishes, or add a retry block.",
+	"40P01": "A concurrent transaction deadlocked with this one. Add a retry block to repeat it automatically.",
+	"40001": "A concurrent transaction conflicted with this one. Add a retry block to repeat it automatically.",
+	"57014": "The statement was canceled, usually by statement_timeout or operation_timeout. Raise the limit for long-running DDL.",
+	"25006": "The session is read-only: read_only is set on the provider, or it is connected to a standby. Set target_session_attrs = \"read-write\" to reach the primary.",
+	"57P01": "The server is shutting down. Try again once it accepts connections, or raise reconnect_window.",
+	"57P03": "The server is starting up. Try again once it accepts connections, or raise reconnect_window.",
+	"0A000": "The server doesn't support this feature. Compare its version with required_postgres_version.",
+}
+
+// errorDiag adds err as an error diagnostic with its SQLSTATE, the
+// failing step and a hint, where known
+func errorDiag(diags *diag.Diagnostics, summary string, err error) {
+	diags.AddError(summary, errorDetail(err))
+}
+
+// connectionErrorDiag adds a failure to connect with poolCfg
+func connectionErrorDiag(diags *diag.Diagnostics, poolCfg *pgxpool.Config, err error) {
+	cc := poolCfg.ConnConfig
+	diags.AddError("PostgreSQL connection failed",
+		fmt.Sprintf("Connecting to %s:%d, database %q, as %q failed.\n\n%s", cc.Host, cc.Port, cc.Database, cc.User, errorDetail(err)))
+}
+
+// isCredentialsError reports whether the server rejected the password or
+// token of the connection
+func isCredentialsError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "28P01"
+}
+
+// credentialsErrorDiag adds rejected credentials against the attribute
+// that provided them, so a rotated password that wasn't updated everywhere
+// fails the plan rather than a later apply
+func credentialsErrorDiag(diags *diag.Diagnostics, cfg config, err error) {
+	attr := "password"
+	switch {
+	case cfg.AWSRDSIAMAuth != nil:
+		attr = "aws_rds_iam_auth"
+	case cfg.AzureADAuth != nil:
+		attr = "azure_ad_auth"
+	case !cfg.PasswordCommand.IsNull():
+		attr = "password_command"
+	case !isSet(cfg.Password) && isSet(cfg.ConnectionString):
+		attr = "connection_string"
+	}
+
+	diags.AddAttributeError(path.Root(attr), "PostgreSQL rejected the credentials",
+		"The server refused to authenticate with the configured credentials. If the password was rotated, "+
+			"update it here, in PGPASSWORD or in the password file.\n\n"+errorDetail(err))
+}
+
+// errorDetail is the error message followed by what is known about it
+func errorDetail(err error) string {
+	var sb strings.Builder
+	sb.WriteString(err.Error())
+
+	var lines []string
+	var queueErr *pgq.QueueError
+	var partmanErr *pgq.PartmanError
+	switch {
+	case errors.As(err, &queueErr):
+		lines = append(lines, "Step: "+queueErr.Op)
+	case errors.As(err, &partmanErr):
+		lines = append(lines, "Step: pg_partman "+partmanErr.Op)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		lines = append(lines, "SQLSTATE: "+pgErr.Code)
+		if pgErr.Detail != "" {
+			lines = append(lines, "Server detail: "+pgErr.Detail)
+		}
+		if pgErr.Hint != "" {
+			lines = append(lines, "Server hint: "+pgErr.Hint)
+		}
+	}
+
+	if hint := errorHint(err); hint != "" {
+		lines = append(lines, "Hint: "+hint)
+	}
+
+	if len(lines) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.Join(lines, "\n"))
+	}
+	return sb.String()
+}
+
+func errorHint(err error) string {
+	var unsupported *pgq.UnsupportedError
+	if errors.As(err, &unsupported) && unsupported.Missing == "pg_partman" {
+		return partmanHint
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// pg_partman functions and schema are missing without the extension
+		if (pgErr.Code == "42883" || pgErr.Code == "3F000") && strings.Contains(pgErr.Message, "partman") {
+			return partmanHint
+		}
+		return sqlStateHints[pgErr.Code]
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return "Check that the server is reachable from where Terraform runs. Private endpoints need ssh_tunnel or proxy_url."
+	}
+
+	return ""
+}
@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestErrorDetail(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{
+			name: "queue step",
+			err: &pgq.QueueError{Op: "create_table", Queue: "app.orders", Err: &pgconn.PgError{
+				Code: "42501", Message: "permission denied for schema app", Hint: "ask the owner",
+			}},
+			want: []string{"Step: create_table", "SQLSTATE: 42501", "Server hint: ask the owner", "Hint: The role lacks a privilege"},
+		},
+		{
+			name: "partman function",
+			err: &pgq.PartmanError{Op: "create_parent", Queue: "app.orders", Err: &pgconn.PgError{
+				Code: "42883", Message: "function partman.create_parent(text, text, text) does not exist",
+			}},
+			want: []string{"Step: pg_partman create_parent", "SQLSTATE: 42883", "Hint: The pg_partman extension is not installed"},
+		},
+		{
+			name: "partman unsupported",
+			err:  &pgq.UnsupportedError{Reason: "pg_partman extension is not installed", Missing: "pg_partman"},
+			want: []string{"Hint: The pg_partman extension is not installed"},
+		},
+		{
+			name: "unknown code",
+			err:  &pgconn.PgError{Code: "XX000", Message: "internal error"},
+			want: []string{"SQLSTATE: XX000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorDetail(tt.err)
+			if !strings.HasPrefix(got, tt.err.Error()) {
+				t.Errorf("errorDetail() = %q, want it to start with the error", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("errorDetail() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+
+	if got, want := errorDetail(errors.New("boom")), "boom"; got != want {
+		t.Errorf("errorDetail() = %q, want the bare message %q", got, want)
+	}
+}
+
+func TestCredentialsErrorDiag(t *testing.T) {
+	rejected := &pgconn.ConnectError{Config: &pgconn.Config{}}
+	if isCredentialsError(rejected) {
+		t.Error("isCredentialsError() = true for a connect error without SQLSTATE")
+	}
+	if !isCredentialsError(fmt.Errorf("ping: %w", &pgconn.PgError{Code: "28P01"})) {
+		t.Error("isCredentialsError() = false for invalid_password")
+	}
+
+	tests := []struct {
+		name string
+		cfg  config
+		want string
+	}{
+		{"password", config{Password: types.StringValue("old")}, "password"},
+		{"password command", config{PasswordCommand: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("vault")})}, "password_command"},
+		{"connection string", config{ConnectionString: types.StringValue("postgres://app:old@db/app")}, "connection_string"},
+		{"iam", config{AWSRDSIAMAuth: &awsRDSIAMAuthModel{}}, "aws_rds_iam_auth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			credentialsErrorDiag(&diags, tt.cfg, &pgconn.PgError{Code: "28P01"})
+
+			if len(diags) != 1 {
+				t.Fatalf("got %d diagnostics, want 1", len(diags))
+			}
+			withPath, ok := diags[0].(diag.DiagnosticWithPath)
+			if !ok || !withPath.Path().Equal(path.Root(tt.want)) {
+				t.Errorf("diagnostic %v, want it on %s", diags[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type (
+	// endpointModel overrides where a resource's objects live
+	endpointModel struct {
+		Host     types.String `tfsdk:"host"`
+		Port     types.Int64  `tfsdk:"port"`
+		Database types.String `tfsdk:"database"`
+	}
+
+	// endpoints hands out a Manager per endpoint override. Their pools
+	// share every provider setting but host, port and database and are
+	// created on first use, so shards that have no work never connect.
+	endpoints struct {
+		base    *pgxpool.Config
+		mgrOpts pgq.ManagerOptions
+
+		mu   sync.Mutex
+		mgrs map[string]*pgq.Manager
+	}
+)
+
+// endpointBlock is the schema of the endpoint block of resources
+func endpointBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Manage the objects in another database than the provider's, e.g. one of several shards. Unset attributes keep the provider's value. Changing it forces a new resource.",
+		Attributes: map[string]schema.Attribute{
+			"host": schema.StringAttribute{
+				Description: "Server host",
+				Optional:    true,
+			},
+			"port": schema.Int64Attribute{
+				Description: "Server port",
+				Optional:    true,
+				Validators:  []validator.Int64{int64validator.Between(1, 65535)},
+			},
+			"database": schema.StringAttribute{
+				Description: "Database name",
+				Optional:    true,
+			},
+		},
+		PlanModifiers: []planmodifier.Object{
+			objectplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+func newEndpoints(base *pgxpool.Config, mgrOpts pgq.ManagerOptions) *endpoints {
+	return &endpoints{base: base, mgrOpts: mgrOpts, mgrs: make(map[string]*pgq.Manager)}
+}
+
+// overrides reports whether the endpoint changes any connection setting
+func (m *endpointModel) overrides() bool {
+	return m != nil && (isSet(m.Host) || isSet(m.Database) || (!m.Port.IsNull() && !m.Port.IsUnknown()))
+}
+
+// known reports whether the endpoint's settings are known at plan time
+func (m *endpointModel) known() bool {
+	return m == nil || (!m.Host.IsUnknown() && !m.Port.IsUnknown() && !m.Database.IsUnknown())
+}
+
+// manager returns the Manager of the endpoint, or def without an
+// override
+func (e *endpoints) manager(ctx context.Context, m *endpointModel, def *pgq.Manager) (*pgq.Manager, error) {
+	if e == nil || !m.overrides() {
+		return def, nil
+	}
+
+	poolCfg := e.poolConfig(m)
+	cc := poolCfg.ConnConfig
+	key := fmt.Sprintf("%s:%d/%s", cc.Host, cc.Port, cc.Database)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if mgr, ok := e.mgrs[key]; ok {
+		return mgr, nil
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %s: %w", key, err)
+	}
+	opts := e.mgrOpts
+	mgr := pgq.NewManagerWithOptions(pool, &opts)
+	e.mgrs[key] = mgr
+	return mgr, nil
+}
+
+// poolConfig returns the provider's pool config with the overrides of m
+func (e *endpoints) poolConfig(m *endpointModel) *pgxpool.Config {
+	poolCfg := e.base.Copy()
+	cc := poolCfg.ConnConfig
+
+	if isSet(m.Host) || (!m.Port.IsNull() && !m.Port.IsUnknown()) {
+		host, port := cc.Host, cc.Port
+		if isSet(m.Host) {
+			host = m.Host.ValueString()
+		}
+		if !m.Port.IsNull() && !m.Port.IsUnknown() {
+			port = uint16(m.Port.ValueInt64())
+		}
+
+		// fallbacks to the provider's other hosts lead to its own servers,
+		// those to the same host with other TLS settings (sslmode=prefer)
+		// apply to the endpoint as well
+		var fallbacks []*pgconn.FallbackConfig
+		for _, fb := range cc.Fallbacks {
+			if fb.Host == cc.Host && fb.Port == cc.Port {
+				fb.Host, fb.Port = host, port
+				setServerName(fb.TLSConfig, host)
+				fallbacks = append(fallbacks, fb)
+			}
+		}
+		cc.Fallbacks = fallbacks
+
+		cc.Host, cc.Port = host, port
+		setServerName(cc.TLSConfig, host)
+	}
+
+	if isSet(m.Database) {
+		cc.Database = m.Database.ValueString()
+	}
+
+	return poolCfg
+}
+
+// setServerName points the certificate verification of tc at host
+func setServerName(tc *tls.Config, host string) {
+	if tc != nil {
+		tc.ServerName = host
+	}
+}
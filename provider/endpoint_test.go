@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestEndpointsManager(t *testing.T) {
+	base, err := pgxpool.ParseConfig("host=db-1 dbname=app sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.MinConns = 0
+
+	def := &pgq.Manager{}
+	e := newEndpoints(base, pgq.ManagerOptions{})
+	ctx := context.Background()
+
+	if mgr, err := e.manager(ctx, nil, def); err != nil || mgr != def {
+		t.Errorf("no endpoint: got %p, %v, want the default manager", mgr, err)
+	}
+	unset := &endpointModel{Host: types.StringNull(), Port: types.Int64Null(), Database: types.StringNull()}
+	if mgr, err := e.manager(ctx, unset, def); err != nil || mgr != def {
+		t.Errorf("empty endpoint: got %p, %v, want the default manager", mgr, err)
+	}
+
+	shard := &endpointModel{Host: types.StringValue("shard-1"), Port: types.Int64Null(), Database: types.StringNull()}
+	mgr, err := e.manager(ctx, shard, def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mgr == def {
+		t.Fatal("got the default manager for a host override")
+	}
+	if again, _ := e.manager(ctx, shard, def); again != mgr {
+		t.Error("managers of the same endpoint are not shared")
+	}
+
+	other := &endpointModel{Host: types.StringValue("shard-1"), Port: types.Int64Null(), Database: types.StringValue("other")}
+	if mgr2, _ := e.manager(ctx, other, def); mgr2 == mgr {
+		t.Error("endpoints with different databases share a manager")
+	}
+
+}
+
+func TestEndpointsPoolConfig(t *testing.T) {
+	base, err := pgxpool.ParseConfig("host=db-1,db-2 port=5432 dbname=app sslmode=prefer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newEndpoints(base, pgq.ManagerOptions{})
+
+	cc := e.poolConfig(&endpointModel{
+		Host:     types.StringValue("shard-1"),
+		Port:     types.Int64Value(6432),
+		Database: types.StringNull(),
+	}).ConnConfig
+
+	if cc.Host != "shard-1" || cc.Port != 6432 || cc.Database != "app" {
+		t.Errorf("got %s:%d/%s, want shard-1:6432/app", cc.Host, cc.Port, cc.Database)
+	}
+	if cc.TLSConfig == nil || cc.TLSConfig.ServerName != "shard-1" {
+		t.Errorf("TLS config not verifying shard-1: %+v", cc.TLSConfig)
+	}
+	// the plaintext fallback of sslmode=prefer stays, db-2 is dropped
+	if len(cc.Fallbacks) != 1 {
+		t.Fatalf("got %d fallbacks, want 1", len(cc.Fallbacks))
+	}
+	if fb := cc.Fallbacks[0]; fb.Host != "shard-1" || fb.Port != 6432 || fb.TLSConfig != nil {
+		t.Errorf("unexpected fallback %s:%d, TLS %v", fb.Host, fb.Port, fb.TLSConfig != nil)
+	}
+
+	if base.ConnConfig.Host != "db-1" || len(base.ConnConfig.Fallbacks) != 3 {
+		t.Errorf("base config was modified: host %s, %d fallbacks", base.ConnConfig.Host, len(base.ConnConfig.Fallbacks))
+	}
+}
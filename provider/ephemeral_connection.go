@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = (*connectionEphemeralResource)(nil)
+	_ ephemeral.EphemeralResourceWithConfigure = (*connectionEphemeralResource)(nil)
+)
+
+type (
+	connectionEphemeralResource struct {
+		mgr *pgq.Manager
+	}
+
+	connectionModel struct {
+		Connected        types.Bool   `tfsdk:"connected"`
+		Error            types.String `tfsdk:"error"`
+		ServerVersion    types.String `tfsdk:"server_version"`
+		PartmanInstalled types.Bool   `tfsdk:"partman_installed"`
+		PartmanVersion   types.String `tfsdk:"partman_version"`
+	}
+)
+
+func NewConnectionEphemeralResource() ephemeral.EphemeralResource {
+	return &connectionEphemeralResource{}
+}
+
+func (r *connectionEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_connection"
+}
+
+func (r *connectionEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Checks the provider's connection to PostgreSQL on every run, without storing anything in state",
+		Attributes: map[string]schema.Attribute{
+			"connected": schema.BoolAttribute{
+				Description: "Whether a connection to the server could be established",
+				Computed:    true,
+			},
+			"error": schema.StringAttribute{
+				Description: "Why the connection failed, null when connected",
+				Computed:    true,
+			},
+			"server_version": schema.StringAttribute{
+				Description: "PostgreSQL version, like 16.2. Null when not connected",
+				Computed:    true,
+			},
+			"partman_installed": schema.BoolAttribute{
+				Description: "Whether the pg_partman extension is installed. Null when not connected",
+				Computed:    true,
+			},
+			"partman_version": schema.StringAttribute{
+				Description: "pg_partman version, null when it isn't installed or not connected",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *connectionEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	r.mgr = data.mgr
+}
+
+// Open reports a failed connection in the result rather than as an error,
+// so configurations can gate resources on it with preconditions
+func (r *connectionEphemeralResource) Open(ctx context.Context, _ ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	data := connectionModel{
+		Connected:        types.BoolValue(false),
+		Error:            types.StringNull(),
+		ServerVersion:    types.StringNull(),
+		PartmanInstalled: types.BoolNull(),
+		PartmanVersion:   types.StringNull(),
+	}
+
+	if err := r.mgr.Ping(ctx); err != nil {
+		data.Error = types.StringValue(err.Error())
+		resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+		return
+	}
+
+	postgres, partman, err := r.mgr.ServerVersions(ctx)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to check server versions", err)
+		return
+	}
+
+	data.Connected = types.BoolValue(true)
+	data.ServerVersion = types.StringValue(postgres)
+	data.PartmanInstalled = types.BoolValue(partman != "")
+	if partman != "" {
+		data.PartmanVersion = types.StringValue(partman)
+	}
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// openConnection opens pgq_connection with mgr and returns its result
+func openConnection(t *testing.T, mgr *pgq.Manager) connectionModel {
+	t.Helper()
+	ctx := context.Background()
+
+	r := &connectionEphemeralResource{mgr: mgr}
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)},
+	}
+	r.Open(ctx, ephemeral.OpenRequest{}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Open() diagnostics = %v", resp.Diagnostics)
+	}
+
+	var data connectionModel
+	if diags := resp.Result.Get(ctx, &data); diags.HasError() {
+		t.Fatalf("Result.Get() diagnostics = %v", diags)
+	}
+	return data
+}
+
+func TestConnectionEphemeralResourceUnreachable(t *testing.T) {
+	// nothing listens on port 1, so the connection is refused right away
+	pool, err := pgxpool.New(context.Background(), "host=127.0.0.1 port=1 user=postgres connect_timeout=2")
+	if err != nil {
+		t.Fatalf("pgxpool.New() error = %v", err)
+	}
+	defer pool.Close()
+
+	data := openConnection(t, pgq.NewManager(pool))
+
+	if data.Connected.ValueBool() {
+		t.Error("connected = true, want false")
+	}
+	if data.Error.ValueString() == "" {
+		t.Error("error should say why the connection failed")
+	}
+	if !data.ServerVersion.IsNull() || !data.PartmanInstalled.IsNull() || !data.PartmanVersion.IsNull() {
+		t.Errorf("server details = %v, %v, %v, want null when not connected",
+			data.ServerVersion, data.PartmanInstalled, data.PartmanVersion)
+	}
+}
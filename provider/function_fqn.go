@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = (*fqnFunction)(nil)
+	_ function.Function = (*splitFQNFunction)(nil)
+)
+
+type (
+	fqnFunction      struct{}
+	splitFQNFunction struct{}
+)
+
+// splitFQNAttrTypes are the attributes of the object split_fqn returns
+var splitFQNAttrTypes = map[string]attr.Type{
+	"schema": types.StringType,
+	"name":   types.StringType,
+}
+
+func NewFQNFunction() function.Function {
+	return &fqnFunction{}
+}
+
+func NewSplitFQNFunction() function.Function {
+	return &splitFQNFunction{}
+}
+
+func (f *fqnFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "fqn"
+}
+
+func (f *fqnFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Build a queue ID from schema and name",
+		Description: "Returns the fully qualified name schema.name, the id of pgq_queue and its import ID.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "schema",
+				Description: "Schema of the queue",
+			},
+			function.StringParameter{
+				Name:        "name",
+				Description: "Name of the queue",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *fqnFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var schema, name string
+	resp.Error = req.Arguments.Get(ctx, &schema, &name)
+	if resp.Error != nil {
+		return
+	}
+
+	// Split cuts at the first dot, so a dot in the schema wouldn't survive
+	if !pgq.SchemaName(schema).Valid() || strings.Contains(schema, ".") {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid schema %q: must be a PostgreSQL identifier without dots", schema))
+		return
+	}
+	if !pgq.QueueName(name).Valid() {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("invalid name %q: must be a PostgreSQL identifier", name))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, pgq.MakeFQN(pgq.SchemaName(schema), pgq.QueueName(name)).String())
+}
+
+func (f *splitFQNFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "split_fqn"
+}
+
+func (f *splitFQNFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Split a queue ID into schema and name",
+		Description: "Returns an object with the schema and name of a fully qualified name like the id of pgq_queue.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "id",
+				Description: "Fully qualified name (schema.name)",
+			},
+		},
+		Return: function.ObjectReturn{AttributeTypes: splitFQNAttrTypes},
+	}
+}
+
+func (f *splitFQNFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var id string
+	resp.Error = req.Arguments.Get(ctx, &id)
+	if resp.Error != nil {
+		return
+	}
+
+	schema, name, err := pgq.FQN(id).Split()
+	if err == nil && (!schema.Valid() || !name.Valid()) {
+		err = fmt.Errorf("invalid FQN format: %s (expected schema.queue)", id)
+	}
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	result, diags := types.ObjectValue(splitFQNAttrTypes, map[string]attr.Value{
+		"schema": types.StringValue(schema.String()),
+		"name":   types.StringValue(name.String()),
+	})
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, result)
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFQNFunction(t *testing.T) {
+	ctx := context.Background()
+	f := NewFQNFunction()
+
+	tests := []struct {
+		schema, name string
+		want         string
+		wantErrArg   int64
+	}{
+		{schema: "queues", name: "orders", want: "queues.orders"},
+		{schema: "a.b", name: "orders", wantErrArg: 0},
+		{schema: "queues", name: "1orders", wantErrArg: 1},
+	}
+	for _, tt := range tests {
+		resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+		f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue(tt.schema), types.StringValue(tt.name),
+		})}, resp)
+
+		if tt.want == "" {
+			if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != tt.wantErrArg {
+				t.Errorf("fqn(%q, %q) error = %v, want an error for argument %d", tt.schema, tt.name, resp.Error, tt.wantErrArg)
+			}
+			continue
+		}
+		if resp.Error != nil {
+			t.Fatalf("fqn(%q, %q) error = %v", tt.schema, tt.name, resp.Error)
+		}
+		if got := resp.Result.Value(); !got.Equal(types.StringValue(tt.want)) {
+			t.Errorf("fqn(%q, %q) = %v, want %q", tt.schema, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitFQNFunction(t *testing.T) {
+	ctx := context.Background()
+	f := NewSplitFQNFunction()
+
+	run := func(id string) *function.RunResponse {
+		resp := &function.RunResponse{Result: function.NewResultData(types.ObjectUnknown(splitFQNAttrTypes))}
+		f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(id)})}, resp)
+		return resp
+	}
+
+	resp := run("queues.orders")
+	if resp.Error != nil {
+		t.Fatalf("split_fqn() error = %v", resp.Error)
+	}
+	want := types.ObjectValueMust(splitFQNAttrTypes, map[string]attr.Value{
+		"schema": types.StringValue("queues"),
+		"name":   types.StringValue("orders"),
+	})
+	if got := resp.Result.Value(); !got.Equal(want) {
+		t.Errorf("split_fqn() = %v, want %v", got, want)
+	}
+
+	for _, id := range []string{"orders", ".orders", "queues."} {
+		if resp := run(id); resp.Error == nil {
+			t.Errorf("split_fqn(%q) succeeded, want error", id)
+		}
+	}
+}
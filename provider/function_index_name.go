@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = (*indexNameFunction)(nil)
+
+type indexNameFunction struct{}
+
+// indexTypes are the index types custom_index accepts
+var indexTypes = []string{"btree", "gin", "gist", "hash", "brin"}
+
+func NewIndexNameFunction() function.Function {
+	return &indexNameFunction{}
+}
+
+func (f *indexNameFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "index_name"
+}
+
+func (f *indexNameFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Name of an auto-named custom index",
+		Description: "Returns the name pgq_queue gives a custom_index block without a name, " +
+			"so other resources can reference the index before it exists.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "queue",
+				Description: "Name of the queue, without the schema",
+			},
+			function.ListParameter{
+				Name:        "columns",
+				Description: "Column expressions, exactly as in custom_index",
+				ElementType: types.StringType,
+			},
+			function.StringParameter{
+				Name:        "type",
+				Description: "Index type: btree, gin, gist, hash or brin",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *indexNameFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		queue, indexType string
+		columns          []string
+	)
+	resp.Error = req.Arguments.Get(ctx, &queue, &columns, &indexType)
+	if resp.Error != nil {
+		return
+	}
+
+	if !pgq.QueueName(queue).Valid() {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid queue name %q: must be a PostgreSQL identifier", queue))
+		return
+	}
+	if len(columns) == 0 {
+		resp.Error = function.NewArgumentFuncError(1, "at least one column is required")
+		return
+	}
+	if !slices.Contains(indexTypes, indexType) {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("invalid index type %q: must be one of btree, gin, gist, hash or brin", indexType))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, pgq.IndexName(pgq.QueueName(queue), columns, indexType))
+}
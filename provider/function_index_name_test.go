@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestIndexNameFunction(t *testing.T) {
+	ctx := context.Background()
+	f := NewIndexNameFunction()
+
+	run := func(queue string, columns []string, indexType string) *function.RunResponse {
+		cols := make([]attr.Value, len(columns))
+		for i, c := range columns {
+			cols[i] = types.StringValue(c)
+		}
+		resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+		f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue(queue),
+			types.ListValueMust(types.StringType, cols),
+			types.StringValue(indexType),
+		})}, resp)
+		return resp
+	}
+
+	columns := []string{"(payload->>'user_id')", "created_at"}
+	resp := run("orders", columns, "btree")
+	if resp.Error != nil {
+		t.Fatalf("index_name() error = %v", resp.Error)
+	}
+	want := pgq.IndexName("orders", columns, "btree")
+	if got := resp.Result.Value(); !got.Equal(types.StringValue(want)) {
+		t.Errorf("index_name() = %v, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		queue     string
+		columns   []string
+		indexType string
+		arg       int64
+	}{
+		{"1orders", columns, "btree", 0},
+		{"orders", nil, "btree", 1},
+		{"orders", columns, "bloom", 2},
+	} {
+		resp := run(tt.queue, tt.columns, tt.indexType)
+		if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != tt.arg {
+			t.Errorf("index_name(%q, %q, %q) error = %v, want an error for argument %d", tt.queue, tt.columns, tt.indexType, resp.Error, tt.arg)
+		}
+	}
+}
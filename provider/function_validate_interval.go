@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*validateIntervalFunction)(nil)
+
+type validateIntervalFunction struct{}
+
+func NewValidateIntervalFunction() function.Function {
+	return &validateIntervalFunction{}
+}
+
+func (f *validateIntervalFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "validate_interval"
+}
+
+func (f *validateIntervalFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Validate and normalize a PostgreSQL interval",
+		Description: "Parses interval like PostgreSQL does, e.g. '1 day', '2 weeks', '1 hour 30 minutes' or 'P1D', " +
+			"and returns it as the server prints it, e.g. '14 days' for '2 weeks'. Invalid input fails at plan time.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "interval",
+				Description: "PostgreSQL interval input",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *validateIntervalFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var in string
+	resp.Error = req.Arguments.Get(ctx, &in)
+	if resp.Error != nil {
+		return
+	}
+
+	iv, err := pgq.ParseInterval(in)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, iv.String())
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestValidateIntervalFunction(t *testing.T) {
+	ctx := context.Background()
+	f := NewValidateIntervalFunction()
+
+	run := func(in string) *function.RunResponse {
+		resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+		f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(in)})}, resp)
+		return resp
+	}
+
+	resp := run("2 weeks")
+	if resp.Error != nil {
+		t.Fatalf("Run() error = %v", resp.Error)
+	}
+	if got := resp.Result.Value(); !got.Equal(types.StringValue("14 days")) {
+		t.Errorf("Run() = %v, want \"14 days\"", got)
+	}
+
+	resp = run("1 fortnight")
+	if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 0 {
+		t.Errorf("Run() error = %v, want an error for the interval argument", resp.Error)
+	}
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// generatedColumnModel is a STORED generated column of a pgq_queue
+type generatedColumnModel struct {
+	Name       types.String `tfsdk:"name"`
+	Type       types.String `tfsdk:"type"`
+	Expression types.String `tfsdk:"expression"`
+}
+
+// generatedColumnBlock is the schema of the generated_column blocks of
+// pgq_queue
+func generatedColumnBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		Description: "STORED generated columns, computed by PostgreSQL on insert, e.g. a message type extracted from the payload to index and filter on",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"name": schema.StringAttribute{
+					Description: "Column name",
+					Required:    true,
+					Validators: []validator.String{
+						identifierValidator(),
+						stringvalidator.NoneOf(pgq.ReservedColumns...),
+					},
+				},
+				"type": schema.StringAttribute{
+					Description: "PostgreSQL type (e.g. 'text', 'integer')",
+					Required:    true,
+					Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+				},
+				"expression": schema.StringAttribute{
+					Description: "Immutable expression over the row's other columns (e.g. \"payload->>'type'\")",
+					Required:    true,
+					Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+				},
+			},
+		},
+	}
+}
+
+func generatedColumnObjectType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"name":       types.StringType,
+			"type":       types.StringType,
+			"expression": types.StringType,
+		},
+	}
+}
+
+// generatedColumns returns the generated_column blocks as pgq columns
+func (m queueModel) generatedColumns(ctx context.Context) ([]pgq.Column, diag.Diagnostics) {
+	var models []generatedColumnModel
+	if m.GeneratedColumns.IsNull() || m.GeneratedColumns.IsUnknown() {
+		return nil, nil
+	}
+	if diags := m.GeneratedColumns.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, diags
+	}
+
+	columns := make([]pgq.Column, len(models))
+	for i, c := range models {
+		columns[i] = pgq.Column{
+			Name:      c.Name.ValueString(),
+			Type:      c.Type.ValueString(),
+			Generated: c.Expression.ValueString(),
+		}
+	}
+	return columns, nil
+}
+
+// columns returns the extra columns followed by the generated ones, which
+// may refer to them
+func (m queueModel) columns(ctx context.Context) ([]pgq.Column, diag.Diagnostics) {
+	extra, diags := m.extraColumns(ctx)
+	generated, d := m.generatedColumns(ctx)
+	diags.Append(d...)
+	return append(extra, generated...), diags
+}
+
+// readGeneratedColumns drops generated columns that no longer exist, or
+// are no longer generated, from state. The server deparses expressions,
+// so existing ones keep the configured expression and type spelling.
+// Columns Terraform doesn't manage are left alone unless all is set, for
+// imports.
+func (r *queueResource) readGeneratedColumns(ctx context.Context, m *queueModel, all bool) diag.Diagnostics {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	actual, err := r.mgr.GetExtraColumns(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read generated columns", map[string]any{"error": err})
+		return nil
+	}
+
+	configured, diags := m.generatedColumns(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	byName := make(map[string]pgq.Column, len(actual))
+	for _, c := range actual {
+		if c.Generated != "" {
+			byName[c.Name] = c
+		}
+	}
+
+	var models []generatedColumnModel
+	add := func(c pgq.Column) {
+		models = append(models, generatedColumnModel{
+			Name:       types.StringValue(c.Name),
+			Type:       types.StringValue(c.Type),
+			Expression: types.StringValue(c.Generated),
+		})
+		delete(byName, c.Name)
+	}
+
+	for _, c := range configured {
+		got, ok := byName[c.Name]
+		if !ok {
+			continue
+		}
+		if formatted, err := r.mgr.FormatType(ctx, c.Type); err == nil && formatted == got.Type {
+			got.Type = c.Type
+		}
+		got.Generated = c.Generated
+		add(got)
+	}
+
+	if all {
+		for _, c := range actual {
+			if _, ok := byName[c.Name]; ok {
+				add(c)
+			}
+		}
+	}
+
+	if len(models) == 0 {
+		m.GeneratedColumns = types.ListNull(generatedColumnObjectType())
+		return diags
+	}
+	m.GeneratedColumns, diags = types.ListValueFrom(ctx, generatedColumnObjectType(), models)
+	return diags
+}
@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/flags"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	krbtypes "github.com/jcmturner/gokrb5/v8/types"
+)
+
+type (
+	gssapiModel struct {
+		KrbSrvName types.String `tfsdk:"krb_srvname"`
+		KrbSPN     types.String `tfsdk:"krb_spn"`
+		Principal  types.String `tfsdk:"principal"`
+		Keytab     types.String `tfsdk:"keytab"`
+		CCache     types.String `tfsdk:"ccache"`
+		Krb5Conf   types.String `tfsdk:"krb5_conf"`
+	}
+
+	// gssapiAuth authenticates with Kerberos, from a keytab or from the
+	// TGT in a credential cache. One gokrb5 client is shared by every
+	// connection, which caches service tickets and, with a keytab, renews
+	// the TGT.
+	gssapiAuth struct {
+		conf      *krbconfig.Config
+		principal string
+		keytab    string
+		ccache    string
+		srvName   string
+		spn       string
+
+		mu     sync.Mutex
+		client *client.Client
+	}
+
+	// gssContext is one connection's GSS-API security context
+	gssContext struct {
+		auth *gssapiAuth
+		key  krbtypes.EncryptionKey
+		// authenticator is the one sent in the AP-REQ, which the server
+		// echoes in its AP-REP
+		authenticator krbtypes.Authenticator
+	}
+)
+
+func newGSSAPIAuth(m *gssapiModel) (*gssapiAuth, error) {
+	confPath := valOrEnv(m.Krb5Conf, "KRB5_CONFIG", "/etc/krb5.conf")
+	conf, err := loadKrbConfig(confPath, isSet(m.Krb5Conf))
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: krb5_conf: %w", err)
+	}
+
+	keytabName, err := filePath(valOrEnv(m.Keytab, "KRB5_CLIENT_KTNAME", ""))
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: keytab: %w", err)
+	}
+	ccacheName, err := filePath(valOrEnv(m.CCache, "KRB5CCNAME", defaultCCache()))
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: ccache: %w", err)
+	}
+
+	a := &gssapiAuth{
+		conf:      conf,
+		principal: m.Principal.ValueString(),
+		keytab:    keytabName,
+		ccache:    ccacheName,
+		srvName:   m.KrbSrvName.ValueString(),
+		spn:       m.KrbSPN.ValueString(),
+	}
+	if a.principal != "" && !strings.Contains(a.principal, "@") {
+		if conf.LibDefaults.DefaultRealm == "" {
+			return nil, errors.New("gssapi: principal has no realm and krb5.conf sets no default_realm")
+		}
+		a.principal += "@" + conf.LibDefaults.DefaultRealm
+	}
+
+	return a, nil
+}
+
+// loadKrbConfig reads krb5.conf. A missing file is only an error when
+// required, since KDCs can also be found in DNS. Directives gokrb5
+// doesn't support are ignored, as MIT does with unknown ones.
+func loadKrbConfig(path string, required bool) (*krbconfig.Config, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && !required {
+		conf := krbconfig.New()
+		conf.LibDefaults.DNSLookupKDC = true
+		return conf, nil
+	}
+
+	conf, err := krbconfig.Load(path)
+	var unsupported krbconfig.UnsupportedDirective
+	if err != nil && !errors.As(err, &unsupported) {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// filePath strips the FILE: residual type; other cache and keytab types
+// live outside the file system
+func filePath(name string) (string, error) {
+	if typ, rest, ok := strings.Cut(name, ":"); ok && len(typ) > 1 {
+		if typ != "FILE" {
+			return "", fmt.Errorf("%s is not supported, only FILE", typ)
+		}
+		return rest, nil
+	}
+	return name, nil
+}
+
+// defaultCCache is the MIT default credential cache of the current user
+func defaultCCache() string {
+	return fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+}
+
+// install makes the pool answer GSSAPI authentication requests. pgconn
+// keeps a single, global GSS provider, which is fine since a provider
+// process serves one configuration.
+func (a *gssapiAuth) install(poolCfg *pgxpool.Config) {
+	if a.srvName != "" {
+		poolCfg.ConnConfig.KerberosSrvName = a.srvName
+	}
+	if a.spn != "" {
+		poolCfg.ConnConfig.KerberosSpn = a.spn
+	}
+	pgconn.RegisterGSSProvider(func() (pgconn.GSS, error) {
+		return &gssContext{auth: a}, nil
+	})
+}
+
+// kerberosClient returns the shared client, logging in with the keytab or
+// loading the credential cache on first use
+func (a *gssapiAuth) kerberosClient() (*client.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	if a.keytab != "" {
+		kt, err := keytab.Load(a.keytab)
+		if err != nil {
+			return nil, fmt.Errorf("gssapi: keytab %s: %w", a.keytab, err)
+		}
+		principal := a.principal
+		if principal == "" {
+			if len(kt.Entries) == 0 {
+				return nil, fmt.Errorf("gssapi: keytab %s has no entries", a.keytab)
+			}
+			principal = kt.Entries[0].Principal.String()
+		}
+		username, realm := splitPrincipal(principal)
+
+		cl := client.NewWithKeytab(username, realm, kt, a.conf, client.DisablePAFXFAST(true))
+		if err := cl.Login(); err != nil {
+			return nil, fmt.Errorf("gssapi: ticket-granting ticket for %s: %w", principal, err)
+		}
+		a.client = cl
+		return cl, nil
+	}
+
+	cc, err := credentials.LoadCCache(a.ccache)
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: credential cache %s: %w", a.ccache, err)
+	}
+	holder := cc.DefaultPrincipal.PrincipalName.PrincipalNameString() + "@" + cc.DefaultPrincipal.Realm
+	if a.principal != "" && holder != a.principal {
+		return nil, fmt.Errorf("gssapi: credential cache %s holds tickets of %s, not %s", a.ccache, holder, a.principal)
+	}
+	cl, err := client.NewFromCCache(cc, a.conf, client.DisablePAFXFAST(true))
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: no valid ticket-granting ticket for %s in %s, run kinit: %w", holder, a.ccache, err)
+	}
+	a.client = cl
+	return cl, nil
+}
+
+// serviceTicket returns a ticket for spn and the client holding it. A
+// client from a credential cache can't renew its TGT, so after a failure
+// the cache is read again for the next connection, kinit may have
+// refreshed it.
+func (a *gssapiAuth) serviceTicket(spn string) (*client.Client, messages.Ticket, krbtypes.EncryptionKey, error) {
+	cl, err := a.kerberosClient()
+	if err != nil {
+		return nil, messages.Ticket{}, krbtypes.EncryptionKey{}, err
+	}
+
+	tkt, key, err := cl.GetServiceTicket(spn)
+	if err != nil {
+		if a.keytab == "" {
+			a.mu.Lock()
+			if a.client == cl {
+				a.client = nil
+			}
+			a.mu.Unlock()
+		}
+		return nil, tkt, key, fmt.Errorf("gssapi: ticket for %s: %w", spn, err)
+	}
+	return cl, tkt, key, nil
+}
+
+// splitPrincipal splits name/instance@REALM at the realm
+func splitPrincipal(principal string) (string, string) {
+	if i := strings.LastIndex(principal, "@"); i >= 0 {
+		return principal[:i], principal[i+1:]
+	}
+	return principal, ""
+}
+
+func (g *gssContext) GetInitToken(host, service string) ([]byte, error) {
+	return g.initToken(service + "/" + strings.ToLower(host))
+}
+
+func (g *gssContext) GetInitTokenFromSPN(spn string) ([]byte, error) {
+	// gokrb5 finds the realm of a service from krb5.conf domain_realm
+	name, _ := splitPrincipal(spn)
+	return g.initToken(name)
+}
+
+// initToken sends a raw Kerberos AP-REQ, as libpq does, requiring mutual
+// authentication since pgconn expects the server to answer the first
+// token
+func (g *gssContext) initToken(spn string) ([]byte, error) {
+	cl, tkt, key, err := g.auth.serviceTicket(spn)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := spnego.NewKRB5TokenAPREQ(cl, tkt, key,
+		[]int{gssapi.ContextFlagMutual, gssapi.ContextFlagConf, gssapi.ContextFlagInteg},
+		[]int{flags.APOptionMutualRequired})
+	if err != nil {
+		return nil, fmt.Errorf("gssapi: %w", err)
+	}
+
+	// the authenticator isn't kept in clear, and is needed to check the
+	// server's reply
+	if err := token.APReq.DecryptAuthenticator(key); err != nil {
+		return nil, fmt.Errorf("gssapi: %w", err)
+	}
+	g.key, g.authenticator = key, token.APReq.Authenticator
+
+	return token.Marshal()
+}
+
+// Continue verifies the server's AP-REP, which completes the context
+func (g *gssContext) Continue(in []byte) (bool, []byte, error) {
+	var token spnego.KRB5Token
+	if err := token.Unmarshal(in); err != nil {
+		return false, nil, fmt.Errorf("gssapi: %w", err)
+	}
+
+	switch {
+	case token.IsKRBError():
+		return false, nil, fmt.Errorf("gssapi: server rejected the ticket: %w", token.KRBError)
+	case !token.IsAPRep():
+		return false, nil, errors.New("gssapi: unexpected token, expected AP-REP")
+	}
+
+	plain, err := crypto.DecryptEncPart(token.APRep.EncPart, g.key, keyusage.AP_REP_ENCPART)
+	if err != nil {
+		return false, nil, fmt.Errorf("gssapi: %w", err)
+	}
+	var part messages.EncAPRepPart
+	if err := part.Unmarshal(plain); err != nil {
+		return false, nil, fmt.Errorf("gssapi: invalid AP-REP: %w", err)
+	}
+	if !part.CTime.Equal(g.authenticator.CTime) || part.Cusec != g.authenticator.Cusec {
+		return false, nil, errors.New("gssapi: server failed mutual authentication")
+	}
+
+	return true, nil, nil
+}
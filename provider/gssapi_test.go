@@ -0,0 +1,401 @@
+package provider
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jcmturner/gofork/encoding/asn1"
+	"github.com/jcmturner/gokrb5/v8/asn1tools"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana"
+	"github.com/jcmturner/gokrb5/v8/iana/asnAppTag"
+	"github.com/jcmturner/gokrb5/v8/iana/errorcode"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/flags"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/msgtype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/test/testdata"
+	krbtypes "github.com/jcmturner/gokrb5/v8/types"
+)
+
+const testRealm = "EXAMPLE.COM"
+
+// testKDC issues tickets for EXAMPLE.COM over TCP, knowing the keys of
+// the client, the TGS and one database service
+type testKDC struct {
+	t       *testing.T
+	client  *keytab.Keytab
+	tgs     *keytab.Keytab
+	service *keytab.Keytab
+	addr    string
+}
+
+func testKeytab(t *testing.T, principal string) *keytab.Keytab {
+	t.Helper()
+	kt := keytab.New()
+	if err := kt.AddEntry(principal, testRealm, principal+"-secret", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	return kt
+}
+
+func newTestKDC(t *testing.T) *testKDC {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	kdc := &testKDC{
+		t:       t,
+		client:  testKeytab(t, "terraform"),
+		tgs:     testKeytab(t, "krbtgt/"+testRealm),
+		service: testKeytab(t, "postgres/db.example.com"),
+		addr:    l.Addr().String(),
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			kdc.serve(conn)
+		}
+	}()
+	return kdc
+}
+
+func (k *testKDC) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return
+	}
+	req := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+
+	resp := k.reply(req)
+	_, _ = conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(resp))), resp...))
+}
+
+func (k *testKDC) reply(req []byte) []byte {
+	var as messages.ASReq
+	if err := as.Unmarshal(req); err == nil {
+		return k.issue(as.ReqBody, k.client, keyusage.AS_REP_ENCPART, msgtype.KRB_AS_REP, krbtypes.EncryptionKey{})
+	}
+
+	var tgs messages.TGSReq
+	if err := tgs.Unmarshal(req); err != nil {
+		k.t.Errorf("KDC request: %v", err)
+		return nil
+	}
+	var apReq messages.APReq
+	if err := apReq.Unmarshal(tgs.PAData[0].PADataValue); err != nil {
+		k.t.Errorf("TGS-REQ AP-REQ: %v", err)
+		return nil
+	}
+	if err := apReq.Ticket.DecryptEncPart(k.tgs, nil); err != nil {
+		k.t.Errorf("TGT: %v", err)
+		return nil
+	}
+	return k.issue(tgs.ReqBody, k.service, keyusage.TGS_REP_ENCPART_SESSION_KEY, msgtype.KRB_TGS_REP, apReq.Ticket.DecryptedEncPart.Key)
+}
+
+// issue answers a request with a ticket for its server, encrypted with
+// the server's key in keys, and the reply part encrypted with replyKey, or
+// the client's key from keys for AS-REQs
+func (k *testKDC) issue(body messages.KDCReqBody, keys *keytab.Keytab, usage uint32, msgType int, replyKey krbtypes.EncryptionKey) []byte {
+	now := time.Now().UTC().Truncate(time.Second)
+	end := now.Add(time.Hour)
+	tktFlags := krbtypes.NewKrbFlags()
+
+	serverKeys := keys
+	if msgType == msgtype.KRB_AS_REP {
+		serverKeys = k.tgs
+	}
+	tkt, sessionKey, err := messages.NewTicket(body.CName, testRealm, body.SName, testRealm, tktFlags, serverKeys,
+		etypeID.AES256_CTS_HMAC_SHA1_96, 1, now, now, end, end)
+	if err != nil {
+		krbErr := messages.NewKRBError(body.SName, testRealm, errorcode.KDC_ERR_S_PRINCIPAL_UNKNOWN, "server principal unknown")
+		b, _ := krbErr.Marshal()
+		return b
+	}
+
+	if msgType == msgtype.KRB_AS_REP {
+		if replyKey, _, err = keys.GetEncryptionKey(body.CName, testRealm, 0, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+			k.t.Errorf("client key: %v", err)
+			return nil
+		}
+	}
+
+	part := messages.EncKDCRepPart{
+		Key:       sessionKey,
+		LastReqs:  []messages.LastReq{},
+		Nonce:     body.Nonce,
+		Flags:     tktFlags,
+		AuthTime:  now,
+		StartTime: now,
+		EndTime:   end,
+		RenewTill: end,
+		SRealm:    testRealm,
+		SName:     body.SName,
+	}
+	plain, err := part.Marshal()
+	if err != nil {
+		k.t.Errorf("reply part: %v", err)
+		return nil
+	}
+	encPart, err := crypto.GetEncryptedData(plain, replyKey, usage, 1)
+	if err != nil {
+		k.t.Errorf("reply part: %v", err)
+		return nil
+	}
+
+	fields := messages.KDCRepFields{
+		PVNO:    iana.PVNO,
+		MsgType: msgType,
+		CRealm:  testRealm,
+		CName:   body.CName,
+		Ticket:  tkt,
+		EncPart: encPart,
+	}
+	var b []byte
+	if msgType == msgtype.KRB_AS_REP {
+		rep := messages.ASRep{KDCRepFields: fields}
+		b, err = rep.Marshal()
+	} else {
+		rep := messages.TGSRep{KDCRepFields: fields}
+		b, err = rep.Marshal()
+	}
+	if err != nil {
+		k.t.Errorf("reply: %v", err)
+	}
+	return b
+}
+
+// testServerAccept checks the client's AP-REQ as the database would and
+// answers with the AP-REP of mutual authentication
+func testServerAccept(t *testing.T, token []byte, serviceKeys *keytab.Keytab) []byte {
+	t.Helper()
+
+	var req spnego.KRB5Token
+	if err := req.Unmarshal(token); err != nil || !req.IsAPReq() {
+		t.Fatalf("client token is not an AP-REQ: %v", err)
+	}
+	if ok, _, err := service.VerifyAPREQ(&req.APReq, service.NewSettings(serviceKeys)); err != nil || !ok {
+		t.Fatalf("server rejected the AP-REQ: %v", err)
+	}
+	if !krbtypes.IsFlagSet(&req.APReq.APOptions, flags.APOptionMutualRequired) {
+		t.Error("AP-REQ doesn't require mutual authentication")
+	}
+
+	auth := req.APReq.Authenticator
+	if gssFlags := binary.LittleEndian.Uint32(auth.Cksum.Checksum[20:24]); gssFlags&uint32(gssapi.ContextFlagMutual) == 0 {
+		t.Errorf("authenticator GSS flags = %#x, want mutual", gssFlags)
+	}
+
+	part, err := asn1.Marshal(messages.EncAPRepPart{CTime: auth.CTime, Cusec: auth.Cusec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encPart, err := crypto.GetEncryptedData(asn1tools.AddASNAppTag(part, asnAppTag.EncAPRepPart),
+		req.APReq.Ticket.DecryptedEncPart.Key, keyusage.AP_REP_ENCPART, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := asn1.Marshal(messages.APRep{PVNO: iana.PVNO, MsgType: msgtype.KRB_AP_REP, EncPart: encPart})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oid, _ := asn1.Marshal(gssapi.OIDKRB5.OID())
+	inner := append(append(oid, 0x02, 0x00), asn1tools.AddASNAppTag(rep, asnAppTag.APREP)...)
+	return asn1tools.AddASNAppTag(inner, 0)
+}
+
+func writeKrb5Conf(t *testing.T, kdcAddr string) string {
+	t.Helper()
+	conf := filepath.Join(t.TempDir(), "krb5.conf")
+	if err := os.WriteFile(conf, []byte(`
+[libdefaults]
+	default_realm = `+testRealm+`
+	udp_preference_limit = 1
+
+[realms]
+	`+testRealm+` = {
+		kdc = `+kdcAddr+`
+	}
+
+[domain_realm]
+	.example.com = `+testRealm+`
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestGSSAPIKeytab(t *testing.T) {
+	kdc := newTestKDC(t)
+	conf := writeKrb5Conf(t, kdc.addr)
+
+	keytabFile := filepath.Join(t.TempDir(), "terraform.keytab")
+	b, err := kdc.client.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keytabFile, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := newGSSAPIAuth(&gssapiModel{
+		Principal: types.StringValue("terraform"),
+		Keytab:    types.StringValue("FILE:" + keytabFile),
+		Krb5Conf:  types.StringValue(conf),
+	})
+	if err != nil {
+		t.Fatalf("newGSSAPIAuth() error = %v", err)
+	}
+	if auth.principal != "terraform@"+testRealm {
+		t.Errorf("principal = %q, want the default realm added", auth.principal)
+	}
+
+	gss := &gssContext{auth: auth}
+	token, err := gss.GetInitToken("DB.example.com", "postgres")
+	if err != nil {
+		t.Fatalf("GetInitToken() error = %v", err)
+	}
+
+	reply := testServerAccept(t, token, kdc.service)
+	done, out, err := gss.Continue(reply)
+	if err != nil || !done || out != nil {
+		t.Fatalf("Continue() = %v, %x, %v, want done", done, out, err)
+	}
+
+	t.Run("unknown service", func(t *testing.T) {
+		_, err := (&gssContext{auth: auth}).GetInitTokenFromSPN("postgres/other.example.com@" + testRealm)
+		if err == nil || !strings.Contains(err.Error(), "KDC_ERR_S_PRINCIPAL_UNKNOWN") {
+			t.Errorf("GetInitTokenFromSPN() error = %v, want KDC_ERR_S_PRINCIPAL_UNKNOWN", err)
+		}
+	})
+
+	t.Run("mutual authentication mismatch", func(t *testing.T) {
+		other := &gssContext{auth: auth}
+		if _, err := other.GetInitToken("db.example.com", "postgres"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := other.Continue(reply); err == nil {
+			t.Error("Continue() accepted a reply to another authenticator")
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		krbErr := messages.NewKRBError(krbtypes.PrincipalName{}, testRealm, errorcode.KRB_AP_ERR_MODIFIED, "")
+		body, _ := krbErr.Marshal()
+		oid, _ := asn1.Marshal(gssapi.OIDKRB5.OID())
+		reply := asn1tools.AddASNAppTag(append(append(oid, 0x03, 0x00), body...), 0)
+		if _, _, err := gss.Continue(reply); err == nil || !strings.Contains(err.Error(), "rejected") {
+			t.Errorf("Continue(KRB-ERROR) error = %v, want rejected", err)
+		}
+	})
+}
+
+func TestGSSAPICCache(t *testing.T) {
+	// a cache of testuser1@TEST.GOKRB5 whose tickets have long expired
+	b, err := hex.DecodeString(testdata.CCACHE_TEST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccache := filepath.Join(t.TempDir(), "krb5cc")
+	if err := os.WriteFile(ccache, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// nothing listens there, so new tickets can't be had
+	conf := writeKrb5Conf(t, "127.0.0.1:1")
+
+	auth, err := newGSSAPIAuth(&gssapiModel{
+		Principal: types.StringValue("alice@TEST.GOKRB5"),
+		CCache:    types.StringValue("FILE:" + ccache),
+		Krb5Conf:  types.StringValue(conf),
+	})
+	if err != nil {
+		t.Fatalf("newGSSAPIAuth() error = %v", err)
+	}
+	_, err = (&gssContext{auth: auth}).GetInitToken("host.test.gokrb5", "HTTP")
+	if err == nil || !strings.Contains(err.Error(), "holds tickets of testuser1@TEST.GOKRB5") {
+		t.Errorf("GetInitToken() error = %v, want a principal mismatch", err)
+	}
+
+	auth.principal = ""
+	_, err = (&gssContext{auth: auth}).GetInitToken("host.test.gokrb5", "HTTP")
+	if err == nil {
+		t.Fatal("GetInitToken() with expired tickets succeeded")
+	}
+	if auth.client != nil {
+		t.Error("client from the credential cache kept after a failure, kinit wouldn't be picked up")
+	}
+}
+
+func TestLoadKrbConfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "krb5.conf")
+
+	conf, err := loadKrbConfig(missing, false)
+	if err != nil {
+		t.Fatalf("loadKrbConfig(missing, optional) error = %v", err)
+	}
+	if !conf.LibDefaults.DNSLookupKDC {
+		t.Error("without krb5.conf KDCs should be looked up in DNS")
+	}
+
+	if _, err := loadKrbConfig(missing, true); err == nil {
+		t.Error("loadKrbConfig(missing, required) succeeded")
+	}
+
+	conf, err = loadKrbConfig(writeKrb5Conf(t, "127.0.0.1:88"), true)
+	if err != nil {
+		t.Fatalf("loadKrbConfig() error = %v", err)
+	}
+	if conf.LibDefaults.DefaultRealm != testRealm {
+		t.Errorf("default_realm = %q, want %q", conf.LibDefaults.DefaultRealm, testRealm)
+	}
+}
+
+func TestNewGSSAPIAuthNoRealm(t *testing.T) {
+	t.Setenv("KRB5_CONFIG", filepath.Join(t.TempDir(), "krb5.conf"))
+
+	_, err := newGSSAPIAuth(&gssapiModel{
+		Principal: types.StringValue("terraform"),
+		Krb5Conf:  types.StringNull(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "default_realm") {
+		t.Errorf("newGSSAPIAuth() error = %v, want a default_realm error", err)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	if got, err := filePath("FILE:/tmp/krb5cc_0"); err != nil || got != "/tmp/krb5cc_0" {
+		t.Errorf("filePath(FILE:) = %q, %v", got, err)
+	}
+	if got, err := filePath("/etc/krb5.keytab"); err != nil || got != "/etc/krb5.keytab" {
+		t.Errorf("filePath(path) = %q, %v", got, err)
+	}
+	if _, err := filePath("KEYRING:persistent:1000"); err == nil {
+		t.Error("filePath(KEYRING:) succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// importDefaults sets the attributes of an imported queue to what a
+// configuration naming only the queue plans: the schema defaults, then the
+// environment profile and partition_defaults. Read overwrites whatever it
+// finds on the table, so attributes it can't read, such as the settings of
+// natively partitioned queues or the guards against destroy, don't show up
+// as changes on the first plan.
+func importDefaults(ctx context.Context, profile *environmentProfile, partitionDefaults *partitionDefaultsModel, state *tfsdk.State) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for name, a := range state.Schema.GetAttributes() {
+		var val attr.Value
+		p := path.Root(name)
+		switch a := a.(type) {
+		case schema.StringAttribute:
+			if a.Default != nil {
+				resp := &defaults.StringResponse{}
+				a.Default.DefaultString(ctx, defaults.StringRequest{Path: p}, resp)
+				diags.Append(resp.Diagnostics...)
+				val = resp.PlanValue
+			}
+		case schema.BoolAttribute:
+			if a.Default != nil {
+				resp := &defaults.BoolResponse{}
+				a.Default.DefaultBool(ctx, defaults.BoolRequest{Path: p}, resp)
+				diags.Append(resp.Diagnostics...)
+				val = resp.PlanValue
+			}
+		case schema.Int64Attribute:
+			if a.Default != nil {
+				resp := &defaults.Int64Response{}
+				a.Default.DefaultInt64(ctx, defaults.Int64Request{Path: p}, resp)
+				diags.Append(resp.Diagnostics...)
+				val = resp.PlanValue
+			}
+		case schema.ListAttribute:
+			if a.Default != nil {
+				resp := &defaults.ListResponse{}
+				a.Default.DefaultList(ctx, defaults.ListRequest{Path: p}, resp)
+				diags.Append(resp.Diagnostics...)
+				val = resp.PlanValue
+			}
+		}
+		if val != nil {
+			diags.Append(state.SetAttribute(ctx, p, val)...)
+		}
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	// the provider level defaults apply to plans, so they are applied to
+	// the state as if it were the plan of an empty configuration
+	empty := tfsdk.Config{Schema: state.Schema, Raw: tftypes.NewValue(state.Schema.Type().TerraformType(ctx), nil)}
+	plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+	diags.Append(applyProfileDefaults(ctx, profile, empty, &plan)...)
+	diags.Append(applyPartitionDefaults(ctx, partitionDefaults, empty, &plan)...)
+	state.Raw = plan.Raw
+
+	return diags
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestImportDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	(&queueResource{}).Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	state := tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	diags := importDefaults(ctx, environmentProfiles["dev"],
+		&partitionDefaultsModel{Interval: types.StringValue("1 hour"), Premake: types.Int64Null(), Retention: types.StringNull(),
+			DatetimeString: types.StringNull(), OptimizeConstraint: types.Int64Null(), DefaultPartition: types.BoolNull()},
+		&state)
+	if diags.HasError() {
+		t.Fatalf("importDefaults() diags = %v", diags)
+	}
+
+	var got queueModel
+	if diags := state.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("state.Get() diags = %v", diags)
+	}
+
+	for attr, tt := range map[string]struct{ got, want attr.Value }{
+		"force_destroy":       {got.ForceDestroy, types.BoolValue(false)},
+		"partition_interval":  {got.PartitionInterval, types.StringValue("1 hour")},
+		"partition_premake":   {got.PartitionPremake, types.Int64Value(1)},
+		"retention_period":    {got.RetentionPeriod, types.StringValue("3 days")},
+		"datetime_string":     {got.DatetimeString, types.StringValue("YYYYMMDD")},
+		"default_partition":   {got.DefaultPartition, types.BoolValue(true)},
+		"legal_hold_schema":   {got.LegalHoldSchema, types.StringValue("pgq_legal_hold")},
+		"comment":             {got.Comment, types.StringNull()},
+		"provisioned":         {got.Provisioned, types.BoolNull()},
+		"enable_partitioning": {got.EnablePartitioning, types.BoolValue(false)},
+	} {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s = %v, want %v", attr, tt.got, tt.want)
+		}
+	}
+}
+
+func TestQueueImportState(t *testing.T) {
+	ctx := context.Background()
+	r := &queueResource{}
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	importState := func(id string) (*resource.ImportStateResponse, queueModel) {
+		resp := &resource.ImportStateResponse{
+			State: tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)},
+		}
+		r.ImportState(ctx, resource.ImportStateRequest{ID: id}, resp)
+
+		var got queueModel
+		if !resp.Diagnostics.HasError() {
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("state.Get() diags = %v", diags)
+			}
+		}
+		return resp, got
+	}
+
+	// the ids listed by the pgq_queues data source
+	resp, got := importState("billing.orders_queue")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("ImportState() diags = %v", resp.Diagnostics)
+	}
+	for attr, tt := range map[string]struct{ got, want attr.Value }{
+		"id":     {got.ID, types.StringValue("billing.orders_queue")},
+		"schema": {got.Schema, types.StringValue("billing")},
+		"name":   {got.Name, types.StringValue("orders_queue")},
+	} {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s = %v, want %v", attr, tt.got, tt.want)
+		}
+	}
+
+	for _, id := range []string{"orders_queue", ""} {
+		if resp, _ := importState(id); !resp.Diagnostics.HasError() {
+			t.Errorf("ImportState(%q) should fail without a schema", id)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type indexColumnModel struct {
+	Expression types.String `tfsdk:"expression"`
+	Opclass    types.String `tfsdk:"opclass"`
+	Order      types.String `tfsdk:"order"`
+	Nulls      types.String `tfsdk:"nulls"`
+}
+
+func indexColumnObjectType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"expression": types.StringType,
+			"opclass":    types.StringType,
+			"order":      types.StringType,
+			"nulls":      types.StringType,
+		},
+	}
+}
+
+// columnSpecs returns the index columns as written in CREATE INDEX, from
+// either columns or column
+func (m customIndexModel) columnSpecs(ctx context.Context) ([]string, diag.Diagnostics) {
+	if m.Column.IsNull() || m.Column.IsUnknown() {
+		var specs []string
+		diags := m.Columns.ElementsAs(ctx, &specs, false)
+		return specs, diags
+	}
+
+	var cols []indexColumnModel
+	diags := m.Column.ElementsAs(ctx, &cols, false)
+	specs := make([]string, len(cols))
+	for i, c := range cols {
+		specs[i] = pgq.IndexColumn{
+			Expression: c.Expression.ValueString(),
+			Opclass:    c.Opclass.ValueString(),
+			Order:      c.Order.ValueString(),
+			Nulls:      c.Nulls.ValueString(),
+		}.String()
+	}
+	return specs, diags
+}
+
+// setColumns sets columns, or column if prior uses it, from the index
+// columns read from the database. Columns equivalent to prior's keep its
+// spelling, so an explicit ASC or default NULLS placement isn't a diff.
+func (m *customIndexModel) setColumns(ctx context.Context, specs []string, prior *customIndexModel) diag.Diagnostics {
+	if prior != nil {
+		priorSpecs, diags := prior.columnSpecs(ctx)
+		if diags.HasError() {
+			return diags
+		}
+		if pgq.IndexColumnsEquivalent(priorSpecs, specs) {
+			m.Columns, m.Column = prior.Columns, prior.Column
+			return nil
+		}
+	}
+
+	var diags diag.Diagnostics
+	if prior == nil || prior.Column.IsNull() {
+		m.Columns, diags = types.ListValueFrom(ctx, types.StringType, specs)
+		m.Column = types.ListNull(indexColumnObjectType())
+		return diags
+	}
+
+	cols := make([]indexColumnModel, len(specs))
+	for i, spec := range specs {
+		c := pgq.ParseIndexColumn(spec)
+		cols[i] = indexColumnModel{
+			Expression: types.StringValue(c.Expression),
+			Opclass:    stringOrNull(c.Opclass),
+			Order:      stringOrNull(c.Order),
+			Nulls:      stringOrNull(c.Nulls),
+		}
+	}
+	m.Columns = types.ListNull(types.StringType)
+	m.Column, diags = types.ListValueFrom(ctx, indexColumnObjectType(), cols)
+	return diags
+}
+
+// stringOrNull returns s as a string value, null when empty
+func stringOrNull(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
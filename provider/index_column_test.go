@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCustomIndexModelSetColumns(t *testing.T) {
+	ctx := context.Background()
+	column := func(expr, opclass, order, nulls types.String) attr.Value {
+		return types.ObjectValueMust(indexColumnObjectType().AttrTypes, map[string]attr.Value{
+			"expression": expr, "opclass": opclass, "order": order, "nulls": nulls,
+		})
+	}
+	prior := customIndexModel{
+		Columns: types.ListNull(types.StringType),
+		Column: types.ListValueMust(indexColumnObjectType(), []attr.Value{
+			column(types.StringValue("created_at"), types.StringNull(), types.StringValue("DESC"), types.StringValue("FIRST")),
+			column(types.StringValue("payload"), types.StringValue("jsonb_path_ops"), types.StringValue("ASC"), types.StringNull()),
+		}),
+	}
+
+	specs, _ := prior.columnSpecs(ctx)
+	if len(specs) != 2 || specs[0] != "created_at DESC NULLS FIRST" || specs[1] != "payload jsonb_path_ops ASC" {
+		t.Errorf("columnSpecs() = %q", specs)
+	}
+
+	// pg_get_indexdef leaves out the defaults
+	var m customIndexModel
+	m.setColumns(ctx, []string{"created_at DESC", "payload jsonb_path_ops"}, &prior)
+	if !m.Column.Equal(prior.Column) || !m.Columns.IsNull() {
+		t.Errorf("setColumns() = %v, %v, want the prior columns", m.Columns, m.Column)
+	}
+
+	m.setColumns(ctx, []string{"created_at DESC NULLS LAST", "payload jsonb_path_ops"}, &prior)
+	want := types.ListValueMust(indexColumnObjectType(), []attr.Value{
+		column(types.StringValue("created_at"), types.StringNull(), types.StringValue("DESC"), types.StringValue("LAST")),
+		column(types.StringValue("payload"), types.StringValue("jsonb_path_ops"), types.StringNull(), types.StringNull()),
+	})
+	if !m.Column.Equal(want) {
+		t.Errorf("setColumns() column = %v, want %v after drift", m.Column, want)
+	}
+
+	m.setColumns(ctx, []string{"created_at DESC"}, nil)
+	if !m.Columns.Equal(types.ListValueMust(types.StringType, []attr.Value{types.StringValue("created_at DESC")})) || !m.Column.IsNull() {
+		t.Errorf("setColumns() = %v, %v, want columns on import", m.Columns, m.Column)
+	}
+}
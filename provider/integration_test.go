@@ -0,0 +1,276 @@
+//go:build integration
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	connStr := fmt.Sprintf(
+		"host=%s port=%s database=%s user=%s password=%s sslmode=disable",
+		getEnv("PGHOST", "localhost"),
+		getEnv("PGPORT", "5432"),
+		getEnv("PGDATABASE", "postgres"),
+		getEnv("PGUSER", "postgres"),
+		getEnv("PGPASSWORD", ""),
+	)
+
+	pool, err := pgxpool.New(context.Background(), connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping database: %v", err)
+	}
+
+	return pool
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func TestQueueConfirmEmpty(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := pgq.NewManager(pool)
+	ctx := context.Background()
+
+	schema := pgq.SchemaName("public")
+	name := pgq.QueueName(fmt.Sprintf("test_confirm_empty_%d", os.Getpid()))
+
+	defer mgr.Drop(ctx, schema, name)
+
+	if err := mgr.CreateSimple(ctx, schema, name, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	r := &queueResource{mgr: mgr}
+	state := queueModel{
+		Name:            types.StringValue(name.String()),
+		Schema:          types.StringValue(schema.String()),
+		PreventNonEmpty: types.BoolValue(true),
+		ForceDestroy:    types.BoolValue(false),
+	}
+
+	if err := r.confirmEmpty(ctx, state); err != nil {
+		t.Errorf("confirmEmpty() on an empty queue error = %v", err)
+	}
+
+	if _, err := pool.Exec(ctx, "INSERT INTO "+pgq.MakeFQN(schema, name).Sanitize()+" (payload, metadata) VALUES ('{}', '{}')"); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	if err := r.confirmEmpty(ctx, state); err == nil {
+		t.Error("confirmEmpty() should refuse to drop a queue holding a message")
+	}
+
+	// force_destroy counts once it is in state, i.e. applied before the destroy
+	state.ForceDestroy = types.BoolValue(true)
+	if err := r.confirmEmpty(ctx, state); err != nil {
+		t.Errorf("confirmEmpty() with force_destroy error = %v", err)
+	}
+}
+
+func TestQueueRenameUpdatesState(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := pgq.NewManager(pool)
+	ctx := context.Background()
+
+	schema := pgq.SchemaName("public")
+	from := pgq.QueueName(fmt.Sprintf("test_rename_state_%d", os.Getpid()))
+	to := pgq.QueueName(from.String() + "_renamed")
+
+	defer mgr.Drop(ctx, schema, from)
+	defer mgr.Drop(ctx, schema, to)
+
+	if err := mgr.CreateSimple(ctx, schema, from, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	r := &queueResource{mgr: mgr}
+	state := queueModel{
+		ID:                 types.StringValue(pgq.MakeFQN(schema, from).String()),
+		Name:               types.StringValue(from.String()),
+		Schema:             types.StringValue(schema.String()),
+		EnablePartitioning: types.BoolValue(false),
+		ClusterSchedule:    types.StringNull(),
+	}
+	plan := state
+	plan.Name = types.StringValue(to.String())
+
+	if err := r.renameQueue(ctx, plan, &state); err != nil {
+		t.Fatalf("renameQueue() error = %v", err)
+	}
+
+	// Update saves this state before its later steps, so it must point at
+	// the renamed queue
+	if want := pgq.MakeFQN(schema, to).String(); state.ID.ValueString() != want {
+		t.Errorf("renameQueue() state id = %s, want %s", state.ID.ValueString(), want)
+	}
+	if _, err := mgr.Get(ctx, schema, to); err != nil {
+		t.Errorf("Get(%s) after rename error = %v", to, err)
+	}
+}
+
+func TestQueueRelocateDeadLetter(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := pgq.NewManager(pool)
+	ctx := context.Background()
+
+	schema := pgq.SchemaName("public")
+	name := pgq.QueueName(fmt.Sprintf("test_relocate_dlq_%d", os.Getpid()))
+	// the dead-letter queue a failed rename left behind
+	stale := pgq.QueueName(name.String() + "_old_dlq")
+	dlq := pgq.QueueName(name.String() + "_dlq")
+
+	defer mgr.Drop(ctx, schema, stale)
+	defer mgr.Drop(ctx, schema, dlq)
+
+	if err := mgr.CreateSimple(ctx, schema, stale, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+
+	r := &queueResource{mgr: mgr}
+	state := queueModel{
+		Name:               types.StringValue(name.String()),
+		Schema:             types.StringValue(schema.String()),
+		EnablePartitioning: types.BoolValue(false),
+		DeadLetter:         &deadLetterModel{EnablePartitioning: types.BoolValue(false)},
+		DeadLetterQueue:    types.StringValue(pgq.MakeFQN(schema, stale).String()),
+	}
+
+	if err := r.relocateDeadLetter(ctx, &state); err != nil {
+		t.Fatalf("relocateDeadLetter() error = %v", err)
+	}
+	if want := pgq.MakeFQN(schema, dlq).String(); state.DeadLetterQueue.ValueString() != want {
+		t.Errorf("relocateDeadLetter() dead_letter_queue = %s, want %s", state.DeadLetterQueue.ValueString(), want)
+	}
+	if _, err := mgr.Get(ctx, schema, dlq); err != nil {
+		t.Errorf("Get(%s) after relocation error = %v", dlq, err)
+	}
+}
+
+func TestConnectionEphemeralResource(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	data := openConnection(t, pgq.NewManager(pool))
+
+	if !data.Connected.ValueBool() || !data.Error.IsNull() {
+		t.Fatalf("connected = %v, error = %v, want a connection", data.Connected, data.Error)
+	}
+	if data.ServerVersion.ValueString() == "" {
+		t.Error("server_version should be set when connected")
+	}
+	if data.PartmanInstalled.IsNull() {
+		t.Error("partman_installed should be known when connected")
+	}
+	if data.PartmanInstalled.ValueBool() == data.PartmanVersion.IsNull() {
+		t.Errorf("partman_installed = %v but partman_version = %v", data.PartmanInstalled, data.PartmanVersion)
+	}
+}
+
+func TestQueuesDataSource(t *testing.T) {
+	pool := testPool(t)
+	defer pool.Close()
+
+	mgr := pgq.NewManager(pool)
+	ctx := context.Background()
+
+	schema := pgq.SchemaName("public")
+	simple := pgq.QueueName(fmt.Sprintf("test_queues_ds_%d", os.Getpid()))
+	partitioned := pgq.QueueName(simple.String() + "_part")
+
+	defer mgr.Drop(ctx, schema, simple)
+	defer mgr.Drop(ctx, schema, partitioned)
+	defer mgr.RemovePartmanConfig(ctx, schema, partitioned)
+
+	if err := mgr.CreateSimple(ctx, schema, simple, nil); err != nil {
+		t.Fatalf("CreateSimple() error = %v", err)
+	}
+	cfg := &pgq.PartitionConfig{Interval: "1 day", Premake: 1, DatetimeString: "YYYYMMDD"}
+	if err := mgr.CreatePartitioned(ctx, schema, partitioned, cfg, nil); err != nil {
+		t.Fatalf("CreatePartitioned() error = %v", err)
+	}
+
+	d := &queuesDataSource{mgr: mgr}
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+	null := tftypes.NewValue(s.Type().TerraformType(ctx), nil)
+
+	// tfsdk.Config can't be set, so the configuration is built as a state
+	config := tfsdk.State{Schema: s, Raw: null}
+	if diags := config.SetAttribute(ctx, path.Root("schema"), schema.String()); diags.HasError() {
+		t.Fatalf("config.SetAttribute() diags = %v", diags)
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: s, Raw: null}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: s, Raw: config.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read() diags = %v", resp.Diagnostics)
+	}
+
+	var got queuesModel
+	if diags := resp.State.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("state.Get() diags = %v", diags)
+	}
+	var ids []string
+	if diags := got.IDs.ElementsAs(ctx, &ids, false); diags.HasError() {
+		t.Fatalf("ids diags = %v", diags)
+	}
+
+	if got.ID.ValueString() != schema.String() {
+		t.Errorf("id = %v, want %s", got.ID, schema)
+	}
+	for _, q := range []pgq.QueueName{simple, partitioned} {
+		id := pgq.MakeFQN(schema, q).String()
+		if !slices.Contains(ids, id) {
+			t.Errorf("ids = %v, missing %s", ids, id)
+		}
+	}
+	for _, q := range got.Queues {
+		if q.Name.ValueString() == partitioned.String() && !q.Partitioned.ValueBool() {
+			t.Errorf("queue %s listed as not partitioned", q.ID.ValueString())
+		}
+	}
+
+	// every listed id is accepted as a pgq_queue import id
+	r := &queueResource{}
+	rSchema := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, rSchema)
+	for _, id := range ids {
+		importResp := &resource.ImportStateResponse{
+			State: tfsdk.State{Schema: rSchema.Schema, Raw: tftypes.NewValue(rSchema.Schema.Type().TerraformType(ctx), nil)},
+		}
+		r.ImportState(ctx, resource.ImportStateRequest{ID: id}, importResp)
+		if importResp.Diagnostics.HasError() {
+			t.Errorf("ImportState(%q) diags = %v", id, importResp.Diagnostics)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// keepalive holds the TCP keepalive and user timeout settings of
+// connections to the server
+type keepalive struct {
+	disabled    bool
+	idle        time.Duration
+	interval    time.Duration
+	count       int
+	userTimeout time.Duration
+}
+
+// libpqKeepaliveParams are the libpq keepalive parameters, which pgx
+// would send to the server as unknown settings
+var libpqKeepaliveParams = []string{"keepalives", "keepalives_idle", "keepalives_interval", "keepalives_count", "tcp_user_timeout"}
+
+// newKeepalive reads the keepalive parameters of the connection string,
+// removing them from the startup parameters, and then the provider
+// attributes, which take precedence. It returns nil if none is set.
+func newKeepalive(poolCfg *pgxpool.Config, cfg config) (*keepalive, error) {
+	params := poolCfg.ConnConfig.RuntimeParams
+	found := false
+	for _, name := range libpqKeepaliveParams {
+		if _, ok := params[name]; ok {
+			found = true
+		}
+	}
+
+	set := found || !cfg.Keepalives.IsNull() || !cfg.KeepalivesCount.IsNull() ||
+		isSet(cfg.KeepalivesIdle) || isSet(cfg.KeepalivesInterval) || isSet(cfg.TCPUserTimeout)
+	if !set {
+		return nil, nil
+	}
+
+	k := &keepalive{}
+
+	// libpq takes seconds, and milliseconds for tcp_user_timeout
+	for _, p := range []struct {
+		name   string
+		target *time.Duration
+		unit   time.Duration
+	}{
+		{"keepalives_idle", &k.idle, time.Second},
+		{"keepalives_interval", &k.interval, time.Second},
+		{"tcp_user_timeout", &k.userTimeout, time.Millisecond},
+	} {
+		v, ok := params[p.name]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a non-negative integer", p.name, v)
+		}
+		*p.target = time.Duration(n) * p.unit
+	}
+	if v, ok := params["keepalives_count"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid keepalives_count %q: must be a non-negative integer", v)
+		}
+		k.count = n
+	}
+	if v, ok := params["keepalives"]; ok {
+		k.disabled = v == "0"
+	}
+	for _, name := range libpqKeepaliveParams {
+		delete(params, name)
+	}
+
+	if !cfg.Keepalives.IsNull() {
+		k.disabled = !cfg.Keepalives.ValueBool()
+	}
+	if !cfg.KeepalivesCount.IsNull() {
+		k.count = int(cfg.KeepalivesCount.ValueInt64())
+	}
+	for _, d := range []struct {
+		name   string
+		val    types.String
+		target *time.Duration
+	}{
+		{"keepalives_idle", cfg.KeepalivesIdle, &k.idle},
+		{"keepalives_interval", cfg.KeepalivesInterval, &k.interval},
+		{"tcp_user_timeout", cfg.TCPUserTimeout, &k.userTimeout},
+	} {
+		if !isSet(d.val) {
+			continue
+		}
+		dur, err := time.ParseDuration(d.val.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.target = dur
+	}
+
+	if k.userTimeout > 0 && !userTimeoutSupported {
+		return nil, fmt.Errorf("tcp_user_timeout is not supported on this platform")
+	}
+
+	return k, nil
+}
+
+// install dials connections to the server with the keepalive settings
+func (k *keepalive) install(poolCfg *pgxpool.Config) {
+	dialer := &net.Dialer{}
+	if k.disabled {
+		dialer.KeepAlive = -1
+	} else {
+		dialer.KeepAliveConfig = net.KeepAliveConfig{
+			Enable:   true,
+			Idle:     k.idle,
+			Interval: k.interval,
+			Count:    k.count,
+		}
+	}
+	if k.userTimeout > 0 {
+		dialer.Control = userTimeoutControl(k.userTimeout)
+	}
+
+	poolCfg.ConnConfig.DialFunc = dialer.DialContext
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package provider
+
+import (
+	"syscall"
+	"time"
+)
+
+// TCP_USER_TIMEOUT is Linux specific
+const userTimeoutSupported = false
+
+func userTimeoutControl(time.Duration) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestNewKeepalive(t *testing.T) {
+	tests := []struct {
+		name    string
+		connStr string
+		cfg     config
+		want    *keepalive
+		wantErr bool
+	}{
+		{
+			name:    "unset",
+			connStr: "host=localhost",
+		},
+		{
+			name:    "connection string",
+			connStr: "host=localhost keepalives_idle=30 keepalives_count=3 tcp_user_timeout=120000",
+			want:    &keepalive{idle: 30 * time.Second, count: 3, userTimeout: 2 * time.Minute},
+		},
+		{
+			name:    "attributes override the connection string",
+			connStr: "host=localhost keepalives_idle=30 keepalives=0",
+			cfg: config{
+				Keepalives:         types.BoolValue(true),
+				KeepalivesIdle:     types.StringValue("1m"),
+				KeepalivesInterval: types.StringValue("10s"),
+			},
+			want: &keepalive{idle: time.Minute, interval: 10 * time.Second},
+		},
+		{
+			name:    "disabled",
+			connStr: "host=localhost",
+			cfg:     config{Keepalives: types.BoolValue(false)},
+			want:    &keepalive{disabled: true},
+		},
+		{
+			name:    "invalid libpq value",
+			connStr: "host=localhost keepalives_idle=30s",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolCfg, err := pgxpool.ParseConfig(tt.connStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := newKeepalive(poolCfg, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newKeepalive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("newKeepalive() = %+v, want %+v", got, tt.want)
+			}
+			for _, name := range libpqKeepaliveParams {
+				if _, ok := poolCfg.ConnConfig.RuntimeParams[name]; ok {
+					t.Errorf("%s is still sent to the server", name)
+				}
+			}
+		})
+	}
+}
+
+func TestKeepaliveDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	poolCfg, err := pgxpool.ParseConfig("host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &keepalive{idle: 30 * time.Second, interval: 5 * time.Second, count: 3}
+	if userTimeoutSupported {
+		k.userTimeout = time.Minute
+	}
+	k.install(poolCfg)
+
+	conn, err := poolCfg.ConnConfig.DialFunc(context.Background(), "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("DialFunc() error = %v", err)
+	}
+	conn.Close()
+}
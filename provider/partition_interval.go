@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// datetimeField is a to_char field of datetime_string with the interval
+// below which partitions of a day need it to get distinct names
+type datetimeField struct {
+	pattern string
+	below   time.Duration
+}
+
+var datetimeFields = []datetimeField{
+	{"HH24", 24 * time.Hour},
+	{"MI", time.Hour},
+	{"SS", time.Minute},
+}
+
+// validatePartitionInterval checks the range partitioning attributes of a
+// planned partitioned queue against each other: datetime_string must tell
+// the partitions of partition_interval apart, and a retention_period
+// shorter than the partitions partition_premake creates ahead is flagged,
+// as it usually means the two are swapped.
+func validatePartitionInterval(ctx context.Context, plan tfsdk.Plan, strategy string) diag.Diagnostics {
+	var (
+		diags                      diag.Diagnostics
+		enabled                    types.Bool
+		interval, retention, datef types.String
+		premake                    types.Int64
+	)
+	diags.Append(plan.GetAttribute(ctx, path.Root("enable_partitioning"), &enabled)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("partition_interval"), &interval)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("retention_period"), &retention)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("datetime_string"), &datef)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("partition_premake"), &premake)...)
+	if diags.HasError() || !enabled.ValueBool() || strategy != pgq.PartitionRange || !isSet(interval) {
+		return diags
+	}
+
+	iv, err := pgq.ParseInterval(interval.ValueString())
+	if err != nil {
+		// reported by the attribute's validator
+		return diags
+	}
+
+	if isSet(datef) {
+		if missing := datetimeFieldNeeded(iv, datef.ValueString()); missing != "" {
+			diags.AddAttributeError(path.Root("datetime_string"), "Partition names not unique",
+				fmt.Sprintf("datetime_string %q lacks %s, so partitions of partition_interval %q would get the same names.",
+					datef.ValueString(), missing, interval.ValueString()))
+		}
+	}
+
+	if isSet(retention) && !premake.IsNull() && !premake.IsUnknown() {
+		ret, err := pgq.ParseInterval(retention.ValueString())
+		if err == nil && ret.Approximate() < iv.Approximate()*time.Duration(premake.ValueInt64()) {
+			diags.AddAttributeWarning(path.Root("retention_period"), "Retention shorter than the premade partitions",
+				fmt.Sprintf("retention_period %q is shorter than the %d partitions of %q that partition_premake creates ahead. "+
+					"Check that partition_interval and retention_period aren't swapped.",
+					retention.ValueString(), premake.ValueInt64(), interval.ValueString()))
+		}
+	}
+
+	return diags
+}
+
+// datetimeFieldNeeded returns the to_char field datetime_string needs to
+// name partitions of interval iv apart, or an empty string if it has them
+func datetimeFieldNeeded(iv pgq.Interval, datetimeString string) string {
+	if iv.Microseconds != 0 {
+		length := time.Duration(iv.Microseconds) * time.Microsecond
+		for _, f := range datetimeFields {
+			if length%f.below != 0 && !strings.Contains(datetimeString, f.pattern) {
+				return f.pattern
+			}
+		}
+	}
+	switch {
+	case iv.Microseconds != 0 || (iv.Days != 0 && iv.Days%7 != 0):
+		if !strings.Contains(datetimeString, "DD") {
+			return "DD"
+		}
+	case iv.Days != 0:
+		if !strings.Contains(datetimeString, "DD") && !strings.Contains(datetimeString, "IW") {
+			return "DD or IW"
+		}
+	case iv.Months%3 == 0 && iv.Months%12 != 0:
+		if !strings.Contains(datetimeString, "MM") && !strings.Contains(datetimeString, "Q") {
+			return "MM or Q"
+		}
+	case iv.Months%12 != 0:
+		if !strings.Contains(datetimeString, "MM") {
+			return "MM"
+		}
+	}
+	return ""
+}
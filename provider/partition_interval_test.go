@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDatetimeFieldNeeded(t *testing.T) {
+	tests := []struct {
+		interval, datetime string
+		want               string
+	}{
+		{"1 day", "YYYYMMDD", ""},
+		{"1 day", "YYYY_MM", "DD"},
+		{"1 week", "IYYY_IW", ""},
+		{"1 week", "YYYYMMDD", ""},
+		{"1 week", "YYYY_MM", "DD or IW"},
+		{"1 month", "YYYY_MM", ""},
+		{"1 month", "YYYY", "MM"},
+		{"3 months", "YYYY_Q", ""},
+		{"3 months", "YYYY", "MM or Q"},
+		{"1 year", "YYYY", ""},
+		{"1 hour", "YYYYMMDD", "HH24"},
+		{"1 hour", "YYYYMMDD_HH24MISS", ""},
+		{"30 minutes", "YYYYMMDD_HH24", "MI"},
+		{"1 day 12:00:00", "YYYYMMDD", "HH24"},
+	}
+	for _, tt := range tests {
+		iv, err := pgq.ParseInterval(tt.interval)
+		if err != nil {
+			t.Fatalf("ParseInterval(%q) error = %v", tt.interval, err)
+		}
+		if got := datetimeFieldNeeded(iv, tt.datetime); got != tt.want {
+			t.Errorf("datetimeFieldNeeded(%q, %q) = %q, want %q", tt.interval, tt.datetime, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePartitionInterval(t *testing.T) {
+	ctx := context.Background()
+
+	s := schema.Schema{Attributes: map[string]schema.Attribute{
+		"enable_partitioning": schema.BoolAttribute{Optional: true},
+		"partition_interval":  schema.StringAttribute{Optional: true},
+		"retention_period":    schema.StringAttribute{Optional: true},
+		"datetime_string":     schema.StringAttribute{Optional: true},
+		"partition_premake":   schema.Int64Attribute{Optional: true},
+	}}
+	plan := func(enabled bool, interval, retention, datetime string, premake int64) tfsdk.Plan {
+		return tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), map[string]tftypes.Value{
+			"enable_partitioning": tftypes.NewValue(tftypes.Bool, enabled),
+			"partition_interval":  tftypes.NewValue(tftypes.String, interval),
+			"retention_period":    tftypes.NewValue(tftypes.String, retention),
+			"datetime_string":     tftypes.NewValue(tftypes.String, datetime),
+			"partition_premake":   tftypes.NewValue(tftypes.Number, premake),
+		})}
+	}
+
+	tests := []struct {
+		name     string
+		plan     tfsdk.Plan
+		strategy string
+		errors   int
+		warnings int
+	}{
+		{"defaults", plan(true, "1 day", "14 days", "YYYYMMDD", 7), pgq.PartitionRange, 0, 0},
+		{"hourly names", plan(true, "1 hour", "14 days", "YYYYMMDD", 7), pgq.PartitionRange, 1, 0},
+		{"swapped", plan(true, "14 days", "1 day", "YYYYMMDD", 7), pgq.PartitionRange, 0, 1},
+		{"no retention", plan(true, "1 day", "", "YYYYMMDD", 7), pgq.PartitionRange, 0, 0},
+		{"simple queue", plan(false, "1 hour", "1 day", "YYYYMMDD", 7), pgq.PartitionRange, 0, 0},
+		{"list", plan(true, "1 hour", "1 day", "YYYYMMDD", 7), pgq.PartitionList, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := validatePartitionInterval(ctx, tt.plan, tt.strategy)
+			if got := diags.ErrorsCount(); got != tt.errors {
+				t.Errorf("errors = %d, want %d: %v", got, tt.errors, diags)
+			}
+			if got := len(diags.Warnings()); got != tt.warnings {
+				t.Errorf("warnings = %d, want %d: %v", got, tt.warnings, diags)
+			}
+		})
+	}
+}
+
+func TestIntervalValidator(t *testing.T) {
+	ctx := context.Background()
+
+	for in, valid := range map[string]bool{
+		"1 day":     true,
+		"P14D":      true,
+		"":          true,
+		"1 dya":     false,
+		"0 days":    false,
+		"1 day ago": false,
+	} {
+		resp := &validator.StringResponse{}
+		intervalValidator().ValidateString(ctx, validator.StringRequest{Path: path.Root("partition_interval"), ConfigValue: types.StringValue(in)}, resp)
+		if resp.Diagnostics.HasError() == valid {
+			t.Errorf("intervalValidator(%q) diags = %v, want valid = %v", in, resp.Diagnostics, valid)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// setPartitionKey sets partition_strategy, partition_key and
+// hash_partitions from a partitioned queue
+func (m *queueModel) setPartitionKey(q *pgq.Queue) {
+	m.PartitionStrategy = types.StringValue(q.Strategy)
+	if q.Key == "created_at" {
+		m.PartitionKey = types.StringNull()
+	} else {
+		m.PartitionKey = types.StringValue(q.Key)
+	}
+	if q.Strategy == pgq.PartitionHash {
+		m.HashPartitions = types.Int64Value(int64(q.Partitions))
+	} else {
+		m.HashPartitions = types.Int64Null()
+	}
+}
+
+// validatePartitionStrategy checks partition_strategy against the
+// attributes it needs and the partitioning_mode that can maintain it
+func validatePartitionStrategy(ctx context.Context, resp *resource.ModifyPlanResponse) {
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if plan.PartitionStrategy.IsUnknown() {
+		return
+	}
+	strategy := plan.PartitionStrategy.ValueString()
+
+	if strategy != pgq.PartitionRange || isSet(plan.PartitionKey) {
+		if !plan.EnablePartitioning.IsUnknown() && !plan.EnablePartitioning.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("partition_strategy"), "Partitioning a simple queue",
+				"partition_strategy and partition_key require enable_partitioning = true.")
+		}
+	}
+
+	switch strategy {
+	case pgq.PartitionHash:
+		if !plan.PartitioningMode.IsUnknown() && !plan.nativePartitioning() {
+			resp.Diagnostics.AddAttributeError(path.Root("partition_strategy"), "Hash partitioning needs native partitioning",
+				fmt.Sprintf("pg_partman doesn't support hash partitioning; set partitioning_mode = %q.", partitioningNative))
+		}
+		if plan.HashPartitions.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("hash_partitions"), "Missing hash partition count",
+				"partition_strategy = \"hash\" requires hash_partitions.")
+		}
+	default:
+		if !plan.HashPartitions.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("hash_partitions"), "Hash partition count without hash partitioning",
+				"hash_partitions requires partition_strategy = \"hash\".")
+		}
+	}
+
+	if strategy != pgq.PartitionRange {
+		if plan.PartitionKey.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("partition_key"), "Missing partition key",
+				fmt.Sprintf("partition_strategy = %q requires partition_key.", strategy))
+		}
+		if plan.SubPartition != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("sub_partition"), "Sub-partitioning without range partitioning",
+				"sub_partition requires partition_strategy = \"range\".")
+		}
+	}
+
+	if !isSet(plan.PartitionKey) || plan.PartitionKey.ValueString() == "created_at" {
+		return
+	}
+
+	// the primary key includes the partition key, which must therefore be
+	// an extra_column that is always set
+	columns, diags := plan.extraColumns(ctx)
+	resp.Diagnostics.Append(diags...)
+	for _, c := range columns {
+		if c.Name != plan.PartitionKey.ValueString() {
+			continue
+		}
+		if !c.NotNull {
+			resp.Diagnostics.AddAttributeError(path.Root("partition_key"), "Nullable partition key",
+				fmt.Sprintf("Partition key %s becomes part of the primary key; set nullable = false on its extra_column.", c.Name))
+		}
+		return
+	}
+	if !plan.ExtraColumns.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("partition_key"), "Unknown partition key",
+			fmt.Sprintf("Partition key %s must be created_at or an extra_column.", plan.PartitionKey.ValueString()))
+	}
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestQueueModelSetPartitionKey(t *testing.T) {
+	tests := []struct {
+		q          pgq.Queue
+		wantKey    types.String
+		wantHashes types.Int64
+	}{
+		{pgq.Queue{Strategy: pgq.PartitionRange, Key: "created_at", Partitions: 9}, types.StringNull(), types.Int64Null()},
+		{pgq.Queue{Strategy: pgq.PartitionList, Key: "tenant_id", Partitions: 9}, types.StringValue("tenant_id"), types.Int64Null()},
+		{pgq.Queue{Strategy: pgq.PartitionHash, Key: "tenant_id", Partitions: 8}, types.StringValue("tenant_id"), types.Int64Value(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.q.Strategy, func(t *testing.T) {
+			var m queueModel
+			m.setPartitionKey(&tt.q)
+			if m.PartitionStrategy.ValueString() != tt.q.Strategy {
+				t.Errorf("partition_strategy = %v, want %q", m.PartitionStrategy, tt.q.Strategy)
+			}
+			if !m.PartitionKey.Equal(tt.wantKey) {
+				t.Errorf("partition_key = %v, want %v", m.PartitionKey, tt.wantKey)
+			}
+			if !m.HashPartitions.Equal(tt.wantHashes) {
+				t.Errorf("hash_partitions = %v, want %v", m.HashPartitions, tt.wantHashes)
+			}
+
+			cfg := m.partitionConfig()
+			if cfg.Strategy != tt.q.Strategy || int64(cfg.HashPartitions) != tt.wantHashes.ValueInt64() {
+				t.Errorf("partitionConfig() strategy = %q, hash partitions = %d", cfg.Strategy, cfg.HashPartitions)
+			}
+		})
+	}
+}
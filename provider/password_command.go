@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runPasswordCommand runs the password_command program, without a shell,
+// and returns what it prints minus the trailing newline
+func runPasswordCommand(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "" {
+		return "", errors.New("password_command: program is required")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("password_command %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("password_command %s: %w", args[0], err)
+	}
+
+	password := strings.TrimRight(stdout.String(), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("password_command %s: printed no password", args[0])
+	}
+
+	return password, nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunPasswordCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{"trailing newline trimmed", []string{"echo", "s3cret"}, "s3cret", ""},
+		{"inner spaces kept", []string{"printf", " a b "}, " a b ", ""},
+		{"no program", nil, "", "program is required"},
+		{"failure shows stderr", []string{"sh", "-c", "echo denied >&2; exit 3"}, "", "denied"},
+		{"empty output", []string{"true"}, "", "printed no password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runPasswordCommand(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runPasswordCommand() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runPasswordCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("runPasswordCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
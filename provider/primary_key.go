@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// keyPartitioning returns the partitioning shaping the default primary
+// key, nil for simple queues
+func (m queueModel) keyPartitioning() *pgq.PartitionConfig {
+	if !m.EnablePartitioning.ValueBool() {
+		return nil
+	}
+	return m.partitionConfig()
+}
+
+// primaryKey returns the configured primary key columns, or the default
+// ones when primary_key is null
+func (m queueModel) primaryKey(ctx context.Context) ([]string, diag.Diagnostics) {
+	if m.PrimaryKey.IsNull() || m.PrimaryKey.IsUnknown() {
+		return pgq.DefaultPrimaryKey(m.keyPartitioning()), nil
+	}
+	var cols []string
+	diags := m.PrimaryKey.ElementsAs(ctx, &cols, false)
+	return cols, diags
+}
+
+// setPrimaryKey sets primary_key from the queue's key, keeping it null
+// while it is the default one
+func (m *queueModel) setPrimaryKey(ctx context.Context, cols []string) diag.Diagnostics {
+	if m.PrimaryKey.IsNull() && slices.Equal(cols, pgq.DefaultPrimaryKey(m.keyPartitioning())) {
+		return nil
+	}
+	var diags diag.Diagnostics
+	m.PrimaryKey, diags = types.ListValueFrom(ctx, types.StringType, cols)
+	return diags
+}
+
+// validatePrimaryKey checks that primary_key contains the columns every
+// key needs, and only ones that are always set
+func validatePrimaryKey(ctx context.Context, resp *resource.ModifyPlanResponse) {
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if plan.PrimaryKey.IsNull() || plan.PrimaryKey.IsUnknown() || plan.EnablePartitioning.IsUnknown() {
+		return
+	}
+
+	key, diags := plan.primaryKey(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	p := path.Root("primary_key")
+	for _, col := range pgq.DefaultPrimaryKey(plan.keyPartitioning()) {
+		if !slices.Contains(key, col) {
+			resp.Diagnostics.AddAttributeError(p, "Incomplete primary key",
+				fmt.Sprintf("primary_key must contain %s: every key contains id and, on partitioned queues, the partition key columns.", col))
+		}
+	}
+
+	// the key would make a nullable column NOT NULL behind extra_column's
+	// back
+	columns, diags := plan.extraColumns(ctx)
+	resp.Diagnostics.Append(diags...)
+	for _, c := range columns {
+		if slices.Contains(key, c.Name) && !c.NotNull {
+			resp.Diagnostics.AddAttributeError(p, "Nullable primary key column",
+				fmt.Sprintf("Primary key column %s must not be nullable; set nullable = false on its extra_column.", c.Name))
+		}
+	}
+}
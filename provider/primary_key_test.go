@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestQueueModelSetPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	partitioned := queueModel{
+		EnablePartitioning: types.BoolValue(true),
+		PrimaryKey:         types.ListNull(types.StringType),
+	}
+
+	m := partitioned
+	m.setPrimaryKey(ctx, []string{"id", "created_at"})
+	if !m.PrimaryKey.IsNull() {
+		t.Errorf("primary_key = %v, want null for the default key", m.PrimaryKey)
+	}
+
+	m.setPrimaryKey(ctx, []string{"created_at", "id"})
+	want := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("created_at"), types.StringValue("id")})
+	if !m.PrimaryKey.Equal(want) {
+		t.Errorf("primary_key = %v, want %v after drift", m.PrimaryKey, want)
+	}
+
+	// a configured key stays set when it matches the default
+	m.setPrimaryKey(ctx, []string{"id", "created_at"})
+	if m.PrimaryKey.IsNull() {
+		t.Error("primary_key = null, want the configured default key")
+	}
+
+	key, _ := partitioned.primaryKey(ctx)
+	if len(key) != 2 || key[0] != "id" || key[1] != "created_at" {
+		t.Errorf("primaryKey() = %v, want the default key", key)
+	}
+}
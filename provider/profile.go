@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// environmentProfile holds the partitioning defaults of an
+// environment_profile, used for attributes the configuration leaves unset
+type environmentProfile struct {
+	Premake   int64
+	Retention string
+}
+
+var environmentProfiles = map[string]*environmentProfile{
+	"dev":     {Premake: 1, Retention: "3 days"},
+	"staging": {Premake: 4, Retention: "7 days"},
+	"prod":    {Premake: 14, Retention: "30 days"},
+}
+
+func environmentProfileNames() []string {
+	return []string{"dev", "staging", "prod"}
+}
+
+// applyProfileDefaults replaces the schema defaults of partition_premake
+// and retention_period with the profile's wherever the configuration
+// doesn't set them
+func applyProfileDefaults(ctx context.Context, profile *environmentProfile, cfg tfsdk.Config, plan *tfsdk.Plan) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if profile == nil {
+		return diags
+	}
+
+	var premake types.Int64
+	diags.Append(cfg.GetAttribute(ctx, path.Root("partition_premake"), &premake)...)
+	if premake.IsNull() {
+		diags.Append(plan.SetAttribute(ctx, path.Root("partition_premake"), profile.Premake)...)
+	}
+
+	var retention types.String
+	diags.Append(cfg.GetAttribute(ctx, path.Root("retention_period"), &retention)...)
+	if retention.IsNull() {
+		diags.Append(plan.SetAttribute(ctx, path.Root("retention_period"), profile.Retention)...)
+	}
+
+	return diags
+}
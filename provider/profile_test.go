@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestApplyProfileDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	s := schema.Schema{Attributes: map[string]schema.Attribute{
+		"partition_premake": schema.Int64Attribute{Optional: true, Computed: true},
+		"retention_period":  schema.StringAttribute{Optional: true, Computed: true},
+	}}
+	typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"partition_premake": tftypes.Number,
+		"retention_period":  tftypes.String,
+	}}
+
+	// premake is configured, retention falls back to the schema default
+	cfg := tfsdk.Config{Schema: s, Raw: tftypes.NewValue(typ, map[string]tftypes.Value{
+		"partition_premake": tftypes.NewValue(tftypes.Number, 3),
+		"retention_period":  tftypes.NewValue(tftypes.String, nil),
+	})}
+	plan := tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(typ, map[string]tftypes.Value{
+		"partition_premake": tftypes.NewValue(tftypes.Number, 3),
+		"retention_period":  tftypes.NewValue(tftypes.String, "14 days"),
+	})}
+
+	if diags := applyProfileDefaults(ctx, environmentProfiles["prod"], cfg, &plan); diags.HasError() {
+		t.Fatalf("applyProfileDefaults() diags = %v", diags)
+	}
+
+	var got struct {
+		Premake   int64  `tfsdk:"partition_premake"`
+		Retention string `tfsdk:"retention_period"`
+	}
+	if diags := plan.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("plan.Get() diags = %v", diags)
+	}
+
+	if got.Premake != 3 {
+		t.Errorf("partition_premake = %d, want configured 3", got.Premake)
+	}
+	if got.Retention != "30 days" {
+		t.Errorf("retention_period = %q, want profile's %q", got.Retention, "30 days")
+	}
+
+	if diags := applyProfileDefaults(ctx, nil, cfg, &plan); diags.HasError() {
+		t.Fatalf("applyProfileDefaults(nil) diags = %v", diags)
+	}
+}
+
+func TestEnvironmentProfileNames(t *testing.T) {
+	names := environmentProfileNames()
+	if len(names) != len(environmentProfiles) {
+		t.Fatalf("environmentProfileNames() has %d names, profiles has %d", len(names), len(environmentProfiles))
+	}
+	for _, name := range names {
+		if environmentProfiles[name] == nil {
+			t.Errorf("profile %q is not defined", name)
+		}
+	}
+}
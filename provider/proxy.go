@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/net/proxy"
+)
+
+// proxyDialer dials the server through a SOCKS5 or HTTP CONNECT proxy
+type proxyDialer struct {
+	url *url.URL
+	// remoteDNS leaves resolving the server's host name to the proxy
+	remoteDNS bool
+}
+
+func newProxyDialer(rawURL string) (*proxyDialer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy_url: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy_url %q: missing host", u.Redacted())
+	}
+
+	d := &proxyDialer{url: u}
+	switch u.Scheme {
+	case "socks5":
+	case "socks5h", "http":
+		d.remoteDNS = true
+	default:
+		return nil, fmt.Errorf("invalid proxy_url %q: scheme must be socks5, socks5h or http", u.Redacted())
+	}
+
+	if u.Port() == "" {
+		port := "1080"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	return d, nil
+}
+
+// install routes every pool connection through the proxy. The proxy
+// itself is dialed with the dial function configured so far, so keepalive
+// settings apply to the connection to the proxy.
+func (d *proxyDialer) install(poolCfg *pgxpool.Config) {
+	forward := poolCfg.ConnConfig.DialFunc
+	if forward == nil {
+		forward = (&net.Dialer{}).DialContext
+	}
+
+	if d.url.Scheme == "http" {
+		poolCfg.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return d.dialHTTP(ctx, forward, network, addr)
+		}
+	} else {
+		poolCfg.ConnConfig.DialFunc = d.socksDialFunc(forward)
+	}
+
+	if d.remoteDNS {
+		poolCfg.ConnConfig.LookupFunc = func(_ context.Context, host string) ([]string, error) {
+			return []string{host}, nil
+		}
+	}
+}
+
+func (d *proxyDialer) socksDialFunc(forward pgconn.DialFunc) pgconn.DialFunc {
+	var auth *proxy.Auth
+	if d.url.User != nil {
+		password, _ := d.url.User.Password()
+		auth = &proxy.Auth{User: d.url.User.Username(), Password: password}
+	}
+
+	// SOCKS5 never fails for a valid proxy address, and the dialer it
+	// returns always implements ContextDialer
+	dialer, _ := proxy.SOCKS5("tcp", d.url.Host, auth, forwardDialer(forward))
+	cd := dialer.(proxy.ContextDialer)
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := cd.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %w", d.url.Redacted(), err)
+		}
+		return conn, nil
+	}
+}
+
+// dialHTTP opens a tunnel to addr with an HTTP CONNECT request
+func (d *proxyDialer) dialHTTP(ctx context.Context, forward pgconn.DialFunc, network, addr string) (net.Conn, error) {
+	conn, err := forward(ctx, network, d.url.Host)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", d.url.Redacted(), err)
+	}
+
+	if err := d.connect(ctx, conn, addr); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", d.url.Redacted(), err)
+	}
+	return conn, nil
+}
+
+func (d *proxyDialer) connect(ctx context.Context, conn net.Conn, addr string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() {
+			_ = conn.SetDeadline(time.Time{})
+		}()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.url.User != nil {
+		password, _ := d.url.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.url.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	// the server waits for the client's startup message, so nothing
+	// follows the response that the reader could buffer
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("CONNECT " + addr + ": " + resp.Status)
+	}
+	return nil
+}
+
+// forwardDialer adapts a pgx dial function to the x/net/proxy dialers
+type forwardDialer pgconn.DialFunc
+
+func (f forwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+func (f forwardDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
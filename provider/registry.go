@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const defaultRegistrySchema = "public"
+
+type (
+	objectRegistryModel struct {
+		Schema types.String `tfsdk:"schema"`
+		Owner  types.String `tfsdk:"owner"`
+	}
+
+	// objectRegistry records the objects created by each resource in the
+	// opt-in pgq_terraform_registry table. A nil registry records nothing.
+	objectRegistry struct {
+		mgr    *pgq.Manager
+		schema pgq.SchemaName
+		owner  string
+	}
+)
+
+func newObjectRegistry(mgr *pgq.Manager, m *objectRegistryModel) *objectRegistry {
+	if m == nil {
+		return nil
+	}
+
+	schema := defaultRegistrySchema
+	if isSet(m.Schema) {
+		schema = m.Schema.ValueString()
+	}
+
+	return &objectRegistry{mgr: mgr, schema: pgq.SchemaName(schema), owner: m.Owner.ValueString()}
+}
+
+// withManager returns the registry recording through mgr, in the database
+// of the objects
+func (r *objectRegistry) withManager(mgr *pgq.Manager) *objectRegistry {
+	if r == nil {
+		return nil
+	}
+	scoped := *r
+	scoped.mgr = mgr
+	return &scoped
+}
+
+// record replaces the registry entries of a resource. Failures are warnings
+// since the objects themselves were changed successfully.
+func (r *objectRegistry) record(ctx context.Context, resourceType, resourceID string, objects []pgq.RegistryEntry) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if r == nil {
+		return diags
+	}
+
+	tflog.Debug(ctx, "recording objects in registry", map[string]any{
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"objects":       len(objects),
+	})
+
+	if err := r.mgr.SyncRegistry(ctx, r.schema, resourceType, resourceID, r.owner, objects); err != nil {
+		diags.AddWarning("Failed to update object registry", err.Error())
+	}
+	return diags
+}
+
+// recordQueues records the objects of queues in schema as owned by a resource
+func (r *objectRegistry) recordQueues(ctx context.Context, resourceType, resourceID string, schema pgq.SchemaName, names []pgq.QueueName) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if r == nil {
+		return diags
+	}
+
+	var objects []pgq.RegistryEntry
+	for _, name := range names {
+		o, err := r.mgr.QueueObjects(ctx, schema, name)
+		if err != nil {
+			diags.AddWarning("Failed to update object registry", err.Error())
+			return diags
+		}
+		objects = append(objects, o...)
+	}
+
+	return r.record(ctx, resourceType, resourceID, objects)
+}
+
+// forget removes a destroyed resource from the registry
+func (r *objectRegistry) forget(ctx context.Context, resourceType, resourceID string) diag.Diagnostics {
+	return r.record(ctx, resourceType, resourceID, nil)
+}
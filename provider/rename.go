@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// requiresReplaceUnlessAllowed replaces the queue when the attribute
+// changes, unless the boolean attribute allow is set, in which case Update
+// renames or moves it in place
+func requiresReplaceUnlessAllowed(allow string) planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			var allowed types.Bool
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(allow), &allowed)...)
+			resp.RequiresReplace = !allowed.ValueBool()
+		},
+		"Changing the value replaces the queue unless "+allow+" is set",
+		"Changing the value replaces the queue unless `"+allow+"` is set",
+	)
+}
+
+// renamed reports whether the plan renames the queue in place
+func (m queueModel) renamed(state queueModel) bool {
+	return !m.Name.Equal(state.Name) && m.AllowRename.ValueBool()
+}
+
+// movedSchema reports whether the plan moves the queue to another schema
+// in place
+func (m queueModel) movedSchema(state queueModel) bool {
+	return !m.Schema.Equal(state.Schema) && m.AllowSchemaMove.ValueBool()
+}
+
+// planRename points id at the new name of a queue renamed or moved in
+// place. Legal holds name partitions after the queue, so they block a
+// rename. A schema filled from default_queue_schema asks for replacement
+// on its own, which a move overrides.
+func planRename(ctx context.Context, plan, state queueModel, resp *resource.ModifyPlanResponse) {
+	if plan.Name.IsUnknown() || plan.Schema.IsUnknown() {
+		return
+	}
+
+	if plan.renamed(state) && len(state.LegalHolds.Elements()) > 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Queue with legal holds can't be renamed",
+			"Release the partitions in legal_hold_partitions before renaming the queue.")
+		return
+	}
+
+	if plan.movedSchema(state) {
+		resp.RequiresReplace = slices.DeleteFunc(resp.RequiresReplace, func(p path.Path) bool {
+			return p.Equal(path.Root("schema"))
+		})
+	}
+
+	if plan.renamed(state) || plan.movedSchema(state) {
+		id := pgq.MakeFQN(pgq.SchemaName(plan.Schema.ValueString()), pgq.QueueName(plan.Name.ValueString()))
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), id.String())...)
+	}
+}
+
+// renameQueue renames the queue of state to the name in plan. The CLUSTER
+// job is named after the queue, so it is removed and state left without
+// it, for applyCluster to schedule it again. state, including id, is
+// updated as soon as the queue is renamed; relocateDeadLetter then renames
+// the dead-letter queue.
+func (r *queueResource) renameQueue(ctx context.Context, plan queueModel, state *queueModel) error {
+	schema := pgq.SchemaName(state.Schema.ValueString())
+	from := pgq.QueueName(state.Name.ValueString())
+	to := pgq.QueueName(plan.Name.ValueString())
+
+	if !state.ClusterSchedule.IsNull() {
+		if err := r.mgr.UnscheduleCluster(ctx, schema, from); err != nil {
+			return err
+		}
+		state.ClusterSchedule = types.StringNull()
+	}
+
+	if err := r.mgr.Rename(ctx, schema, from, to, state.EnablePartitioning.ValueBool() && !state.nativePartitioning()); err != nil {
+		return err
+	}
+
+	state.Name = plan.Name
+	state.ID = types.StringValue(pgq.MakeFQN(schema, to).String())
+	return nil
+}
+
+// moveQueue moves the queue of state to the schema in plan. Like
+// renameQueue, it leaves the CLUSTER job to applyCluster and the
+// dead-letter queue to relocateDeadLetter.
+func (r *queueResource) moveQueue(ctx context.Context, plan queueModel, state *queueModel) error {
+	from := pgq.SchemaName(state.Schema.ValueString())
+	to := pgq.SchemaName(plan.Schema.ValueString())
+	name := pgq.QueueName(state.Name.ValueString())
+
+	if !state.ClusterSchedule.IsNull() {
+		if err := r.mgr.UnscheduleCluster(ctx, from, name); err != nil {
+			return err
+		}
+		state.ClusterSchedule = types.StringNull()
+	}
+
+	if err := r.mgr.MoveSchema(ctx, from, name, to, state.EnablePartitioning.ValueBool() && !state.nativePartitioning()); err != nil {
+		return err
+	}
+
+	state.Schema = plan.Schema
+	state.ID = types.StringValue(pgq.MakeFQN(to, name).String())
+	return nil
+}
+
+// relocateDeadLetter moves and renames the dead-letter queue of state,
+// found at dead_letter_queue, to follow the queue's schema and name.
+// dead_letter_queue is updated after each step, so a dead-letter queue
+// left behind by a failed step is picked up by the next apply.
+func (r *queueResource) relocateDeadLetter(ctx context.Context, state *queueModel) error {
+	if state.DeadLetter == nil || !isSet(state.DeadLetterQueue) {
+		state.setDeadLetterQueue()
+		return nil
+	}
+
+	schema, name, err := pgq.FQN(state.DeadLetterQueue.ValueString()).Split()
+	if err != nil {
+		return err
+	}
+	partman := state.DeadLetter.EnablePartitioning.ValueBool() && !state.nativePartitioning()
+
+	if to := pgq.SchemaName(state.Schema.ValueString()); schema != to {
+		if err := r.mgr.MoveSchema(ctx, schema, name, to, partman); err != nil {
+			return fmt.Errorf("failed to move dead-letter queue: %w", err)
+		}
+		schema = to
+		state.DeadLetterQueue = types.StringValue(pgq.MakeFQN(schema, name).String())
+	}
+
+	if to := state.deadLetterName(); name != to {
+		if err := r.mgr.Rename(ctx, schema, name, to, partman); err != nil {
+			return fmt.Errorf("failed to rename dead-letter queue: %w", err)
+		}
+	}
+
+	state.setDeadLetterQueue()
+	return nil
+}
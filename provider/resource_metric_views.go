@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource              = (*metricViewsResource)(nil)
+	_ resource.ResourceWithConfigure = (*metricViewsResource)(nil)
+)
+
+type (
+	metricViewsResource struct {
+		mgr      *pgq.Manager
+		registry *objectRegistry
+
+		readOnly bool
+	}
+
+	metricViewsModel struct {
+		ID              types.String `tfsdk:"id"`
+		Schema          types.String `tfsdk:"schema"`
+		Queues          types.Set    `tfsdk:"queues"`
+		ExporterQueries types.String `tfsdk:"exporter_queries"`
+	}
+)
+
+func NewMetricViewsResource() resource.Resource {
+	return &metricViewsResource{}
+}
+
+func (m metricViewsModel) queueFQNs(ctx context.Context) ([]pgq.FQN, diag.Diagnostics) {
+	var queues []string
+	diags := m.Queues.ElementsAs(ctx, &queues, false)
+
+	fqns := make([]pgq.FQN, len(queues))
+	for i, q := range queues {
+		fqns[i] = pgq.FQN(q)
+	}
+	return fqns, diags
+}
+
+func (r *metricViewsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_metric_views"
+}
+
+func (r *metricViewsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Views exposing per-queue metrics in the postgres_exporter custom query format",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Schema holding the views",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"schema": schema.StringAttribute{
+				Description:   "Schema the views are created in",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{identifierValidator()},
+			},
+			"queues": schema.SetAttribute{
+				Description: "Fully qualified names (schema.name) of the queues covered by the views",
+				ElementType: types.StringType,
+				Required:    true,
+				Validators:  []validator.Set{setvalidator.ValueStringsAre(fqnValidator())},
+			},
+			"exporter_queries": schema.StringAttribute{
+				Description:   "postgres_exporter queries.yaml reading the views",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *metricViewsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	r.mgr = data.mgr
+	r.readOnly = data.readOnly
+	r.registry = data.registry
+}
+
+func (r *metricViewsResource) apply(ctx context.Context, plan *metricViewsModel) diag.Diagnostics {
+	queues, diags := plan.queueFQNs(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	schemaName := pgq.SchemaName(plan.Schema.ValueString())
+	if err := r.mgr.CreateMetricViews(ctx, schemaName, queues); err != nil {
+		errorDiag(&diags, "Failed to create metric views", err)
+		return diags
+	}
+
+	plan.ID = types.StringValue(schemaName.String())
+	plan.ExporterQueries = types.StringValue(pgq.ExporterQueries(schemaName))
+	diags.Append(r.registry.record(ctx, "pgq_metric_views", plan.ID.ValueString(), pgq.MetricViewObjects(schemaName))...)
+	return diags
+}
+
+func (r *metricViewsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "created")
+		return
+	}
+
+	var plan metricViewsModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *metricViewsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state metricViewsModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	exists, err := r.mgr.MetricViewsExist(ctx, pgq.SchemaName(state.Schema.ValueString()))
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to read metric views", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *metricViewsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "updated")
+		return
+	}
+
+	var plan metricViewsModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *metricViewsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "destroyed")
+		return
+	}
+
+	var state metricViewsModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.mgr.DropMetricViews(ctx, pgq.SchemaName(state.Schema.ValueString())); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to drop metric views", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.registry.forget(ctx, "pgq_metric_views", state.ID.ValueString())...)
+}
@@ -2,9 +2,16 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
-	"github.com/dataddo/terraform-provider-pgq/internal/pgq"
+	"slices"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
@@ -14,23 +21,41 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var (
-	_ resource.Resource                = (*queueResource)(nil)
-	_ resource.ResourceWithConfigure   = (*queueResource)(nil)
-	_ resource.ResourceWithImportState = (*queueResource)(nil)
+	_ resource.Resource                     = (*queueResource)(nil)
+	_ resource.ResourceWithConfigure        = (*queueResource)(nil)
+	_ resource.ResourceWithConfigValidators = (*queueResource)(nil)
+	_ resource.ResourceWithImportState      = (*queueResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*queueResource)(nil)
 )
 
 type (
 	queueResource struct {
-		mgr *pgq.Manager
+		mgr               *pgq.Manager
+		profile           *environmentProfile
+		defaultSchema     string
+		partitionDefaults *partitionDefaultsModel
+		registry          *objectRegistry
+		endpoints         *endpoints
+
+		fastRefresh bool
+
+		readOnly bool
 	}
 
 	queueModel struct {
@@ -44,14 +69,95 @@ type (
 		DatetimeString     types.String `tfsdk:"datetime_string"`
 		OptimizeConstraint types.Int64  `tfsdk:"optimize_constraint"`
 		DefaultPartition   types.Bool   `tfsdk:"default_partition"`
+		Timezone           types.String `tfsdk:"timezone"`
+		RetentionMode      types.String `tfsdk:"retention_mode"`
+		ArchiveSchema      types.String `tfsdk:"archive_schema"`
+		RetentionKeepTable types.Bool   `tfsdk:"retention_keep_table"`
+		RetentionKeepIndex types.Bool   `tfsdk:"retention_keep_index"`
+		InfinitePartitions types.Bool   `tfsdk:"infinite_time_partitions"`
+		Jobmon             types.Bool   `tfsdk:"jobmon"`
+		ConstraintCols     types.List   `tfsdk:"constraint_columns"`
+		InheritPrivileges  types.Bool   `tfsdk:"inherit_privileges"`
+		PartitionStart     types.String `tfsdk:"partition_start"`
+		PartitioningMode   types.String `tfsdk:"partitioning_mode"`
+		PartitionStrategy  types.String `tfsdk:"partition_strategy"`
+		PartitionKey       types.String `tfsdk:"partition_key"`
+		HashPartitions     types.Int64  `tfsdk:"hash_partitions"`
 		CustomIndexes      types.Set    `tfsdk:"custom_index"`
+		ExtraColumns       types.List   `tfsdk:"extra_column"`
+		GeneratedColumns   types.List   `tfsdk:"generated_column"`
+		ColumnStorage      types.Set    `tfsdk:"column_storage"`
+		CheckConstraints   types.Set    `tfsdk:"check_constraint"`
+		BeforeCreateSQL    types.List   `tfsdk:"before_create_sql"`
+		AfterCreateSQL     types.List   `tfsdk:"after_create_sql"`
+		BeforeDestroySQL   types.List   `tfsdk:"before_destroy_sql"`
+		RequireConfirm     types.Bool   `tfsdk:"require_confirmation_phrase"`
+		ConfirmationPhrase types.String `tfsdk:"confirmation_phrase"`
+		DestroyPhrase      types.String `tfsdk:"destroy_confirmation_phrase"`
+		AllowRename        types.Bool   `tfsdk:"allow_rename"`
+		AllowSchemaMove    types.Bool   `tfsdk:"allow_schema_move"`
+		PreventNonEmpty    types.Bool   `tfsdk:"prevent_destroy_if_not_empty"`
+		ForceDestroy       types.Bool   `tfsdk:"force_destroy"`
+		FailOnDestructive  types.Bool   `tfsdk:"fail_on_destructive_change"`
+		TextCollation      types.String `tfsdk:"text_collation"`
+		Tablespace         types.String `tfsdk:"tablespace"`
+		AccessMethod       types.String `tfsdk:"access_method"`
+		Owner              types.String `tfsdk:"owner"`
+		Comment            types.String `tfsdk:"comment"`
+		OrderingColumn     types.Bool   `tfsdk:"ordering_column"`
+		IDType             types.String `tfsdk:"id_type"`
+		PrimaryKey         types.List   `tfsdk:"primary_key"`
+		RejectOlderThan    types.String `tfsdk:"reject_messages_older_than"`
+		NotifyChannel      types.String `tfsdk:"notify_channel"`
+		VerifyGrants       types.Bool   `tfsdk:"verify_partition_grants"`
+		GrantDrift         types.List   `tfsdk:"partition_grant_drift"`
+		StructureHash      types.String `tfsdk:"structure_hash"`
+		MissingIndexes     types.List   `tfsdk:"missing_indexes"`
+		PendingPartitions  types.List   `tfsdk:"pending_partition_changes"`
+		CloneFrom          types.String `tfsdk:"clone_from"`
+		ClonedIndexes      types.List   `tfsdk:"cloned_indexes"`
+		SkipUnsupported    types.Bool   `tfsdk:"skip_if_unsupported"`
+		Provisioned        types.Bool   `tfsdk:"provisioned"`
+		ClusterOn          types.String `tfsdk:"cluster_on"`
+		ClusterSchedule    types.String `tfsdk:"cluster_schedule"`
+		LegalHolds         types.Set    `tfsdk:"legal_hold_partitions"`
+		Publications       types.Set    `tfsdk:"publications"`
+		ReplicaIdentity    types.String `tfsdk:"replica_identity"`
+		LegalHoldSchema    types.String `tfsdk:"legal_hold_schema"`
+
+		AutomaticMaintenance types.String `tfsdk:"automatic_maintenance"`
+
+		Endpoint        *endpointModel       `tfsdk:"endpoint"`
+		SubPartition    *subPartitionModel   `tfsdk:"sub_partition"`
+		DefaultIndexes  *defaultIndexesModel `tfsdk:"default_indexes"`
+		DeadLetter      *deadLetterModel     `tfsdk:"dead_letter"`
+		DeadLetterQueue types.String         `tfsdk:"dead_letter_queue"`
+		RLS             *rlsModel            `tfsdk:"rls"`
 	}
 
 	customIndexModel struct {
 		Name    types.String `tfsdk:"name"`
 		Columns types.List   `tfsdk:"columns"`
+		Column  types.List   `tfsdk:"column"`
 		Type    types.String `tfsdk:"type"`
 		Where   types.String `tfsdk:"where"`
+		Comment types.String `tfsdk:"comment"`
+
+		With         types.Map    `tfsdk:"with"`
+		Tablespace   types.String `tfsdk:"tablespace"`
+		Concurrently types.Bool   `tfsdk:"concurrently"`
+	}
+
+	extraColumnModel struct {
+		Name     types.String `tfsdk:"name"`
+		Type     types.String `tfsdk:"type"`
+		Nullable types.Bool   `tfsdk:"nullable"`
+		Default  types.String `tfsdk:"default"`
+	}
+
+	checkConstraintModel struct {
+		Name       types.String `tfsdk:"name"`
+		Expression types.String `tfsdk:"expression"`
 	}
 )
 
@@ -64,17 +170,25 @@ func convertCustomIndexes(ctx context.Context, models []customIndexModel) ([]pgq
 	indexes := make([]pgq.CustomIndex, 0, len(models))
 
 	for _, m := range models {
-		var columns []string
-		if d := m.Columns.ElementsAs(ctx, &columns, false); d.HasError() {
+		columns, d := m.columnSpecs(ctx)
+		if d.HasError() {
 			diags.Append(d...)
 			continue
 		}
 
 		idx := pgq.CustomIndex{
-			Name:    m.Name.ValueString(),
-			Columns: columns,
-			Type:    m.Type.ValueString(),
-			Where:   m.Where.ValueString(),
+			Name:       m.Name.ValueString(),
+			Columns:    columns,
+			Type:       m.Type.ValueString(),
+			Where:      m.Where.ValueString(),
+			Comment:    m.Comment.ValueString(),
+			Tablespace: m.Tablespace.ValueString(),
+		}
+		if !m.With.IsNull() && !m.With.IsUnknown() {
+			if d := m.With.ElementsAs(ctx, &idx.With, false); d.HasError() {
+				diags.Append(d...)
+				continue
+			}
 		}
 		indexes = append(indexes, idx)
 	}
@@ -82,27 +196,53 @@ func convertCustomIndexes(ctx context.Context, models []customIndexModel) ([]pgq
 	return indexes, diags
 }
 
-func convertToCustomIndexModels(ctx context.Context, indexes []pgq.CustomIndex) ([]customIndexModel, diag.Diagnostics) {
+// convertToCustomIndexModels converts the indexes read from the database,
+// spelling their columns like the prior index of the same name
+func convertToCustomIndexModels(ctx context.Context, indexes []pgq.CustomIndex, prior []customIndexModel) ([]customIndexModel, diag.Diagnostics) {
 	var diags diag.Diagnostics
 	models := make([]customIndexModel, 0, len(indexes))
 
+	priorByName := make(map[string]*customIndexModel, len(prior))
+	for i := range prior {
+		priorByName[prior[i].Name.ValueString()] = &prior[i]
+	}
+
 	for _, idx := range indexes {
-		cols, d := types.ListValueFrom(ctx, types.StringType, idx.Columns)
-		if d.HasError() {
+		m := customIndexModel{
+			Name:         types.StringValue(idx.Name),
+			Type:         types.StringValue(idx.Type),
+			With:         types.MapNull(types.StringType),
+			Tablespace:   stringOrNull(idx.Tablespace),
+			Concurrently: types.BoolValue(false),
+		}
+		if len(idx.With) > 0 {
+			with, d := types.MapValueFrom(ctx, types.StringType, idx.With)
+			if d.HasError() {
+				diags.Append(d...)
+				continue
+			}
+			m.With = with
+		}
+		// how the index was built can't be read back
+		if p := priorByName[idx.Name]; p != nil && !p.Concurrently.IsNull() {
+			m.Concurrently = p.Concurrently
+		}
+		if d := m.setColumns(ctx, idx.Columns, priorByName[idx.Name]); d.HasError() {
 			diags.Append(d...)
 			continue
 		}
 
-		m := customIndexModel{
-			Name:    types.StringValue(idx.Name),
-			Columns: cols,
-			Type:    types.StringValue(idx.Type),
+		// the server adds parentheses and casts to the WHERE clause
+		if p := priorByName[idx.Name]; p != nil && isSet(p.Where) && pgq.EquivalentExpressions(p.Where.ValueString(), idx.Where) {
+			m.Where = p.Where
+		} else {
+			m.Where = stringOrNull(idx.Where)
 		}
 
-		if idx.Where != "" {
-			m.Where = types.StringValue(idx.Where)
+		if idx.Comment != "" {
+			m.Comment = types.StringValue(idx.Comment)
 		} else {
-			m.Where = types.StringNull()
+			m.Comment = types.StringNull()
 		}
 
 		models = append(models, m)
@@ -111,24 +251,42 @@ func convertToCustomIndexModels(ctx context.Context, indexes []pgq.CustomIndex)
 	return models, diags
 }
 
-func (r *queueResource) createCustomIndexesInTransaction(ctx context.Context, schema pgq.SchemaName, name pgq.QueueName, indexes []pgq.CustomIndex) error {
-	tx, err := r.mgr.Pool().Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// createCustomIndexes creates the indexes in one transaction, then builds
+// the concurrently ones outside of it, one at a time
+func (r *queueResource) createCustomIndexes(ctx context.Context, schema pgq.SchemaName, name pgq.QueueName, models []customIndexModel) diag.Diagnostics {
+	var inTx, concurrent []customIndexModel
+	for _, m := range models {
+		if m.Concurrently.ValueBool() {
+			concurrent = append(concurrent, m)
+		} else {
+			inTx = append(inTx, m)
+		}
 	}
-	defer func() {
-		_ = tx.Rollback(ctx)
-	}()
 
-	if err := r.mgr.CreateCustomIndexes(ctx, tx, schema, name, indexes); err != nil {
-		return fmt.Errorf("failed to create custom indexes: %w", err)
+	indexes, diags := convertCustomIndexes(ctx, inTx)
+	if diags.HasError() {
+		return diags
+	}
+	if len(indexes) > 0 {
+		if err := r.mgr.AddCustomIndexes(ctx, schema, name, indexes); err != nil {
+			errorDiag(&diags, "Failed to create custom indexes", err)
+			return diags
+		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit custom indexes: %w", err)
+	indexes, d := convertCustomIndexes(ctx, concurrent)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+	for _, idx := range indexes {
+		if err := r.mgr.CreateIndexConcurrently(ctx, schema, name, idx); err != nil {
+			errorDiag(&diags, "Failed to create custom index concurrently", err)
+			return diags
+		}
 	}
 
-	return nil
+	return diags
 }
 
 func customIndexObjectType() types.ObjectType {
@@ -136,311 +294,2056 @@ func customIndexObjectType() types.ObjectType {
 		AttrTypes: map[string]attr.Type{
 			"name":    types.StringType,
 			"columns": types.ListType{ElemType: types.StringType},
+			"column":  types.ListType{ElemType: indexColumnObjectType()},
 			"type":    types.StringType,
 			"where":   types.StringType,
+			"comment": types.StringType,
+
+			"with":         types.MapType{ElemType: types.StringType},
+			"tablespace":   types.StringType,
+			"concurrently": types.BoolType,
 		},
 	}
 }
 
-func indexDefinitionEqual(ctx context.Context, a, b customIndexModel) (bool, error) {
-	if a.Name.ValueString() != b.Name.ValueString() {
-		return false, nil
-	}
-	if a.Type.ValueString() != b.Type.ValueString() {
-		return false, nil
-	}
-	if a.Where.ValueString() != b.Where.ValueString() {
-		return false, nil
+func extraColumnObjectType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"name":     types.StringType,
+			"type":     types.StringType,
+			"nullable": types.BoolType,
+			"default":  types.StringType,
+		},
 	}
+}
 
-	var aCols, bCols []string
-	if diags := a.Columns.ElementsAs(ctx, &aCols, false); diags.HasError() {
-		return false, fmt.Errorf("failed to extract columns from index a: %v", diags)
-	}
-	if diags := b.Columns.ElementsAs(ctx, &bCols, false); diags.HasError() {
-		return false, fmt.Errorf("failed to extract columns from index b: %v", diags)
+// extraColumns returns the extra_column blocks as pgq columns
+func (m queueModel) extraColumns(ctx context.Context) ([]pgq.Column, diag.Diagnostics) {
+	var models []extraColumnModel
+	if m.ExtraColumns.IsNull() || m.ExtraColumns.IsUnknown() {
+		return nil, nil
 	}
-
-	if len(aCols) != len(bCols) {
-		return false, nil
+	if diags := m.ExtraColumns.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, diags
 	}
 
-	for i := range aCols {
-		if aCols[i] != bCols[i] {
-			return false, nil
+	columns := make([]pgq.Column, len(models))
+	for i, c := range models {
+		columns[i] = pgq.Column{
+			Name:    c.Name.ValueString(),
+			Type:    c.Type.ValueString(),
+			NotNull: !c.Nullable.ValueBool(),
+			Default: c.Default.ValueString(),
 		}
 	}
-
-	return true, nil
-}
-
-func (r *queueResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
-	resp.TypeName = req.ProviderTypeName + "_queue"
+	return columns, nil
 }
 
-func (r *queueResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
-	resp.Schema = schema.Schema{
-		Description: "pgq queue table",
-		Attributes: map[string]schema.Attribute{
-			"id": schema.StringAttribute{
-				Description:   "Fully qualified name (schema.name)",
-				Computed:      true,
-				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
-			},
-			"name": schema.StringAttribute{
-				Description:   "Queue name",
-				Required:      true,
-				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
-			},
-			"schema": schema.StringAttribute{
-				Description:   "PostgreSQL schema",
-				Optional:      true,
-				Computed:      true,
-				Default:       stringdefault.StaticString("public"),
-				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
-			},
-			"enable_partitioning": schema.BoolAttribute{
-				Description:   "Enable pg_partman partitioning",
-				Optional:      true,
-				Computed:      true,
-				Default:       booldefault.StaticBool(false),
-				PlanModifiers: []planmodifier.Bool{boolplanmodifier.RequiresReplace()},
-			},
-			"partition_interval": schema.StringAttribute{
-				Description: "Partition interval (e.g. '1 day', '1 week')",
-				Optional:    true,
-				Computed:    true,
-				Default:     stringdefault.StaticString("1 day"),
-				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
-			},
-			"partition_premake": schema.Int64Attribute{
-				Description: "Partitions to create ahead",
-				Optional:    true,
-				Computed:    true,
-				Default:     int64default.StaticInt64(7),
-			},
-			"retention_period": schema.StringAttribute{
-				Description: "How long to keep partitions (e.g. '14 days')",
-				Optional:    true,
-				Computed:    true,
-				Default:     stringdefault.StaticString("14 days"),
-			},
-			"datetime_string": schema.StringAttribute{
-				Description: "Partition naming format (e.g. 'YYYYMMDD')",
-				Optional:    true,
-				Computed:    true,
-				Default:     stringdefault.StaticString("YYYYMMDD"),
-			},
-			"optimize_constraint": schema.Int64Attribute{
-				Description: "Partitions to optimize",
-				Optional:    true,
-				Computed:    true,
-				Default:     int64default.StaticInt64(30),
-			},
-			"default_partition": schema.BoolAttribute{
-				Description: "Create default partition",
-				Optional:    true,
-				Computed:    true,
-				Default:     booldefault.StaticBool(true),
-			},
-		},
-		Blocks: map[string]schema.Block{
-			"custom_index": schema.SetNestedBlock{
-				Description: "Custom indexes to create on the queue table",
-				NestedObject: schema.NestedBlockObject{
-					Attributes: map[string]schema.Attribute{
-						"name": schema.StringAttribute{
-							Description: "Index name (auto-generated if not provided)",
-							Optional:    true,
-							Computed:    true,
-						},
-						"columns": schema.ListAttribute{
-							Description: "Column expressions (e.g. 'created_at', '(payload->>''user_id'')')",
-							Required:    true,
-							ElementType: types.StringType,
-						},
-						"type": schema.StringAttribute{
-							Description: "Index type",
-							Optional:    true,
-							Computed:    true,
-							Default:     stringdefault.StaticString("btree"),
-							Validators: []validator.String{
-								stringvalidator.OneOf("btree", "gin", "gist", "hash", "brin"),
-							},
-						},
-						"where": schema.StringAttribute{
-							Description: "Partial index WHERE clause",
-							Optional:    true,
-						},
-					},
-				},
-			},
-		},
-	}
-}
+// readExtraColumns verifies the extra columns in state against the table.
+// Columns that are gone drop out of state and changed ones take the
+// table's definition, so the next plan restores them. Columns Terraform
+// doesn't manage are left alone unless all is set, for imports.
+func (r *queueResource) readExtraColumns(ctx context.Context, m *queueModel, all bool) diag.Diagnostics {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
 
-func (r *queueResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	if req.ProviderData == nil {
-		return
+	actual, err := r.mgr.GetExtraColumns(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read extra columns", map[string]any{"error": err})
+		return nil
 	}
 
-	mgr, ok := req.ProviderData.(*pgq.Manager)
-	if !ok {
-		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *pgq.Manager, got %T", req.ProviderData))
-		return
+	configured, diags := m.extraColumns(ctx)
+	if diags.HasError() {
+		return diags
 	}
 
-	r.mgr = mgr
-}
+	// generated columns have their own blocks
+	actual = slices.DeleteFunc(actual, func(c pgq.Column) bool { return c.Generated != "" })
 
-func (r *queueResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var plan queueModel
-	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
-		resp.Diagnostics.Append(diags...)
-		return
+	byName := make(map[string]pgq.Column, len(actual))
+	for _, c := range actual {
+		byName[c.Name] = c
 	}
 
-	schema := pgq.SchemaName(plan.Schema.ValueString())
-	name := pgq.QueueName(plan.Name.ValueString())
-
-	tflog.Debug(ctx, "creating queue", map[string]any{
-		"fqn":         string(pgq.MakeFQN(schema, name)),
-		"partitioned": plan.EnablePartitioning.ValueBool(),
-	})
-
-	if plan.EnablePartitioning.ValueBool() {
-		cfg := &pgq.PartitionConfig{
-			Interval:           plan.PartitionInterval.ValueString(),
-			Premake:            int(plan.PartitionPremake.ValueInt64()),
-			Retention:          plan.RetentionPeriod.ValueString(),
-			DatetimeString:     plan.DatetimeString.ValueString(),
-			OptimizeConstraint: int(plan.OptimizeConstraint.ValueInt64()),
-			DefaultPartition:   plan.DefaultPartition.ValueBool(),
-		}
-
-		if err := r.mgr.CreatePartitioned(ctx, schema, name, cfg); err != nil {
-			resp.Diagnostics.AddError("Failed to create partitioned queue", err.Error())
-			return
+	var models []extraColumnModel
+	add := func(c pgq.Column) {
+		col := extraColumnModel{
+			Name:     types.StringValue(c.Name),
+			Type:     types.StringValue(c.Type),
+			Nullable: types.BoolValue(!c.NotNull),
+			Default:  types.StringNull(),
 		}
-	} else {
-		if err := r.mgr.CreateSimple(ctx, schema, name); err != nil {
-			resp.Diagnostics.AddError("Failed to create queue", err.Error())
-			return
+		if c.Default != "" {
+			col.Default = types.StringValue(c.Default)
 		}
+		models = append(models, col)
+		delete(byName, c.Name)
 	}
 
-	if !plan.CustomIndexes.IsNull() && !plan.CustomIndexes.IsUnknown() {
-		var customIndexes []customIndexModel
-		if diags := plan.CustomIndexes.ElementsAs(ctx, &customIndexes, false); diags.HasError() {
-			resp.Diagnostics.Append(diags...)
-			return
+	for _, c := range configured {
+		got, ok := byName[c.Name]
+		if !ok {
+			continue
 		}
 
-		indexes, diags := convertCustomIndexes(ctx, customIndexes)
-		if diags.HasError() {
-			resp.Diagnostics.Append(diags...)
-			return
+		// keep the configured spelling of equivalent types and defaults,
+		// the server normalizes both
+		if formatted, err := r.mgr.FormatType(ctx, c.Type); err == nil && formatted == got.Type {
+			got.Type = c.Type
 		}
+		if got.Default != "" && c.Default != "" {
+			got.Default = c.Default
+		}
+		add(got)
+	}
 
-		if err := r.createCustomIndexesInTransaction(ctx, schema, name, indexes); err != nil {
-			resp.Diagnostics.AddError("Failed to create custom indexes", err.Error())
-			return
+	if all {
+		for _, c := range actual {
+			if _, ok := byName[c.Name]; ok {
+				add(c)
+			}
 		}
 	}
 
-	plan.ID = types.StringValue(string(pgq.MakeFQN(schema, name)))
-	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+	if len(models) == 0 {
+		m.ExtraColumns = types.ListNull(extraColumnObjectType())
+		return diags
+	}
+	m.ExtraColumns, diags = types.ListValueFrom(ctx, extraColumnObjectType(), models)
+	return diags
 }
 
-func (r *queueResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	var state queueModel
-	if diags := req.State.Get(ctx, &state); diags.HasError() {
-		resp.Diagnostics.Append(diags...)
-		return
+func checkConstraintObjectType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"name":       types.StringType,
+			"expression": types.StringType,
+		},
 	}
+}
 
-	schema := pgq.SchemaName(state.Schema.ValueString())
-	name := pgq.QueueName(state.Name.ValueString())
+// checkConstraints returns the check_constraint blocks as pgq constraints
+func (m queueModel) checkConstraints(ctx context.Context) ([]pgq.CheckConstraint, diag.Diagnostics) {
+	var models []checkConstraintModel
+	if m.CheckConstraints.IsNull() || m.CheckConstraints.IsUnknown() {
+		return nil, nil
+	}
+	if diags := m.CheckConstraints.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, diags
+	}
 
-	q, err := r.mgr.Get(ctx, schema, name)
+	constraints := make([]pgq.CheckConstraint, len(models))
+	for i, c := range models {
+		constraints[i] = pgq.CheckConstraint{Name: c.Name.ValueString(), Expression: c.Expression.ValueString()}
+	}
+	return constraints, nil
+}
+
+// readCheckConstraints drops constraints that no longer exist from state.
+// The server deparses expressions, so existing ones keep the configured
+// expression. Constraints Terraform doesn't manage are left alone unless
+// all is set, for imports.
+func (r *queueResource) readCheckConstraints(ctx context.Context, m *queueModel, all bool) diag.Diagnostics {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	actual, err := r.mgr.GetCheckConstraints(ctx, schema, name)
 	if err != nil {
-		if _, ok := err.(*pgq.QueueNotFoundError); ok {
-			resp.State.RemoveResource(ctx)
-			return
-		}
-		resp.Diagnostics.AddError("Failed to read queue", err.Error())
-		return
+		tflog.Warn(ctx, "failed to read check constraints", map[string]any{"error": err})
+		return nil
 	}
 
-	state.EnablePartitioning = types.BoolValue(q.Partitioned)
+	configured, diags := m.checkConstraints(ctx)
+	if diags.HasError() {
+		return diags
+	}
 
-	if q.Partitioned {
-		cfg, err := r.mgr.GetPartitionConfig(ctx, schema, name)
-		if err != nil {
-			tflog.Warn(ctx, "failed to read partition config", map[string]any{"error": err})
-		} else {
-			state.PartitionInterval = types.StringValue(cfg.Interval)
-			state.PartitionPremake = types.Int64Value(int64(cfg.Premake))
-			state.RetentionPeriod = types.StringValue(cfg.Retention)
-			state.DatetimeString = types.StringValue(cfg.DatetimeString)
-			state.OptimizeConstraint = types.Int64Value(int64(cfg.OptimizeConstraint))
-			state.DefaultPartition = types.BoolValue(cfg.DefaultPartition)
-		}
+	expressions := make(map[string]string, len(configured))
+	for _, c := range configured {
+		expressions[c.Name] = c.Expression
 	}
 
-	customIndexes, err := r.mgr.GetCustomIndexes(ctx, schema, name)
-	if err != nil {
-		tflog.Warn(ctx, "failed to read custom indexes", map[string]any{"error": err})
-	} else {
-		models, diags := convertToCustomIndexModels(ctx, customIndexes)
-		if diags.HasError() {
-			resp.Diagnostics.Append(diags...)
-			return
+	var models []checkConstraintModel
+	for _, c := range actual {
+		expr, ok := expressions[c.Name]
+		if !ok && !all {
+			continue
 		}
-
-		if len(models) > 0 {
-			set, diags := types.SetValueFrom(ctx, customIndexObjectType(), models)
-			if diags.HasError() {
-				resp.Diagnostics.Append(diags...)
-				return
-			}
-			state.CustomIndexes = set
-		} else {
-			state.CustomIndexes = types.SetNull(customIndexObjectType())
+		if !ok {
+			expr = c.Expression
 		}
+		models = append(models, checkConstraintModel{Name: types.StringValue(c.Name), Expression: types.StringValue(expr)})
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	if len(models) == 0 {
+		m.CheckConstraints = types.SetNull(checkConstraintObjectType())
+		return diags
+	}
+	m.CheckConstraints, diags = types.SetValueFrom(ctx, checkConstraintObjectType(), models)
+	return diags
 }
 
-func (r *queueResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var plan, state queueModel
-	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
-		resp.Diagnostics.Append(diags...)
-		return
+func indexDefinitionEqual(ctx context.Context, a, b customIndexModel) (bool, error) {
+	if a.Name.ValueString() != b.Name.ValueString() {
+		return false, nil
 	}
-	if diags := req.State.Get(ctx, &state); diags.HasError() {
-		resp.Diagnostics.Append(diags...)
-		return
+	if a.Type.ValueString() != b.Type.ValueString() {
+		return false, nil
+	}
+	if !pgq.EquivalentExpressions(a.Where.ValueString(), b.Where.ValueString()) {
+		return false, nil
+	}
+	if a.Tablespace.ValueString() != b.Tablespace.ValueString() || !a.With.Equal(b.With) {
+		return false, nil
+	}
+
+	aCols, diags := a.columnSpecs(ctx)
+	if diags.HasError() {
+		return false, fmt.Errorf("failed to extract columns from index a: %v", diags)
+	}
+	bCols, diags := b.columnSpecs(ctx)
+	if diags.HasError() {
+		return false, fmt.Errorf("failed to extract columns from index b: %v", diags)
 	}
 
+	return pgq.IndexColumnsEquivalent(aCols, bCols), nil
+}
+
+// applyLegalHolds releases partitions dropped from legal_hold_partitions and
+// holds added ones. Changing legal_hold_schema moves every hold.
+func (r *queueResource) applyLegalHolds(ctx context.Context, plan, state queueModel) error {
 	schema := pgq.SchemaName(plan.Schema.ValueString())
 	name := pgq.QueueName(plan.Name.ValueString())
 
-	if state.EnablePartitioning.ValueBool() && plan.EnablePartitioning.ValueBool() {
-		cfg := &pgq.PartitionConfig{
-			Interval:           plan.PartitionInterval.ValueString(),
-			Premake:            int(plan.PartitionPremake.ValueInt64()),
-			Retention:          plan.RetentionPeriod.ValueString(),
-			DatetimeString:     plan.DatetimeString.ValueString(),
-			OptimizeConstraint: int(plan.OptimizeConstraint.ValueInt64()),
-			DefaultPartition:   plan.DefaultPartition.ValueBool(),
-		}
-
-		if err := r.mgr.UpdatePartitionConfig(ctx, schema, name, cfg); err != nil {
-			resp.Diagnostics.AddError("Failed to update partition config", err.Error())
-			return
-		}
+	var planHolds, stateHolds []string
+	if diags := plan.LegalHolds.ElementsAs(ctx, &planHolds, false); diags.HasError() {
+		return fmt.Errorf("invalid legal_hold_partitions")
+	}
+	if diags := state.LegalHolds.ElementsAs(ctx, &stateHolds, false); diags.HasError() {
+		return fmt.Errorf("invalid legal_hold_partitions")
+	}
+
+	moved := !plan.LegalHoldSchema.Equal(state.LegalHoldSchema)
+
+	for _, p := range stateHolds {
+		if moved || !slices.Contains(planHolds, p) {
+			if err := r.mgr.ReleasePartition(ctx, schema, name, p, pgq.SchemaName(state.LegalHoldSchema.ValueString())); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range planHolds {
+		if moved || !slices.Contains(stateHolds, p) {
+			if err := r.mgr.HoldPartition(ctx, schema, name, p, pgq.SchemaName(plan.LegalHoldSchema.ValueString())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// legalHoldValidator rejects legal_hold_partitions on a simple queue at
+// plan time, before Create makes the table
+type legalHoldValidator struct{}
+
+func (v legalHoldValidator) Description(_ context.Context) string {
+	return "legal_hold_partitions requires enable_partitioning = true"
+}
+
+func (v legalHoldValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v legalHoldValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var holds types.Set
+	var partitioned types.Bool
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("legal_hold_partitions"), &holds)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("enable_partitioning"), &partitioned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if holds.IsNull() || holds.IsUnknown() || len(holds.Elements()) == 0 || partitioned.IsUnknown() || partitioned.ValueBool() {
+		return
+	}
+	resp.Diagnostics.AddAttributeError(path.Root("legal_hold_partitions"), "Legal hold on a simple queue",
+		"legal_hold_partitions requires enable_partitioning = true.")
+}
+
+// applyPublications adds the queue to the publications added to
+// publications and removes it from the dropped ones
+func (r *queueResource) applyPublications(ctx context.Context, plan, state queueModel) error {
+	if plan.Publications.Equal(state.Publications) {
+		return nil
+	}
+
+	var planPubs, statePubs []string
+	if diags := plan.Publications.ElementsAs(ctx, &planPubs, false); diags.HasError() {
+		return fmt.Errorf("invalid publications")
+	}
+	if diags := state.Publications.ElementsAs(ctx, &statePubs, false); diags.HasError() {
+		return fmt.Errorf("invalid publications")
+	}
+
+	return r.mgr.SetPublications(ctx, pgq.SchemaName(plan.Schema.ValueString()), pgq.QueueName(plan.Name.ValueString()),
+		statePubs, planPubs, plan.EnablePartitioning.ValueBool())
+}
+
+// applyCluster reconciles cluster_on and cluster_schedule. Custom index
+// changes recreate indexes, which loses the CLUSTER marking, so they
+// trigger it too.
+func (r *queueResource) applyCluster(ctx context.Context, plan, state queueModel) error {
+	schema := pgq.SchemaName(plan.Schema.ValueString())
+	name := pgq.QueueName(plan.Name.ValueString())
+
+	if !plan.ClusterOn.Equal(state.ClusterOn) || (!plan.ClusterOn.IsNull() && !plan.CustomIndexes.Equal(state.CustomIndexes)) {
+		if err := r.mgr.SetClusterIndex(ctx, schema, name, plan.ClusterOn.ValueString()); err != nil {
+			return err
+		}
+	}
+
+	if plan.ClusterSchedule.Equal(state.ClusterSchedule) && plan.ClusterOn.Equal(state.ClusterOn) {
+		return nil
+	}
+	if plan.ClusterSchedule.IsNull() {
+		if state.ClusterSchedule.IsNull() {
+			return nil
+		}
+		return r.mgr.UnscheduleCluster(ctx, schema, name)
+	}
+	return r.mgr.ScheduleCluster(ctx, schema, name, plan.ClusterOn.ValueString(), plan.ClusterSchedule.ValueString())
+}
+
+// validateClusterSchedule checks at plan time that cluster_schedule is set
+// on a pg_partman partitioned queue, before Create makes the table
+func validateClusterSchedule(ctx context.Context, resp *resource.ModifyPlanResponse) {
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if plan.ClusterSchedule.IsNull() {
+		return
+	}
+
+	if !plan.EnablePartitioning.IsUnknown() && !plan.EnablePartitioning.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("cluster_schedule"), "Clustering a simple queue",
+			"cluster_schedule requires enable_partitioning = true.")
+	} else if !plan.PartitioningMode.IsUnknown() && plan.nativePartitioning() {
+		resp.Diagnostics.AddAttributeError(path.Root("cluster_schedule"), "Clustering without pg_partman",
+			fmt.Sprintf("cluster_schedule requires partitioning_mode = %q.", partitioningPartman))
+	}
+}
+
+// setPartialState records a queue whose table exists before the rest of
+// Create or Update runs, so a later failure leaves it in state, tainted,
+// instead of orphaned. Values only known after apply are saved as null.
+func setPartialState(ctx context.Context, state *tfsdk.State, m queueModel) diag.Diagnostics {
+	diags := state.Set(ctx, m)
+	if diags.HasError() {
+		return diags
+	}
+
+	raw, err := tftypes.Transform(state.Raw, func(_ *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if !v.IsKnown() {
+			return tftypes.NewValue(v.Type(), nil), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		diags.AddError("Failed to save partial state", err.Error())
+		return diags
+	}
+	state.Raw = raw
+
+	return diags
+}
+
+// skipProvisioning fills the computed attributes of a queue skipped by
+// skip_if_unsupported
+func (m *queueModel) skipProvisioning() {
+	m.ID = types.StringValue(pgq.MakeFQN(pgq.SchemaName(m.Schema.ValueString()), pgq.QueueName(m.Name.ValueString())).String())
+	m.Provisioned = types.BoolValue(false)
+	m.GrantDrift = types.ListValueMust(types.StringType, []attr.Value{})
+	m.StructureHash = types.StringNull()
+	m.MissingIndexes = types.ListValueMust(types.StringType, []attr.Value{})
+	m.PendingPartitions = types.ListValueMust(types.StringType, []attr.Value{})
+	m.ClonedIndexes = types.ListNull(types.StringType)
+	if m.Owner.IsUnknown() {
+		m.Owner = types.StringNull()
+	}
+	m.DeadLetterQueue = types.StringNull()
+	if m.Jobmon.IsUnknown() {
+		m.Jobmon = types.BoolNull()
+	}
+	m.setPartmanSettings(nil)
+}
+
+// upgradeState fills the attributes that force replacement but are missing
+// from states written before they existed, so no replacement is planned.
+// Those queues were all created with the former behavior.
+func (m *queueModel) upgradeState() {
+	if m.PartitioningMode.IsNull() {
+		m.PartitioningMode = types.StringValue(partitioningPartman)
+	}
+	if m.PartitionStrategy.IsNull() {
+		m.PartitionStrategy = types.StringValue(pgq.PartitionRange)
+	}
+	if m.IDType.IsNull() {
+		m.IDType = types.StringValue(pgq.IDUUIDv4)
+	}
+}
+
+// skipped reports whether the queue was skipped by skip_if_unsupported.
+// States written before provisioned existed count as provisioned.
+func (m queueModel) skipped() bool {
+	return !m.Provisioned.IsNull() && !m.Provisioned.IsUnknown() && !m.Provisioned.ValueBool()
+}
+
+// checkPartitionGrants sets partition_grant_drift and warns about partitions
+// missing the parent's grants. Nothing is queried unless
+// verify_partition_grants is set on a partitioned queue.
+func (r *queueResource) checkPartitionGrants(ctx context.Context, m *queueModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.GrantDrift = types.ListValueMust(types.StringType, []attr.Value{})
+
+	if !m.VerifyGrants.ValueBool() || !m.EnablePartitioning.ValueBool() {
+		return diags
+	}
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	drift, err := r.mgr.GetPartitionGrantDrift(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to verify partition grants", map[string]any{"error": err})
+		return diags
+	}
+
+	var lines []string
+	for _, d := range drift {
+		lines = append(lines, d.String())
+	}
+
+	list, d := types.ListValueFrom(ctx, types.StringType, lines)
+	diags.Append(d...)
+	if len(lines) > 0 {
+		m.GrantDrift = list
+		diags.AddAttributeWarning(path.Root("partition_grant_drift"), "Partitions are missing grants",
+			fmt.Sprintf("%d partition(s) of %s lack privileges granted on the queue:\n\n%s",
+				len(lines), pgq.MakeFQN(schema, name), strings.Join(lines, "\n")))
+	}
+
+	return diags
+}
+
+func (r *queueResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_queue"
+}
+
+// partitionAttributes are the pg_partman settings shared by every resource
+// that creates queues
+func partitionAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"enable_partitioning": schema.BoolAttribute{
+			Description:   "Enable pg_partman partitioning",
+			Optional:      true,
+			Computed:      true,
+			Default:       booldefault.StaticBool(false),
+			PlanModifiers: []planmodifier.Bool{boolplanmodifier.RequiresReplace()},
+		},
+		"partition_interval": schema.StringAttribute{
+			Description: "Partition interval (e.g. '1 day', '1 week')",
+			Optional:    true,
+			Computed:    true,
+			Default:     stringdefault.StaticString("1 day"),
+			Validators:  []validator.String{stringvalidator.LengthAtLeast(1), intervalValidator()},
+		},
+		"partition_premake": schema.Int64Attribute{
+			Description: "Partitions to create ahead",
+			Optional:    true,
+			Computed:    true,
+			Default:     int64default.StaticInt64(7),
+		},
+		"retention_period": schema.StringAttribute{
+			Description: "How long to keep partitions (e.g. '14 days')",
+			Optional:    true,
+			Computed:    true,
+			Default:     stringdefault.StaticString("14 days"),
+			Validators:  []validator.String{intervalValidator()},
+		},
+		"datetime_string": schema.StringAttribute{
+			Description: "Partition naming format (e.g. 'YYYYMMDD')",
+			Optional:    true,
+			Computed:    true,
+			Default:     stringdefault.StaticString("YYYYMMDD"),
+			Validators:  []validator.String{datetimeStringValidator()},
+		},
+		"optimize_constraint": schema.Int64Attribute{
+			Description: "Partitions to optimize",
+			Optional:    true,
+			Computed:    true,
+			Default:     int64default.StaticInt64(30),
+		},
+		"default_partition": schema.BoolAttribute{
+			Description: "Create default partition",
+			Optional:    true,
+			Computed:    true,
+			Default:     booldefault.StaticBool(true),
+		},
+		"timezone": schema.StringAttribute{
+			Description: "Timezone for partition boundaries and names computed at creation (e.g. 'UTC'), server timezone if unset. " +
+				"Changing it replaces a partitioned queue; partitions created later by maintenance follow the maintenance session's timezone",
+			Optional:      true,
+			Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+			PlanModifiers: []planmodifier.String{requiresReplaceIfPartitioned()},
+		},
+	}
+}
+
+// requiresReplaceIfPartitioned replaces a partitioned queue when a setting
+// only applied as its partitions are created changes. It can't be read back,
+// so a simple queue keeps it in state only.
+func requiresReplaceIfPartitioned() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			var partitioned types.Bool
+			resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("enable_partitioning"), &partitioned)...)
+			resp.RequiresReplace = partitioned.ValueBool()
+		},
+		"Changing the value replaces the queue if it is partitioned",
+		"Changing the value replaces the queue if `enable_partitioning` is set",
+	)
+}
+
+func withPartitionAttributes(attrs map[string]schema.Attribute) map[string]schema.Attribute {
+	for k, v := range partitionAttributes() {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// queueOptions collects the creation-time table options
+func (m queueModel) queueOptions(ctx context.Context) (*pgq.QueueOptions, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	opts := &pgq.QueueOptions{
+		TextCollation:  m.TextCollation.ValueString(),
+		OrderingColumn: m.OrderingColumn.ValueBool(),
+		IDType:         m.IDType.ValueString(),
+		Tablespace:     m.Tablespace.ValueString(),
+		AccessMethod:   m.AccessMethod.ValueString(),
+		DefaultIndexes: m.DefaultIndexes.indexes(),
+	}
+
+	var d diag.Diagnostics
+	opts.ExtraColumns, d = m.columns(ctx)
+	diags.Append(d...)
+	opts.CheckConstraints, d = m.checkConstraints(ctx)
+	diags.Append(d...)
+
+	diags.Append(m.BeforeCreateSQL.ElementsAs(ctx, &opts.BeforeCreateSQL, false)...)
+	diags.Append(m.AfterCreateSQL.ElementsAs(ctx, &opts.AfterCreateSQL, false)...)
+	diags.Append(m.PrimaryKey.ElementsAs(ctx, &opts.PrimaryKey, false)...)
+
+	return opts, diags
+}
+
+// confirmDestructive enforces require_confirmation_phrase for drop and
+// replace. confirmation_phrase is write-only, so it is only set in a model
+// read from the config: a destroy, which has no config, is confirmed by
+// destroy_confirmation_phrase from state instead.
+func (m queueModel) confirmDestructive() error {
+	if !m.RequireConfirm.ValueBool() {
+		return nil
+	}
+
+	fqn := pgq.MakeFQN(pgq.SchemaName(m.Schema.ValueString()), pgq.QueueName(m.Name.ValueString()))
+	if m.ConfirmationPhrase.ValueString() != fqn.String() {
+		return fmt.Errorf("queue %s has require_confirmation_phrase set; set confirmation_phrase = %q to replace it, "+
+			"or apply destroy_confirmation_phrase = %q before destroying it", fqn, fqn, fqn)
+	}
+	return nil
+}
+
+// replacedBy returns the attributes whose change replaces the queue, in
+// schema order
+func (m queueModel) replacedBy(state queueModel) []string {
+	var attrs []string
+	if !m.Name.Equal(state.Name) && !m.renamed(state) {
+		attrs = append(attrs, "name")
+	}
+	if !m.Schema.Equal(state.Schema) && !m.movedSchema(state) {
+		attrs = append(attrs, "schema")
+	}
+	if !m.EnablePartitioning.Equal(state.EnablePartitioning) {
+		attrs = append(attrs, "enable_partitioning")
+	}
+	return attrs
+}
+
+// confirmEmpty enforces prevent_destroy_if_not_empty: dropping a queue, or
+// its dead-letter queue, holding unprocessed messages needs force_destroy.
+// Delete only has the prior state, so force_destroy must have been applied
+// before the destroy to count.
+func (r *queueResource) confirmEmpty(ctx context.Context, m queueModel) error {
+	if !m.PreventNonEmpty.ValueBool() || m.ForceDestroy.ValueBool() {
+		return nil
+	}
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	for _, name := range m.queueNames() {
+		backlog, err := r.mgr.Backlog(ctx, schema, name)
+		if err != nil {
+			return err
+		}
+		if backlog > 0 {
+			return fmt.Errorf("queue %s has %d unprocessed messages and prevent_destroy_if_not_empty set; apply force_destroy = true, then destroy again, to drop it anyway",
+				pgq.MakeFQN(schema, name), backlog)
+		}
+	}
+	return nil
+}
+
+// setPartmanSettings copies the read-only part_config settings, cfg is nil
+// for simple queues
+func (m *queueModel) setPartmanSettings(cfg *pgq.PartitionConfig) {
+	if cfg == nil {
+		m.AutomaticMaintenance = types.StringNull()
+		return
+	}
+
+	m.AutomaticMaintenance = types.StringValue(cfg.AutomaticMaintenance)
+}
+
+func (m queueModel) partitionConfig() *pgq.PartitionConfig {
+	cfg := &pgq.PartitionConfig{
+		Interval:               m.PartitionInterval.ValueString(),
+		Premake:                int(m.PartitionPremake.ValueInt64()),
+		Retention:              m.RetentionPeriod.ValueString(),
+		DatetimeString:         m.DatetimeString.ValueString(),
+		OptimizeConstraint:     int(m.OptimizeConstraint.ValueInt64()),
+		DefaultPartition:       m.DefaultPartition.ValueBool(),
+		Timezone:               m.Timezone.ValueString(),
+		RetentionKeepTable:     m.RetentionKeepTable.ValueBool(),
+		InfiniteTimePartitions: m.InfinitePartitions.ValueBool(),
+		Jobmon:                 m.Jobmon.ValueBoolPointer(),
+		InheritPrivileges:      m.InheritPrivileges.ValueBool(),
+		StartPartition:         m.PartitionStart.ValueString(),
+		SubPartition:           m.SubPartition.config(),
+		Native:                 m.nativePartitioning(),
+		Strategy:               m.PartitionStrategy.ValueString(),
+		Key:                    m.PartitionKey.ValueString(),
+		HashPartitions:         int(m.HashPartitions.ValueInt64()),
+		// null in state written before retention_keep_index existed
+		RetentionDropIndexes: m.RetentionKeepIndex.Equal(types.BoolValue(false)),
+	}
+
+	for _, v := range m.ConstraintCols.Elements() {
+		if col, ok := v.(types.String); ok {
+			cfg.ConstraintCols = append(cfg.ConstraintCols, col.ValueString())
+		}
+	}
+
+	switch m.RetentionMode.ValueString() {
+	case retentionDetach:
+		cfg.RetentionKeepTable = true
+	case retentionArchive:
+		cfg.RetentionSchema = m.ArchiveSchema.ValueString()
+	}
+
+	return cfg
+}
+
+// Values of partitioning_mode
+const (
+	partitioningPartman = "partman"
+	partitioningNative  = "native"
+)
+
+// nativePartitioning reports whether the queue's partitions are maintained
+// by the provider rather than pg_partman
+func (m queueModel) nativePartitioning() bool {
+	return m.PartitioningMode.ValueString() == partitioningNative
+}
+
+// maintainNative maintains the partitions of a natively partitioned queue
+// and its dead-letter queue
+func (r *queueResource) maintainNative(ctx context.Context, m queueModel) error {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+
+	if err := r.mgr.MaintainNativePartitions(ctx, schema, pgq.QueueName(m.Name.ValueString()), m.partitionConfig()); err != nil {
+		return err
+	}
+
+	if cfg := m.deadLetterPartitionConfig(); cfg != nil {
+		return r.mgr.MaintainNativePartitions(ctx, schema, m.deadLetterName(), cfg)
+	}
+	return nil
+}
+
+// readPendingPartitions sets pending_partition_changes to the partitions
+// maintenance would create or retire for a natively partitioned queue and
+// its dead-letter queue, and warns about them. Read only looks, so the
+// next apply does the maintenance.
+func (r *queueResource) readPendingPartitions(ctx context.Context, m *queueModel, partitioned bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	pending := []string{}
+	if partitioned && m.nativePartitioning() {
+		queue, err := r.mgr.PendingNativeMaintenance(ctx, schema, name, m.partitionConfig())
+		if err != nil {
+			tflog.Warn(ctx, "failed to check partitions", map[string]any{"error": err})
+		}
+		pending = append(pending, queue...)
+
+		if cfg := m.deadLetterPartitionConfig(); cfg != nil {
+			dlq, err := r.mgr.PendingNativeMaintenance(ctx, schema, m.deadLetterName(), cfg)
+			if err != nil {
+				tflog.Warn(ctx, "failed to check dead-letter queue partitions", map[string]any{"error": err})
+			}
+			pending = append(pending, dlq...)
+		}
+	}
+
+	list, d := types.ListValueFrom(ctx, types.StringType, pending)
+	diags.Append(d...)
+	m.PendingPartitions = list
+
+	if len(pending) > 0 {
+		diags.AddAttributeWarning(path.Root("pending_partition_changes"), "Queue partitions need maintenance",
+			fmt.Sprintf("%s is due partition maintenance; the next apply does it:\n\n%s",
+				pgq.MakeFQN(schema, name), strings.Join(pending, "\n")))
+	}
+	return diags
+}
+
+// notPartman reports whether err from GetPartitionConfig means pg_partman
+// doesn't manage the queue, either because it has no part_config row or
+// because pg_partman isn't installed
+func notPartman(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "42P01" || pgErr.Code == "3F000"
+	}
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// Values of retention_mode
+const (
+	retentionDrop    = "drop"
+	retentionDetach  = "detach"
+	retentionArchive = "archive"
+)
+
+// setRetention sets retention_mode and archive_schema from part_config
+func (m *queueModel) setRetention(cfg *pgq.PartitionConfig) {
+	switch {
+	case cfg.RetentionSchema != "":
+		m.RetentionMode = types.StringValue(retentionArchive)
+		m.ArchiveSchema = types.StringValue(cfg.RetentionSchema)
+		return
+	case cfg.RetentionKeepTable:
+		m.RetentionMode = types.StringValue(retentionDetach)
+	default:
+		m.RetentionMode = types.StringValue(retentionDrop)
+	}
+	m.ArchiveSchema = types.StringNull()
+}
+
+// planRetention checks that archive_schema is set exactly when
+// retention_mode is archive, and plans retention_keep_table and
+// retention_mode from each other. retention_keep_table = true is the
+// pg_partman spelling of retention_mode = detach.
+func planRetention(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var mode, archive, configMode types.String
+	var keepTable types.Bool
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("retention_mode"), &mode)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("archive_schema"), &archive)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("retention_mode"), &configMode)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("retention_keep_table"), &keepTable)...)
+	if resp.Diagnostics.HasError() || mode.IsUnknown() || archive.IsUnknown() || keepTable.IsUnknown() {
+		return
+	}
+
+	switch {
+	case keepTable.IsNull():
+		keepTable = types.BoolValue(mode.ValueString() == retentionDetach)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("retention_keep_table"), keepTable)...)
+	case configMode.IsNull() && keepTable.ValueBool():
+		mode = types.StringValue(retentionDetach)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("retention_mode"), mode)...)
+	case mode.ValueString() == retentionDetach && !keepTable.ValueBool(),
+		mode.ValueString() == retentionDrop && keepTable.ValueBool():
+		resp.Diagnostics.AddAttributeError(path.Root("retention_keep_table"), "Conflicting retention settings",
+			fmt.Sprintf("retention_keep_table = %t contradicts retention_mode = %q; set only one of them.", keepTable.ValueBool(), mode.ValueString()))
+		return
+	}
+
+	switch {
+	case mode.ValueString() == retentionArchive && archive.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("archive_schema"), "Missing archive schema",
+			`retention_mode = "archive" requires archive_schema.`)
+	case mode.ValueString() != retentionArchive && !archive.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("archive_schema"), "Unused archive schema",
+			`archive_schema is only used with retention_mode = "archive".`)
+	}
+}
+
+func (r *queueResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "pgq queue table",
+		Attributes: withPartitionAttributes(map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Fully qualified name (schema.name)",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description:   "Queue name",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{requiresReplaceUnlessAllowed("allow_rename")},
+			},
+			"schema": schema.StringAttribute{
+				Description:   "PostgreSQL schema (default: the provider's default_queue_schema, or public)",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("public"),
+				PlanModifiers: []planmodifier.String{requiresReplaceUnlessAllowed("allow_schema_move")},
+			},
+			"before_create_sql": schema.ListAttribute{
+				Description: "SQL statements run in the creation transaction before the table is created",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"after_create_sql": schema.ListAttribute{
+				Description: "SQL statements run in the last creation transaction after the queue is provisioned",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"before_destroy_sql": schema.ListAttribute{
+				Description: "SQL statements run in a transaction before the queue is dropped",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"require_confirmation_phrase": schema.BoolAttribute{
+				Description: "Refuse to drop or replace the queue unless confirmation_phrase equals its fully qualified name",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"confirmation_phrase": schema.StringAttribute{
+				Description: "Must equal the queue's fully qualified name (schema.name) to allow a replacement when require_confirmation_phrase is set. Write-only, never stored in state",
+				Optional:    true,
+				WriteOnly:   true,
+			},
+			"destroy_confirmation_phrase": schema.StringAttribute{
+				Description: "Must equal the queue's fully qualified name (schema.name) to allow a destroy when require_confirmation_phrase is set. Read from state, so it must be applied before the destroy",
+				Optional:    true,
+			},
+			"prevent_destroy_if_not_empty": schema.BoolAttribute{
+				Description: "Refuse to drop or replace the queue while it or its dead-letter queue holds unprocessed messages, unless force_destroy is set",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"force_destroy": schema.BoolAttribute{
+				Description: "Drop the queue even if it holds unprocessed messages and prevent_destroy_if_not_empty is set. Read from state, so it must be applied before the destroy",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"fail_on_destructive_change": schema.BoolAttribute{
+				Description: "Fail the plan instead of warning when a change replaces the queue while it holds messages",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"allow_rename": schema.BoolAttribute{
+				Description: "Rename the queue in place, keeping its messages, when name changes instead of replacing it",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"allow_schema_move": schema.BoolAttribute{
+				Description: "Move the queue to the new schema in place, keeping its messages, when schema changes instead of replacing it",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"cluster_on": schema.StringAttribute{
+				Description: "Index on the queue to mark as CLUSTER index, applied to the template and existing partitions for partitioned queues",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"cluster_schedule": schema.StringAttribute{
+				Description: "pg_cron schedule clustering the most recently closed partition on cluster_on (partitioned queues only)",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.AlsoRequires(path.MatchRoot("cluster_on"))},
+			},
+			"automatic_maintenance": schema.StringAttribute{
+				Description:   "pg_partman automatic_maintenance setting (read-only)",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"retention_mode": schema.StringAttribute{
+				Description: "What happens to partitions past retention_period: drop, detach (keep them as standalone tables) or archive (detach and move them to archive_schema)",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(retentionDrop),
+				Validators:  []validator.String{stringvalidator.OneOf(retentionDrop, retentionDetach, retentionArchive)},
+			},
+			"archive_schema": schema.StringAttribute{
+				Description: "Schema partitions past retention are moved to with retention_mode = archive, created if needed",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"retention_keep_table": schema.BoolAttribute{
+				Description: "Detach partitions past retention instead of dropping them, the same as retention_mode = detach",
+				Optional:    true,
+				Computed:    true,
+			},
+			"infinite_time_partitions": schema.BoolAttribute{
+				Description: "Keep premaking partitions while no new rows arrive, so low-volume queues don't fall back to the default partition",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"constraint_columns": schema.ListAttribute{
+				Description: "Columns (e.g. 'scheduled_for') pg_partman adds CHECK constraints for on partitions older than optimize_constraint, so queries filtering on them skip those partitions",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators:  []validator.List{listvalidator.SizeAtLeast(1), listvalidator.ValueStringsAre(identifierValidator())},
+			},
+			"partition_start": schema.StringAttribute{
+				Description: "Timestamp (e.g. '2023-01-01') of the first partition created, for queues backfilled with older messages; only used at creation",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"inherit_privileges": schema.BoolAttribute{
+				Description: "Grant the privileges of the queue table on new partitions, so grants on the queue reach partitions created later",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"jobmon": schema.BoolAttribute{
+				Description:   "Log pg_partman maintenance to pg_jobmon, default: whether pg_jobmon is installed",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Bool{boolplanmodifier.UseStateForUnknown()},
+			},
+			"retention_keep_index": schema.BoolAttribute{
+				Description: "Keep the indexes of partitions detached by retention",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+			"partitioning_mode": schema.StringAttribute{
+				Description:   "Who maintains the partitions: partman (pg_partman) or native (the provider, on every refresh and apply, without any extension)",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString(partitioningPartman),
+				Validators:    []validator.String{stringvalidator.OneOf(partitioningPartman, partitioningNative)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"partition_strategy": schema.StringAttribute{
+				Description:   "How rows are assigned to partitions: range (time ranges of partition_key), list (one partition per value of an integer partition_key) or hash (hash_partitions partitions by a hash of partition_key, native partitioning only)",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString(pgq.PartitionRange),
+				Validators:    []validator.String{stringvalidator.OneOf(pgq.PartitionRange, pgq.PartitionList, pgq.PartitionHash)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"partition_key": schema.StringAttribute{
+				Description:   "Partition key column, created_at or a NOT NULL extra_column; it becomes part of the primary key. Default: created_at, required for list and hash partitioning.",
+				Optional:      true,
+				Validators:    []validator.String{identifierValidator()},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"hash_partitions": schema.Int64Attribute{
+				Description:   "Number of partitions with partition_strategy = hash",
+				Optional:      true,
+				Validators:    []validator.Int64{int64validator.AtLeast(2)},
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.RequiresReplace()},
+			},
+			"text_collation": schema.StringAttribute{
+				Description: "Collation of the queue's text columns, error_detail and text extra_columns (e.g. 'C'), database default if unset",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"tablespace": schema.StringAttribute{
+				Description: "Tablespace of the queue table, its template table and default indexes, database default if unset",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"access_method": schema.StringAttribute{
+				Description:   "Table access method of the queue table and its template table (e.g. 'heap', or one added by an extension such as orioledb), default_table_access_method if unset. Partitioned queues need PostgreSQL 17 or later.",
+				Optional:      true,
+				Validators:    []validator.String{identifierValidator()},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"owner": schema.StringAttribute{
+				Description:   "Role owning the queue table, its template table and partitions, the creating role if unset",
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"comment": schema.StringAttribute{
+				Description: "Comment on the queue table",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"dead_letter_queue": schema.StringAttribute{
+				Description: "Fully qualified name of the dead-letter queue, null without a dead_letter block",
+				Computed:    true,
+			},
+			"skip_if_unsupported": schema.BoolAttribute{
+				Description: "Skip creating the queue with a warning, instead of failing, when the server can't host it (no pg_partman, PostgreSQL too old)",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"provisioned": schema.BoolAttribute{
+				Description:   "Whether the queue exists; false when skipped by skip_if_unsupported",
+				Computed:      true,
+				PlanModifiers: []planmodifier.Bool{boolplanmodifier.UseStateForUnknown()},
+			},
+			"verify_partition_grants": schema.BoolAttribute{
+				Description: "Check on every read that each partition carries the parent's grants, reporting drift",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"partition_grant_drift": schema.ListAttribute{
+				Description: "Partitions missing grants of the parent, when verify_partition_grants is set",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"missing_indexes": schema.ListAttribute{
+				Description: "Built-in indexes the queue lacks or has invalid, e.g. after a manual DROP INDEX; the next apply recreates them",
+				ElementType: types.StringType,
+				Computed:    true,
+				Default:     listdefault.StaticValue(types.ListValueMust(types.StringType, []attr.Value{})),
+			},
+			"pending_partition_changes": schema.ListAttribute{
+				Description: "With partitioning_mode native, partitions the queue and its dead-letter queue are due to have created or retired, as 'create <partition>' or 'retire <partition>'; refresh only reports them and the next apply does the maintenance",
+				ElementType: types.StringType,
+				Computed:    true,
+				Default:     listdefault.StaticValue(types.ListValueMust(types.StringType, []attr.Value{})),
+			},
+			"clone_from": schema.StringAttribute{
+				Description: "Fully qualified name of an existing queue whose partitioning, custom indexes, grants and storage parameters the queue is created with; only read on creation",
+				Optional:    true,
+				Validators:  []validator.String{fqnValidator()},
+			},
+			"cloned_indexes": schema.ListAttribute{
+				Description:   "Custom indexes copied from the clone_from queue on creation",
+				ElementType:   types.StringType,
+				Computed:      true,
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+			},
+			"structure_hash": schema.StringAttribute{
+				Description:   "Hash of the names, types, nullability and defaults of the built-in columns; changes when they are altered outside Terraform",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"reject_messages_older_than": schema.StringAttribute{
+				Description: "Reject inserts whose created_at or scheduled_for is older than this interval (e.g. '1 day'), via a BEFORE INSERT trigger",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"notify_channel": schema.StringAttribute{
+				Description: "Send the id of every new message to this channel with pg_notify, via an AFTER INSERT trigger, so consumers can LISTEN instead of polling",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthBetween(1, 63)},
+			},
+			"publications": schema.SetAttribute{
+				Description: "Logical replication publications the queue table is added to. Partitioned queues are added with their partitions and the publications set to publish_via_partition_root.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators:  []validator.Set{setvalidator.SizeAtLeast(1), setvalidator.ValueStringsAre(identifierValidator())},
+			},
+			"replica_identity": schema.StringAttribute{
+				Description: "Replica identity of the queue table and its partitions: default, full, nothing or index:<name> of a unique index such as the primary key. Logical decoding needs it to publish UPDATEs and DELETEs.",
+				Optional:    true,
+				Validators:  []validator.String{replicaIdentityValidator()},
+			},
+			"legal_hold_partitions": schema.SetAttribute{
+				Description: "Partitions (table names, e.g. 'events_queue_p20240101') exempt from retention. They are detached and moved to legal_hold_schema; removing one reattaches it.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators:  []validator.Set{setvalidator.ValueStringsAre(identifierValidator())},
+			},
+			"legal_hold_schema": schema.StringAttribute{
+				Description: "Schema holding the partitions of legal_hold_partitions",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("pgq_legal_hold"),
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"ordering_column": schema.BoolAttribute{
+				Description: "Add a sequence-backed bigint 'seq' column with an index, a monotonic ordering key for consumers",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"id_type": schema.StringAttribute{
+				Description:   "Type of the id column: uuid_v4 (random), uuid_v7 (time-ordered, needs PostgreSQL 18 or the pg_uuidv7 extension) or bigint_identity",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString(pgq.IDUUIDv4),
+				Validators:    []validator.String{stringvalidator.OneOf(pgq.IDUUIDv4, pgq.IDUUIDv7, pgq.IDBigintIdentity)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"primary_key": schema.ListAttribute{
+				Description: "Primary key columns in order, e.g. ['tenant_id', 'id']; must contain id and the partition key columns. Default: id, then the partition key columns.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+					listvalidator.ValueStringsAre(identifierValidator()),
+				},
+			},
+		}),
+		Blocks: map[string]schema.Block{
+			"endpoint":        endpointBlock(),
+			"dead_letter":     deadLetterBlock(),
+			"sub_partition":   subPartitionBlock(),
+			"default_indexes": defaultIndexesBlock(),
+			"rls":             rlsBlock(),
+			"extra_column": schema.ListNestedBlock{
+				Description: "Columns added after the built-in ones, e.g. a tenant_id for partition pruning or row-level security",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Column name",
+							Required:    true,
+							Validators: []validator.String{
+								identifierValidator(),
+								stringvalidator.NoneOf(pgq.ReservedColumns...),
+							},
+						},
+						"type": schema.StringAttribute{
+							Description: "PostgreSQL type (e.g. 'uuid', 'varchar(64)')",
+							Required:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+						"nullable": schema.BoolAttribute{
+							Description: "Whether the column accepts NULL",
+							Optional:    true,
+							Computed:    true,
+							Default:     booldefault.StaticBool(true),
+						},
+						"default": schema.StringAttribute{
+							Description: "Default expression (e.g. '0', 'gen_random_uuid()')",
+							Optional:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+					},
+				},
+			},
+			"generated_column": generatedColumnBlock(),
+			"column_storage":   columnStorageBlock(),
+			"check_constraint": schema.SetNestedBlock{
+				Description: "CHECK constraints created with the queue table and its template table",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Constraint name",
+							Required:    true,
+							Validators:  []validator.String{identifierValidator()},
+						},
+						"expression": schema.StringAttribute{
+							Description: "Boolean expression (e.g. 'consumed_count < 100')",
+							Required:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+					},
+				},
+			},
+			"custom_index": schema.SetNestedBlock{
+				Description: "Custom indexes to create on the queue table",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Index name (auto-generated if not provided)",
+							Optional:    true,
+							Computed:    true,
+						},
+						"columns": schema.ListAttribute{
+							Description: "Column expressions (e.g. 'created_at', '(payload->>''user_id'')'), optionally followed by an operator class and sort order",
+							Optional:    true,
+							ElementType: types.StringType,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+								listvalidator.ExactlyOneOf(path.MatchRelative().AtParent().AtName("column")),
+							},
+						},
+						"column": schema.ListNestedAttribute{
+							Description: "Index columns with an operator class or sort order, instead of columns",
+							Optional:    true,
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"expression": schema.StringAttribute{
+										Description: "Column name or parenthesized expression",
+										Required:    true,
+										Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+									},
+									"opclass": schema.StringAttribute{
+										Description: "Operator class, e.g. jsonb_path_ops or text_pattern_ops",
+										Optional:    true,
+										Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+									},
+									"order": schema.StringAttribute{
+										Description: "Sort order: ASC or DESC",
+										Optional:    true,
+										Validators:  []validator.String{stringvalidator.OneOf("ASC", "DESC")},
+									},
+									"nulls": schema.StringAttribute{
+										Description: "Whether NULLs sort FIRST or LAST",
+										Optional:    true,
+										Validators:  []validator.String{stringvalidator.OneOf("FIRST", "LAST")},
+									},
+								},
+							},
+							Validators: []validator.List{listvalidator.SizeAtLeast(1)},
+						},
+						"type": schema.StringAttribute{
+							Description: "Index type",
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString("btree"),
+							Validators: []validator.String{
+								stringvalidator.OneOf(indexTypes...),
+							},
+						},
+						"where": schema.StringAttribute{
+							Description: "Partial index WHERE clause",
+							Optional:    true,
+						},
+						"comment": schema.StringAttribute{
+							Description: "Comment on the index",
+							Optional:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+						"with": schema.MapAttribute{
+							Description: "Storage parameters of the index, e.g. fillfactor or fastupdate",
+							Optional:    true,
+							ElementType: types.StringType,
+							Validators: []validator.Map{
+								mapvalidator.SizeAtLeast(1),
+								mapvalidator.KeysAre(storageParameterValidator()),
+							},
+						},
+						"tablespace": schema.StringAttribute{
+							Description: "Tablespace of the index, database default if unset",
+							Optional:    true,
+							Validators:  []validator.String{identifierValidator()},
+						},
+						"concurrently": schema.BoolAttribute{
+							Description: "Build the index with CREATE INDEX CONCURRENTLY, without blocking writes to the queue",
+							Optional:    true,
+							Computed:    true,
+							Default:     booldefault.StaticBool(false),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *queueResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{legalHoldValidator{}}
+}
+
+func (r *queueResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	r.mgr = data.mgr
+	r.readOnly = data.readOnly
+	r.profile = data.profile
+	r.defaultSchema = data.defaultSchema
+	r.partitionDefaults = data.partitionDefaults
+	r.registry = data.registry
+	r.endpoints = data.endpoints
+	r.fastRefresh = data.fastRefresh
+}
+
+// atEndpoint returns the resource managing the database of the endpoint
+// block, which is r itself without one
+func (r *queueResource) atEndpoint(ctx context.Context, m *endpointModel) (*queueResource, error) {
+	mgr, err := r.endpoints.manager(ctx, m, r.mgr)
+	if err != nil || mgr == r.mgr {
+		return r, err
+	}
+	scoped := *r
+	scoped.mgr = mgr
+	scoped.registry = r.registry.withManager(mgr)
+	return &scoped, nil
+}
+
+func (r *queueResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "created")
+		return
+	}
+
+	var plan queueModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	r, err := r.atEndpoint(ctx, plan.Endpoint)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to connect to endpoint", err)
+		return
+	}
+
+	schema := pgq.SchemaName(plan.Schema.ValueString())
+	name := pgq.QueueName(plan.Name.ValueString())
+
+	tflog.Debug(ctx, "creating queue", map[string]any{
+		"fqn":         string(pgq.MakeFQN(schema, name)),
+		"partitioned": plan.EnablePartitioning.ValueBool(),
+	})
+
+	opts, diags := plan.queueOptions(ctx)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if plan.SkipUnsupported.ValueBool() {
+		err := r.mgr.CheckSupport(ctx, plan.EnablePartitioning.ValueBool() && !plan.nativePartitioning())
+		if unsupported, ok := err.(*pgq.UnsupportedError); ok {
+			resp.Diagnostics.AddWarning("Queue not provisioned",
+				fmt.Sprintf("Skipped creating %s because skip_if_unsupported is set: %s", pgq.MakeFQN(schema, name), unsupported.Reason))
+			plan.skipProvisioning()
+			resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+			return
+		}
+		if err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to check server capabilities", err)
+			return
+		}
+	}
+	plan.Provisioned = types.BoolValue(true)
+
+	if plan.EnablePartitioning.ValueBool() {
+		if err := r.mgr.CreatePartitioned(ctx, schema, name, plan.partitionConfig(), opts); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to create partitioned queue", err)
+			return
+		}
+	} else {
+		if err := r.mgr.CreateSimple(ctx, schema, name, opts); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to create queue", err)
+			return
+		}
+	}
+
+	// the table exists, a failure in the steps below must not orphan it
+	plan.ID = types.StringValue(string(pgq.MakeFQN(schema, name)))
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.EnablePartitioning.ValueBool() && !plan.nativePartitioning() {
+		created, err := r.mgr.GetPartitionConfig(ctx, schema, name)
+		if err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to read partition config", err)
+			return
+		}
+		plan.setPartmanSettings(created)
+		plan.Jobmon = types.BoolPointerValue(created.Jobmon)
+	} else {
+		plan.setPartmanSettings(nil)
+		if plan.Jobmon.IsUnknown() {
+			plan.Jobmon = types.BoolNull()
+		}
+	}
+
+	if !plan.CustomIndexes.IsNull() && !plan.CustomIndexes.IsUnknown() {
+		var customIndexes []customIndexModel
+		if diags := plan.CustomIndexes.ElementsAs(ctx, &customIndexes, false); diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+
+		if diags := r.createCustomIndexes(ctx, schema, name, customIndexes); diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+	}
+
+	plan.ClonedIndexes = types.ListNull(types.StringType)
+	if isSet(plan.CloneFrom) {
+		existing, err := r.mgr.GetCustomIndexes(ctx, schema, name)
+		if err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to read custom indexes", err)
+			return
+		}
+		skip := make([]string, len(existing))
+		for i, idx := range existing {
+			skip[i] = idx.Name
+		}
+
+		cloned, err := r.mgr.CloneObjects(ctx, pgq.FQN(plan.CloneFrom.ValueString()), schema, name, skip)
+		if err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to clone queue", err)
+			return
+		}
+		var d diag.Diagnostics
+		plan.ClonedIndexes, d = types.ListValueFrom(ctx, types.StringType, cloned)
+		resp.Diagnostics.Append(d...)
+	}
+
+	if !plan.RejectOlderThan.IsNull() {
+		if err := r.mgr.SetMaxMessageAge(ctx, schema, name, plan.RejectOlderThan.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to set maximum message age", err)
+			return
+		}
+	}
+
+	if !plan.NotifyChannel.IsNull() {
+		if err := r.mgr.SetNotifyChannel(ctx, schema, name, plan.NotifyChannel.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to create notify trigger", err)
+			return
+		}
+	}
+
+	if plan.RLS != nil {
+		if err := r.mgr.SetRowLevelSecurity(ctx, schema, name, plan.RLS.enabled(), nil, plan.RLS.policies()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to set row-level security", err)
+			return
+		}
+	}
+
+	if !plan.Comment.IsNull() {
+		if err := r.mgr.SetComment(ctx, schema, name, plan.Comment.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to set comment", err)
+			return
+		}
+	}
+
+	if err := r.applyCluster(ctx, plan, queueModel{}); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to configure clustering", err)
+		return
+	}
+
+	if err := r.applyLegalHolds(ctx, plan, queueModel{}); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to place legal holds", err)
+		return
+	}
+
+	if !plan.ReplicaIdentity.IsNull() {
+		if err := r.mgr.SetReplicaIdentity(ctx, schema, name, plan.ReplicaIdentity.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to set replica identity", err)
+			return
+		}
+	}
+
+	if !plan.ColumnStorage.IsNull() {
+		resp.Diagnostics.Append(r.applyColumnStorage(ctx, plan, queueModel{})...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !plan.Publications.IsNull() {
+		if err := r.applyPublications(ctx, plan, queueModel{}); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to add queue to publications", err)
+			return
+		}
+	}
+
+	if plan.DeadLetter != nil {
+		if err := r.createDeadLetter(ctx, plan, opts); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to create dead-letter queue", err)
+			return
+		}
+	}
+	plan.setDeadLetterQueue()
+
+	if isSet(plan.Owner) {
+		if err := r.mgr.SetOwner(ctx, schema, name, plan.Owner.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to set owner", err)
+			return
+		}
+	} else {
+		owner, err := r.mgr.GetOwner(ctx, schema, name)
+		if err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to read owner", err)
+			return
+		}
+		plan.Owner = types.StringValue(owner)
+	}
+
+	resp.Diagnostics.Append(r.checkPartitionGrants(ctx, &plan)...)
+	resp.Diagnostics.Append(r.readStructure(ctx, &plan)...)
+
+	plan.ID = types.StringValue(string(pgq.MakeFQN(schema, name)))
+	resp.Diagnostics.Append(r.registry.recordQueues(ctx, "pgq_queue", plan.ID.ValueString(), schema, plan.queueNames())...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *queueResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state queueModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	r, err := r.atEndpoint(ctx, state.Endpoint)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to connect to endpoint", err)
+		return
+	}
+
+	schema := pgq.SchemaName(state.Schema.ValueString())
+	name := pgq.QueueName(state.Name.ValueString())
+
+	// a skipped queue stays skipped until the server gains support, then it
+	// is removed from state so the next apply creates it
+	if state.skipped() {
+		err := r.mgr.CheckSupport(ctx, state.EnablePartitioning.ValueBool() && !state.nativePartitioning())
+		if err == nil {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		if _, ok := err.(*pgq.UnsupportedError); !ok {
+			tflog.Warn(ctx, "failed to check server capabilities", map[string]any{"error": err})
+		}
+		if state.PartitioningMode.IsNull() || state.PartitionStrategy.IsNull() {
+			state.upgradeState()
+			resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+		}
+		return
+	}
+
+	q, err := r.mgr.Get(ctx, schema, name)
+	if err != nil {
+		if _, ok := err.(*pgq.QueueNotFoundError); ok {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		errorDiag(&resp.Diagnostics, "Failed to read queue", err)
+		return
+	}
+
+	// imported queues, whose state only has the name, are always read in full
+	imported := state.Provisioned.IsNull()
+
+	state.EnablePartitioning = types.BoolValue(q.Partitioned)
+	state.Provisioned = types.BoolValue(true)
+	resp.Diagnostics.Append(state.setStructure(q)...)
+	if q.Partitioned {
+		state.setPartitionKey(q)
+	}
+
+	if !imported {
+		state.upgradeState()
+	}
+
+	// native partitions run out unless maintained, so they are checked in
+	// fast mode too
+	resp.Diagnostics.Append(r.readPendingPartitions(ctx, &state, q.Partitioned)...)
+
+	if r.fastRefresh && !imported {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+		return
+	}
+
+	clusterOn, err := r.mgr.GetClusterIndex(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read cluster index", map[string]any{"error": err})
+	} else if clusterOn != "" {
+		state.ClusterOn = types.StringValue(clusterOn)
+	} else {
+		state.ClusterOn = types.StringNull()
+	}
+
+	// cron.job only exists with pg_cron, so only look when a job is expected
+	if !state.ClusterSchedule.IsNull() {
+		schedule, err := r.mgr.GetClusterSchedule(ctx, schema, name)
+		if err != nil {
+			tflog.Warn(ctx, "failed to read cluster schedule", map[string]any{"error": err})
+		} else if schedule != "" {
+			state.ClusterSchedule = types.StringValue(schedule)
+		} else {
+			state.ClusterSchedule = types.StringNull()
+		}
+	}
+
+	maxAge, err := r.mgr.GetMaxMessageAge(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read maximum message age", map[string]any{"error": err})
+	} else if maxAge != "" {
+		state.RejectOlderThan = types.StringValue(maxAge)
+	} else {
+		state.RejectOlderThan = types.StringNull()
+	}
+
+	// publications managed elsewhere are left alone, except on import
+	if !state.Publications.IsNull() || imported {
+		pubs, err := r.mgr.GetPublications(ctx, schema, name)
+		if err != nil {
+			tflog.Warn(ctx, "failed to read publications", map[string]any{"error": err})
+		} else if len(pubs) > 0 {
+			set, diags := types.SetValueFrom(ctx, types.StringType, pubs)
+			resp.Diagnostics.Append(diags...)
+			state.Publications = set
+		} else {
+			state.Publications = types.SetNull(types.StringType)
+		}
+	}
+
+	// without replica_identity the setting is left alone; imports only
+	// pick up one that isn't the default
+	if !state.ReplicaIdentity.IsNull() || imported {
+		identity, err := r.mgr.GetReplicaIdentity(ctx, schema, name)
+		if err != nil {
+			tflog.Warn(ctx, "failed to read replica identity", map[string]any{"error": err})
+		} else if !state.ReplicaIdentity.IsNull() || identity != "default" {
+			state.ReplicaIdentity = types.StringValue(identity)
+		}
+	}
+
+	channel, err := r.mgr.GetNotifyChannel(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read notify channel", map[string]any{"error": err})
+	} else if channel != "" {
+		state.NotifyChannel = types.StringValue(channel)
+	} else {
+		state.NotifyChannel = types.StringNull()
+	}
+
+	ordering, err := r.mgr.HasOrderingColumn(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read ordering column", map[string]any{"error": err})
+	} else {
+		state.OrderingColumn = types.BoolValue(ordering)
+	}
+
+	key, err := r.mgr.GetPrimaryKey(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read primary key", map[string]any{"error": err})
+	} else {
+		resp.Diagnostics.Append(state.setPrimaryKey(ctx, key)...)
+	}
+
+	resp.Diagnostics.Append(r.readMissingIndexes(ctx, &state)...)
+
+	defaultIndexes, err := r.mgr.GetDefaultIndexes(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read default indexes", map[string]any{"error": err})
+	} else {
+		state.setDefaultIndexes(defaultIndexes)
+	}
+
+	idType, err := r.mgr.GetIDType(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read id type", map[string]any{"error": err})
+	} else {
+		state.IDType = types.StringValue(idType)
+	}
+
+	collation, err := r.mgr.GetTextCollation(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read text collation", map[string]any{"error": err})
+	} else if collation != "" {
+		state.TextCollation = types.StringValue(collation)
+	} else {
+		state.TextCollation = types.StringNull()
+	}
+
+	owner, err := r.mgr.GetOwner(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read owner", map[string]any{"error": err})
+	} else {
+		state.Owner = types.StringValue(owner)
+	}
+
+	resp.Diagnostics.Append(r.readExtraColumns(ctx, &state, imported)...)
+	resp.Diagnostics.Append(r.readGeneratedColumns(ctx, &state, imported)...)
+	resp.Diagnostics.Append(r.readColumnStorage(ctx, &state)...)
+	resp.Diagnostics.Append(r.readCheckConstraints(ctx, &state, imported)...)
+	r.readRLS(ctx, &state, imported)
+	r.readDeadLetter(ctx, &state)
+
+	comment, err := r.mgr.GetComment(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read comment", map[string]any{"error": err})
+	} else if comment != "" {
+		state.Comment = types.StringValue(comment)
+	} else {
+		state.Comment = types.StringNull()
+	}
+
+	// without access_method the default is left alone; imports only pick
+	// up one that isn't heap
+	if !state.AccessMethod.IsNull() || imported {
+		method, err := r.mgr.GetAccessMethod(ctx, schema, name)
+		if err != nil {
+			tflog.Warn(ctx, "failed to read access method", map[string]any{"error": err})
+		} else if !state.AccessMethod.IsNull() || (method != "" && method != "heap") {
+			state.AccessMethod = stringOrNull(method)
+		}
+	}
+
+	tablespace, err := r.mgr.GetTablespace(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read tablespace", map[string]any{"error": err})
+	} else if tablespace != "" {
+		state.Tablespace = types.StringValue(tablespace)
+	} else {
+		state.Tablespace = types.StringNull()
+	}
+
+	if q.Partitioned {
+		// imported queues are native unless pg_partman manages them
+		var cfg *pgq.PartitionConfig
+		if !state.nativePartitioning() {
+			cfg, err = r.mgr.GetPartitionConfig(ctx, schema, name)
+		}
+		if state.PartitioningMode.IsNull() {
+			if notPartman(err) {
+				state.PartitioningMode = types.StringValue(partitioningNative)
+			} else {
+				state.PartitioningMode = types.StringValue(partitioningPartman)
+			}
+		}
+
+		if state.nativePartitioning() {
+			state.setPartmanSettings(nil)
+			// native settings live in the state, except this one
+			if imported {
+				state.DefaultPartition = types.BoolValue(q.DefaultPartition)
+			}
+		} else if err != nil {
+			tflog.Warn(ctx, "failed to read partition config", map[string]any{"error": err})
+		} else {
+			// pg_partman's interval and retention of list partitioning
+			// aren't pgq_queue's
+			if q.Strategy == pgq.PartitionRange {
+				state.PartitionInterval = keepInterval(state.PartitionInterval, cfg.Interval)
+				state.RetentionPeriod = keepInterval(state.RetentionPeriod, cfg.Retention)
+				state.DatetimeString = types.StringValue(cfg.DatetimeString)
+			}
+			state.PartitionPremake = types.Int64Value(int64(cfg.Premake))
+			state.OptimizeConstraint = types.Int64Value(int64(cfg.OptimizeConstraint))
+			state.DefaultPartition = types.BoolValue(cfg.DefaultPartition)
+			state.setRetention(cfg)
+			state.RetentionKeepTable = types.BoolValue(cfg.RetentionKeepTable)
+			state.RetentionKeepIndex = types.BoolValue(!cfg.RetentionDropIndexes)
+			state.InfinitePartitions = types.BoolValue(cfg.InfiniteTimePartitions)
+			state.Jobmon = types.BoolPointerValue(cfg.Jobmon)
+			state.InheritPrivileges = types.BoolValue(cfg.InheritPrivileges)
+			state.setSubPartition(cfg.SubPartition)
+			if len(cfg.ConstraintCols) > 0 {
+				cols, diags := types.ListValueFrom(ctx, types.StringType, cfg.ConstraintCols)
+				resp.Diagnostics.Append(diags...)
+				state.ConstraintCols = cols
+			} else {
+				state.ConstraintCols = types.ListNull(types.StringType)
+			}
+			state.setPartmanSettings(cfg)
+		}
+
+		held, err := r.mgr.HeldPartitions(ctx, schema, name, pgq.SchemaName(state.LegalHoldSchema.ValueString()))
+		if err != nil {
+			tflog.Warn(ctx, "failed to read legal holds", map[string]any{"error": err})
+		} else if len(held) > 0 {
+			set, diags := types.SetValueFrom(ctx, types.StringType, held)
+			resp.Diagnostics.Append(diags...)
+			state.LegalHolds = set
+		} else {
+			state.LegalHolds = types.SetNull(types.StringType)
+		}
+	} else {
+		state.upgradeState()
+		state.setPartmanSettings(nil)
+	}
+
+	resp.Diagnostics.Append(r.checkPartitionGrants(ctx, &state)...)
+
+	customIndexes, err := r.mgr.GetCustomIndexes(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read custom indexes", map[string]any{"error": err})
+	} else {
+		var cloned []string
+		if !state.ClonedIndexes.IsNull() && !state.ClonedIndexes.IsUnknown() {
+			resp.Diagnostics.Append(state.ClonedIndexes.ElementsAs(ctx, &cloned, false)...)
+		}
+		customIndexes = slices.DeleteFunc(customIndexes, func(idx pgq.CustomIndex) bool {
+			return slices.Contains(cloned, idx.Name)
+		})
+
+		var prior []customIndexModel
+		if !state.CustomIndexes.IsNull() && !state.CustomIndexes.IsUnknown() {
+			if diags := state.CustomIndexes.ElementsAs(ctx, &prior, false); diags.HasError() {
+				resp.Diagnostics.Append(diags...)
+				return
+			}
+		}
+
+		models, diags := convertToCustomIndexModels(ctx, customIndexes, prior)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+
+		if len(models) > 0 {
+			set, diags := types.SetValueFrom(ctx, customIndexObjectType(), models)
+			if diags.HasError() {
+				resp.Diagnostics.Append(diags...)
+				return
+			}
+			state.CustomIndexes = set
+		} else {
+			state.CustomIndexes = types.SetNull(customIndexObjectType())
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *queueResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "updated")
+		return
+	}
+
+	var plan, state queueModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	r, err := r.atEndpoint(ctx, state.Endpoint)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to connect to endpoint", err)
+		return
+	}
+
+	if state.skipped() {
+		plan.skipProvisioning()
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+	plan.Provisioned = types.BoolValue(true)
+
+	// the rename or move is committed, so state follows it right away for
+	// a failure in a later step not to leave it at a queue that's gone
+	if plan.renamed(state) || plan.movedSchema(state) {
+		oldID := state.ID.ValueString()
+		// state is saved after each step, failed or not, so it never points
+		// at a name the queue no longer has
+		if plan.renamed(state) {
+			err := r.renameQueue(ctx, plan, &state)
+			resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+			if err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to rename queue", err)
+				return
+			}
+		}
+		if plan.movedSchema(state) {
+			err := r.moveQueue(ctx, plan, &state)
+			resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+			if err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to move queue", err)
+				return
+			}
+		}
+		resp.Diagnostics.Append(r.registry.forget(ctx, "pgq_queue", oldID)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// also moves a dead-letter queue a previous apply failed to move
+	if err := r.relocateDeadLetter(ctx, &state); err != nil {
+		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+		errorDiag(&resp.Diagnostics, "Failed to move dead-letter queue", err)
+		return
+	}
+
+	schema := pgq.SchemaName(plan.Schema.ValueString())
+	name := pgq.QueueName(plan.Name.ValueString())
+
+	if state.EnablePartitioning.ValueBool() && plan.EnablePartitioning.ValueBool() {
+		cfg := plan.partitionConfig()
+
+		if err := r.mgr.UpdatePartitionConfig(ctx, schema, name, cfg); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update partition config", err)
+			return
+		}
+
+		if !cfg.Native && cfg.InheritPrivileges && !state.InheritPrivileges.ValueBool() {
+			if err := r.mgr.ReapplyPrivileges(ctx, schema, name); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to grant the queue's privileges on its partitions", err)
+				return
+			}
+		}
+	}
+
+	opts, diags := plan.queueOptions(ctx)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.updateDeadLetter(ctx, plan, state, opts); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to update dead-letter queue", err)
+		return
+	}
+
+	// column changes apply to a dead-letter queue that existed before too,
+	// a new one was created with them
+	targets := []pgq.QueueName{name}
+	if plan.DeadLetter != nil && state.DeadLetter != nil {
+		targets = append(targets, plan.deadLetterName())
+	}
+
+	if !plan.TextCollation.Equal(state.TextCollation) {
+		for _, target := range targets {
+			if err := r.mgr.SetTextCollation(ctx, schema, target, plan.TextCollation.ValueString()); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update text collation", err)
+				return
+			}
+		}
+	}
+
+	if !plan.ExtraColumns.Equal(state.ExtraColumns) || !plan.GeneratedColumns.Equal(state.GeneratedColumns) {
+		from, diags := state.columns(ctx)
+		resp.Diagnostics.Append(diags...)
+		to, diags := plan.columns(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		for _, target := range targets {
+			if err := r.mgr.SetExtraColumns(ctx, schema, target, from, to); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update extra columns", err)
+				return
+			}
+		}
+	}
+
+	if !plan.CheckConstraints.Equal(state.CheckConstraints) {
+		from, diags := state.checkConstraints(ctx)
+		resp.Diagnostics.Append(diags...)
+		to, diags := plan.checkConstraints(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		for _, target := range targets {
+			if err := r.mgr.SetCheckConstraints(ctx, schema, target, from, to); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update check constraints", err)
+				return
+			}
+		}
+	}
+
+	if !plan.PrimaryKey.Equal(state.PrimaryKey) {
+		key, diags := plan.primaryKey(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := r.mgr.SetPrimaryKey(ctx, schema, name, key); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update primary key", err)
+			return
+		}
+	}
+
+	if *plan.DefaultIndexes.indexes() != *state.DefaultIndexes.indexes() {
+		if err := r.mgr.SetDefaultIndexes(ctx, schema, name, plan.DefaultIndexes.indexes(), plan.Tablespace.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update default indexes", err)
+			return
+		}
+	}
+
+	if !plan.Comment.Equal(state.Comment) {
+		if err := r.mgr.SetComment(ctx, schema, name, plan.Comment.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update comment", err)
+			return
+		}
+	}
+
+	if !plan.Tablespace.Equal(state.Tablespace) {
+		if err := r.mgr.SetTablespace(ctx, schema, name, plan.Tablespace.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update tablespace", err)
+			return
+		}
+	}
+
+	if !plan.RejectOlderThan.Equal(state.RejectOlderThan) {
+		if err := r.mgr.SetMaxMessageAge(ctx, schema, name, plan.RejectOlderThan.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update maximum message age", err)
+			return
+		}
+	}
+
+	if !plan.NotifyChannel.Equal(state.NotifyChannel) {
+		if err := r.mgr.SetNotifyChannel(ctx, schema, name, plan.NotifyChannel.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update notify trigger", err)
+			return
+		}
+	}
+
+	if rlsChanged(plan.RLS, state.RLS) {
+		if err := r.mgr.SetRowLevelSecurity(ctx, schema, name, plan.RLS.enabled(), state.RLS.policies(), plan.RLS.policies()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update row-level security", err)
+			return
+		}
+	}
+
+	if !plan.OrderingColumn.Equal(state.OrderingColumn) {
+		for _, target := range targets {
+			if err := r.mgr.SetOrderingColumn(ctx, schema, target, plan.OrderingColumn.ValueBool()); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update ordering column", err)
+				return
+			}
+		}
+	}
+
+	if plan.EnablePartitioning.ValueBool() && plan.nativePartitioning() {
+		if err := r.maintainNative(ctx, plan); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to maintain partitions", err)
+			return
+		}
+	}
+
+	if len(state.MissingIndexes.Elements()) > 0 {
+		if err := r.mgr.RepairDefaultIndexes(ctx, schema, name, plan.DefaultIndexes.indexes(), plan.Tablespace.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to recreate missing indexes", err)
+			return
+		}
 	}
 
 	if !plan.CustomIndexes.Equal(state.CustomIndexes) {
@@ -478,7 +2381,7 @@ func (r *queueResource) Update(ctx context.Context, req resource.UpdateRequest,
 			} else {
 				equal, err := indexDefinitionEqual(ctx, stateIdx, planIdx)
 				if err != nil {
-					resp.Diagnostics.AddError("Failed to compare index definitions", err.Error())
+					errorDiag(&resp.Diagnostics, "Failed to compare index definitions", err)
 					return
 				}
 				if !equal {
@@ -489,12 +2392,12 @@ func (r *queueResource) Update(ctx context.Context, req resource.UpdateRequest,
 
 		if len(toDrop) > 0 {
 			if err := r.mgr.DropCustomIndexes(ctx, schema, name, toDrop); err != nil {
-				resp.Diagnostics.AddError("Failed to drop custom indexes", err.Error())
+				errorDiag(&resp.Diagnostics, "Failed to drop custom indexes", err)
 				return
 			}
 		}
 
-		var toCreate []customIndexModel
+		var toCreate, toComment []customIndexModel
 		for planName, planIdx := range planMap {
 			stateIdx, existsInState := stateMap[planName]
 			if !existsInState {
@@ -502,54 +2405,357 @@ func (r *queueResource) Update(ctx context.Context, req resource.UpdateRequest,
 			} else {
 				equal, err := indexDefinitionEqual(ctx, stateIdx, planIdx)
 				if err != nil {
-					resp.Diagnostics.AddError("Failed to compare index definitions", err.Error())
+					errorDiag(&resp.Diagnostics, "Failed to compare index definitions", err)
 					return
 				}
 				if !equal {
 					toCreate = append(toCreate, planIdx)
+				} else if !planIdx.Comment.Equal(stateIdx.Comment) {
+					toComment = append(toComment, planIdx)
 				}
 			}
 		}
 
+		for _, idx := range toComment {
+			if err := r.mgr.SetIndexComment(ctx, schema, name, idx.Name.ValueString(), idx.Comment.ValueString()); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update index comment", err)
+				return
+			}
+		}
+
 		if len(toCreate) > 0 {
-			indexes, diags := convertCustomIndexes(ctx, toCreate)
-			if diags.HasError() {
+			if diags := r.createCustomIndexes(ctx, schema, name, toCreate); diags.HasError() {
 				resp.Diagnostics.Append(diags...)
 				return
 			}
+		}
+	}
+
+	// queues whose custom indexes predate the template copies get them on
+	// their next update
+	if plan.EnablePartitioning.ValueBool() && !plan.nativePartitioning() && !plan.CustomIndexes.IsNull() {
+		if err := r.mgr.SyncTemplateIndexes(ctx, schema, name); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to copy custom indexes to the template table", err)
+			return
+		}
+	}
+
+	if err := r.applyCluster(ctx, plan, state); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to configure clustering", err)
+		return
+	}
+
+	if err := r.applyLegalHolds(ctx, plan, state); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to update legal holds", err)
+		return
+	}
+
+	if !plan.ReplicaIdentity.IsNull() && !plan.ReplicaIdentity.Equal(state.ReplicaIdentity) {
+		if err := r.mgr.SetReplicaIdentity(ctx, schema, name, plan.ReplicaIdentity.ValueString()); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to update replica identity", err)
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(r.applyColumnStorage(ctx, plan, state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.applyPublications(ctx, plan, state); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to update publications", err)
+		return
+	}
 
-			if err := r.createCustomIndexesInTransaction(ctx, schema, name, indexes); err != nil {
-				resp.Diagnostics.AddError("Failed to create custom indexes", err.Error())
+	if isSet(plan.Owner) && !plan.Owner.Equal(state.Owner) {
+		for _, target := range targets {
+			if err := r.mgr.SetOwner(ctx, schema, target, plan.Owner.ValueString()); err != nil {
+				errorDiag(&resp.Diagnostics, "Failed to update owner", err)
 				return
 			}
 		}
 	}
 
+	resp.Diagnostics.Append(r.checkPartitionGrants(ctx, &plan)...)
+	resp.Diagnostics.Append(r.readStructure(ctx, &plan)...)
+
+	resp.Diagnostics.Append(r.registry.recordQueues(ctx, "pgq_queue", plan.ID.ValueString(), schema, plan.queueNames())...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
 func (r *queueResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "destroyed")
+		return
+	}
+
 	var state queueModel
 	if diags := req.State.Get(ctx, &state); diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
 	}
 
+	r, err := r.atEndpoint(ctx, state.Endpoint)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to connect to endpoint", err)
+		return
+	}
+
+	if state.skipped() {
+		return
+	}
+
+	if err := r.confirmEmpty(ctx, state); err != nil {
+		errorDiag(&resp.Diagnostics, "Queue not empty", err)
+		return
+	}
+
 	schema := pgq.SchemaName(state.Schema.ValueString())
 	name := pgq.QueueName(state.Name.ValueString())
 
-	if state.EnablePartitioning.ValueBool() {
+	var beforeDestroy []string
+	if diags := state.BeforeDestroySQL.ElementsAs(ctx, &beforeDestroy, false); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.mgr.ExecHooks(ctx, schema, name, "before_destroy", beforeDestroy); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to run before_destroy_sql", err)
+		return
+	}
+
+	if !state.ClusterSchedule.IsNull() {
+		if err := r.mgr.UnscheduleCluster(ctx, schema, name); err != nil {
+			tflog.Warn(ctx, "failed to remove cluster job", map[string]any{"error": err})
+		}
+	}
+
+	if !state.RejectOlderThan.IsNull() {
+		if err := r.mgr.SetMaxMessageAge(ctx, schema, name, ""); err != nil {
+			tflog.Warn(ctx, "failed to remove maximum message age trigger", map[string]any{"error": err})
+		}
+	}
+
+	if !state.NotifyChannel.IsNull() {
+		if err := r.mgr.SetNotifyChannel(ctx, schema, name, ""); err != nil {
+			tflog.Warn(ctx, "failed to remove notify trigger", map[string]any{"error": err})
+		}
+	}
+
+	if state.EnablePartitioning.ValueBool() && !state.nativePartitioning() {
 		if err := r.mgr.RemovePartmanConfig(ctx, schema, name); err != nil {
 			tflog.Warn(ctx, "failed to remove partman config", map[string]any{"error": err})
 		}
 	}
 
+	if state.DeadLetter != nil {
+		if err := r.dropDeadLetter(ctx, state); err != nil {
+			errorDiag(&resp.Diagnostics, "Failed to drop dead-letter queue", err)
+			return
+		}
+	}
+
 	if err := r.mgr.Drop(ctx, schema, name); err != nil {
-		resp.Diagnostics.AddError("Failed to drop queue", err.Error())
+		errorDiag(&resp.Diagnostics, "Failed to drop queue", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.registry.forget(ctx, "pgq_queue", state.ID.ValueString())...)
+}
+
+// ModifyPlan guards destroy and replacement. The check happens here rather
+// than in Delete because the write-only confirmation phrase is only in the
+// config, which Delete doesn't get.
+func (r *queueResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if !req.Plan.Raw.IsNull() {
+		applyDefaultSchema(ctx, r.defaultSchema, req, resp)
+		resp.Diagnostics.Append(applyProfileDefaults(ctx, r.profile, req.Config, &resp.Plan)...)
+		resp.Diagnostics.Append(applyPartitionDefaults(ctx, r.partitionDefaults, req.Config, &resp.Plan)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		r.planClone(ctx, req, resp)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		planRetention(ctx, req, resp)
+		validateSubPartition(ctx, resp)
+		validatePartitionStrategy(ctx, resp)
+		var strategy types.String
+		resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("partition_strategy"), &strategy)...)
+		resp.Diagnostics.Append(validatePartitionInterval(ctx, resp.Plan, strategy.ValueString())...)
+		validatePrimaryKey(ctx, resp)
+		validateClusterSchedule(ctx, resp)
+		planDeadLetter(ctx, req, resp)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if req.State.Raw.IsNull() {
+		if !req.Plan.Raw.IsNull() {
+			var plan queueModel
+			if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+				resp.Diagnostics.Append(diags...)
+				return
+			}
+			r.warnServerTimezone(ctx, plan, resp)
+		}
+		return
+	}
+
+	var state queueModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if req.Plan.Raw.IsNull() {
+		// a destroy has no config, so only the phrase applied to state
+		// confirms it; a confirmation_phrase in state from before it was
+		// write-only confirms nothing
+		state.ConfirmationPhrase = state.DestroyPhrase
+		if err := state.confirmDestructive(); err != nil {
+			resp.Diagnostics.AddError("Destroy not confirmed", err.Error())
+		}
+		return
+	}
+
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	planRename(ctx, plan, state, resp)
+	planStructure(ctx, plan, state, resp)
+
+	// besides name, schema and enable_partitioning, whose change undoes the
+	// partitioning, attributes like partitioning_mode replace the queue
+	replacedBy := plan.replacedBy(state)
+	if len(replacedBy) == 0 && len(resp.RequiresReplace) == 0 {
+		return
+	}
+
+	confirmReplacement(ctx, req.Config, state, &resp.Diagnostics)
+
+	if len(replacedBy) > 0 {
+		r.warnReplacement(ctx, plan, state, replacedBy, resp)
+	}
+}
+
+// confirmReplacement checks the old queue's require_confirmation_phrase
+// against the phrase in the new config
+func confirmReplacement(ctx context.Context, config tfsdk.Config, state queueModel, diags *diag.Diagnostics) {
+	guard := state
+	diags.Append(config.GetAttribute(ctx, path.Root("confirmation_phrase"), &guard.ConfirmationPhrase)...)
+	if diags.HasError() {
+		return
+	}
+	if err := guard.confirmDestructive(); err != nil {
+		diags.AddError("Replacement not confirmed", err.Error())
+	}
+}
+
+// warnReplacement spells out that replacing the queue drops its messages,
+// failing the plan instead with fail_on_destructive_change. Queues found
+// empty are replaced without a diagnostic; when the check can't run the
+// queue is assumed to hold messages.
+func (r *queueResource) warnReplacement(ctx context.Context, plan, state queueModel, replacedBy []string, resp *resource.ModifyPlanResponse) {
+	if state.skipped() {
+		return
+	}
+
+	schema := pgq.SchemaName(state.Schema.ValueString())
+	fqn := pgq.MakeFQN(schema, pgq.QueueName(state.Name.ValueString()))
+
+	if r.mgr != nil {
+		if empty, err := r.queueEmpty(ctx, state); err != nil {
+			tflog.Warn(ctx, "failed to check queue for messages", map[string]any{"queue": fqn.String(), "error": err})
+		} else if empty {
+			return
+		}
+	}
+
+	queues := "queue " + fqn.String()
+	if state.DeadLetter != nil {
+		queues += " and its dead-letter queue " + pgq.MakeFQN(schema, state.deadLetterName()).String()
+	}
+	detail := fmt.Sprintf("Changing %s forces replacement: %s will be dropped and recreated empty, and all messages in them will be lost.",
+		strings.Join(replacedBy, ", "), queues)
+	if replacedBy[0] != "enable_partitioning" {
+		detail += " Set allow_rename or allow_schema_move to change name or schema in place."
+	}
+
+	at := path.Root(replacedBy[0])
+	if plan.FailOnDestructive.ValueBool() {
+		resp.Diagnostics.AddAttributeError(at, "Destructive change refused",
+			detail+" fail_on_destructive_change is set; drain the queue or unset it to proceed.")
+		return
+	}
+	resp.Diagnostics.AddAttributeWarning(at, "Queue replacement loses all messages", detail)
+}
+
+// queueEmpty reports whether the queue in state and its dead-letter queue
+// hold no messages at all
+func (r *queueResource) queueEmpty(ctx context.Context, state queueModel) (bool, error) {
+	r, err := r.atEndpoint(ctx, state.Endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	schema := pgq.SchemaName(state.Schema.ValueString())
+	for _, name := range state.queueNames() {
+		has, err := r.mgr.HasMessages(ctx, schema, name)
+		if err != nil {
+			return false, err
+		}
+		if has {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// warnServerTimezone flags partitioned queues that would get partition
+// boundaries at a non-UTC midnight because neither the server nor the
+// queue sets a timezone
+func (r *queueResource) warnServerTimezone(ctx context.Context, plan queueModel, resp *resource.ModifyPlanResponse) {
+	if r.mgr == nil || !plan.EnablePartitioning.ValueBool() || plan.nativePartitioning() || !plan.Timezone.IsNull() || !plan.Endpoint.known() {
+		return
+	}
+
+	r, err := r.atEndpoint(ctx, plan.Endpoint)
+	if err != nil {
+		tflog.Warn(ctx, "failed to connect to endpoint", map[string]any{"error": err})
+		return
+	}
+
+	tz, err := r.mgr.ServerTimezone(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read server timezone", map[string]any{"error": err})
 		return
 	}
+
+	if tz != "UTC" && tz != "Etc/UTC" {
+		resp.Diagnostics.AddAttributeWarning(path.Root("timezone"), "Server timezone is not UTC",
+			fmt.Sprintf("The server timezone is %s, so pg_partman partition boundaries and names roll over at %s midnight. "+
+				"Set timezone = \"UTC\" on the queue to compute them in UTC.", tz, tz))
+	}
 }
 
+// ImportState accepts the queue's fully qualified name (schema.name), the
+// same value as its id and as the ids of the pgq_queues data source. The
+// rest of the state is read from the table by the Read that follows.
 func (r *queueResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	schema, name, err := pgq.FQN(req.ID).Split()
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(importDefaults(ctx, r.profile, r.partitionDefaults, &resp.State)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schema"), schema.String())...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name.String())...)
 }
@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                     = (*queueAlertResource)(nil)
+	_ resource.ResourceWithConfigure        = (*queueAlertResource)(nil)
+	_ resource.ResourceWithConfigValidators = (*queueAlertResource)(nil)
+)
+
+type (
+	queueAlertResource struct {
+		mgr      *pgq.Manager
+		registry *objectRegistry
+
+		readOnly bool
+	}
+
+	queueAlertModel struct {
+		ID            types.String `tfsdk:"id"`
+		Queue         types.String `tfsdk:"queue"`
+		Name          types.String `tfsdk:"name"`
+		MaxRows       types.Int64  `tfsdk:"max_rows"`
+		MaxBytes      types.Int64  `tfsdk:"max_bytes"`
+		NotifyChannel types.String `tfsdk:"notify_channel"`
+		AlertsTable   types.String `tfsdk:"alerts_table"`
+		Schedule      types.String `tfsdk:"schedule"`
+	}
+)
+
+func NewQueueAlertResource() resource.Resource {
+	return &queueAlertResource{}
+}
+
+func (m queueAlertModel) alert() *pgq.Alert {
+	return &pgq.Alert{
+		Name:          m.Name.ValueString(),
+		MaxRows:       m.MaxRows.ValueInt64(),
+		MaxBytes:      m.MaxBytes.ValueInt64(),
+		NotifyChannel: m.NotifyChannel.ValueString(),
+		AlertsTable:   pgq.FQN(m.AlertsTable.ValueString()),
+		Schedule:      m.Schedule.ValueString(),
+	}
+}
+
+func (r *queueAlertResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_queue_alert"
+}
+
+func (r *queueAlertResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Size alert on a pgq queue, checked by a pg_cron job",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Fully qualified name of the check function",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"queue": schema.StringAttribute{
+				Description:   "Fully qualified queue name (schema.name)",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{fqnValidator()},
+			},
+			"name": schema.StringAttribute{
+				Description:   "Alert name, unique per schema: the check function is named after it, so creating an alert whose name another alert in the schema uses fails",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{identifierValidator()},
+			},
+			"max_rows": schema.Int64Attribute{
+				Description: "Unprocessed message count threshold",
+				Optional:    true,
+				Validators:  []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"max_bytes": schema.Int64Attribute{
+				Description: "Total size threshold in bytes, including partitions and indexes",
+				Optional:    true,
+				Validators:  []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"notify_channel": schema.StringAttribute{
+				Description: "Channel notified with a JSON payload when a threshold is exceeded",
+				Optional:    true,
+			},
+			"alerts_table": schema.StringAttribute{
+				Description: "Table (schema.name) receiving a row when a threshold is exceeded, created if missing",
+				Optional:    true,
+				Validators:  []validator.String{fqnValidator()},
+			},
+			"schedule": schema.StringAttribute{
+				Description: "pg_cron schedule of the check",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("* * * * *"),
+			},
+		},
+	}
+}
+
+func (r *queueAlertResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.AtLeastOneOf(path.MatchRoot("max_rows"), path.MatchRoot("max_bytes")),
+		resourcevalidator.AtLeastOneOf(path.MatchRoot("notify_channel"), path.MatchRoot("alerts_table")),
+	}
+}
+
+func (r *queueAlertResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	r.mgr = data.mgr
+	r.readOnly = data.readOnly
+	r.registry = data.registry
+}
+
+// apply creates the alert of plan, or replaces an existing one on update
+func (r *queueAlertResource) apply(ctx context.Context, plan *queueAlertModel, update bool) error {
+	schema, name, err := pgq.FQN(plan.Queue.ValueString()).Split()
+	if err != nil {
+		return err
+	}
+
+	alert := plan.alert()
+	install := r.mgr.CreateAlert
+	if update {
+		install = r.mgr.UpdateAlert
+	}
+	if err := install(ctx, schema, name, alert); err != nil {
+		return err
+	}
+
+	plan.ID = types.StringValue(alert.FunctionFQN(schema).String())
+	return nil
+}
+
+// recordObjects records the alert's function and job in the registry
+func (r *queueAlertResource) recordObjects(ctx context.Context, plan queueAlertModel) diag.Diagnostics {
+	schema, _, err := pgq.FQN(plan.Queue.ValueString()).Split()
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddWarning("Failed to update object registry", err.Error())
+		return diags
+	}
+
+	return r.registry.record(ctx, "pgq_queue_alert", plan.ID.ValueString(), pgq.AlertObjects(schema, plan.alert()))
+}
+
+func (r *queueAlertResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "created")
+		return
+	}
+
+	var plan queueAlertModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.apply(ctx, &plan, false); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to create queue alert", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.recordObjects(ctx, plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *queueAlertResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state queueAlertModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	schema, name, err := pgq.FQN(state.Queue.ValueString()).Split()
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid queue name", err.Error())
+		return
+	}
+
+	exists, schedule, err := r.mgr.AlertExists(ctx, schema, name, state.Name.ValueString())
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to read queue alert", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.Schedule = types.StringValue(schedule)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *queueAlertResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "updated")
+		return
+	}
+
+	var plan queueAlertModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.apply(ctx, &plan, true); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to update queue alert", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.recordObjects(ctx, plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *queueAlertResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "destroyed")
+		return
+	}
+
+	var state queueAlertModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	schema, name, err := pgq.FQN(state.Queue.ValueString()).Split()
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid queue name", err.Error())
+		return
+	}
+
+	if err := r.mgr.DropAlert(ctx, schema, name, state.Name.ValueString()); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to drop queue alert", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.registry.forget(ctx, "pgq_queue_alert", state.ID.ValueString())...)
+}
@@ -1,9 +1,22 @@
 package provider
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"testing"
 
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestQueueModel(t *testing.T) {
@@ -23,3 +36,603 @@ func TestQueueModel(t *testing.T) {
 		t.Error("EnablePartitioning should be true")
 	}
 }
+
+func TestQueueModelConfirmDestructive(t *testing.T) {
+	m := queueModel{
+		Name:           types.StringValue("orders"),
+		Schema:         types.StringValue("public"),
+		RequireConfirm: types.BoolValue(true),
+	}
+
+	if err := m.confirmDestructive(); err == nil {
+		t.Error("confirmDestructive() without phrase should fail")
+	}
+
+	m.ConfirmationPhrase = types.StringValue("public.orders")
+	if err := m.confirmDestructive(); err != nil {
+		t.Errorf("confirmDestructive() with matching phrase error = %v", err)
+	}
+
+	m.RequireConfirm = types.BoolValue(false)
+	m.ConfirmationPhrase = types.StringNull()
+	if err := m.confirmDestructive(); err != nil {
+		t.Errorf("confirmDestructive() without guard error = %v", err)
+	}
+}
+
+func TestQueueConfirmationPhraseWriteOnly(t *testing.T) {
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	(&queueResource{}).Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	if !s.Attributes["confirmation_phrase"].IsWriteOnly() {
+		t.Fatal("confirmation_phrase should be write-only")
+	}
+
+	// a state written before the phrase was write-only
+	state := tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	for attr, v := range map[string]attr.Value{
+		"name":                        types.StringValue("orders"),
+		"schema":                      types.StringValue("public"),
+		"require_confirmation_phrase": types.BoolValue(true),
+		"confirmation_phrase":         types.StringValue("public.orders"),
+	} {
+		if diags := state.SetAttribute(ctx, path.Root(attr), v); diags.HasError() {
+			t.Fatalf("state.SetAttribute(%s) diags = %v", attr, diags)
+		}
+	}
+
+	t.Run("destroy", func(t *testing.T) {
+		resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}}
+		(&queueResource{}).ModifyPlan(ctx, resource.ModifyPlanRequest{
+			Config: tfsdk.Config{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)},
+			Plan:   resp.Plan,
+			State:  state,
+		}, resp)
+		if resp.Diagnostics.ErrorsCount() != 1 || resp.Diagnostics.Errors()[0].Summary() != "Destroy not confirmed" {
+			t.Errorf("destroy should be refused despite the phrase in state, diags = %v", resp.Diagnostics)
+		}
+	})
+
+	t.Run("destroy with destroy_confirmation_phrase", func(t *testing.T) {
+		confirmed := state
+		if diags := confirmed.SetAttribute(ctx, path.Root("destroy_confirmation_phrase"), types.StringValue("public.orders")); diags.HasError() {
+			t.Fatalf("SetAttribute() diags = %v", diags)
+		}
+
+		resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}}
+		(&queueResource{}).ModifyPlan(ctx, resource.ModifyPlanRequest{
+			Config: tfsdk.Config{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)},
+			Plan:   resp.Plan,
+			State:  confirmed,
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Errorf("destroy confirmed in state should be allowed, diags = %v", resp.Diagnostics)
+		}
+	})
+
+	var prior queueModel
+	if diags := state.Get(ctx, &prior); diags.HasError() {
+		t.Fatalf("state.Get() diags = %v", diags)
+	}
+
+	for _, tt := range []struct {
+		phrase attr.Value
+		ok     bool
+	}{
+		{types.StringNull(), false},
+		{types.StringValue("public.jobs"), false},
+		{types.StringValue("public.orders"), true},
+	} {
+		t.Run("replace with "+tt.phrase.String(), func(t *testing.T) {
+			config := state
+			if diags := config.SetAttribute(ctx, path.Root("confirmation_phrase"), tt.phrase); diags.HasError() {
+				t.Fatalf("SetAttribute() diags = %v", diags)
+			}
+
+			var diags diag.Diagnostics
+			confirmReplacement(ctx, tfsdk.Config{Schema: s, Raw: config.Raw}, prior, &diags)
+			if diags.HasError() == tt.ok {
+				t.Errorf("confirmReplacement() diags = %v, want ok = %v", diags, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQueueModelRenamed(t *testing.T) {
+	state := queueModel{Name: types.StringValue("orders")}
+
+	tests := []struct {
+		name string
+		plan queueModel
+		want bool
+	}{
+		{"same name", queueModel{Name: types.StringValue("orders"), AllowRename: types.BoolValue(true)}, false},
+		{"new name", queueModel{Name: types.StringValue("orders_v2"), AllowRename: types.BoolValue(true)}, true},
+		{"new name without allow_rename", queueModel{Name: types.StringValue("orders_v2"), AllowRename: types.BoolValue(false)}, false},
+		{"allow_rename null", queueModel{Name: types.StringValue("orders_v2"), AllowRename: types.BoolNull()}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plan.renamed(state); got != tt.want {
+				t.Errorf("renamed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueModelMovedSchema(t *testing.T) {
+	state := queueModel{Schema: types.StringValue("public")}
+
+	moved := queueModel{Schema: types.StringValue("queues"), AllowSchemaMove: types.BoolValue(true)}
+	if !moved.movedSchema(state) {
+		t.Error("movedSchema() = false for a new schema with allow_schema_move")
+	}
+
+	replaced := queueModel{Schema: types.StringValue("queues"), AllowSchemaMove: types.BoolValue(false)}
+	if replaced.movedSchema(state) {
+		t.Error("movedSchema() = true without allow_schema_move")
+	}
+}
+
+func TestQueueModelReplacedBy(t *testing.T) {
+	state := queueModel{
+		Name:               types.StringValue("orders"),
+		Schema:             types.StringValue("public"),
+		EnablePartitioning: types.BoolValue(false),
+	}
+
+	tests := []struct {
+		name string
+		plan func(m *queueModel)
+		want []string
+	}{
+		{"unchanged", func(m *queueModel) {}, nil},
+		{"name", func(m *queueModel) { m.Name = types.StringValue("jobs") }, []string{"name"}},
+		{"renamed in place", func(m *queueModel) {
+			m.Name = types.StringValue("jobs")
+			m.AllowRename = types.BoolValue(true)
+		}, nil},
+		{"schema and partitioning", func(m *queueModel) {
+			m.Schema = types.StringValue("queues")
+			m.EnablePartitioning = types.BoolValue(true)
+		}, []string{"schema", "enable_partitioning"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := state
+			tt.plan(&plan)
+			if got := plan.replacedBy(state); !slices.Equal(got, tt.want) {
+				t.Errorf("replacedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnReplacement(t *testing.T) {
+	state := queueModel{
+		Name:   types.StringValue("orders"),
+		Schema: types.StringValue("public"),
+	}
+
+	tests := []struct {
+		name        string
+		fail        bool
+		provisioned types.Bool
+		warnings    int
+		errors      int
+	}{
+		{"warns", false, types.BoolValue(true), 1, 0},
+		{"fails", true, types.BoolValue(true), 0, 1},
+		{"skipped queue", true, types.BoolValue(false), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := state
+			state.Provisioned = tt.provisioned
+			plan := state
+			plan.Name = types.StringValue("jobs")
+			plan.FailOnDestructive = types.BoolValue(tt.fail)
+
+			resp := &resource.ModifyPlanResponse{}
+			(&queueResource{}).warnReplacement(context.Background(), plan, state, []string{"name"}, resp)
+			if got := resp.Diagnostics.WarningsCount(); got != tt.warnings {
+				t.Errorf("warnings = %d, want %d", got, tt.warnings)
+			}
+			if got := resp.Diagnostics.ErrorsCount(); got != tt.errors {
+				t.Errorf("errors = %d, want %d", got, tt.errors)
+			}
+		})
+	}
+}
+
+func TestQueueModelSkipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		provisioned types.Bool
+		want        bool
+	}{
+		{"provisioned", types.BoolValue(true), false},
+		{"skipped", types.BoolValue(false), true},
+		{"state before provisioned existed", types.BoolNull(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := queueModel{Provisioned: tt.provisioned}
+			if got := m.skipped(); got != tt.want {
+				t.Errorf("skipped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueModelDeadLetter(t *testing.T) {
+	m := queueModel{
+		Name:              types.StringValue("orders"),
+		Schema:            types.StringValue("public"),
+		PartitionInterval: types.StringValue("1 hour"),
+		PartitionPremake:  types.Int64Value(4),
+		RetentionPeriod:   types.StringValue("7 days"),
+	}
+
+	m.setDeadLetterQueue()
+	if !m.DeadLetterQueue.IsNull() {
+		t.Errorf("DeadLetterQueue without dead_letter = %v, want null", m.DeadLetterQueue)
+	}
+	if got := len(m.queueNames()); got != 1 {
+		t.Errorf("len(queueNames()) = %d, want 1", got)
+	}
+
+	m.DeadLetter = &deadLetterModel{
+		EnablePartitioning: types.BoolValue(false),
+		PartitionInterval:  types.StringValue("1 week"),
+		RetentionPeriod:    types.StringValue("90 days"),
+	}
+	m.setDeadLetterQueue()
+	if got := m.DeadLetterQueue.ValueString(); got != "public.orders_dlq" {
+		t.Errorf("DeadLetterQueue = %q, want %q", got, "public.orders_dlq")
+	}
+	if cfg := m.deadLetterPartitionConfig(); cfg != nil {
+		t.Errorf("deadLetterPartitionConfig() = %+v, want nil", cfg)
+	}
+
+	m.DeadLetter.EnablePartitioning = types.BoolValue(true)
+	cfg := m.deadLetterPartitionConfig()
+	if cfg == nil {
+		t.Fatal("deadLetterPartitionConfig() = nil")
+	}
+	if cfg.Interval != "1 week" || cfg.Retention != "90 days" || cfg.Premake != 4 {
+		t.Errorf("deadLetterPartitionConfig() = %+v, want the dead_letter interval and retention with the queue's premake", cfg)
+	}
+}
+
+func TestQueueModelRetentionMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		archive types.String
+		cfg     pgq.PartitionConfig
+	}{
+		{retentionDrop, types.StringNull(), pgq.PartitionConfig{}},
+		{retentionDetach, types.StringNull(), pgq.PartitionConfig{RetentionKeepTable: true}},
+		{retentionArchive, types.StringValue("archive"), pgq.PartitionConfig{RetentionSchema: "archive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			m := queueModel{RetentionMode: types.StringValue(tt.mode), ArchiveSchema: tt.archive}
+			cfg := m.partitionConfig()
+			if cfg.RetentionKeepTable != tt.cfg.RetentionKeepTable || cfg.RetentionSchema != tt.cfg.RetentionSchema {
+				t.Errorf("partitionConfig() keep_table = %v, schema = %q, want %v, %q",
+					cfg.RetentionKeepTable, cfg.RetentionSchema, tt.cfg.RetentionKeepTable, tt.cfg.RetentionSchema)
+			}
+
+			if cfg.RetentionDropIndexes {
+				t.Error("partitionConfig() without retention_keep_index should keep indexes")
+			}
+
+			var read queueModel
+			read.setRetention(cfg)
+			if read.RetentionMode.ValueString() != tt.mode || !read.ArchiveSchema.Equal(tt.archive) {
+				t.Errorf("setRetention() = %v, %v, want %q, %v", read.RetentionMode, read.ArchiveSchema, tt.mode, tt.archive)
+			}
+		})
+	}
+}
+
+func TestNotPartman(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no part_config row", &pgq.PartmanError{Op: "get_config", Err: pgx.ErrNoRows}, true},
+		{"pg_partman not installed", &pgq.PartmanError{Op: "get_config", Err: &pgconn.PgError{Code: "3F000"}}, true},
+		{"connection lost", &pgq.PartmanError{Op: "get_config", Err: errors.New("connection reset")}, false},
+		{"permission denied", &pgq.PartmanError{Op: "get_config", Err: &pgconn.PgError{Code: "42501"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notPartman(tt.err); got != tt.want {
+				t.Errorf("notPartman() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueModelUpgradeState(t *testing.T) {
+	var m queueModel
+	m.upgradeState()
+	if m.PartitioningMode.ValueString() != partitioningPartman || m.PartitionStrategy.ValueString() != pgq.PartitionRange ||
+		m.IDType.ValueString() != pgq.IDUUIDv4 {
+		t.Errorf("upgradeState() = %v, %v, %v, want the former behavior", m.PartitioningMode, m.PartitionStrategy, m.IDType)
+	}
+
+	m = queueModel{
+		PartitioningMode:  types.StringValue(partitioningNative),
+		PartitionStrategy: types.StringValue(pgq.PartitionHash),
+		IDType:            types.StringValue(pgq.IDUUIDv7),
+	}
+	m.upgradeState()
+	if m.PartitioningMode.ValueString() != partitioningNative || m.PartitionStrategy.ValueString() != pgq.PartitionHash ||
+		m.IDType.ValueString() != pgq.IDUUIDv7 {
+		t.Errorf("upgradeState() changed set attributes: %v, %v, %v", m.PartitioningMode, m.PartitionStrategy, m.IDType)
+	}
+}
+
+func TestConvertToCustomIndexModels(t *testing.T) {
+	ctx := context.Background()
+	prior := []customIndexModel{{
+		Name:         types.StringValue("orders_created_idx"),
+		Columns:      types.ListValueMust(types.StringType, []attr.Value{types.StringValue("created_at")}),
+		Column:       types.ListNull(indexColumnObjectType()),
+		Concurrently: types.BoolValue(true),
+	}}
+	indexes := []pgq.CustomIndex{
+		{Name: "orders_created_idx", Columns: []string{"created_at"}, Type: "btree"},
+		{Name: "orders_payload_idx", Columns: []string{"payload"}, Type: "gin"},
+	}
+
+	models, diags := convertToCustomIndexModels(ctx, indexes, prior)
+	if diags.HasError() || len(models) != 2 {
+		t.Fatalf("convertToCustomIndexModels() = %v, %v", models, diags)
+	}
+	if !models[0].Concurrently.ValueBool() {
+		t.Error("concurrently = false, want it kept from the prior state")
+	}
+	if models[1].Concurrently.IsNull() || models[1].Concurrently.ValueBool() {
+		t.Errorf("concurrently = %v, want false for an imported index", models[1].Concurrently)
+	}
+}
+
+// queuePlan builds a pgq_queue plan with attrs set and everything else null
+func queuePlan(t *testing.T, attrs map[string]attr.Value) tfsdk.Plan {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	(&queueResource{}).Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	plan := tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	for attr, v := range attrs {
+		if diags := plan.SetAttribute(ctx, path.Root(attr), v); diags.HasError() {
+			t.Fatalf("plan.SetAttribute(%s) diags = %v", attr, diags)
+		}
+	}
+	return plan
+}
+
+func TestValidateClusterSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		partitioned attr.Value
+		mode        string
+		wantErr     bool
+	}{
+		{"partman", types.BoolValue(true), partitioningPartman, false},
+		{"simple queue", types.BoolValue(false), partitioningPartman, true},
+		{"native", types.BoolValue(true), partitioningNative, true},
+		{"unknown partitioning", types.BoolUnknown(), partitioningPartman, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &resource.ModifyPlanResponse{Plan: queuePlan(t, map[string]attr.Value{
+				"cluster_schedule":    types.StringValue("0 3 * * 0"),
+				"enable_partitioning": tt.partitioned,
+				"partitioning_mode":   types.StringValue(tt.mode),
+			})}
+			validateClusterSchedule(context.Background(), resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("validateClusterSchedule() diags = %v, want error = %v", resp.Diagnostics, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetPartialState(t *testing.T) {
+	ctx := context.Background()
+
+	plan := queuePlan(t, map[string]attr.Value{
+		"id":     types.StringValue("public.orders"),
+		"name":   types.StringValue("orders"),
+		"schema": types.StringValue("public"),
+		"owner":  types.StringUnknown(),
+	})
+	var m queueModel
+	if diags := plan.Get(ctx, &m); diags.HasError() {
+		t.Fatalf("plan.Get() diags = %v", diags)
+	}
+
+	state := tfsdk.State{Schema: plan.Schema, Raw: tftypes.NewValue(plan.Schema.Type().TerraformType(ctx), nil)}
+	if diags := setPartialState(ctx, &state, m); diags.HasError() {
+		t.Fatalf("setPartialState() diags = %v", diags)
+	}
+
+	if !state.Raw.IsFullyKnown() {
+		t.Error("partial state should not hold unknown values")
+	}
+	var got queueModel
+	if diags := state.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("state.Get() diags = %v", diags)
+	}
+	if got.ID.ValueString() != "public.orders" || !got.Owner.IsNull() {
+		t.Errorf("partial state id = %v, owner = %v, want public.orders and null", got.ID, got.Owner)
+	}
+}
+
+func TestLegalHoldValidator(t *testing.T) {
+	holds := types.SetValueMust(types.StringType, []attr.Value{types.StringValue("orders_p20240101")})
+
+	tests := []struct {
+		name        string
+		holds       attr.Value
+		partitioned attr.Value
+		wantErr     bool
+	}{
+		{"partitioned", holds, types.BoolValue(true), false},
+		{"simple queue", holds, types.BoolValue(false), true},
+		{"default partitioning", holds, types.BoolNull(), true},
+		{"unknown partitioning", holds, types.BoolUnknown(), false},
+		{"no holds", types.SetValueMust(types.StringType, nil), types.BoolValue(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := queuePlan(t, map[string]attr.Value{
+				"legal_hold_partitions": tt.holds,
+				"enable_partitioning":   tt.partitioned,
+			})
+			resp := &resource.ValidateConfigResponse{}
+			legalHoldValidator{}.ValidateResource(context.Background(),
+				resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("ValidateResource() diags = %v, want error = %v", resp.Diagnostics, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimezoneRequiresReplace(t *testing.T) {
+	ctx := context.Background()
+
+	for _, partitioned := range []bool{true, false} {
+		state := queuePlan(t, map[string]attr.Value{
+			"enable_partitioning": types.BoolValue(partitioned),
+			"timezone":            types.StringValue("UTC"),
+		})
+		plan := queuePlan(t, map[string]attr.Value{
+			"enable_partitioning": types.BoolValue(partitioned),
+			"timezone":            types.StringValue("Europe/Prague"),
+		})
+
+		req := planmodifier.StringRequest{
+			Path:        path.Root("timezone"),
+			State:       tfsdk.State{Schema: state.Schema, Raw: state.Raw},
+			Plan:        plan,
+			StateValue:  types.StringValue("UTC"),
+			PlanValue:   types.StringValue("Europe/Prague"),
+			ConfigValue: types.StringValue("Europe/Prague"),
+		}
+		resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+		requiresReplaceIfPartitioned().PlanModifyString(ctx, req, resp)
+		if resp.RequiresReplace != partitioned {
+			t.Errorf("partitioned = %v: RequiresReplace = %v, want %v", partitioned, resp.RequiresReplace, partitioned)
+		}
+	}
+}
+
+func TestQueueConfirmEmptyWithoutCheck(t *testing.T) {
+	// neither case queries the queue, so a nil manager must do
+	r := &queueResource{}
+
+	for _, tt := range []struct {
+		name            string
+		prevent, forced bool
+	}{
+		{"guard off", false, false},
+		{"force_destroy in state", true, true},
+	} {
+		state := queueModel{
+			Name:            types.StringValue("orders"),
+			Schema:          types.StringValue("public"),
+			PreventNonEmpty: types.BoolValue(tt.prevent),
+			ForceDestroy:    types.BoolValue(tt.forced),
+		}
+		if err := r.confirmEmpty(context.Background(), state); err != nil {
+			t.Errorf("%s: confirmEmpty() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestReadPendingPartitionsWithoutCheck(t *testing.T) {
+	// neither case queries the queue, so a nil manager must do
+	r := &queueResource{}
+
+	for _, tt := range []struct {
+		name        string
+		mode        string
+		partitioned bool
+	}{
+		{"simple queue", partitioningNative, false},
+		{"pg_partman queue", partitioningPartman, true},
+	} {
+		m := queueModel{
+			Name:              types.StringValue("orders"),
+			Schema:            types.StringValue("public"),
+			PartitioningMode:  types.StringValue(tt.mode),
+			PendingPartitions: types.ListNull(types.StringType),
+		}
+		diags := r.readPendingPartitions(context.Background(), &m, tt.partitioned)
+		if diags.HasError() || diags.WarningsCount() > 0 {
+			t.Errorf("%s: readPendingPartitions() diagnostics = %v", tt.name, diags)
+		}
+		if m.PendingPartitions.IsNull() || len(m.PendingPartitions.Elements()) != 0 {
+			t.Errorf("%s: pending_partition_changes = %v, want empty", tt.name, m.PendingPartitions)
+		}
+	}
+}
+
+func TestQueueOptionsOrderingColumn(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		plan := queuePlan(t, map[string]attr.Value{
+			"name":            types.StringValue("orders"),
+			"schema":          types.StringValue("public"),
+			"ordering_column": types.BoolValue(enabled),
+		})
+		var m queueModel
+		if diags := plan.Get(context.Background(), &m); diags.HasError() {
+			t.Fatalf("plan.Get() diags = %v", diags)
+		}
+
+		opts, diags := m.queueOptions(context.Background())
+		if diags.HasError() {
+			t.Fatalf("queueOptions() diags = %v", diags)
+		}
+		if opts.OrderingColumn != enabled {
+			t.Errorf("queueOptions() OrderingColumn = %v, want %v", opts.OrderingColumn, enabled)
+		}
+	}
+}
+
+func TestQueueModelPartmanSettings(t *testing.T) {
+	var m queueModel
+
+	m.setPartmanSettings(&pgq.PartitionConfig{AutomaticMaintenance: "off"})
+	if !m.AutomaticMaintenance.Equal(types.StringValue("off")) {
+		t.Errorf("automatic_maintenance = %v, want off", m.AutomaticMaintenance)
+	}
+
+	// simple queues have no part_config row
+	m.setPartmanSettings(nil)
+	if !m.AutomaticMaintenance.IsNull() {
+		t.Errorf("automatic_maintenance of a simple queue = %v, want null", m.AutomaticMaintenance)
+	}
+}
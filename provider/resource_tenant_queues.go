@@ -0,0 +1,460 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const tenantPlaceholder = "{tenant}"
+
+var tenantPlaceholderRegexp = regexp.MustCompile(regexp.QuoteMeta(tenantPlaceholder))
+
+var (
+	_ resource.Resource               = (*tenantQueuesResource)(nil)
+	_ resource.ResourceWithConfigure  = (*tenantQueuesResource)(nil)
+	_ resource.ResourceWithModifyPlan = (*tenantQueuesResource)(nil)
+)
+
+type (
+	tenantQueuesResource struct {
+		mgr               *pgq.Manager
+		profile           *environmentProfile
+		defaultSchema     string
+		partitionDefaults *partitionDefaultsModel
+		registry          *objectRegistry
+
+		readOnly bool
+	}
+
+	tenantQueuesModel struct {
+		ID                 types.String `tfsdk:"id"`
+		Tenants            types.Set    `tfsdk:"tenants"`
+		NameTemplate       types.String `tfsdk:"name_template"`
+		Schema             types.String `tfsdk:"schema"`
+		BatchSize          types.Int64  `tfsdk:"batch_size"`
+		Queues             types.Map    `tfsdk:"queues"`
+		EnablePartitioning types.Bool   `tfsdk:"enable_partitioning"`
+		PartitionInterval  types.String `tfsdk:"partition_interval"`
+		PartitionPremake   types.Int64  `tfsdk:"partition_premake"`
+		RetentionPeriod    types.String `tfsdk:"retention_period"`
+		DatetimeString     types.String `tfsdk:"datetime_string"`
+		OptimizeConstraint types.Int64  `tfsdk:"optimize_constraint"`
+		DefaultPartition   types.Bool   `tfsdk:"default_partition"`
+		Timezone           types.String `tfsdk:"timezone"`
+	}
+)
+
+func NewTenantQueuesResource() resource.Resource {
+	return &tenantQueuesResource{}
+}
+
+func (m tenantQueuesModel) partitionConfig() *pgq.PartitionConfig {
+	if !m.EnablePartitioning.ValueBool() {
+		return nil
+	}
+	return &pgq.PartitionConfig{
+		Interval:           m.PartitionInterval.ValueString(),
+		Premake:            int(m.PartitionPremake.ValueInt64()),
+		Retention:          m.RetentionPeriod.ValueString(),
+		DatetimeString:     m.DatetimeString.ValueString(),
+		OptimizeConstraint: int(m.OptimizeConstraint.ValueInt64()),
+		DefaultPartition:   m.DefaultPartition.ValueBool(),
+		Timezone:           m.Timezone.ValueString(),
+	}
+}
+
+// queueName renders the queue name of a tenant from the name template
+func (m tenantQueuesModel) queueName(tenant string) pgq.QueueName {
+	return pgq.QueueName(strings.ReplaceAll(m.NameTemplate.ValueString(), tenantPlaceholder, tenant))
+}
+
+func (m tenantQueuesModel) queueNames(tenants []string) ([]pgq.QueueName, error) {
+	names := make([]pgq.QueueName, 0, len(tenants))
+	for _, tenant := range tenants {
+		name := m.queueName(tenant)
+		if !name.Valid() {
+			return nil, fmt.Errorf("tenant %q renders invalid queue name %q", tenant, name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m tenantQueuesModel) queueMap(tenants []string) map[string]string {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	queues := make(map[string]string, len(tenants))
+	for _, tenant := range tenants {
+		queues[tenant] = pgq.MakeFQN(schema, m.queueName(tenant)).String()
+	}
+	return queues
+}
+
+// batches splits names into chunks of at most size elements
+func batches[T any](items []T, size int) [][]T {
+	if size < 1 {
+		size = 1
+	}
+	var out [][]T
+	for len(items) > size {
+		out = append(out, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		out = append(out, items)
+	}
+	return out
+}
+
+func (r *tenantQueuesResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tenant_queues"
+}
+
+func (r *tenantQueuesResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "One pgq queue per tenant, sharing the same settings",
+		Attributes: withPartitionAttributes(map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Schema and name template (schema.template)",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"tenants": schema.SetAttribute{
+				Description: "Tenant identifiers, one queue is created per tenant",
+				Required:    true,
+				ElementType: types.StringType,
+				Validators:  []validator.Set{setvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1))},
+			},
+			"name_template": schema.StringAttribute{
+				Description:   "Queue name template, " + tenantPlaceholder + " is replaced by the tenant identifier (e.g. 'orders_{tenant}')",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{stringvalidator.RegexMatches(tenantPlaceholderRegexp, "must contain "+tenantPlaceholder)},
+			},
+			"schema": schema.StringAttribute{
+				Description:   "PostgreSQL schema (default: the provider's default_queue_schema, or public)",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("public"),
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"batch_size": schema.Int64Attribute{
+				Description: "Queues created or dropped per transaction",
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(20),
+			},
+			"queues": schema.MapAttribute{
+				Description: "Fully qualified queue name per tenant",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+		}),
+	}
+}
+
+func (r *tenantQueuesResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected type", fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+
+	r.mgr = data.mgr
+	r.readOnly = data.readOnly
+	r.profile = data.profile
+	r.defaultSchema = data.defaultSchema
+	r.partitionDefaults = data.partitionDefaults
+	r.registry = data.registry
+}
+
+func (r *tenantQueuesResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	applyDefaultSchema(ctx, r.defaultSchema, req, resp)
+	resp.Diagnostics.Append(applyProfileDefaults(ctx, r.profile, req.Config, &resp.Plan)...)
+	resp.Diagnostics.Append(applyPartitionDefaults(ctx, r.partitionDefaults, req.Config, &resp.Plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validatePartitionInterval(ctx, resp.Plan, pgq.PartitionRange)...)
+}
+
+// createQueues creates the queues of tenants in batches. It returns the
+// tenants whose queues were created, which on error are those of the
+// batches committed before the failing one.
+func (r *tenantQueuesResource) createQueues(ctx context.Context, m tenantQueuesModel, tenants []string) ([]string, error) {
+	if _, err := m.queueNames(tenants); err != nil {
+		return nil, err
+	}
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	var created []string
+	for _, batch := range batches(tenants, int(m.BatchSize.ValueInt64())) {
+		names, _ := m.queueNames(batch)
+		tflog.Debug(ctx, "creating tenant queue batch", map[string]any{"schema": string(schema), "count": len(batch)})
+		if err := r.mgr.CreateBatch(ctx, schema, names, m.partitionConfig(), nil); err != nil {
+			return created, err
+		}
+		created = append(created, batch...)
+	}
+	return created, nil
+}
+
+// setTenantsState saves m with tenants as its tenants, so queues created
+// before a failure stay in state instead of being orphaned
+func setTenantsState(ctx context.Context, state *tfsdk.State, m tenantQueuesModel, tenants []string) diag.Diagnostics {
+	tenants = slices.Sorted(slices.Values(tenants))
+
+	set, diags := types.SetValueFrom(ctx, types.StringType, tenants)
+	queues, d := types.MapValueFrom(ctx, types.StringType, m.queueMap(tenants))
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+
+	m.Tenants = set
+	m.Queues = queues
+	m.ID = types.StringValue(m.Schema.ValueString() + "." + m.NameTemplate.ValueString())
+	return state.Set(ctx, m)
+}
+
+// recordObjects records the objects of the tenants' queues in the registry
+func (r *tenantQueuesResource) recordObjects(ctx context.Context, m tenantQueuesModel, tenants []string) diag.Diagnostics {
+	names, err := m.queueNames(tenants)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddWarning("Failed to update object registry", err.Error())
+		return diags
+	}
+
+	return r.registry.recordQueues(ctx, "pgq_tenant_queues", m.ID.ValueString(), pgq.SchemaName(m.Schema.ValueString()), names)
+}
+
+func (r *tenantQueuesResource) dropQueues(ctx context.Context, m tenantQueuesModel, tenants []string) error {
+	names, err := m.queueNames(tenants)
+	if err != nil {
+		return err
+	}
+
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	for _, batch := range batches(names, int(m.BatchSize.ValueInt64())) {
+		if m.EnablePartitioning.ValueBool() {
+			for _, name := range batch {
+				if err := r.mgr.RemovePartmanConfig(ctx, schema, name); err != nil {
+					tflog.Warn(ctx, "failed to remove partman config", map[string]any{"error": err})
+				}
+			}
+		}
+		if err := r.mgr.DropBatch(ctx, schema, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *tenantQueuesResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "created")
+		return
+	}
+
+	var plan tenantQueuesModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	var tenants []string
+	if diags := plan.Tenants.ElementsAs(ctx, &tenants, false); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	slices.Sort(tenants)
+
+	created, err := r.createQueues(ctx, plan, tenants)
+	if err != nil {
+		if len(created) > 0 {
+			resp.Diagnostics.Append(setTenantsState(ctx, &resp.State, plan, created)...)
+		}
+		errorDiag(&resp.Diagnostics, "Failed to create tenant queues", err)
+		return
+	}
+
+	resp.Diagnostics.Append(setTenantsState(ctx, &resp.State, plan, tenants)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = types.StringValue(plan.Schema.ValueString() + "." + plan.NameTemplate.ValueString())
+	resp.Diagnostics.Append(r.recordObjects(ctx, plan, tenants)...)
+}
+
+func (r *tenantQueuesResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state tenantQueuesModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	var tenants []string
+	if diags := state.Tenants.ElementsAs(ctx, &tenants, false); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	names, err := state.queueNames(tenants)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid tenant queue name", err.Error())
+		return
+	}
+
+	existing, err := r.mgr.ExistingQueues(ctx, pgq.SchemaName(state.Schema.ValueString()), names)
+	if err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to read tenant queues", err)
+		return
+	}
+
+	// Tenants whose queue disappeared drop out of state so the next plan recreates them
+	present := make([]string, 0, len(existing))
+	for _, tenant := range tenants {
+		if slices.Contains(existing, state.queueName(tenant)) {
+			present = append(present, tenant)
+		}
+	}
+
+	if len(present) == 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	set, diags := types.SetValueFrom(ctx, types.StringType, present)
+	resp.Diagnostics.Append(diags...)
+	queues, diags := types.MapValueFrom(ctx, types.StringType, state.queueMap(present))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Tenants = set
+	state.Queues = queues
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *tenantQueuesResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "updated")
+		return
+	}
+
+	var plan, state tenantQueuesModel
+	if diags := req.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	var planTenants, stateTenants []string
+	resp.Diagnostics.Append(plan.Tenants.ElementsAs(ctx, &planTenants, false)...)
+	resp.Diagnostics.Append(state.Tenants.ElementsAs(ctx, &stateTenants, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	slices.Sort(planTenants)
+
+	var added, removed, kept []string
+	for _, tenant := range planTenants {
+		if slices.Contains(stateTenants, tenant) {
+			kept = append(kept, tenant)
+		} else {
+			added = append(added, tenant)
+		}
+	}
+	for _, tenant := range stateTenants {
+		if !slices.Contains(planTenants, tenant) {
+			removed = append(removed, tenant)
+		}
+	}
+
+	// on failure the prior state is saved with the tenants that still have
+	// a queue; the response state otherwise starts out as the plan. Queues
+	// dropped before a failed batch leave state on the next refresh.
+	if err := r.dropQueues(ctx, state, removed); err != nil {
+		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+		errorDiag(&resp.Diagnostics, "Failed to drop tenant queues", err)
+		return
+	}
+
+	if plan.EnablePartitioning.ValueBool() && !reflect.DeepEqual(plan.partitionConfig(), state.partitionConfig()) {
+		schema := pgq.SchemaName(plan.Schema.ValueString())
+		for _, tenant := range kept {
+			if err := r.mgr.UpdatePartitionConfig(ctx, schema, plan.queueName(tenant), plan.partitionConfig()); err != nil {
+				resp.Diagnostics.Append(setTenantsState(ctx, &resp.State, state, kept)...)
+				errorDiag(&resp.Diagnostics, "Failed to update partition config", err)
+				return
+			}
+		}
+	}
+
+	created, err := r.createQueues(ctx, plan, added)
+	if err != nil {
+		resp.Diagnostics.Append(setTenantsState(ctx, &resp.State, plan, append(kept, created...))...)
+		errorDiag(&resp.Diagnostics, "Failed to create tenant queues", err)
+		return
+	}
+
+	resp.Diagnostics.Append(setTenantsState(ctx, &resp.State, plan, planTenants)...)
+	resp.Diagnostics.Append(r.recordObjects(ctx, plan, planTenants)...)
+}
+
+func (r *tenantQueuesResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.readOnly {
+		readOnlyError(&resp.Diagnostics, "destroyed")
+		return
+	}
+
+	var state tenantQueuesModel
+	if diags := req.State.Get(ctx, &state); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	var tenants []string
+	if diags := state.Tenants.ElementsAs(ctx, &tenants, false); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	if err := r.dropQueues(ctx, state, tenants); err != nil {
+		errorDiag(&resp.Diagnostics, "Failed to drop tenant queues", err)
+		return
+	}
+
+	resp.Diagnostics.Append(r.registry.forget(ctx, "pgq_tenant_queues", state.ID.ValueString())...)
+}
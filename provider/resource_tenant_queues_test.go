@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestBatches(t *testing.T) {
+	tests := []struct {
+		items []int
+		size  int
+		want  int
+	}{
+		{nil, 10, 0},
+		{[]int{1, 2, 3}, 10, 1},
+		{[]int{1, 2, 3, 4}, 2, 2},
+		{[]int{1, 2, 3, 4, 5}, 2, 3},
+		{[]int{1, 2}, 0, 2},
+	}
+
+	for _, tt := range tests {
+		got := batches(tt.items, tt.size)
+		if len(got) != tt.want {
+			t.Errorf("batches(%v, %d) = %d batches, want %d", tt.items, tt.size, len(got), tt.want)
+		}
+
+		var n int
+		for _, b := range got {
+			n += len(b)
+		}
+		if n != len(tt.items) {
+			t.Errorf("batches(%v, %d) lost items: got %d, want %d", tt.items, tt.size, n, len(tt.items))
+		}
+	}
+}
+
+func TestTenantQueueNames(t *testing.T) {
+	m := tenantQueuesModel{
+		NameTemplate: types.StringValue("orders_{tenant}"),
+		Schema:       types.StringValue("queues"),
+	}
+
+	if got := m.queueName("acme"); got != "orders_acme" {
+		t.Errorf("queueName() = %q, want %q", got, "orders_acme")
+	}
+
+	queues := m.queueMap([]string{"acme"})
+	if queues["acme"] != "queues.orders_acme" {
+		t.Errorf("queueMap()[acme] = %q, want %q", queues["acme"], "queues.orders_acme")
+	}
+
+	bad := tenantQueuesModel{NameTemplate: types.StringValue("{tenant}_orders")}
+	if _, err := bad.queueNames([]string{"1acme"}); err == nil {
+		t.Error("queueNames() should reject names starting with a digit")
+	}
+}
+
+func TestSetTenantsState(t *testing.T) {
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	(&tenantQueuesResource{}).Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+	state := tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+
+	m := tenantQueuesModel{
+		Tenants:      types.SetNull(types.StringType),
+		NameTemplate: types.StringValue("orders_{tenant}"),
+		Schema:       types.StringValue("queues"),
+		Queues:       types.MapUnknown(types.StringType),
+	}
+	// the batches committed before a failure
+	if diags := setTenantsState(ctx, &state, m, []string{"globex", "acme"}); diags.HasError() {
+		t.Fatalf("setTenantsState() diags = %v", diags)
+	}
+
+	var got tenantQueuesModel
+	if diags := state.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("state.Get() diags = %v", diags)
+	}
+	var tenants []string
+	got.Tenants.ElementsAs(ctx, &tenants, false)
+	if !slices.Equal(tenants, []string{"acme", "globex"}) {
+		t.Errorf("tenants = %v, want [acme globex]", tenants)
+	}
+	queues := map[string]string{}
+	got.Queues.ElementsAs(ctx, &queues, false)
+	if len(queues) != 2 || queues["acme"] != "queues.orders_acme" {
+		t.Errorf("queues = %v, want the created tenants' queues", queues)
+	}
+	if got.ID.ValueString() != "queues.orders_{tenant}" {
+		t.Errorf("id = %s, want queues.orders_{tenant}", got.ID)
+	}
+}
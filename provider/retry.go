@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBackoff     = time.Second
+
+	defaultReconnectWindow = 30 * time.Second
+)
+
+var sqlstateRegexp = regexp.MustCompile(`^[0-9A-Z]{5}$`)
+
+type retryModel struct {
+	MaxAttempts        types.Int64  `tfsdk:"max_attempts"`
+	Backoff            types.String `tfsdk:"backoff"`
+	RetryableSQLStates types.List   `tfsdk:"retryable_sqlstates"`
+}
+
+// retryPolicy converts the retry block; without it nothing is retried
+func retryPolicy(ctx context.Context, m *retryModel) (pgq.RetryPolicy, error) {
+	if m == nil {
+		return pgq.RetryPolicy{}, nil
+	}
+
+	policy := pgq.RetryPolicy{
+		MaxAttempts: defaultRetryMaxAttempts,
+		Backoff:     defaultRetryBackoff,
+	}
+
+	if !m.MaxAttempts.IsNull() && !m.MaxAttempts.IsUnknown() {
+		policy.MaxAttempts = int(m.MaxAttempts.ValueInt64())
+	}
+
+	if isSet(m.Backoff) {
+		dur, err := time.ParseDuration(m.Backoff.ValueString())
+		if err != nil {
+			return policy, fmt.Errorf("invalid retry backoff: %w", err)
+		}
+		policy.Backoff = dur
+	}
+
+	if !m.RetryableSQLStates.IsNull() && !m.RetryableSQLStates.IsUnknown() {
+		codes := []string{}
+		if diags := m.RetryableSQLStates.ElementsAs(ctx, &codes, false); diags.HasError() {
+			return policy, fmt.Errorf("invalid retryable_sqlstates")
+		}
+		policy.Codes = codes
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	codes := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("40P01"), types.StringValue("53300")})
+
+	tests := []struct {
+		name    string
+		model   *retryModel
+		want    pgq.RetryPolicy
+		wantErr bool
+	}{
+		{
+			name: "no block",
+			want: pgq.RetryPolicy{},
+		},
+		{
+			name: "defaults",
+			model: &retryModel{
+				MaxAttempts:        types.Int64Null(),
+				Backoff:            types.StringNull(),
+				RetryableSQLStates: types.ListNull(types.StringType),
+			},
+			want: pgq.RetryPolicy{MaxAttempts: 3, Backoff: time.Second},
+		},
+		{
+			name: "configured",
+			model: &retryModel{
+				MaxAttempts:        types.Int64Value(5),
+				Backoff:            types.StringValue("250ms"),
+				RetryableSQLStates: codes,
+			},
+			want: pgq.RetryPolicy{MaxAttempts: 5, Backoff: 250 * time.Millisecond, Codes: []string{"40P01", "53300"}},
+		},
+		{
+			name: "invalid backoff",
+			model: &retryModel{
+				MaxAttempts:        types.Int64Null(),
+				Backoff:            types.StringValue("soon"),
+				RetryableSQLStates: types.ListNull(types.StringType),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := retryPolicy(context.Background(), tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("retryPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
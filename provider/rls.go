@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// rlsModel configures row-level security on a pgq_queue
+type rlsModel struct {
+	Enabled  types.Bool    `tfsdk:"enabled"`
+	Policies []policyModel `tfsdk:"policy"`
+}
+
+type policyModel struct {
+	Name      types.String `tfsdk:"name"`
+	Command   types.String `tfsdk:"command"`
+	Using     types.String `tfsdk:"using"`
+	WithCheck types.String `tfsdk:"with_check"`
+}
+
+// rlsBlock is the schema of the rls block of pgq_queue
+func rlsBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Row-level security on the queue table, e.g. to restrict the consumers of a multi-tenant queue to their tenant's rows. " +
+			"Policies apply to queries through the queue; partitions queried directly, the table owner and superusers bypass them.",
+		Attributes: map[string]schema.Attribute{
+			"enabled": schema.BoolAttribute{
+				Description: "Enable row-level security; without policies only the owner sees any rows",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"policy": schema.ListNestedBlock{
+				Description: "Permissive policies; a row is visible if any policy for the command allows it",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Policy name",
+							Required:    true,
+							Validators:  []validator.String{identifierValidator()},
+						},
+						"command": schema.StringAttribute{
+							Description: "Command the policy applies to: ALL, SELECT, INSERT, UPDATE or DELETE",
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString("ALL"),
+							Validators:  []validator.String{stringvalidator.OneOf("ALL", "SELECT", "INSERT", "UPDATE", "DELETE")},
+						},
+						"using": schema.StringAttribute{
+							Description: "Boolean expression filtering the existing rows the command sees (e.g. \"tenant_id = current_setting('app.tenant_id')::int\")",
+							Optional:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+						"with_check": schema.StringAttribute{
+							Description: "Boolean expression new and updated rows must satisfy; defaults to using for ALL and UPDATE",
+							Optional:    true,
+							Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// enabled reports whether row-level security is enabled, false without
+// the block
+func (m *rlsModel) enabled() bool {
+	return m != nil && m.Enabled.ValueBool()
+}
+
+// policies returns the policy blocks as pgq policies
+func (m *rlsModel) policies() []pgq.Policy {
+	if m == nil {
+		return nil
+	}
+	policies := make([]pgq.Policy, len(m.Policies))
+	for i, p := range m.Policies {
+		policies[i] = pgq.Policy{
+			Name:      p.Name.ValueString(),
+			Command:   p.Command.ValueString(),
+			Using:     p.Using.ValueString(),
+			WithCheck: p.WithCheck.ValueString(),
+		}
+	}
+	return policies
+}
+
+// rlsChanged reports whether the row-level security of plan differs from
+// state
+func rlsChanged(plan, state *rlsModel) bool {
+	if plan.enabled() != state.enabled() {
+		return true
+	}
+	from, to := state.policies(), plan.policies()
+	if len(from) != len(to) {
+		return true
+	}
+	for i := range from {
+		if from[i] != to[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// readRLS refreshes the rls block. The server deparses expressions, so
+// existing policies keep the configured ones, and drops policies that no
+// longer exist. Policies Terraform doesn't manage are left alone unless all
+// is set, for imports. The block stays out while row-level security is
+// disabled and no policy is managed.
+func (r *queueResource) readRLS(ctx context.Context, m *queueModel, all bool) {
+	schema := pgq.SchemaName(m.Schema.ValueString())
+	name := pgq.QueueName(m.Name.ValueString())
+
+	enabled, actual, err := r.mgr.GetRowLevelSecurity(ctx, schema, name)
+	if err != nil {
+		tflog.Warn(ctx, "failed to read row-level security", map[string]any{"error": err})
+		return
+	}
+
+	byName := make(map[string]pgq.Policy, len(actual))
+	for _, p := range actual {
+		byName[p.Name] = p
+	}
+
+	policies := []policyModel{}
+	if m.RLS != nil {
+		for _, prev := range m.RLS.Policies {
+			p, ok := byName[prev.Name.ValueString()]
+			if !ok {
+				continue
+			}
+			delete(byName, p.Name)
+			model := policyModelFrom(p)
+			if (p.Using == "") == prev.Using.IsNull() {
+				model.Using = prev.Using
+			}
+			if (p.WithCheck == "") == prev.WithCheck.IsNull() {
+				model.WithCheck = prev.WithCheck
+			}
+			policies = append(policies, model)
+		}
+	}
+	if all {
+		for _, p := range actual {
+			if _, ok := byName[p.Name]; ok {
+				policies = append(policies, policyModelFrom(p))
+			}
+		}
+	}
+
+	if m.RLS == nil && !enabled && len(policies) == 0 {
+		return
+	}
+	m.RLS = &rlsModel{Enabled: types.BoolValue(enabled), Policies: policies}
+}
+
+func policyModelFrom(p pgq.Policy) policyModel {
+	return policyModel{
+		Name:      types.StringValue(p.Name),
+		Command:   types.StringValue(p.Command),
+		Using:     stringOrNull(p.Using),
+		WithCheck: stringOrNull(p.WithCheck),
+	}
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRLSChanged(t *testing.T) {
+	policy := func(name, using string) policyModel {
+		return policyModel{
+			Name:      types.StringValue(name),
+			Command:   types.StringValue("ALL"),
+			Using:     types.StringValue(using),
+			WithCheck: types.StringNull(),
+		}
+	}
+	enabled := func(policies ...policyModel) *rlsModel {
+		return &rlsModel{Enabled: types.BoolValue(true), Policies: policies}
+	}
+
+	tests := []struct {
+		name        string
+		plan, state *rlsModel
+		want        bool
+	}{
+		{"both absent", nil, nil, false},
+		{"disabled block", &rlsModel{Enabled: types.BoolValue(false)}, nil, false},
+		{"added", enabled(), nil, true},
+		{"removed", nil, enabled(policy("tenant", "true")), true},
+		{"same", enabled(policy("tenant", "true")), enabled(policy("tenant", "true")), false},
+		{"expression", enabled(policy("tenant", "false")), enabled(policy("tenant", "true")), true},
+		{"policy added", enabled(policy("a", "true"), policy("b", "true")), enabled(policy("a", "true")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rlsChanged(tt.plan, tt.state); got != tt.want {
+				t.Errorf("rlsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/jackc/pgx/v5"
+)
+
+// sqlTracer logs every statement the pool runs at TRACE level, for
+// log_sql. Query arguments are never logged and string literals are
+// redacted, since hooks and grants may embed secrets.
+type sqlTracer struct{}
+
+var _ pgx.QueryTracer = sqlTracer{}
+
+type (
+	sqlTraceKey struct{}
+
+	sqlTrace struct {
+		sql   string
+		start time.Time
+	}
+)
+
+func (sqlTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, sqlTraceKey{}, sqlTrace{sql: data.SQL, start: time.Now()})
+}
+
+func (sqlTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(sqlTraceKey{}).(sqlTrace)
+	if !ok {
+		return
+	}
+
+	fields := map[string]any{
+		"sql":           redactSQL(strings.TrimSpace(trace.sql)),
+		"duration_ms":   time.Since(trace.start).Milliseconds(),
+		"rows_affected": data.CommandTag.RowsAffected(),
+	}
+	if data.Err != nil {
+		fields["error"] = data.Err.Error()
+	}
+	tflog.Trace(ctx, "executed SQL", fields)
+}
+
+// redactSQL replaces the string literals of sql with '?'. Quoted
+// identifiers, comments and dollar-quoted function bodies are kept, so
+// the structure of DDL stays readable.
+func redactSQL(sql string) string {
+	var out strings.Builder
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentChar(sql[i-2]))
+			i = skipString(sql, i+1, escapes)
+			out.WriteString("'?'")
+			continue
+		case c == '"':
+			end := strings.IndexByte(sql[i+1:], '"')
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			out.WriteString(sql[i : i+end+2])
+			i += end + 2
+			continue
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+			continue
+		case c == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			if tag, ok := dollarTag(sql[i:]); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					end = len(sql) - i - 2*len(tag)
+				}
+				out.WriteString(sql[i : i+2*len(tag)+end])
+				i += 2*len(tag) + end
+				continue
+			}
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String()
+}
+
+// skipString returns the index after the literal whose content starts at
+// i. Doubled quotes are escapes, and backslashes too in E-prefixed strings.
+func skipString(sql string, i int, escapes bool) int {
+	for i < len(sql) {
+		switch {
+		case escapes && sql[i] == '\\':
+			i += 2
+		case sql[i] == '\'' && i+1 < len(sql) && sql[i+1] == '\'':
+			i += 2
+		case sql[i] == '\'':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag returns the opening $tag$ at the start of s
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1], true
+		}
+		// $1 is a parameter, not a tag
+		if !isIdentChar(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
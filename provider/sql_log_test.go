@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"literal", "SELECT set_config('search_path', 'queues', false)", "SELECT set_config('?', '?', false)"},
+		{"doubled quote", "COMMENT ON TABLE q IS 'it''s secret'", "COMMENT ON TABLE q IS '?'"},
+		{"escape string", `SELECT E'a\'b', 'c'`, `SELECT E'?', '?'`},
+		{"identifier", `CREATE TABLE "it's"."q" ()`, `CREATE TABLE "it's"."q" ()`},
+		{"comment", "SELECT 1 -- don't\nFROM t", "SELECT 1 -- don't\nFROM t"},
+		{"parameter", "SELECT $1, 'x'", "SELECT $1, '?'"},
+		{"dollar quoted", "CREATE FUNCTION f() AS $pgq$ SELECT 'x' $pgq$", "CREATE FUNCTION f() AS $pgq$ SELECT 'x' $pgq$"},
+		{"unterminated", "SELECT 'abc", "SELECT '?'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactSQL(tt.sql); got != tt.want {
+				t.Errorf("redactSQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLTracer(t *testing.T) {
+	var out bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &out)
+
+	tracer := sqlTracer{}
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL:  "ALTER ROLE app PASSWORD 'hunter2'",
+		Args: []any{"arg-secret"},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("ALTER ROLE"),
+		Err:        errors.New("permission denied"),
+	})
+
+	entries, err := tflogtest.MultilineJSONDecode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["@level"] != "trace" || entry["sql"] != "ALTER ROLE app PASSWORD '?'" || entry["error"] != "permission denied" {
+		t.Errorf("logged %v", entry)
+	}
+	if _, ok := entry["rows_affected"]; !ok {
+		t.Error("rows_affected not logged")
+	}
+	if bytes.Contains(out.Bytes(), []byte("secret")) || bytes.Contains(out.Bytes(), []byte("hunter2")) {
+		t.Error("log contains a literal or argument")
+	}
+}
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type (
+	sshTunnelModel struct {
+		Host                  types.String `tfsdk:"host"`
+		Port                  types.Int64  `tfsdk:"port"`
+		User                  types.String `tfsdk:"user"`
+		PrivateKey            types.String `tfsdk:"private_key"`
+		PrivateKeyPassphrase  types.String `tfsdk:"private_key_passphrase"`
+		UseAgent              types.Bool   `tfsdk:"use_agent"`
+		HostKey               types.String `tfsdk:"host_key"`
+		KnownHostsFile        types.String `tfsdk:"known_hosts_file"`
+		InsecureIgnoreHostKey types.Bool   `tfsdk:"insecure_ignore_host_key"`
+	}
+
+	// sshTunnel dials PostgreSQL through a jump host. The SSH connection is
+	// opened on the first dial and shared by every pool connection.
+	sshTunnel struct {
+		addr   string
+		config *ssh.ClientConfig
+		// forward dials the jump host, nil for a plain TCP dial
+		forward pgconn.DialFunc
+
+		mu     sync.Mutex
+		client *ssh.Client
+	}
+)
+
+func newSSHTunnel(m *sshTunnelModel) (*sshTunnel, error) {
+	if m.Host.ValueString() == "" || m.User.ValueString() == "" {
+		return nil, errors.New("ssh_tunnel: host and user are required")
+	}
+
+	port := int64(22)
+	if !m.Port.IsNull() && !m.Port.IsUnknown() {
+		port = m.Port.ValueInt64()
+	}
+
+	auth, err := sshAuthMethods(m)
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: %w", err)
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(m)
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: %w", err)
+	}
+
+	return &sshTunnel{
+		addr: net.JoinHostPort(m.Host.ValueString(), strconv.FormatInt(port, 10)),
+		config: &ssh.ClientConfig{
+			User:            m.User.ValueString(),
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+		},
+	}, nil
+}
+
+// install routes every pool connection through the tunnel. Host names
+// are resolved by the jump host, since private database endpoints often
+// don't resolve from where Terraform runs. Like a proxy, the jump host is
+// dialed with the dial function configured so far, so keepalive settings
+// apply to the SSH connection, the only one crossing the network.
+func (t *sshTunnel) install(poolCfg *pgxpool.Config) {
+	t.forward = poolCfg.ConnConfig.DialFunc
+	poolCfg.ConnConfig.DialFunc = t.dial
+	poolCfg.ConnConfig.LookupFunc = func(_ context.Context, host string) ([]string, error) {
+		return []string{host}, nil
+	}
+}
+
+func (t *sshTunnel) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, addr)
+	if err == nil {
+		return conn, nil
+	}
+
+	// the jump host may have dropped the SSH connection; reconnect once
+	t.reset(client)
+	if client, err = t.sshClient(ctx); err != nil {
+		return nil, err
+	}
+	return client.DialContext(ctx, network, addr)
+}
+
+func (t *sshTunnel) sshClient(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	forward := t.forward
+	if forward == nil {
+		forward = (&net.Dialer{}).DialContext
+	}
+	conn, err := forward(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: connect to %s: %w", t.addr, err)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ssh_tunnel: connect to %s: %w", t.addr, err)
+	}
+	t.client = ssh.NewClient(c, chans, reqs)
+	return t.client, nil
+}
+
+func (t *sshTunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == client {
+		_ = client.Close()
+		t.client = nil
+	}
+}
+
+func sshAuthMethods(m *sshTunnelModel) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if key := m.PrivateKey.ValueString(); key != "" {
+		var signer ssh.Signer
+		var err error
+		if pass := m.PrivateKeyPassphrase.ValueString(); pass != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(pass))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(key))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid private_key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if m.UseAgent.ValueBool() {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, errors.New("use_agent is set but SSH_AUTH_SOCK is empty")
+		}
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			conn, err := net.Dial("unix", sock)
+			if err != nil {
+				return nil, err
+			}
+			return agent.NewClient(conn).Signers()
+		}))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("one of private_key or use_agent is required")
+	}
+	return methods, nil
+}
+
+func sshHostKeyCallback(m *sshTunnelModel) (ssh.HostKeyCallback, error) {
+	if m.InsecureIgnoreHostKey.ValueBool() {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	if hostKey := m.HostKey.ValueString(); hostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host_key: %w", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+
+	file := m.KnownHostsFile.ValueString()
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("host key verification needs host_key, known_hosts_file or insecure_ignore_host_key: %w", err)
+	}
+	return callback, nil
+}
@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHTunnelDial(t *testing.T) {
+	// target service reachable only "behind" the jump host
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	clientKeyPEM, clientSigner := testSSHKey(t)
+	_, hostSigner := testSSHKey(t)
+
+	jump := testSSHServer(t, hostSigner, clientSigner.PublicKey())
+	defer jump.Close()
+
+	host, port, _ := net.SplitHostPort(jump.Addr().String())
+	portNum, _ := strconv.ParseInt(port, 10, 64)
+
+	tunnel, err := newSSHTunnel(&sshTunnelModel{
+		Host:       types.StringValue(host),
+		Port:       types.Int64Value(portNum),
+		User:       types.StringValue("bastion"),
+		PrivateKey: types.StringValue(clientKeyPEM),
+		HostKey:    types.StringValue(string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))),
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error = %v", err)
+	}
+
+	conn, err := tunnel.dial(context.Background(), "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("tunnel echoed %q, want ping", buf)
+	}
+}
+
+func TestSSHTunnelRejectsUnknownHostKey(t *testing.T) {
+	clientKeyPEM, clientSigner := testSSHKey(t)
+	_, hostSigner := testSSHKey(t)
+	_, otherSigner := testSSHKey(t)
+
+	jump := testSSHServer(t, hostSigner, clientSigner.PublicKey())
+	defer jump.Close()
+
+	host, port, _ := net.SplitHostPort(jump.Addr().String())
+	portNum, _ := strconv.ParseInt(port, 10, 64)
+
+	tunnel, err := newSSHTunnel(&sshTunnelModel{
+		Host:       types.StringValue(host),
+		Port:       types.Int64Value(portNum),
+		User:       types.StringValue("bastion"),
+		PrivateKey: types.StringValue(clientKeyPEM),
+		HostKey:    types.StringValue(string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey()))),
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error = %v", err)
+	}
+
+	if _, err := tunnel.dial(context.Background(), "tcp", "127.0.0.1:5432"); err == nil {
+		t.Error("dial() should fail on a host key mismatch")
+	}
+}
+
+func TestSSHTunnelForwardsDial(t *testing.T) {
+	clientKeyPEM, clientSigner := testSSHKey(t)
+	_, hostSigner := testSSHKey(t)
+
+	jump := testSSHServer(t, hostSigner, clientSigner.PublicKey())
+	defer jump.Close()
+
+	host, port, _ := net.SplitHostPort(jump.Addr().String())
+	portNum, _ := strconv.ParseInt(port, 10, 64)
+
+	tunnel, err := newSSHTunnel(&sshTunnelModel{
+		Host:       types.StringValue(host),
+		Port:       types.Int64Value(portNum),
+		User:       types.StringValue("bastion"),
+		PrivateKey: types.StringValue(clientKeyPEM),
+		HostKey:    types.StringValue(string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))),
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error = %v", err)
+	}
+
+	// the dial function set before, e.g. by keepalive, dials the jump host
+	poolCfg, err := pgxpool.ParseConfig("postgres://app@db.internal/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dialed []string
+	poolCfg.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	tunnel.install(poolCfg)
+
+	if _, err := tunnel.sshClient(context.Background()); err != nil {
+		t.Fatalf("sshClient() error = %v", err)
+	}
+	if len(dialed) != 1 || dialed[0] != jump.Addr().String() {
+		t.Errorf("forward dial function dialed %v, want the jump host %s", dialed, jump.Addr())
+	}
+}
+
+func testSSHKey(t *testing.T) (string, ssh.Signer) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block)), signer
+}
+
+// testSSHServer accepts clientKey and forwards direct-tcpip channels
+func testSSHServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) net.Listener {
+	t.Helper()
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			nc, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nch := range chans {
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if nch.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nch.ExtraData(), &target) != nil {
+						_ = nch.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						_ = nch.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, chReqs, err := nch.Accept()
+					if err != nil {
+						upstream.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() {
+						defer ch.Close()
+						defer upstream.Close()
+						go func() { _, _ = io.Copy(upstream, ch) }()
+						_, _ = io.Copy(ch, upstream)
+					}()
+				}
+			}()
+		}
+	}()
+
+	return l
+}
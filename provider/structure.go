@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// setStructure sets structure_hash from q and warns about built-in columns
+// altered outside Terraform
+func (m *queueModel) setStructure(q *pgq.Queue) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.StructureHash = types.StringValue(q.StructureHash())
+
+	if drift := q.Drift(); len(drift) > 0 {
+		diags.AddAttributeWarning(path.Root("structure_hash"), "Queue structure drifted",
+			fmt.Sprintf("The built-in columns of %s differ from those the queue was created with, which producers and consumers may not expect:\n\n%s",
+				q.FQN(), strings.Join(drift, "\n")))
+	}
+	return diags
+}
+
+// readStructure fills an unknown structure_hash after Create or Update. A
+// failure to read it leaves it empty until the next refresh.
+func (r *queueResource) readStructure(ctx context.Context, m *queueModel) diag.Diagnostics {
+	if !m.StructureHash.IsUnknown() {
+		return nil
+	}
+
+	q, err := r.mgr.Get(ctx, pgq.SchemaName(m.Schema.ValueString()), pgq.QueueName(m.Name.ValueString()))
+	if err != nil {
+		tflog.Warn(ctx, "failed to read queue structure", map[string]any{"error": err})
+		m.StructureHash = types.StringNull()
+		return nil
+	}
+	return m.setStructure(q)
+}
+
+// planStructure expects a new structure_hash when the ordering column is
+// added or dropped, the only in-place change to the built-in columns
+func planStructure(ctx context.Context, plan, state queueModel, resp *resource.ModifyPlanResponse) {
+	if plan.OrderingColumn.Equal(state.OrderingColumn) {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("structure_hash"), types.StringUnknown())...)
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// subPartitionModel partitions each partition of a pgq_queue again
+type subPartitionModel struct {
+	Column   types.String `tfsdk:"column"`
+	Interval types.String `tfsdk:"interval"`
+	Type     types.String `tfsdk:"type"`
+	Premake  types.Int64  `tfsdk:"premake"`
+}
+
+// subPartitionBlock is the schema of the sub_partition block of pgq_queue
+func subPartitionBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Partition each partition again by a second column, e.g. to spread a very hot queue over several tables per day. Changing it forces a new resource.",
+		Attributes: map[string]schema.Attribute{
+			"column": schema.StringAttribute{
+				Description: "Sub-partition key, a NOT NULL time or integer column such as an extra_column; it becomes part of the primary key",
+				Optional:    true,
+				Validators:  []validator.String{identifierValidator()},
+			},
+			"interval": schema.StringAttribute{
+				Description: "Range of each sub-partition, an interval for time columns (e.g. '1 hour') or a number for integer columns (e.g. '1000')",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"type": schema.StringAttribute{
+				Description: "pg_partman partition type: range, or list for one integer value per sub-partition (interval '1')",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("range"),
+				Validators:  []validator.String{stringvalidator.OneOf("range", "list")},
+			},
+			"premake": schema.Int64Attribute{
+				Description: "Sub-partitions to create ahead in each partition",
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(4),
+				Validators:  []validator.Int64{int64validator.AtLeast(1)},
+			},
+		},
+		PlanModifiers: []planmodifier.Object{
+			objectplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// config returns the pg_partman sub-partitioning, nil without the block
+func (m *subPartitionModel) config() *pgq.SubPartitionConfig {
+	if m == nil {
+		return nil
+	}
+	return &pgq.SubPartitionConfig{
+		Column:   m.Column.ValueString(),
+		Interval: m.Interval.ValueString(),
+		Type:     m.Type.ValueString(),
+		Premake:  int(m.Premake.ValueInt64()),
+	}
+}
+
+// setSubPartition refreshes the sub_partition block from cfg. The
+// configured interval is kept when the server spells it differently
+// ('1 hour' is read back as '01:00:00'), since any change replaces the
+// queue.
+func (m *queueModel) setSubPartition(cfg *pgq.SubPartitionConfig) {
+	if cfg == nil {
+		m.SubPartition = nil
+		return
+	}
+
+	interval := types.StringValue(cfg.Interval)
+	if m.SubPartition != nil {
+		interval = keepInterval(m.SubPartition.Interval, cfg.Interval)
+	}
+
+	m.SubPartition = &subPartitionModel{
+		Column:   types.StringValue(cfg.Column),
+		Interval: interval,
+		Type:     types.StringValue(cfg.Type),
+		Premake:  types.Int64Value(int64(cfg.Premake)),
+	}
+}
+
+// sameInterval reports whether two interval strings mean the same
+func sameInterval(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ia, err := pgq.ParseInterval(a)
+	if err != nil {
+		return false
+	}
+	ib, err := pgq.ParseInterval(b)
+	return err == nil && ia == ib
+}
+
+// keepInterval returns the interval read from the server, or prior when
+// it is a different spelling of it, e.g. '2 weeks' read back as '14 days'
+func keepInterval(prior types.String, read string) types.String {
+	if !prior.IsNull() && !prior.IsUnknown() && sameInterval(prior.ValueString(), read) {
+		return prior
+	}
+	return types.StringValue(read)
+}
+
+// validateSubPartition checks that a sub_partition block is complete and
+// only used on partitioned queues
+func validateSubPartition(ctx context.Context, resp *resource.ModifyPlanResponse) {
+	var plan queueModel
+	if diags := resp.Plan.Get(ctx, &plan); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	sub := plan.SubPartition
+	if sub == nil {
+		return
+	}
+
+	p := path.Root("sub_partition")
+	if sub.Column.IsNull() {
+		resp.Diagnostics.AddAttributeError(p.AtName("column"), "Missing sub-partition column",
+			"A sub_partition block requires column.")
+	}
+	if sub.Interval.IsNull() {
+		resp.Diagnostics.AddAttributeError(p.AtName("interval"), "Missing sub-partition interval",
+			"A sub_partition block requires interval.")
+	}
+
+	// the primary key would make a nullable column NOT NULL behind
+	// extra_column's back
+	columns, diags := plan.extraColumns(ctx)
+	resp.Diagnostics.Append(diags...)
+	for _, c := range columns {
+		if c.Name == sub.Column.ValueString() && !c.NotNull {
+			resp.Diagnostics.AddAttributeError(p.AtName("column"), "Nullable sub-partition column",
+				fmt.Sprintf("Sub-partition column %s becomes part of the primary key; set nullable = false on its extra_column.", c.Name))
+		}
+	}
+
+	if !plan.EnablePartitioning.IsUnknown() && !plan.EnablePartitioning.ValueBool() {
+		resp.Diagnostics.AddAttributeError(p, "Sub-partitioning a simple queue",
+			"sub_partition requires enable_partitioning = true.")
+	}
+	if plan.nativePartitioning() {
+		resp.Diagnostics.AddAttributeError(p, "Sub-partitioning a native queue",
+			"sub_partition requires partitioning_mode = \"partman\".")
+	}
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestSameInterval(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1 hour", "01:00:00", true},
+		{"1 day", "1 day", true},
+		{"1000", "1000", true},
+		{"1 hour", "1 day", false},
+		{"1000", "100", false},
+		{"2 weeks", "14 days", true},
+		{"P14D", "14 days", true},
+		{"1 mon", "30 days", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameInterval(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameInterval(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestKeepInterval(t *testing.T) {
+	tests := []struct {
+		prior types.String
+		read  string
+		want  types.String
+	}{
+		{types.StringValue("2 weeks"), "14 days", types.StringValue("2 weeks")},
+		{types.StringValue("P1D"), "1 day", types.StringValue("P1D")},
+		{types.StringValue("2 weeks"), "7 days", types.StringValue("7 days")},
+		{types.StringNull(), "14 days", types.StringValue("14 days")},
+	}
+
+	for _, tt := range tests {
+		if got := keepInterval(tt.prior, tt.read); !got.Equal(tt.want) {
+			t.Errorf("keepInterval(%v, %q) = %v, want %v", tt.prior, tt.read, got, tt.want)
+		}
+	}
+}
+
+func TestQueueModelSetSubPartition(t *testing.T) {
+	m := queueModel{SubPartition: &subPartitionModel{
+		Column:   types.StringValue("tenant_id"),
+		Interval: types.StringValue("1 hour"),
+		Type:     types.StringValue("range"),
+		Premake:  types.Int64Value(4),
+	}}
+
+	m.setSubPartition(&pgq.SubPartitionConfig{Column: "tenant_id", Interval: "01:00:00", Type: "range", Premake: 4})
+	if got := m.SubPartition.Interval.ValueString(); got != "1 hour" {
+		t.Errorf("interval = %q, want the configured %q", got, "1 hour")
+	}
+
+	m.setSubPartition(&pgq.SubPartitionConfig{Column: "tenant_id", Interval: "02:00:00", Type: "range", Premake: 4})
+	if got := m.SubPartition.Interval.ValueString(); got != "02:00:00" {
+		t.Errorf("interval = %q, want the server's %q", got, "02:00:00")
+	}
+
+	m.setSubPartition(nil)
+	if m.SubPartition != nil {
+		t.Errorf("SubPartition = %+v, want nil", m.SubPartition)
+	}
+}
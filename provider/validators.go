@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dataddo/terraform-provider-pgq/pkg/pgq"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+	fqnRegexp        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}\.[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+	versionRegexp    = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	settingRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+	reloptionRegexp  = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	replIdentRegexp  = regexp.MustCompile(`^(default|full|nothing|index:[A-Za-z_][A-Za-z0-9_]{0,62})$`)
+	// partition suffixes must sort by time and be valid in table names
+	datetimeRegexp = regexp.MustCompile(`^(YYYY|IYYY)(_?(MM|Q|DD|IW|HH24|MI|SS))*$`)
+)
+
+// identifierValidator accepts plain (unquoted) PostgreSQL identifiers
+func identifierValidator() validator.String {
+	return stringvalidator.RegexMatches(identifierRegexp, "must be a valid PostgreSQL identifier")
+}
+
+// replicaIdentityValidator accepts default, full, nothing or index:<name>
+func replicaIdentityValidator() validator.String {
+	return stringvalidator.RegexMatches(replIdentRegexp, "must be default, full, nothing or index:<index name>")
+}
+
+// datetimeStringValidator accepts the to_char formats of partition
+// suffixes, e.g. 'YYYYMMDD' or 'YYYYMMDD_HH24MISS'
+func datetimeStringValidator() validator.String {
+	return stringvalidator.RegexMatches(datetimeRegexp,
+		"must be a year (YYYY or IYYY) followed by MM, Q, DD, IW, HH24, MI or SS, optionally separated by _, e.g. 'YYYYMMDD' or 'YYYYMMDD_HH24MISS'")
+}
+
+// fqnValidator accepts schema-qualified names like 'public.orders'
+func fqnValidator() validator.String {
+	return stringvalidator.RegexMatches(fqnRegexp, "must be a fully qualified name (schema.name)")
+}
+
+// versionValidator accepts dot separated version numbers like '14' or '5.1.0'
+func versionValidator() validator.String {
+	return stringvalidator.RegexMatches(versionRegexp, "must be a version like '14' or '5.1.0'")
+}
+
+// settingNameValidator accepts configuration parameter names like
+// 'work_mem' or, for extensions, 'pg_partman_bgw.interval'
+func settingNameValidator() validator.String {
+	return stringvalidator.RegexMatches(settingRegexp, "must be a configuration parameter name")
+}
+
+// storageParameterValidator accepts storage parameter names like
+// 'fillfactor', which PostgreSQL stores in lower case
+func storageParameterValidator() validator.String {
+	return stringvalidator.RegexMatches(reloptionRegexp, "must be a lower case storage parameter name")
+}
+
+// durationValidator accepts Go durations like '30s' or '1h30m'
+func durationValidator() validator.String {
+	return durationStringValidator{}
+}
+
+type durationStringValidator struct{}
+
+func (v durationStringValidator) Description(_ context.Context) string {
+	return "must be a duration like '30s', '5m' or '1h'"
+}
+
+func (v durationStringValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationStringValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := time.ParseDuration(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid duration", v.Description(ctx)+": "+err.Error())
+	}
+}
+
+// intervalValidator accepts positive PostgreSQL intervals like '1 day',
+// '2 weeks' or 'P1D'. Empty values are left to other validators.
+func intervalValidator() validator.String {
+	return intervalStringValidator{}
+}
+
+type intervalStringValidator struct{}
+
+func (v intervalStringValidator) Description(_ context.Context) string {
+	return "must be a positive PostgreSQL interval like '1 day', '2 weeks' or 'P1D'"
+}
+
+func (v intervalStringValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v intervalStringValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() || req.ConfigValue.ValueString() == "" {
+		return
+	}
+
+	iv, err := pgq.ParseInterval(req.ConfigValue.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid interval", v.Description(ctx)+": "+err.Error())
+		return
+	}
+	if iv.Approximate() <= 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid interval",
+			fmt.Sprintf("%s: %q isn't positive", v.Description(ctx), req.ConfigValue.ValueString()))
+	}
+}